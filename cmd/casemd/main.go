@@ -10,6 +10,7 @@ import (
 	"github.com/9renpoto/casemd/internal/core/parser"
 	"github.com/9renpoto/casemd/internal/interfaces/cli"
 	"github.com/9renpoto/casemd/internal/interfaces/googleapi"
+	"github.com/9renpoto/casemd/internal/interfaces/runstore"
 	"github.com/9renpoto/casemd/internal/interfaces/web"
 )
 
@@ -19,8 +20,17 @@ func (p *coreParserAdapter) Parse(r io.Reader) ([]domain.Case, error) {
 	return parser.Parse(r)
 }
 
+func (p *coreParserAdapter) ParseDocument(r io.Reader) (domain.Document, error) {
+	return parser.ParseDocument(r)
+}
+
+func (p *coreParserAdapter) ParseDocumentWithIncludes(r io.Reader, includes app.Includes) (domain.Document, error) {
+	return parser.ParseDocumentWithIncludes(r, parser.Includes{Name: includes.Name, Open: includes.Open})
+}
+
 func main() {
-	parserAdapter := &coreParserAdapter{}
+	stats := app.NewStats()
+	parserAdapter := stats.Parser(&coreParserAdapter{})
 	csvConverter := app.NewMarkdownToCSV(parserAdapter)
 	spreadsheetConverter := app.NewMarkdownToSpreadsheet(parserAdapter)
 	var googleConverter cli.GoogleSpreadsheetCreator
@@ -33,25 +43,32 @@ func main() {
 		}
 	}
 
-	if len(os.Args) > 1 && os.Args[1] == "serve" {
-		addr := os.Getenv("CASEMD_WEB_ADDR")
-		if len(os.Args) > 2 {
-			addr = os.Args[2]
-		}
-		if addr == "" {
-			addr = ":3000"
-		}
-
-		server := web.NewServer(csvConverter)
-		fmt.Fprintf(os.Stdout, "Starting casemd web UI on %s\n", addr)
-		if err := server.Listen(addr); err != nil {
-			fmt.Fprintln(os.Stderr, err)
-			os.Exit(1)
-		}
-		return
-	}
-
-	tool := cli.New(os.Stdout, os.Stderr, csvConverter, spreadsheetConverter, googleConverter)
+	tool := cli.New(os.Stdout, os.Stderr, cli.Converters{
+		CSV:           csvConverter,
+		Spreadsheet:   spreadsheetConverter,
+		ODS:           app.NewMarkdownToODS(parserAdapter),
+		JUnit:         app.NewMarkdownToJUnit(parserAdapter),
+		TAP:           app.NewMarkdownToTAP(parserAdapter),
+		HTML:          app.NewMarkdownToHTML(parserAdapter, web.Stylesheet()),
+		XLSXImport:    app.NewXLSXToMarkdown(),
+		Google:        googleConverter,
+		Planner:       app.NewTestPlanner(parserAdapter),
+		RunRecorder:   app.NewRunRecorder(parserAdapter),
+		TrendReporter: app.NewTrendReporter(parserAdapter, web.Stylesheet()),
+		WebServer: &webServer{
+			csv: csvConverter,
+			options: web.Options{
+				Previewer:   app.NewMarkdownPreview(parserAdapter),
+				Parser:      app.NewMarkdownDocument(parserAdapter),
+				Executor:    app.NewMarkdownExecution(parserAdapter),
+				Spreadsheet: spreadsheetConverter,
+				JSON:        app.NewMarkdownToJSON(parserAdapter),
+				Stats:       stats,
+			},
+		},
+		RunStore: func(dir string) cli.RunStore { return runstore.New(dir) },
+		Stats:    stats,
+	})
 	application := app.New(tool)
 
 	if err := application.Run(os.Args[1:]); err != nil {
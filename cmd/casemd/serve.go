@@ -0,0 +1,83 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+
+	"github.com/9renpoto/casemd/internal/interfaces/cli"
+	"github.com/9renpoto/casemd/internal/interfaces/web"
+)
+
+// webServer serves the web UI configured by casemd serve.
+type webServer struct {
+	csv     web.CSVConverter
+	options web.Options
+}
+
+func (s *webServer) Serve(ctx context.Context, config cli.ServeConfig) error {
+	options := s.options
+	if config.Workspace != "" {
+		workspace, err := web.OpenWorkspace(config.Workspace)
+		if err != nil {
+			return err
+		}
+		defer workspace.Close()
+		options.Workspace = workspace
+	}
+
+	authenticator, err := authenticator(config)
+	if err != nil {
+		return err
+	}
+	options.Auth = authenticator
+	options.SessionKey = config.SessionKey
+	options.Language = config.Language
+	options.AssetsDir = config.AssetsDir
+	options.BasePath = config.BasePath
+	options.BodyLimit = config.BodyLimit
+	options.ConvertTimeout = config.ConvertTimeout
+	options.RateLimit = config.RateLimit
+	if config.AccessLog {
+		options.AccessLog = slog.New(slog.NewTextHandler(os.Stderr, nil))
+	}
+	server := web.NewServer(s.csv, options)
+	listener, err := web.OpenListener(web.ListenOptions{Addr: config.Addr, CertFile: config.CertFile, KeyFile: config.KeyFile})
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintf(os.Stdout, "Starting casemd web UI on %s\n", config.Addr)
+	return server.Serve(ctx, listener, web.DrainOptions{ReadyGrace: config.ReadyGrace, Timeout: config.DrainTimeout})
+}
+
+// authenticator builds the sign-in method selected on the command line; it
+// returns nil when the UI is left open.
+func authenticator(config cli.ServeConfig) (web.Authenticator, error) {
+	switch {
+	case config.TokenFile != "":
+		file, err := os.Open(config.TokenFile)
+		if err != nil {
+			return nil, fmt.Errorf("open token file: %w", err)
+		}
+		defer file.Close()
+		return web.NewTokenAuth(file)
+	case config.Htpasswd != "":
+		file, err := os.Open(config.Htpasswd)
+		if err != nil {
+			return nil, fmt.Errorf("open htpasswd file: %w", err)
+		}
+		defer file.Close()
+		return web.NewBasicAuth(file)
+	case config.OIDCIssuer != "":
+		return web.NewOIDCAuth(context.Background(), web.OIDCConfig{
+			Issuer:       config.OIDCIssuer,
+			ClientID:     config.OIDCClientID,
+			ClientSecret: config.OIDCClientSecret,
+			RedirectURL:  config.OIDCRedirectURL,
+		})
+	default:
+		return nil, nil
+	}
+}
@@ -0,0 +1,223 @@
+package app
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/9renpoto/casemd/internal/core/domain"
+	"github.com/9renpoto/casemd/internal/i18n"
+)
+
+// Column is a case field written as one column of the CSV and spreadsheet
+// outputs, or a blank column left for testers to fill in.
+type Column struct {
+	// Key selects the column, e.g. in --columns; it is empty for a blank
+	// column.
+	Key    string
+	Header string
+	value  func(domain.Case) string
+}
+
+// ColumnSpec selects a column: the field Key, titled Header instead of its
+// default header when Header is set. A spec without a Key adds a blank
+// column titled Header, e.g. "Build" or "Ticket". The Key "default" stands
+// for the default columns.
+type ColumnSpec struct {
+	Key    string
+	Header string
+}
+
+// columnCatalog lists every selectable column in its default order.
+var columnCatalog = []Column{
+	{Key: "major", Header: "Major Item", value: func(c domain.Case) string { return c.MajorItem }},
+	{Key: "medium", Header: "Medium Item", value: func(c domain.Case) string { return c.MediumItem }},
+	{Key: "minor", Header: "Minor Item", value: func(c domain.Case) string { return c.MinorItem }},
+	{Key: "preconditions", Header: "Preconditions", value: func(c domain.Case) string { return strings.Join(c.Preconditions, "\n") }},
+	{Key: "steps", Header: "Validation Steps", value: func(c domain.Case) string { return strings.Join(c.ValidationSteps, "\n") }},
+	{Key: "checkpoints", Header: "Checkpoints", value: func(c domain.Case) string { return strings.Join(c.Checkpoints, "\n") }},
+	{Key: "expected", Header: "Expected Results", value: func(c domain.Case) string { return strings.Join(c.ExpectedResults, "\n") }},
+	{Key: "priority", Header: "Priority", value: func(c domain.Case) string { return c.Priority }},
+	{Key: "type", Header: "Test Type", value: func(c domain.Case) string { return c.TestType }},
+	{Key: "tags", Header: "Tags", value: func(c domain.Case) string { return strings.Join(c.Tags, ", ") }},
+	{Key: "effort", Header: "Effort", value: func(c domain.Case) string { return c.Effort }},
+	{Key: "result", Header: "Result", value: func(c domain.Case) string { return c.Result }},
+	{Key: "date", Header: "Test Date", value: func(c domain.Case) string { return c.TestDate }},
+	{Key: "tester", Header: "Tester", value: func(c domain.Case) string { return c.Tester }},
+	{Key: "notes", Header: "Notes", value: func(c domain.Case) string { return c.Notes }},
+}
+
+// defaultColumnKeys are the nine columns of the original inspection sheet.
+var defaultColumnKeys = []string{"major", "medium", "minor", "steps", "checkpoints", "result", "date", "tester", "notes"}
+
+// DefaultColumns returns the columns written when none are selected.
+func DefaultColumns() []Column {
+	columns := make([]Column, 0, len(defaultColumnKeys))
+	for _, key := range defaultColumnKeys {
+		column, _ := lookupColumn(key)
+		columns = append(columns, column)
+	}
+	return columns
+}
+
+// ColumnKeys lists the keys accepted by ParseColumns.
+func ColumnKeys() []string {
+	keys := make([]string, 0, len(columnCatalog))
+	for _, column := range columnCatalog {
+		keys = append(keys, column.Key)
+	}
+	return keys
+}
+
+// ParseColumns selects columns from a comma-separated list such as
+// "minor,priority,steps,checkpoints". "key=Label" renames a column and
+// "+Label" adds a blank column; the key "default" stands for the default
+// columns, so "default,priority,+Build" appends to them.
+func ParseColumns(spec string) ([]Column, error) {
+	var specs []ColumnSpec
+	for _, item := range strings.Split(spec, ",") {
+		item = strings.TrimSpace(item)
+		switch {
+		case item == "":
+			continue
+		case strings.HasPrefix(item, "+"):
+			specs = append(specs, ColumnSpec{Header: strings.TrimSpace(item[1:])})
+			if specs[len(specs)-1].Header == "" {
+				return nil, fmt.Errorf("blank column %q has no header", item)
+			}
+		default:
+			key, header, _ := strings.Cut(item, "=")
+			specs = append(specs, ColumnSpec{Key: strings.TrimSpace(key), Header: strings.TrimSpace(header)})
+		}
+	}
+	return BuildColumns(specs)
+}
+
+// BuildColumns resolves column specs in order. Unknown keys, keys or headers
+// used more than once, and an empty selection are errors.
+func BuildColumns(specs []ColumnSpec) ([]Column, error) {
+	var columns []Column
+	seenKeys := make(map[string]bool)
+	seenHeaders := make(map[string]bool)
+	add := func(column Column) error {
+		if column.Key != "" && seenKeys[column.Key] {
+			return fmt.Errorf("column %q is selected more than once", column.Key)
+		}
+		// Headers identify the columns when a workbook is imported.
+		header := strings.ToLower(column.Header)
+		if seenHeaders[header] {
+			return fmt.Errorf("header %q is used more than once", column.Header)
+		}
+		seenKeys[column.Key] = true
+		seenHeaders[header] = true
+		columns = append(columns, column)
+		return nil
+	}
+
+	for _, spec := range specs {
+		key := strings.ToLower(strings.TrimSpace(spec.Key))
+		header := strings.TrimSpace(spec.Header)
+		switch {
+		case key == "" && header == "":
+			return nil, fmt.Errorf("column has neither a field nor a header")
+		case key == "":
+			if err := add(Column{Header: header, value: func(domain.Case) string { return "" }}); err != nil {
+				return nil, err
+			}
+		case key == "default":
+			if header != "" {
+				return nil, fmt.Errorf("\"default\" cannot be renamed; list its columns to rename them")
+			}
+			for _, column := range DefaultColumns() {
+				if err := add(column); err != nil {
+					return nil, err
+				}
+			}
+		default:
+			column, ok := lookupColumn(key)
+			if !ok {
+				return nil, fmt.Errorf("unknown column %q (available: %s)", key, strings.Join(ColumnKeys(), ", "))
+			}
+			if header != "" {
+				column.Header = header
+			}
+			if err := add(column); err != nil {
+				return nil, err
+			}
+		}
+	}
+	if len(columns) == 0 {
+		return nil, fmt.Errorf("no columns selected")
+	}
+	return columns, nil
+}
+
+func lookupColumn(key string) (Column, bool) {
+	for _, column := range columnCatalog {
+		if column.Key == key {
+			return column, true
+		}
+	}
+	return Column{}, false
+}
+
+// localizeColumns translates the headers that columns still have from
+// columnCatalog into language.
+func localizeColumns(columns []Column, language i18n.Language) []Column {
+	if language == "" || language == i18n.English {
+		return columns
+	}
+	localized := make([]Column, len(columns))
+	for i, column := range columns {
+		localized[i] = column
+		if defaults, ok := lookupColumn(column.Key); ok && column.Header == defaults.Header {
+			localized[i].Header = language.Text("column." + column.Key)
+		}
+	}
+	return localized
+}
+
+// fieldHeaders maps every column key to the headers it is recognized by
+// when importing: the label of the selected column, or else the default
+// header in any language.
+func fieldHeaders(columns []Column) map[string][]string {
+	headers := make(map[string][]string, len(columnCatalog))
+	for _, column := range columnCatalog {
+		for _, language := range i18n.Languages() {
+			headers[column.Key] = append(headers[column.Key], language.Text("column."+column.Key))
+		}
+	}
+	for _, column := range columns {
+		if column.Key != "" {
+			headers[column.Key] = []string{column.Header}
+		}
+	}
+	return headers
+}
+
+func columnHeaders(columns []Column) []string {
+	headers := make([]string, 0, len(columns))
+	for _, column := range columns {
+		headers = append(headers, column.Header)
+	}
+	return headers
+}
+
+func caseRow(columns []Column, aCase domain.Case) []string {
+	row := make([]string, 0, len(columns))
+	for _, column := range columns {
+		row = append(row, column.value(aCase))
+	}
+	return row
+}
+
+// headingColumns returns the positions of the Major and Medium Item
+// columns, whose repeated values are shared in workbooks.
+func headingColumns(columns []Column) []int {
+	var positions []int
+	for i, column := range columns {
+		if column.Key == "major" || column.Key == "medium" {
+			positions = append(positions, i)
+		}
+	}
+	return positions
+}
@@ -0,0 +1,187 @@
+package app
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/9renpoto/casemd/internal/core/domain"
+	"github.com/9renpoto/casemd/internal/i18n"
+)
+
+func TestParseColumns(t *testing.T) {
+	columns, err := ParseColumns("minor, default ,priority,Tags")
+	if err == nil {
+		t.Fatalf("expected minor to be reported twice, got %v", columns)
+	}
+
+	columns, err = ParseColumns("default,priority,tags")
+	if err != nil {
+		t.Fatalf("ParseColumns() returned an unexpected error: %v", err)
+	}
+	expected := append(columnHeaders(DefaultColumns()), "Priority", "Tags")
+	if headers := columnHeaders(columns); !reflect.DeepEqual(headers, expected) {
+		t.Fatalf("unexpected headers: %v", headers)
+	}
+
+	columns, err = ParseColumns("minor=Case, steps ,+Build,+ Ticket ")
+	if err != nil {
+		t.Fatalf("ParseColumns() returned an unexpected error: %v", err)
+	}
+	if headers := columnHeaders(columns); !reflect.DeepEqual(headers, []string{"Case", "Validation Steps", "Build", "Ticket"}) {
+		t.Fatalf("unexpected headers: %v", headers)
+	}
+	if columns[2].Key != "" || caseRow(columns, domain.Case{MinorItem: "Sign in"})[2] != "" {
+		t.Fatalf("expected a blank Build column, got %+v", columns[2])
+	}
+
+	for _, spec := range []string{"", " , ", "minor,owner", "+", "minor=Build,+build", "default=Sheet"} {
+		if _, err := ParseColumns(spec); err == nil {
+			t.Fatalf("expected an error for %q", spec)
+		}
+	}
+}
+
+func TestMarkdownToCSV_SelectedColumns(t *testing.T) {
+	parser := &mockCaseParser{cases: []domain.Case{{
+		MajorItem:       "Account",
+		MinorItem:       "Sign in",
+		Preconditions:   []string{"A user exists", "The user is signed out"},
+		ExpectedResults: []string{"The dashboard opens"},
+		Priority:        "P1",
+		TestType:        "functional",
+		Tags:            []string{"smoke", "regression"},
+	}}}
+	columns, err := ParseColumns("minor,priority,type,tags,preconditions,expected")
+	if err != nil {
+		t.Fatalf("ParseColumns() returned an unexpected error: %v", err)
+	}
+
+	var output bytes.Buffer
+	sources := []Source{{Name: "account.md", Reader: strings.NewReader(""), Columns: columns}}
+	if err := NewMarkdownToCSV(parser).Convert(sources, &output); err != nil {
+		t.Fatalf("Convert() returned an unexpected error: %v", err)
+	}
+
+	expected := "Minor Item,Priority,Test Type,Tags,Preconditions,Expected Results\n" +
+		"Sign in,P1,functional,\"smoke, regression\",\"A user exists\nThe user is signed out\",The dashboard opens\n"
+	if output.String() != expected {
+		t.Fatalf("unexpected csv:\n%s", output.String())
+	}
+}
+
+func TestXLSXToMarkdown_ReadsRenamedColumns(t *testing.T) {
+	columns, err := ParseColumns("major,minor=Case,steps=Procedure,+Build,checkpoints=Checks")
+	if err != nil {
+		t.Fatalf("ParseColumns() returned an unexpected error: %v", err)
+	}
+	cases := []domain.Case{{MajorItem: "Account", MinorItem: "Sign in", ValidationSteps: []string{"Open the page"}, Checkpoints: []string{"* [ ] Form is shown"}}}
+
+	var workbook bytes.Buffer
+	sources := []Source{{Name: "account.md", Reader: strings.NewReader(""), Columns: columns}}
+	if err := NewMarkdownToSpreadsheet(&mockCaseParser{cases: cases}).Convert(sources, &workbook); err != nil {
+		t.Fatalf("Convert() returned an unexpected error: %v", err)
+	}
+
+	var markdown bytes.Buffer
+	imports := []Source{{Name: "account.xlsx", Reader: &workbook, Columns: columns}}
+	if err := NewXLSXToMarkdown().Convert(imports, &markdown); err != nil {
+		t.Fatalf("Convert() returned an unexpected error: %v", err)
+	}
+	if expected := "# account\n\n## Account\n#### Sign in\n\n1. Open the page\n* [ ] Form is shown\n"; markdown.String() != expected {
+		t.Fatalf("unexpected markdown:\n%q", markdown.String())
+	}
+}
+
+func TestReadWorkbookCasesRoundTripsEveryColumn(t *testing.T) {
+	cases := []domain.Case{{
+		MajorItem:       "Account",
+		MediumItem:      "Session",
+		MinorItem:       "Sign in",
+		ValidationSteps: []string{"Open the sign-in page"},
+		Checkpoints:     []string{"* [ ] Form is shown"},
+		Preconditions:   []string{"A user exists"},
+		ExpectedResults: []string{"The dashboard opens", "The user name is shown"},
+		Priority:        "P1",
+		TestType:        "functional",
+		Tags:            []string{"smoke", "regression"},
+		Result:          "OK",
+	}}
+	columns, err := ParseColumns(strings.Join(ColumnKeys(), ","))
+	if err != nil {
+		t.Fatalf("ParseColumns() returned an unexpected error: %v", err)
+	}
+
+	var workbook bytes.Buffer
+	sources := []Source{{Name: "account.md", Reader: strings.NewReader(""), Columns: columns}}
+	if err := NewMarkdownToSpreadsheet(&mockCaseParser{cases: cases}).Convert(sources, &workbook); err != nil {
+		t.Fatalf("Convert() returned an unexpected error: %v", err)
+	}
+	sheets, err := ReadWorkbookCases(workbook.Bytes())
+	if err != nil {
+		t.Fatalf("ReadWorkbookCases() returned an unexpected error: %v", err)
+	}
+	if !reflect.DeepEqual(sheets[0].Cases, cases) {
+		t.Fatalf("unexpected cases:\n got: %#v\nwant: %#v", sheets[0].Cases, cases)
+	}
+
+	var markdown bytes.Buffer
+	if err := WriteMarkdown(&markdown, "", sheets[0].Cases); err != nil {
+		t.Fatalf("WriteMarkdown() returned an unexpected error: %v", err)
+	}
+	expected := `## Account
+### Session
+#### Sign in
+
+Priority: P1
+Type: functional
+Tags: smoke, regression
+Preconditions:
+- A user exists
+1. Open the sign-in page
+* [ ] Form is shown
+Expected results:
+- The dashboard opens
+- The user name is shown
+`
+	if markdown.String() != expected {
+		t.Fatalf("unexpected markdown:\n%s", markdown.String())
+	}
+}
+
+func TestMarkdownToCSV_TranslatesDefaultHeaders(t *testing.T) {
+	columns, err := ParseColumns("major,minor=Case,priority")
+	if err != nil {
+		t.Fatalf("ParseColumns() returned an unexpected error: %v", err)
+	}
+	cases := []domain.Case{{MajorItem: "Account", MinorItem: "Sign in", Priority: "P1"}}
+
+	var output bytes.Buffer
+	sources := []Source{{Name: "account.md", Reader: strings.NewReader(""), Columns: columns, Language: i18n.Japanese}}
+	if err := NewMarkdownToCSV(&mockCaseParser{cases: cases}).Convert(sources, &output); err != nil {
+		t.Fatalf("Convert() returned an unexpected error: %v", err)
+	}
+	if expected := "大項目,Case,優先度\nAccount,Sign in,P1\n"; output.String() != expected {
+		t.Fatalf("unexpected csv:\n%s", output.String())
+	}
+}
+
+func TestXLSXToMarkdown_ReadsHeadersInAnyLanguage(t *testing.T) {
+	cases := []domain.Case{{MajorItem: "Account", MinorItem: "Sign in", ValidationSteps: []string{"Open the page"}, Checkpoints: []string{"* [ ] Form is shown"}}}
+
+	var workbook bytes.Buffer
+	sources := []Source{{Name: "account.md", Reader: strings.NewReader(""), Language: i18n.Japanese}}
+	if err := NewMarkdownToSpreadsheet(&mockCaseParser{cases: cases}).Convert(sources, &workbook); err != nil {
+		t.Fatalf("Convert() returned an unexpected error: %v", err)
+	}
+
+	var markdown bytes.Buffer
+	imports := []Source{{Name: "account.xlsx", Reader: &workbook}}
+	if err := NewXLSXToMarkdown().Convert(imports, &markdown); err != nil {
+		t.Fatalf("Convert() returned an unexpected error: %v", err)
+	}
+	if expected := "# account\n\n## Account\n#### Sign in\n\n1. Open the page\n* [ ] Form is shown\n"; markdown.String() != expected {
+		t.Fatalf("unexpected markdown:\n%q", markdown.String())
+	}
+}
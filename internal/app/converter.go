@@ -1,7 +1,6 @@
 package app
 
 import (
-	"archive/zip"
 	"bytes"
 	"context"
 	"encoding/csv"
@@ -13,6 +12,9 @@ import (
 	"unicode/utf8"
 
 	"github.com/9renpoto/casemd/internal/core/domain"
+	"github.com/9renpoto/casemd/internal/core/filter"
+	"github.com/9renpoto/casemd/internal/core/results"
+	"github.com/9renpoto/casemd/internal/i18n"
 )
 
 // CaseParser defines the behavior required to parse test cases from Markdown.
@@ -24,6 +26,142 @@ type CaseParser interface {
 type Source struct {
 	Name   string
 	Reader io.Reader
+	// Results, when set, fills the execution columns and checkpoint states
+	// of the parsed cases.
+	Results *domain.Run
+	// Context, when set, aborts parsing and writing the source's rows once
+	// it is done, e.g. when the web request that submitted the Markdown
+	// times out.
+	Context context.Context
+	// Columns selects the CSV and spreadsheet columns written for the
+	// source's cases; DefaultColumns is used when empty. A CSV file has a
+	// single header, so it uses the columns of the first source.
+	Columns []Column
+	// Layout maps the source's cases to CSV and spreadsheet rows, both when
+	// writing them and when importing a workbook; CaseLayout is used when
+	// empty.
+	Layout Layout
+	// Language translates the default column headers and the HTML report;
+	// English is used when empty. Headers renamed in Columns are kept.
+	Language i18n.Language
+	// Filter drops the cases it does not match before they are converted,
+	// after Results are merged so results can be filtered on too. The zero
+	// Filter keeps every case.
+	Filter filter.Filter
+	// Includes resolves the include directives of the Markdown when the
+	// parser is an IncludeParser.
+	Includes Includes
+}
+
+// Includes locates the documents named by include directives.
+type Includes struct {
+	// Name is the slash-separated path of the source; relative include
+	// paths resolve against its directory.
+	Name string
+	// Open opens the document at a slash-separated path. Without it only
+	// the snippets and minor items of the source itself can be included.
+	Open func(name string) (io.ReadCloser, error)
+}
+
+// IncludeParser is implemented by parsers that expand include directives
+// and shared step snippets.
+type IncludeParser interface {
+	ParseDocumentWithIncludes(r io.Reader, includes Includes) (domain.Document, error)
+}
+
+// columns returns the columns selected for the source, with default headers
+// in the source's language.
+func (s Source) columns() []Column {
+	columns := s.Columns
+	if len(columns) == 0 {
+		columns = DefaultColumns()
+	}
+	return localizeColumns(columns, s.Language)
+}
+
+// reader returns the Markdown reader, failing reads once the source's
+// context is done.
+func (s Source) reader() io.Reader {
+	if s.Context == nil {
+		return s.Reader
+	}
+	return contextReader{ctx: s.Context, reader: s.Reader}
+}
+
+// contextErr reports why the source's context is done, if it is.
+func (s Source) contextErr() error {
+	if s.Context == nil {
+		return nil
+	}
+	return s.Context.Err()
+}
+
+type contextReader struct {
+	ctx    context.Context
+	reader io.Reader
+}
+
+func (r contextReader) Read(p []byte) (int, error) {
+	if err := r.ctx.Err(); err != nil {
+		return 0, err
+	}
+	return r.reader.Read(p)
+}
+
+// parseDocument parses a source, expanding its includes when parser
+// supports them.
+func parseDocument(parser DocumentParser, source Source) (domain.Document, error) {
+	var document domain.Document
+	var err error
+	if includer, ok := parser.(IncludeParser); ok {
+		document, err = includer.ParseDocumentWithIncludes(source.reader(), source.Includes)
+	} else {
+		document, err = parser.ParseDocument(source.reader())
+	}
+	if err == nil {
+		err = source.contextErr()
+	}
+	return document, err
+}
+
+// parseCases parses the cases of a source. Includes that cannot be expanded
+// fail the parse, as the cases would silently miss their steps.
+func parseCases(parser CaseParser, source Source) ([]domain.Case, error) {
+	includer, ok := parser.(IncludeParser)
+	if !ok {
+		cases, err := parser.Parse(source.reader())
+		if err == nil {
+			err = source.contextErr()
+		}
+		return cases, err
+	}
+
+	document, err := includer.ParseDocumentWithIncludes(source.reader(), source.Includes)
+	if err == nil {
+		err = source.contextErr()
+	}
+	if err != nil {
+		return nil, err
+	}
+	for _, diagnostic := range document.Diagnostics {
+		if diagnostic.Severity == domain.SeverityError {
+			return nil, fmt.Errorf("line %d: %s", diagnostic.Line, diagnostic.Message)
+		}
+	}
+	return document.Cases, nil
+}
+
+// parseSource parses a source, merges the results recorded for it and
+// applies its filter.
+func parseSource(parser CaseParser, source Source) ([]domain.Case, error) {
+	cases, err := parseCases(parser, source)
+	if err != nil {
+		return nil, err
+	}
+	if source.Results != nil {
+		cases = results.Merge(cases, *source.Results)
+	}
+	return source.Filter.Apply(cases), nil
 }
 
 // GoogleSpreadsheetCreator defines the behavior required to create Google Spreadsheets.
@@ -43,26 +181,6 @@ type GoogleSpreadsheetSheet struct {
 	Rows  [][]string
 }
 
-var spreadsheetHeaders = []string{
-	"Major Item", "Medium Item", "Minor Item",
-	"Validation Steps", "Checkpoints",
-	"Result", "Test Date", "Tester", "Notes",
-}
-
-func caseRow(aCase domain.Case) []string {
-	return []string{
-		aCase.MajorItem,
-		aCase.MediumItem,
-		aCase.MinorItem,
-		strings.Join(aCase.ValidationSteps, "\n"),
-		strings.Join(aCase.Checkpoints, "\n"),
-		"", // Result
-		"", // Test Date
-		"", // Tester
-		"", // Notes
-	}
-}
-
 // MarkdownToCSV orchestrates the conversion of Markdown test cases into CSV rows.
 type MarkdownToCSV struct {
 	parser CaseParser
@@ -79,20 +197,25 @@ func (c *MarkdownToCSV) Convert(sources []Source, output io.Writer) error {
 		return fmt.Errorf("no sources provided")
 	}
 
+	columns := sources[0].columns()
 	writer := csv.NewWriter(output)
-	if err := writer.Write(spreadsheetHeaders); err != nil {
+	if err := writer.Write(columnHeaders(columns)); err != nil {
 		return fmt.Errorf("write csv header: %w", err)
 	}
 
 	for _, source := range sources {
-		cases, err := c.parser.Parse(source.Reader)
+		cases, err := parseSource(c.parser, source)
 		if err != nil {
 			writer.Flush()
 			return fmt.Errorf("parse %s: %w", source.Name, err)
 		}
 
-		for _, aCase := range cases {
-			if err := writer.Write(caseRow(aCase)); err != nil {
+		for _, aCase := range source.rows(cases) {
+			if err := source.contextErr(); err != nil {
+				writer.Flush()
+				return fmt.Errorf("write %s: %w", source.Name, err)
+			}
+			if err := writer.Write(caseRow(columns, aCase)); err != nil {
 				writer.Flush()
 				return fmt.Errorf("write csv row: %w", err)
 			}
@@ -119,8 +242,35 @@ func NewMarkdownToSpreadsheet(parser CaseParser) *MarkdownToSpreadsheet {
 
 // Convert reads Markdown data and writes a spreadsheet workbook with one sheet per Markdown file.
 func (c *MarkdownToSpreadsheet) Convert(sources []Source, output io.Writer) error {
+	sheets, err := buildWorkbookSheets(c.parser, sources)
+	if err != nil {
+		return err
+	}
+	return writeWorkbook(output, sheets)
+}
+
+// MarkdownToODS orchestrates the conversion of Markdown test cases into OpenDocument spreadsheets.
+type MarkdownToODS struct {
+	parser CaseParser
+}
+
+// NewMarkdownToODS wires the converter with the provided parser implementation.
+func NewMarkdownToODS(parser CaseParser) *MarkdownToODS {
+	return &MarkdownToODS{parser: parser}
+}
+
+// Convert reads Markdown data and writes an OpenDocument spreadsheet with one table per Markdown file.
+func (c *MarkdownToODS) Convert(sources []Source, output io.Writer) error {
+	sheets, err := buildWorkbookSheets(c.parser, sources)
+	if err != nil {
+		return err
+	}
+	return writeODS(output, sheets)
+}
+
+func buildWorkbookSheets(parser CaseParser, sources []Source) ([]workbookSheet, error) {
 	if len(sources) == 0 {
-		return fmt.Errorf("no sources provided")
+		return nil, fmt.Errorf("no sources provided")
 	}
 
 	sheets := make([]workbookSheet, 0, len(sources))
@@ -131,22 +281,36 @@ func (c *MarkdownToSpreadsheet) Convert(sources []Source, output io.Writer) erro
 		sheetBase := deriveSheetName(source.Name, index)
 		sheetName := ensureUniqueSheetName(sheetBase, nameUsage, finalNames)
 
-		cases, err := c.parser.Parse(source.Reader)
+		cases, err := parseSource(parser, source)
 		if err != nil {
-			return fmt.Errorf("parse %s: %w", sheetName, err)
+			return nil, fmt.Errorf("parse %s: %w", sheetName, err)
 		}
 
-		rows := make([][]string, 0, len(cases)+1)
-		rows = append(rows, append([]string(nil), spreadsheetHeaders...))
+		sheets = append(sheets, newWorkbookSheet(sheetName, source, cases))
+	}
 
-		for _, aCase := range cases {
-			rows = append(rows, caseRow(aCase))
-		}
+	return sheets, nil
+}
 
-		sheets = append(sheets, workbookSheet{Name: sheetName, Rows: rows})
+// newWorkbookSheet lays out the cases of source as a sheet named name. The
+// cells of a row are only rendered when the row is written.
+func newWorkbookSheet(name string, source Source, cases []domain.Case) workbookSheet {
+	columns := source.columns()
+	header := columnHeaders(columns)
+	rows := source.rows(cases)
+	return workbookSheet{
+		Name:   name,
+		Width:  len(header),
+		Height: len(rows) + 1,
+		Row: func(i int) []string {
+			if i == 0 {
+				return header
+			}
+			return caseRow(columns, rows[i-1])
+		},
+		SharedColumns: headingColumns(columns),
+		Context:       source.Context,
 	}
-
-	return writeWorkbook(output, sheets)
 }
 
 // MarkdownToGoogleSpreadsheet orchestrates the conversion of Markdown cases into Google Sheets.
@@ -165,31 +329,15 @@ func (c *MarkdownToGoogleSpreadsheet) Create(ctx context.Context, title string,
 	if title == "" {
 		return "", fmt.Errorf("spreadsheet title cannot be empty")
 	}
-	if len(sources) == 0 {
-		return "", fmt.Errorf("no sources provided")
-	}
 
-	sheets := make([]GoogleSpreadsheetSheet, 0, len(sources))
-	nameUsage := make(map[string]int)
-	finalNames := make(map[string]struct{})
-
-	for index, source := range sources {
-		sheetBase := deriveSheetName(source.Name, index)
-		sheetName := ensureUniqueSheetName(sheetBase, nameUsage, finalNames)
-
-		cases, err := c.parser.Parse(source.Reader)
-		if err != nil {
-			return "", fmt.Errorf("parse %s: %w", sheetName, err)
-		}
-
-		rows := make([][]string, 0, len(cases)+1)
-		rows = append(rows, append([]string(nil), spreadsheetHeaders...))
-
-		for _, aCase := range cases {
-			rows = append(rows, caseRow(aCase))
-		}
+	workbookSheets, err := buildWorkbookSheets(c.parser, sources)
+	if err != nil {
+		return "", err
+	}
 
-		sheets = append(sheets, GoogleSpreadsheetSheet{Title: sheetName, Rows: rows})
+	sheets := make([]GoogleSpreadsheetSheet, 0, len(workbookSheets))
+	for _, sheet := range workbookSheets {
+		sheets = append(sheets, GoogleSpreadsheetSheet{Title: sheet.Name, Rows: sheet.grid()})
 	}
 
 	spreadsheet := GoogleSpreadsheet{Title: title, Sheets: sheets}
@@ -201,138 +349,47 @@ func (c *MarkdownToGoogleSpreadsheet) Create(ctx context.Context, title string,
 	return spreadsheetID, nil
 }
 
+// workbookSheet is one sheet of a written workbook. Rows are produced one at
+// a time while the sheet is written, so the cells of a large export are
+// never all held in memory.
 type workbookSheet struct {
 	Name string
-	Rows [][]string
+	// Width is the number of columns and Height the number of rows,
+	// header included.
+	Width, Height int
+	// Row returns row i of the sheet; row 0 is the header.
+	Row func(i int) []string
+	// SharedColumns, like the header row, repeat heavily and are stored in
+	// the XLSX shared strings table instead of inline.
+	SharedColumns []int
+	// Context, when set, aborts writing the sheet once it is done.
+	Context context.Context
 }
 
-func writeWorkbook(w io.Writer, sheets []workbookSheet) error {
-	zipWriter := zip.NewWriter(w)
-
-	if err := writeZipFile(zipWriter, "[Content_Types].xml", buildContentTypes(sheets)); err != nil {
-		zipWriter.Close()
-		return err
-	}
-
-	if err := writeZipFile(zipWriter, "_rels/.rels", rootRelationships); err != nil {
-		zipWriter.Close()
-		return err
-	}
-
-	if err := writeZipFile(zipWriter, "docProps/app.xml", appProperties); err != nil {
-		zipWriter.Close()
-		return err
-	}
-
-	if err := writeZipFile(zipWriter, "docProps/core.xml", coreProperties); err != nil {
-		zipWriter.Close()
-		return err
-	}
-
-	if err := writeZipFile(zipWriter, "xl/workbook.xml", buildWorkbookXML(sheets)); err != nil {
-		zipWriter.Close()
-		return err
-	}
-
-	if err := writeZipFile(zipWriter, "xl/_rels/workbook.xml.rels", buildWorkbookRelationships(sheets)); err != nil {
-		zipWriter.Close()
-		return err
-	}
-
-	for i, sheet := range sheets {
-		path := fmt.Sprintf("xl/worksheets/sheet%d.xml", i+1)
-		if err := writeZipFile(zipWriter, path, buildWorksheetXML(sheet.Rows)); err != nil {
-			zipWriter.Close()
-			return err
-		}
+// tableSheet returns a sheet of rows already held in memory, header first.
+func tableSheet(name string, rows [][]string) workbookSheet {
+	sheet := workbookSheet{Name: name, Height: len(rows), Row: func(i int) []string { return rows[i] }}
+	if len(rows) > 0 {
+		sheet.Width = len(rows[0])
 	}
-
-	return zipWriter.Close()
+	return sheet
 }
 
-func writeZipFile(zipWriter *zip.Writer, name, content string) error {
-	writer, err := zipWriter.Create(name)
-	if err != nil {
-		return fmt.Errorf("create %s: %w", name, err)
-	}
-	if _, err := writer.Write([]byte(content)); err != nil {
-		return fmt.Errorf("write %s: %w", name, err)
+// contextErr reports why the sheet's context is done, if it is.
+func (s workbookSheet) contextErr() error {
+	if s.Context == nil {
+		return nil
 	}
-	return nil
+	return s.Context.Err()
 }
 
-func buildContentTypes(sheets []workbookSheet) string {
-	var builder strings.Builder
-	builder.WriteString(xml.Header)
-	builder.WriteString(`<Types xmlns="http://schemas.openxmlformats.org/package/2006/content-types">`)
-	builder.WriteString(`<Default Extension="rels" ContentType="application/vnd.openxmlformats-package.relationships+xml"/>`)
-	builder.WriteString(`<Default Extension="xml" ContentType="application/xml"/>`)
-	builder.WriteString(`<Override PartName="/xl/workbook.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.sheet.main+xml"/>`)
-	for i := range sheets {
-		builder.WriteString(fmt.Sprintf(`<Override PartName="/xl/worksheets/sheet%d.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.worksheet+xml"/>`, i+1))
-	}
-	builder.WriteString(`</Types>`)
-	return builder.String()
-}
-
-func buildWorkbookXML(sheets []workbookSheet) string {
-	var builder strings.Builder
-	builder.WriteString(xml.Header)
-	builder.WriteString(`<workbook xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main" xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships">`)
-	builder.WriteString(`<sheets>`)
-	for i, sheet := range sheets {
-		builder.WriteString(fmt.Sprintf(`<sheet name="%s" sheetId="%d" r:id="rId%d"/>`, xmlEscapeAttr(sheet.Name), i+1, i+1))
+// grid returns every row of the sheet, for the writers that need them at once.
+func (s workbookSheet) grid() [][]string {
+	rows := make([][]string, s.Height)
+	for i := range rows {
+		rows[i] = s.Row(i)
 	}
-	builder.WriteString(`</sheets></workbook>`)
-	return builder.String()
-}
-
-func buildWorkbookRelationships(sheets []workbookSheet) string {
-	var builder strings.Builder
-	builder.WriteString(xml.Header)
-	builder.WriteString(`<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">`)
-	for i := range sheets {
-		builder.WriteString(fmt.Sprintf(`<Relationship Id="rId%d" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/worksheet" Target="worksheets/sheet%d.xml"/>`, i+1, i+1))
-	}
-	builder.WriteString(`</Relationships>`)
-	return builder.String()
-}
-
-func buildWorksheetXML(rows [][]string) string {
-	var builder strings.Builder
-	builder.WriteString(xml.Header)
-	builder.WriteString(`<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main">`)
-
-	if len(rows) > 0 && len(rows[0]) > 0 {
-		lastCol := columnName(len(rows[0]))
-		builder.WriteString(fmt.Sprintf(`<dimension ref="A1:%s%d"/>`, lastCol, len(rows)))
-	}
-
-	builder.WriteString(`<sheetData>`)
-	for i, row := range rows {
-		rowIndex := i + 1
-		builder.WriteString(fmt.Sprintf(`<row r="%d">`, rowIndex))
-		for j, value := range row {
-			cellRef := fmt.Sprintf("%s%d", columnName(j+1), rowIndex)
-			if value == "" {
-				builder.WriteString(fmt.Sprintf(`<c r="%s"/>`, cellRef))
-				continue
-			}
-			builder.WriteString(fmt.Sprintf(`<c r="%s" t="inlineStr"><is><t>%s</t></is></c>`, cellRef, escapeCellText(value)))
-		}
-		builder.WriteString(`</row>`)
-	}
-	builder.WriteString(`</sheetData></worksheet>`)
-	return builder.String()
-}
-
-func escapeCellText(value string) string {
-	var buf bytes.Buffer
-	if err := xml.EscapeText(&buf, []byte(value)); err != nil {
-		return ""
-	}
-	escaped := buf.String()
-	return strings.ReplaceAll(escaped, "\n", "&#10;")
+	return rows
 }
 
 func xmlEscapeAttr(value string) string {
@@ -343,20 +400,6 @@ func xmlEscapeAttr(value string) string {
 	return buf.String()
 }
 
-func columnName(n int) string {
-	const letters = "ABCDEFGHIJKLMNOPQRSTUVWXYZ"
-	if n <= 0 {
-		return "A"
-	}
-	result := ""
-	for n > 0 {
-		n--
-		result = string(letters[n%26]) + result
-		n /= 26
-	}
-	return result
-}
-
 func deriveSheetName(name string, index int) string {
 	if name == "" {
 		return fmt.Sprintf("Sheet%d", index+1)
@@ -464,21 +507,3 @@ var invalidSheetNameChars = strings.NewReplacer(
 	"/", "_",
 	"\\", "_",
 )
-
-const rootRelationships = `<?xml version="1.0" encoding="UTF-8"?>
-<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">
-  <Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/officeDocument" Target="xl/workbook.xml"/>
-  <Relationship Id="rId2" Type="http://schemas.openxmlformats.org/package/2006/relationships/metadata/core-properties" Target="docProps/core.xml"/>
-  <Relationship Id="rId3" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/extended-properties" Target="docProps/app.xml"/>
-</Relationships>`
-
-const appProperties = `<?xml version="1.0" encoding="UTF-8"?>
-<Properties xmlns="http://schemas.openxmlformats.org/officeDocument/2006/extended-properties" xmlns:vt="http://schemas.openxmlformats.org/officeDocument/2006/docPropsVTypes">
-  <Application>casemd</Application>
-</Properties>`
-
-const coreProperties = `<?xml version="1.0" encoding="UTF-8"?>
-<cp:coreProperties xmlns:cp="http://schemas.openxmlformats.org/package/2006/metadata/core-properties" xmlns:dc="http://purl.org/dc/elements/1.1/" xmlns:dcterms="http://purl.org/dc/terms/" xmlns:dcmitype="http://purl.org/dc/dcmitype/" xmlns:xsi="http://www.w3.org/2001/XMLSchema-instance">
-  <dc:creator>casemd</dc:creator>
-  <cp:lastModifiedBy>casemd</cp:lastModifiedBy>
-</cp:coreProperties>`
@@ -6,13 +6,16 @@ import (
 	"context"
 	"encoding/csv"
 	"encoding/xml"
+	"errors"
 	"fmt"
 	"io"
 	"reflect"
+	"strconv"
 	"strings"
 	"testing"
 
 	"github.com/9renpoto/casemd/internal/core/domain"
+	"github.com/9renpoto/casemd/internal/core/filter"
 )
 
 type mockCaseParser struct {
@@ -70,9 +73,9 @@ func TestMarkdownToCSV_Convert(t *testing.T) {
 	}
 
 	expectedRecords := [][]string{
-		spreadsheetHeaders,
-		caseRow(mockCases[0]),
-		caseRow(mockCases[1]),
+		columnHeaders(DefaultColumns()),
+		caseRow(DefaultColumns(), mockCases[0]),
+		caseRow(DefaultColumns(), mockCases[1]),
 	}
 
 	if !reflect.DeepEqual(records, expectedRecords) {
@@ -80,6 +83,123 @@ func TestMarkdownToCSV_Convert(t *testing.T) {
 	}
 }
 
+func TestMarkdownToCSV_ConvertMergesResults(t *testing.T) {
+	parser := &mockCaseParser{cases: []domain.Case{
+		{MajorItem: "Setup", MinorItem: "Install", ValidationSteps: []string{"Install"}, Checkpoints: []string{"* [ ] Installed"}},
+	}}
+	converter := NewMarkdownToCSV(parser)
+
+	run := &domain.Run{Cases: []domain.CaseResult{
+		{MajorItem: "Setup", MinorItem: "Install", Checkpoints: []string{"* [x] Installed"}, Result: "pass", TestDate: "2026-10-01", Tester: "alice", Notes: "ok"},
+	}}
+	var output bytes.Buffer
+	if err := converter.Convert([]Source{{Name: "checks.md", Reader: strings.NewReader(""), Results: run}}, &output); err != nil {
+		t.Fatalf("Convert() returned an unexpected error: %v", err)
+	}
+
+	records, err := csv.NewReader(&output).ReadAll()
+	if err != nil {
+		t.Fatalf("ReadAll() returned an unexpected error: %v", err)
+	}
+	expected := []string{"Setup", "", "Install", "Install", "* [x] Installed", "pass", "2026-10-01", "alice", "ok"}
+	if !reflect.DeepEqual(records[1], expected) {
+		t.Fatalf("unexpected merged row: %#v", records[1])
+	}
+}
+
+func TestMarkdownToCSV_ConvertAppliesFilter(t *testing.T) {
+	parser := &mockCaseParser{cases: []domain.Case{
+		{MajorItem: "Setup", MinorItem: "Install", Priority: "P1", Tags: []string{"smoke"}},
+		{MajorItem: "Setup", MinorItem: "Configure", Priority: "P3", Tags: []string{"smoke"}},
+		{MajorItem: "Execution", MinorItem: "Run", Priority: "P1"},
+	}}
+	selected, err := filter.Parse("tag:smoke && priority<=P2")
+	if err != nil {
+		t.Fatalf("Parse() returned an unexpected error: %v", err)
+	}
+	// Results are merged before filtering, so a result can be selected too.
+	run := &domain.Run{Cases: []domain.CaseResult{{MajorItem: "Execution", MinorItem: "Run", Result: "NG"}}}
+	failed, err := filter.Parse("result:NG")
+	if err != nil {
+		t.Fatalf("Parse() returned an unexpected error: %v", err)
+	}
+
+	columns, err := ParseColumns("minor,result")
+	if err != nil {
+		t.Fatalf("ParseColumns() returned an unexpected error: %v", err)
+	}
+	var output bytes.Buffer
+	sources := []Source{
+		{Name: "setup.md", Reader: strings.NewReader(""), Columns: columns, Filter: selected},
+		{Name: "run.md", Reader: strings.NewReader(""), Results: run, Filter: failed},
+	}
+	if err := NewMarkdownToCSV(parser).Convert(sources, &output); err != nil {
+		t.Fatalf("Convert() returned an unexpected error: %v", err)
+	}
+	if expected := "Minor Item,Result\nInstall,\nRun,NG\n"; output.String() != expected {
+		t.Fatalf("unexpected csv:\n%s", output.String())
+	}
+}
+
+func TestMarkdownToCSV_ConvertStopsWhenContextIsDone(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	converter := NewMarkdownToCSV(&mockCaseParser{})
+
+	// The mock ignores its reader, so this also covers parsers that finish
+	// without reading after the deadline.
+	err := converter.Convert([]Source{{Name: "checks.md", Reader: strings.NewReader("## Setup"), Context: ctx}}, io.Discard)
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected context.Canceled, got %v", err)
+	}
+
+	reader := Source{Reader: strings.NewReader("## Setup"), Context: ctx}.reader()
+	if _, err := io.ReadAll(reader); !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected reads to fail once the context is done, got %v", err)
+	}
+}
+
+// mockIncludeParser returns document for any source and records the includes
+// it was asked to resolve.
+type mockIncludeParser struct {
+	mockCaseParser
+	document domain.Document
+	includes Includes
+}
+
+func (m *mockIncludeParser) ParseDocumentWithIncludes(r io.Reader, includes Includes) (domain.Document, error) {
+	m.includes = includes
+	return m.document, nil
+}
+
+func TestMarkdownToCSV_ConvertFailsOnUnresolvedIncludes(t *testing.T) {
+	parser := &mockIncludeParser{document: domain.Document{
+		Cases: []domain.Case{{MajorItem: "Auth", MinorItem: "Login"}},
+		Diagnostics: []domain.Diagnostic{
+			{Line: 2, Severity: domain.SeverityWarning, Message: "checkpoint must be written as \"* [ ]\" or \"* [x]\"; line is ignored"},
+			{Line: 4, Severity: domain.SeverityError, Message: "cannot open included document shared/login.md: file does not exist"},
+		},
+	}}
+	includes := Includes{Name: "checks/login.md"}
+
+	err := NewMarkdownToCSV(parser).Convert([]Source{{Name: "checks/login.md", Reader: strings.NewReader(""), Includes: includes}}, io.Discard)
+	if err == nil || !strings.Contains(err.Error(), "line 4: cannot open included document shared/login.md") {
+		t.Fatalf("expected the include error, got %v", err)
+	}
+	if parser.includes.Name != includes.Name {
+		t.Fatalf("expected the source includes to reach the parser, got %+v", parser.includes)
+	}
+
+	parser.document.Diagnostics = parser.document.Diagnostics[:1]
+	var output bytes.Buffer
+	if err := NewMarkdownToCSV(parser).Convert([]Source{{Name: "checks/login.md", Reader: strings.NewReader("")}}, &output); err != nil {
+		t.Fatalf("Convert() returned an unexpected error: %v", err)
+	}
+	if !strings.Contains(output.String(), "Auth,,Login") {
+		t.Fatalf("expected the parsed document's cases, got:\n%s", output.String())
+	}
+}
+
 func TestMarkdownToSpreadsheet_Convert(t *testing.T) {
 	mockCases := []domain.Case{
 		{
@@ -111,9 +231,9 @@ func TestMarkdownToSpreadsheet_Convert(t *testing.T) {
 	rows := readSheetRows(t, output.Bytes(), 1)
 
 	expectedRows := [][]string{
-		append([]string(nil), spreadsheetHeaders...),
-		caseRow(mockCases[0]),
-		caseRow(mockCases[1]),
+		columnHeaders(DefaultColumns()),
+		caseRow(DefaultColumns(), mockCases[0]),
+		caseRow(DefaultColumns(), mockCases[1]),
 	}
 
 	if !reflect.DeepEqual(rows, expectedRows) {
@@ -121,6 +241,37 @@ func TestMarkdownToSpreadsheet_Convert(t *testing.T) {
 	}
 }
 
+func TestMarkdownToSpreadsheet_ConvertSharesRepeatedValues(t *testing.T) {
+	parser := &mockCaseParser{cases: []domain.Case{
+		{MajorItem: "Setup", MediumItem: "Environment", MinorItem: "One"},
+		{MajorItem: "Setup", MediumItem: "Environment", MinorItem: "Two"},
+		{MajorItem: "Setup", MediumItem: "Configuration", MinorItem: "Three"},
+	}}
+	converter := NewMarkdownToSpreadsheet(parser)
+
+	sources := []Source{{Name: "checks.md", Reader: strings.NewReader("")}}
+	var output bytes.Buffer
+	if err := converter.Convert(sources, &output); err != nil {
+		t.Fatalf("Convert() returned an unexpected error: %v", err)
+	}
+
+	zipReader, err := zip.NewReader(bytes.NewReader(output.Bytes()), int64(output.Len()))
+	if err != nil {
+		t.Fatalf("open zip: %v", err)
+	}
+
+	shared := readSharedStrings(t, zipReader)
+	expected := append(columnHeaders(DefaultColumns()), "Setup", "Environment", "Configuration")
+	if !reflect.DeepEqual(shared, expected) {
+		t.Fatalf("unexpected shared strings: %#v", shared)
+	}
+
+	rows := readSheetRows(t, output.Bytes(), 1)
+	if rows[3][0] != "Setup" || rows[3][1] != "Configuration" || rows[3][2] != "Three" {
+		t.Fatalf("unexpected row: %#v", rows[3])
+	}
+}
+
 func TestMarkdownToSpreadsheet_ConvertMultipleSources(t *testing.T) {
 	parser := &mockCaseParser{cases: []domain.Case{{MinorItem: "Row"}}}
 	converter := NewMarkdownToSpreadsheet(parser)
@@ -152,6 +303,8 @@ func readSheetRows(t *testing.T, data []byte, sheetIndex int) [][]string {
 		t.Fatalf("open zip: %v", err)
 	}
 
+	shared := readSharedStrings(t, zipReader)
+
 	target := fmt.Sprintf("xl/worksheets/sheet%d.xml", sheetIndex)
 	for _, file := range zipReader.File {
 		if file.Name != target {
@@ -178,7 +331,7 @@ func readSheetRows(t *testing.T, data []byte, sheetIndex int) [][]string {
 		for i, row := range ws.SheetData.Rows {
 			values := make([]string, len(row.Cells))
 			for j, cell := range row.Cells {
-				values[j] = cell.Value()
+				values[j] = cell.Value(shared)
 			}
 			rows[i] = values
 		}
@@ -189,6 +342,30 @@ func readSheetRows(t *testing.T, data []byte, sheetIndex int) [][]string {
 	return nil
 }
 
+func readSharedStrings(t *testing.T, zipReader *zip.Reader) []string {
+	t.Helper()
+
+	for _, file := range zipReader.File {
+		if file.Name != "xl/sharedStrings.xml" {
+			continue
+		}
+
+		rc, err := file.Open()
+		if err != nil {
+			t.Fatalf("open shared strings: %v", err)
+		}
+		defer rc.Close()
+
+		var table sharedStringTable
+		if err := xml.NewDecoder(rc).Decode(&table); err != nil {
+			t.Fatalf("unmarshal shared strings: %v", err)
+		}
+		return table.Items
+	}
+
+	return nil
+}
+
 func readSheetNames(t *testing.T, data []byte) []string {
 	t.Helper()
 
@@ -255,7 +432,7 @@ func TestMarkdownToGoogleSpreadsheet_Create(t *testing.T) {
 		t.Fatalf("unexpected sheet title: %s", sheet.Title)
 	}
 	expectedRows := [][]string{
-		append([]string(nil), spreadsheetHeaders...),
+		columnHeaders(DefaultColumns()),
 		{"", "", "One", "", "", "", "", "", ""},
 	}
 	if !reflect.DeepEqual(sheet.Rows, expectedRows) {
@@ -315,6 +492,8 @@ type sheetRow struct {
 }
 
 type sheetCell struct {
+	Type      string       `xml:"t,attr"`
+	Raw       string       `xml:"v"`
 	InlineStr inlineString `xml:"is"`
 }
 
@@ -322,10 +501,21 @@ type inlineString struct {
 	Text string `xml:"t"`
 }
 
-func (c sheetCell) Value() string {
+func (c sheetCell) Value(shared []string) string {
+	if c.Type == "s" {
+		index, err := strconv.Atoi(c.Raw)
+		if err != nil || index >= len(shared) {
+			return ""
+		}
+		return shared[index]
+	}
 	return c.InlineStr.Text
 }
 
+type sharedStringTable struct {
+	Items []string `xml:"si>t"`
+}
+
 type workbookFile struct {
 	XMLName xml.Name    `xml:"workbook"`
 	Sheets  workbookSet `xml:"sheets"`
@@ -0,0 +1,148 @@
+package app
+
+import (
+	"fmt"
+
+	"github.com/9renpoto/casemd/internal/core/domain"
+	"github.com/9renpoto/casemd/internal/core/lint"
+	"github.com/9renpoto/casemd/internal/core/results"
+)
+
+// StructuredDocument is the parsed form of a Markdown source served by the
+// parse API: the heading hierarchy with its cases, and the diagnostics.
+type StructuredDocument struct {
+	Name        string              `json:"name"`
+	Title       string              `json:"title"`
+	MajorItems  []MajorItem         `json:"majorItems"`
+	Diagnostics []domain.Diagnostic `json:"diagnostics"`
+}
+
+// MajorItem groups the cases under a `##` heading.
+type MajorItem struct {
+	Name        string       `json:"name"`
+	MediumItems []MediumItem `json:"mediumItems"`
+}
+
+// MediumItem groups the cases under a `###` heading. Cases written directly
+// below a major item share a medium item with an empty name.
+type MediumItem struct {
+	Name  string           `json:"name"`
+	Cases []StructuredCase `json:"cases"`
+}
+
+// StructuredCase is a `####` minor item with its steps and checkpoints.
+type StructuredCase struct {
+	MinorItem   string       `json:"minorItem"`
+	Steps       []string     `json:"steps"`
+	Checkpoints []Checkpoint `json:"checkpoints"`
+	// Procedure pairs each step with the checkpoints nested under it.
+	Procedure       []ProcedureStep `json:"procedure,omitempty"`
+	Preconditions   []string        `json:"preconditions,omitempty"`
+	ExpectedResults []string        `json:"expectedResults,omitempty"`
+	Priority        string          `json:"priority,omitempty"`
+	TestType        string          `json:"testType,omitempty"`
+	Tags            []string        `json:"tags,omitempty"`
+	Effort          string          `json:"effort,omitempty"`
+	Result          string          `json:"result,omitempty"`
+	TestDate        string          `json:"testDate,omitempty"`
+	Tester          string          `json:"tester,omitempty"`
+	Notes           string          `json:"notes,omitempty"`
+	Line            int             `json:"line,omitempty"`
+	EndLine         int             `json:"endLine,omitempty"`
+}
+
+// Checkpoint is a task list item split into its text and ticked state.
+type Checkpoint struct {
+	Text    string `json:"text"`
+	Checked bool   `json:"checked"`
+}
+
+// ProcedureStep is a validation step with the checkpoints that verify it.
+type ProcedureStep struct {
+	Action       string       `json:"action"`
+	Expectations []Checkpoint `json:"expectations"`
+}
+
+// MarkdownDocument orchestrates parsing Markdown into structured documents.
+type MarkdownDocument struct {
+	parser DocumentParser
+}
+
+// NewMarkdownDocument wires the use case with the provided parser implementation.
+func NewMarkdownDocument(parser DocumentParser) *MarkdownDocument {
+	return &MarkdownDocument{parser: parser}
+}
+
+// Parse parses a single source into its hierarchy, with parser and lint diagnostics.
+func (d *MarkdownDocument) Parse(source Source) (StructuredDocument, error) {
+	document, err := parseDocument(d.parser, source)
+	if err != nil {
+		return StructuredDocument{}, fmt.Errorf("parse %s: %w", source.Name, err)
+	}
+	if source.Results != nil {
+		document.Cases = results.Merge(document.Cases, *source.Results)
+	}
+
+	structured := StructuredDocument{
+		Name:        source.Name,
+		Title:       document.Title,
+		MajorItems:  []MajorItem{},
+		Diagnostics: append(document.Diagnostics, lint.Check(document.Cases)...),
+	}
+	if structured.Diagnostics == nil {
+		structured.Diagnostics = []domain.Diagnostic{}
+	}
+
+	for _, aCase := range source.Filter.Apply(document.Cases) {
+		majors := structured.MajorItems
+		if len(majors) == 0 || majors[len(majors)-1].Name != aCase.MajorItem {
+			structured.MajorItems = append(majors, MajorItem{Name: aCase.MajorItem, MediumItems: []MediumItem{}})
+		}
+		major := &structured.MajorItems[len(structured.MajorItems)-1]
+
+		mediums := major.MediumItems
+		if len(mediums) == 0 || mediums[len(mediums)-1].Name != aCase.MediumItem {
+			major.MediumItems = append(mediums, MediumItem{Name: aCase.MediumItem, Cases: []StructuredCase{}})
+		}
+		medium := &major.MediumItems[len(major.MediumItems)-1]
+		medium.Cases = append(medium.Cases, newStructuredCase(aCase))
+	}
+	return structured, nil
+}
+
+func newStructuredCase(aCase domain.Case) StructuredCase {
+	structured := StructuredCase{
+		MinorItem:       aCase.MinorItem,
+		Steps:           aCase.ValidationSteps,
+		Checkpoints:     splitCheckpoints(aCase.Checkpoints),
+		Preconditions:   aCase.Preconditions,
+		ExpectedResults: aCase.ExpectedResults,
+		Priority:        aCase.Priority,
+		TestType:        aCase.TestType,
+		Tags:            aCase.Tags,
+		Effort:          aCase.Effort,
+		Result:          aCase.Result,
+		TestDate:        aCase.TestDate,
+		Tester:          aCase.Tester,
+		Notes:           aCase.Notes,
+		Line:            aCase.Line,
+		EndLine:         aCase.EndLine,
+	}
+	// Keep empty lists as [] so consumers can iterate without null checks.
+	if structured.Steps == nil {
+		structured.Steps = []string{}
+	}
+	for _, step := range aCase.Steps {
+		structured.Procedure = append(structured.Procedure, ProcedureStep{Action: step.Action, Expectations: splitCheckpoints(step.Expectations)})
+	}
+	return structured
+}
+
+func splitCheckpoints(lines []string) []Checkpoint {
+	checkpoints := make([]Checkpoint, 0, len(lines))
+	for _, line := range lines {
+		text, checked := checkpointState(line)
+		checkpoints = append(checkpoints, Checkpoint{Text: text, Checked: checked})
+	}
+	return checkpoints
+}
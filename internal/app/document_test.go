@@ -0,0 +1,46 @@
+package app
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/9renpoto/casemd/internal/core/domain"
+)
+
+func TestMarkdownDocument_Parse(t *testing.T) {
+	parser := &mockDocumentParser{document: domain.Document{
+		Title: "Inspection Sheet",
+		Cases: []domain.Case{
+			{MajorItem: "Setup", MediumItem: "Environment", MinorItem: "Dependencies", ValidationSteps: []string{"Install"}, Checkpoints: []string{"* [x] Installed", "* [ ] Defaults"}, Line: 4, EndLine: 7},
+			{MajorItem: "Setup", MediumItem: "Environment", MinorItem: "Variables", Checkpoints: []string{"* [ ] Exported"}, Line: 9, EndLine: 10},
+			{MajorItem: "Execution", MinorItem: "CLI run", ValidationSteps: []string{"Run casemd"}, Line: 13, EndLine: 14},
+		},
+	}}
+
+	document, err := NewMarkdownDocument(parser).Parse(Source{Name: "checks.md", Reader: strings.NewReader("")})
+	if err != nil {
+		t.Fatalf("Parse() returned an unexpected error: %v", err)
+	}
+
+	expected := StructuredDocument{
+		Name:  "checks.md",
+		Title: "Inspection Sheet",
+		MajorItems: []MajorItem{
+			{Name: "Setup", MediumItems: []MediumItem{{Name: "Environment", Cases: []StructuredCase{
+				{MinorItem: "Dependencies", Steps: []string{"Install"}, Checkpoints: []Checkpoint{{Text: "Installed", Checked: true}, {Text: "Defaults"}}, Line: 4, EndLine: 7},
+				{MinorItem: "Variables", Steps: []string{}, Checkpoints: []Checkpoint{{Text: "Exported"}}, Line: 9, EndLine: 10},
+			}}}},
+			{Name: "Execution", MediumItems: []MediumItem{{Name: "", Cases: []StructuredCase{
+				{MinorItem: "CLI run", Steps: []string{"Run casemd"}, Checkpoints: []Checkpoint{}, Line: 13, EndLine: 14},
+			}}}},
+		},
+		Diagnostics: []domain.Diagnostic{
+			{Line: 9, Severity: domain.SeverityWarning, Message: `"Variables" has no validation steps`},
+			{Line: 13, Severity: domain.SeverityWarning, Message: `"CLI run" has no checkpoints`},
+		},
+	}
+	if !reflect.DeepEqual(document, expected) {
+		t.Fatalf("unexpected document:\n got %#v\nwant %#v", document, expected)
+	}
+}
@@ -0,0 +1,131 @@
+package app
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/9renpoto/casemd/internal/core/domain"
+)
+
+// TestDateLayout is the format used for the Test Date column.
+const TestDateLayout = "2006-01-02"
+
+// MarkdownExecution prepares checklists for testers executing them outside a
+// spreadsheet and turns what they recorded into a results run.
+type MarkdownExecution struct {
+	parser CaseParser
+}
+
+// NewMarkdownExecution wires the execution use case with the provided parser implementation.
+func NewMarkdownExecution(parser CaseParser) *MarkdownExecution {
+	return &MarkdownExecution{parser: parser}
+}
+
+// Checklist returns the cases of source with any results already recorded for it.
+func (e *MarkdownExecution) Checklist(source Source) ([]domain.Case, error) {
+	cases, err := parseSource(e.parser, source)
+	if err != nil {
+		return nil, fmt.Errorf("parse %s: %w", source.Name, err)
+	}
+	return cases, nil
+}
+
+// Record validates submitted results against the checklist in source and
+// returns them as a run. Every result must name a case of the checklist.
+// Results without a Test Date are stamped with now. The submitted results
+// replace those already in source.Results; recorded results the submission
+// leaves out are kept.
+//
+// A non-empty tester is the signed-in user recording the run: results that
+// differ from those already in source.Results are attributed to them, while
+// unchanged results keep their original tester.
+func (e *MarkdownExecution) Record(source Source, submitted []domain.CaseResult, tester string, now time.Time) (domain.Run, error) {
+	cases, err := parseCases(e.parser, source)
+	if err != nil {
+		return domain.Run{}, fmt.Errorf("parse %s: %w", source.Name, err)
+	}
+
+	known := make(map[string]int, len(cases))
+	for _, aCase := range cases {
+		known[aCase.Key()]++
+	}
+
+	recorded := make(map[string][]domain.CaseResult)
+	if source.Results != nil {
+		for _, result := range source.Results.Cases {
+			recorded[result.Key()] = append(recorded[result.Key()], result)
+		}
+	}
+
+	run := domain.Run{Source: source.Name, Cases: make([]domain.CaseResult, 0, len(submitted))}
+	var unknown []string
+	for _, result := range submitted {
+		if known[result.Key()] == 0 {
+			unknown = append(unknown, result.Key())
+			continue
+		}
+		known[result.Key()]--
+
+		result.Result = strings.TrimSpace(result.Result)
+		result.Tester = strings.TrimSpace(result.Tester)
+		if tester != "" {
+			var previous domain.CaseResult
+			if queue := recorded[result.Key()]; len(queue) > 0 {
+				previous, recorded[result.Key()] = queue[0], queue[1:]
+			}
+			if sameOutcome(result, previous) {
+				result.Tester, result.TestDate = previous.Tester, previous.TestDate
+			} else {
+				result.Tester, result.TestDate = tester, ""
+			}
+		}
+		if result.Result != "" && result.TestDate == "" {
+			result.TestDate = now.Format(TestDateLayout)
+		}
+		run.Cases = append(run.Cases, result)
+	}
+	if len(unknown) > 0 {
+		return domain.Run{}, fmt.Errorf("results do not match cases in %s: %s", source.Name, strings.Join(unknown, "; "))
+	}
+	if source.Results != nil {
+		run.Cases = overlayResults(source.Results.Cases, run.Cases)
+	}
+	return run, nil
+}
+
+// overlayResults returns the recorded results with each replaced by the
+// update for the same case, followed by the updates for cases that had no
+// result yet. Duplicate keys are matched in order.
+func overlayResults(recorded, updates []domain.CaseResult) []domain.CaseResult {
+	pending := make(map[string][]domain.CaseResult, len(updates))
+	for _, update := range updates {
+		pending[update.Key()] = append(pending[update.Key()], update)
+	}
+
+	merged := make([]domain.CaseResult, 0, len(recorded)+len(updates))
+	replaced := make(map[string]int)
+	for _, result := range recorded {
+		key := result.Key()
+		if queue := pending[key]; len(queue) > 0 {
+			result, pending[key] = queue[0], queue[1:]
+			replaced[key]++
+		}
+		merged = append(merged, result)
+	}
+	for _, update := range updates {
+		if key := update.Key(); replaced[key] > 0 {
+			replaced[key]--
+			continue
+		}
+		merged = append(merged, update)
+	}
+	return merged
+}
+
+// sameOutcome reports whether two results record the same outcome, ignoring
+// who recorded them and when.
+func sameOutcome(a, b domain.CaseResult) bool {
+	return a.Result == b.Result && a.Notes == b.Notes && slices.Equal(a.Checkpoints, b.Checkpoints)
+}
@@ -0,0 +1,128 @@
+package app
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/9renpoto/casemd/internal/core/domain"
+)
+
+func TestMarkdownExecution_ChecklistMergesRecordedResults(t *testing.T) {
+	parser := &mockCaseParser{cases: []domain.Case{
+		{MajorItem: "Setup", MinorItem: "Install", Checkpoints: []string{"* [ ] Installed"}},
+	}}
+	execution := NewMarkdownExecution(parser)
+
+	cases, err := execution.Checklist(Source{
+		Name:    "setup.md",
+		Reader:  strings.NewReader(""),
+		Results: &domain.Run{Cases: []domain.CaseResult{{MajorItem: "Setup", MinorItem: "Install", Checkpoints: []string{"* [x] Installed"}, Result: "pass"}}},
+	})
+	if err != nil {
+		t.Fatalf("Checklist() returned an unexpected error: %v", err)
+	}
+	if cases[0].Result != "pass" || cases[0].Checkpoints[0] != "* [x] Installed" {
+		t.Fatalf("expected recorded results to be merged, got %+v", cases[0])
+	}
+}
+
+func TestMarkdownExecution_Record(t *testing.T) {
+	parser := &mockCaseParser{cases: []domain.Case{
+		{MajorItem: "Setup", MinorItem: "Install"},
+		{MajorItem: "Setup", MinorItem: "Configure"},
+	}}
+	execution := NewMarkdownExecution(parser)
+	now := time.Date(2026, 10, 18, 9, 30, 0, 0, time.UTC)
+
+	run, err := execution.Record(Source{Name: "setup.md", Reader: strings.NewReader("")}, []domain.CaseResult{
+		{MajorItem: "Setup", MinorItem: "Install", Result: " pass ", Tester: "alice"},
+		{MajorItem: "Setup", MinorItem: "Configure", Result: "fail", TestDate: "2026-10-17"},
+	}, "", now)
+	if err != nil {
+		t.Fatalf("Record() returned an unexpected error: %v", err)
+	}
+
+	expected := domain.Run{Source: "setup.md", Cases: []domain.CaseResult{
+		{MajorItem: "Setup", MinorItem: "Install", Result: "pass", TestDate: "2026-10-18", Tester: "alice"},
+		{MajorItem: "Setup", MinorItem: "Configure", Result: "fail", TestDate: "2026-10-17"},
+	}}
+	if !reflect.DeepEqual(run, expected) {
+		t.Fatalf("unexpected run:\n got: %#v\nwant: %#v", run, expected)
+	}
+
+	_, err = execution.Record(Source{Name: "setup.md", Reader: strings.NewReader("")}, []domain.CaseResult{
+		{MajorItem: "Setup", MinorItem: "Install"},
+		{MajorItem: "Setup", MinorItem: "Install"},
+		{MajorItem: "Teardown", MinorItem: "Cleanup"},
+	}, "", now)
+	if err == nil || !strings.Contains(err.Error(), "Setup /  / Install") || !strings.Contains(err.Error(), "Teardown") {
+		t.Fatalf("expected unknown cases to be rejected, got %v", err)
+	}
+}
+
+func TestMarkdownExecution_RecordAttributesChangesToTester(t *testing.T) {
+	parser := &mockCaseParser{cases: []domain.Case{
+		{MajorItem: "Setup", MinorItem: "Install"},
+		{MajorItem: "Setup", MinorItem: "Configure"},
+	}}
+	execution := NewMarkdownExecution(parser)
+	now := time.Date(2026, 10, 18, 9, 30, 0, 0, time.UTC)
+
+	source := Source{
+		Name:   "setup.md",
+		Reader: strings.NewReader(""),
+		Results: &domain.Run{Cases: []domain.CaseResult{
+			{MajorItem: "Setup", MinorItem: "Install", Result: "pass", TestDate: "2026-10-17", Tester: "alice"},
+			{MajorItem: "Setup", MinorItem: "Configure", Result: "fail", TestDate: "2026-10-17", Tester: "alice"},
+		}},
+	}
+	run, err := execution.Record(source, []domain.CaseResult{
+		{MajorItem: "Setup", MinorItem: "Install", Result: "pass", TestDate: "2026-10-17", Tester: "mallory"},
+		{MajorItem: "Setup", MinorItem: "Configure", Result: "pass", TestDate: "2026-10-17", Tester: "mallory"},
+	}, "bob", now)
+	if err != nil {
+		t.Fatalf("Record() returned an unexpected error: %v", err)
+	}
+
+	expected := []domain.CaseResult{
+		{MajorItem: "Setup", MinorItem: "Install", Result: "pass", TestDate: "2026-10-17", Tester: "alice"},
+		{MajorItem: "Setup", MinorItem: "Configure", Result: "pass", TestDate: "2026-10-18", Tester: "bob"},
+	}
+	if !reflect.DeepEqual(run.Cases, expected) {
+		t.Fatalf("unexpected results:\n got: %#v\nwant: %#v", run.Cases, expected)
+	}
+}
+
+func TestMarkdownExecution_RecordKeepsResultsLeftOut(t *testing.T) {
+	parser := &mockCaseParser{cases: []domain.Case{
+		{MajorItem: "Setup", MinorItem: "Install"},
+		{MajorItem: "Setup", MinorItem: "Configure"},
+		{MajorItem: "Setup", MinorItem: "Verify"},
+	}}
+	source := Source{
+		Name:   "setup.md",
+		Reader: strings.NewReader(""),
+		Results: &domain.Run{Cases: []domain.CaseResult{
+			{MajorItem: "Setup", MinorItem: "Install", Result: "pass", TestDate: "2026-10-17", Tester: "alice"},
+			{MajorItem: "Setup", MinorItem: "Configure", Result: "fail", TestDate: "2026-10-17", Tester: "alice"},
+		}},
+	}
+	run, err := NewMarkdownExecution(parser).Record(source, []domain.CaseResult{
+		{MajorItem: "Setup", MinorItem: "Verify", Result: "pass", TestDate: "2026-10-18"},
+		{MajorItem: "Setup", MinorItem: "Configure", Result: "pass", TestDate: "2026-10-18"},
+	}, "", time.Date(2026, 10, 18, 9, 30, 0, 0, time.UTC))
+	if err != nil {
+		t.Fatalf("Record() returned an unexpected error: %v", err)
+	}
+
+	expected := []domain.CaseResult{
+		{MajorItem: "Setup", MinorItem: "Install", Result: "pass", TestDate: "2026-10-17", Tester: "alice"},
+		{MajorItem: "Setup", MinorItem: "Configure", Result: "pass", TestDate: "2026-10-18"},
+		{MajorItem: "Setup", MinorItem: "Verify", Result: "pass", TestDate: "2026-10-18"},
+	}
+	if !reflect.DeepEqual(run.Cases, expected) {
+		t.Fatalf("unexpected results:\n got: %#v\nwant: %#v", run.Cases, expected)
+	}
+}
@@ -0,0 +1,347 @@
+package app
+
+import (
+	"fmt"
+	"html/template"
+	"io"
+
+	"github.com/9renpoto/casemd/internal/core/domain"
+	"github.com/9renpoto/casemd/internal/i18n"
+)
+
+// HTMLReportOptions tweaks a single HTML report rendering.
+type HTMLReportOptions struct {
+	// Title is shown as the document heading; a generic title in the
+	// language of the first source is used when empty.
+	Title string
+	// StylesheetHref links an external stylesheet instead of inlining the
+	// converter stylesheet, producing a smaller but not self-contained file.
+	StylesheetHref string
+}
+
+// MarkdownToHTML orchestrates the conversion of Markdown test cases into a static HTML report.
+type MarkdownToHTML struct {
+	parser     CaseParser
+	stylesheet string
+}
+
+// NewMarkdownToHTML wires the converter with the parser and the CSS inlined into single-file reports.
+func NewMarkdownToHTML(parser CaseParser, stylesheet string) *MarkdownToHTML {
+	return &MarkdownToHTML{parser: parser, stylesheet: stylesheet}
+}
+
+// Convert writes a self-contained HTML report with the stylesheet inlined.
+func (c *MarkdownToHTML) Convert(sources []Source, output io.Writer) error {
+	return c.ConvertWithOptions(sources, output, HTMLReportOptions{})
+}
+
+// ConvertWithOptions reads Markdown sources and writes an HTML report that groups cases
+// by hierarchy, with collapsible sections and checkpoint progress per major item.
+func (c *MarkdownToHTML) ConvertWithOptions(sources []Source, output io.Writer, options HTMLReportOptions) error {
+	if len(sources) == 0 {
+		return fmt.Errorf("no sources provided")
+	}
+
+	report := htmlReport{Title: options.Title, StylesheetHref: options.StylesheetHref, Language: sources[0].Language}
+	if report.Language == "" {
+		report.Language = i18n.English
+	}
+	if report.Title == "" {
+		report.Title = report.T("report.title")
+	}
+	if report.StylesheetHref == "" {
+		report.Stylesheet = template.CSS(c.stylesheet)
+	}
+
+	for _, source := range sources {
+		cases, err := parseSource(c.parser, source)
+		if err != nil {
+			return fmt.Errorf("parse %s: %w", source.Name, err)
+		}
+
+		reportSource := buildHTMLReportSource(source.Name, cases)
+		for _, major := range reportSource.Majors {
+			report.Progress.add(major.Progress)
+			for _, medium := range major.Mediums {
+				for _, aCase := range medium.Cases {
+					report.Summary.count(aCase.Status)
+				}
+			}
+		}
+		report.Sources = append(report.Sources, reportSource)
+	}
+
+	if err := htmlReportTemplate.Execute(output, report); err != nil {
+		return fmt.Errorf("render html report: %w", err)
+	}
+	return nil
+}
+
+func buildHTMLReportSource(name string, cases []domain.Case) htmlReportSource {
+	source := htmlReportSource{Name: name}
+
+	var major *htmlReportMajor
+	var medium *htmlReportMedium
+	for _, aCase := range cases {
+		if major == nil || major.Name != aCase.MajorItem {
+			source.Majors = append(source.Majors, htmlReportMajor{Name: aCase.MajorItem})
+			major = &source.Majors[len(source.Majors)-1]
+			medium = nil
+		}
+		if medium == nil || medium.Name != aCase.MediumItem {
+			major.Mediums = append(major.Mediums, htmlReportMedium{Name: aCase.MediumItem})
+			medium = &major.Mediums[len(major.Mediums)-1]
+		}
+
+		outcome, message := evaluateCase(aCase)
+		reportCase := htmlReportCase{
+			Name:            aCase.MinorItem,
+			Status:          outcome.String(),
+			Message:         message,
+			Preconditions:   aCase.Preconditions,
+			ExpectedResults: aCase.ExpectedResults,
+			Priority:        aCase.Priority,
+			TestType:        aCase.TestType,
+			Tags:            aCase.Tags,
+			Result:          aCase.Result,
+			TestDate:        aCase.TestDate,
+			Tester:          aCase.Tester,
+			Notes:           aCase.Notes,
+		}
+		for _, step := range caseSteps(aCase) {
+			reportCase.Steps = append(reportCase.Steps, htmlReportStep{Action: step.Action, Checkpoints: htmlReportCheckpoints(step.Expectations)})
+		}
+		reportCase.Checkpoints = htmlReportCheckpoints(looseCheckpoints(aCase))
+		for _, checkpoint := range aCase.Checkpoints {
+			major.Progress.Total++
+			if _, checked := checkpointState(checkpoint); checked {
+				major.Progress.Checked++
+			}
+		}
+		medium.Cases = append(medium.Cases, reportCase)
+	}
+
+	return source
+}
+
+type htmlReport struct {
+	Title          string
+	Stylesheet     template.CSS
+	StylesheetHref string
+	Progress       htmlReportProgress
+	Summary        htmlReportSummary
+	Sources        []htmlReportSource
+	Language       i18n.Language
+}
+
+// T returns the report message with key, formatted with args, in the
+// report's language.
+func (r htmlReport) T(key string, args ...any) string {
+	return r.Language.Format(key, args...)
+}
+
+type htmlReportSummary struct {
+	Passed  int
+	Failed  int
+	Skipped int
+}
+
+func (s *htmlReportSummary) count(status string) {
+	switch status {
+	case outcomePassed.String():
+		s.Passed++
+	case outcomeFailed.String():
+		s.Failed++
+	default:
+		s.Skipped++
+	}
+}
+
+type htmlReportProgress struct {
+	Checked int
+	Total   int
+}
+
+func (p *htmlReportProgress) add(other htmlReportProgress) {
+	p.Checked += other.Checked
+	p.Total += other.Total
+}
+
+// Percent returns the share of ticked checkpoints, rounded down.
+func (p htmlReportProgress) Percent() int {
+	if p.Total == 0 {
+		return 0
+	}
+	return p.Checked * 100 / p.Total
+}
+
+type htmlReportSource struct {
+	Name   string
+	Majors []htmlReportMajor
+}
+
+type htmlReportMajor struct {
+	Name     string
+	Progress htmlReportProgress
+	Mediums  []htmlReportMedium
+}
+
+type htmlReportMedium struct {
+	Name  string
+	Cases []htmlReportCase
+}
+
+type htmlReportCase struct {
+	Name    string
+	Status  string
+	Message string
+	Steps   []htmlReportStep
+	// Checkpoints holds the checkpoints not nested under a step.
+	Checkpoints     []htmlReportCheckpoint
+	Preconditions   []string
+	ExpectedResults []string
+	Priority        string
+	TestType        string
+	Tags            []string
+	Result          string
+	TestDate        string
+	Tester          string
+	Notes           string
+}
+
+type htmlReportStep struct {
+	Action      string
+	Checkpoints []htmlReportCheckpoint
+}
+
+type htmlReportCheckpoint struct {
+	Text    string
+	Checked bool
+}
+
+func htmlReportCheckpoints(lines []string) []htmlReportCheckpoint {
+	var checkpoints []htmlReportCheckpoint
+	for _, line := range lines {
+		text, checked := checkpointState(line)
+		checkpoints = append(checkpoints, htmlReportCheckpoint{Text: text, Checked: checked})
+	}
+	return checkpoints
+}
+
+var htmlReportTemplate = template.Must(template.New("report").Parse(`<!DOCTYPE html>
+<html lang="{{ .Language }}">
+  <head>
+    <meta charset="utf-8">
+    <title>{{ .Title }}</title>
+    <meta name="viewport" content="width=device-width,initial-scale=1">
+    {{- if .StylesheetHref }}
+    <link rel="stylesheet" href="{{ .StylesheetHref }}">
+    {{- else }}
+    <style>{{ .Stylesheet }}</style>
+    {{- end }}
+  </head>
+  <body>
+    <main class="report">
+      <section>
+        <h1>{{ .Title }}</h1>
+        <p class="progress">
+          <progress value="{{ .Progress.Checked }}" max="{{ .Progress.Total }}"></progress>
+          <span>{{ .T "report.checkpoints" .Progress.Checked .Progress.Total .Progress.Percent }}</span>
+        </p>
+        <p>
+          <span class="status status-passed">{{ .T "report.passed" .Summary.Passed }}</span>
+          <span class="status status-failed">{{ .T "report.failed" .Summary.Failed }}</span>
+          <span class="status status-skipped">{{ .T "report.skipped" .Summary.Skipped }}</span>
+        </p>
+      </section>
+      {{- range .Sources }}
+      <section>
+        <h2>{{ .Name }}</h2>
+        {{- range .Majors }}
+        <details class="major" open>
+          <summary>
+            {{ .Name }}
+            <span class="progress">
+              <progress value="{{ .Progress.Checked }}" max="{{ .Progress.Total }}"></progress>
+              <span>{{ .Progress.Checked }}/{{ .Progress.Total }} ({{ .Progress.Percent }}%)</span>
+            </span>
+          </summary>
+          {{- range .Mediums }}
+          {{- if .Name }}
+          <h3>{{ .Name }}</h3>
+          {{- end }}
+          {{- range .Cases }}
+          <details class="case">
+            <summary>{{ .Name }}<span class="status status-{{ .Status }}">{{ $.T (print "report.status." .Status) }}</span></summary>
+            {{- if .Message }}
+            <p class="notice">{{ .Message }}</p>
+            {{- end }}
+            {{- if or .Priority .TestType .Tags }}
+            <p class="case-fields">
+              {{- if .Priority }} <span>{{ $.T "report.priority" .Priority }}</span>{{ end }}
+              {{- if .TestType }} <span>{{ $.T "report.type" .TestType }}</span>{{ end }}
+              {{- range .Tags }} <span class="tag">{{ . }}</span>{{ end }}
+            </p>
+            {{- end }}
+            {{- if .Preconditions }}
+            <h4>{{ $.T "report.preconditions" }}</h4>
+            <ul>
+              {{- range .Preconditions }}
+              <li>{{ . }}</li>
+              {{- end }}
+            </ul>
+            {{- end }}
+            {{- if .Steps }}
+            <h4>{{ $.T "report.steps" }}</h4>
+            <ol>
+              {{- range .Steps }}
+              <li>{{ .Action }}
+                {{- if .Checkpoints }}
+                <ul class="checkpoints">
+                  {{- range .Checkpoints }}
+                  <li><input type="checkbox" disabled{{ if .Checked }} checked{{ end }}> {{ .Text }}</li>
+                  {{- end }}
+                </ul>
+                {{- end }}
+              </li>
+              {{- end }}
+            </ol>
+            {{- end }}
+            {{- if .Checkpoints }}
+            <h4>{{ $.T "report.checkpointsList" }}</h4>
+            <ul class="checkpoints">
+              {{- range .Checkpoints }}
+              <li><input type="checkbox" disabled{{ if .Checked }} checked{{ end }}> {{ .Text }}</li>
+              {{- end }}
+            </ul>
+            {{- end }}
+            {{- if .ExpectedResults }}
+            <h4>{{ $.T "report.expected" }}</h4>
+            <ul>
+              {{- range .ExpectedResults }}
+              <li>{{ . }}</li>
+              {{- end }}
+            </ul>
+            {{- end }}
+            {{- if or .Result .TestDate .Tester .Notes }}
+            <table>
+              <tr><th>{{ $.T "column.result" }}</th><th>{{ $.T "column.date" }}</th><th>{{ $.T "column.tester" }}</th><th>{{ $.T "column.notes" }}</th></tr>
+              <tr><td>{{ .Result }}</td><td>{{ .TestDate }}</td><td>{{ .Tester }}</td><td>{{ .Notes }}</td></tr>
+            </table>
+            {{- end }}
+          </details>
+          {{- end }}
+          {{- end }}
+        </details>
+        {{- end }}
+      </section>
+      {{- end }}
+    </main>
+    <script>
+      window.addEventListener("beforeprint", () => {
+        document.querySelectorAll("details").forEach((element) => { element.open = true; });
+      });
+    </script>
+  </body>
+</html>
+`))
@@ -0,0 +1,88 @@
+package app
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/9renpoto/casemd/internal/core/domain"
+	"github.com/9renpoto/casemd/internal/i18n"
+)
+
+func TestMarkdownToHTML_Convert(t *testing.T) {
+	parser := &mockCaseParser{cases: []domain.Case{
+		{
+			MajorItem:       "Setup",
+			MediumItem:      "Environment",
+			MinorItem:       "Dependencies",
+			ValidationSteps: []string{"Install <packages>"},
+			Checkpoints:     []string{"* [x] Installed", "* [ ] Configured"},
+		},
+		{MajorItem: "Execution", MediumItem: "Workflow", MinorItem: "Run", Checkpoints: []string{"* [x] Exit code is 0"}},
+	}}
+	converter := NewMarkdownToHTML(parser, "body { color: red; }")
+
+	var output bytes.Buffer
+	sources := []Source{{Name: "checks.md", Reader: strings.NewReader("")}}
+	if err := converter.Convert(sources, &output); err != nil {
+		t.Fatalf("Convert() returned an unexpected error: %v", err)
+	}
+
+	html := output.String()
+	for _, expected := range []string{
+		"<style>body { color: red; }</style>",
+		"<title>Inspection Report</title>",
+		"<h2>checks.md</h2>",
+		`<progress value="1" max="2"></progress>`,
+		"1/2 (50%)",
+		"Install &lt;packages&gt;",
+		`<input type="checkbox" disabled checked> Installed`,
+		`<span class="status status-failed">failed</span>`,
+		"1 passed",
+	} {
+		if !strings.Contains(html, expected) {
+			t.Fatalf("report missing %q:\n%s", expected, html)
+		}
+	}
+}
+
+func TestMarkdownToHTML_ConvertWithStylesheetHref(t *testing.T) {
+	parser := &mockCaseParser{cases: []domain.Case{{MajorItem: "Setup", MinorItem: "Dependencies"}}}
+	converter := NewMarkdownToHTML(parser, "body { color: red; }")
+
+	var output bytes.Buffer
+	sources := []Source{{Name: "checks.md", Reader: strings.NewReader("")}}
+	options := HTMLReportOptions{Title: "Release 1.0", StylesheetHref: "report.css"}
+	if err := converter.ConvertWithOptions(sources, &output, options); err != nil {
+		t.Fatalf("ConvertWithOptions() returned an unexpected error: %v", err)
+	}
+
+	html := output.String()
+	if !strings.Contains(html, `<link rel="stylesheet" href="report.css">`) {
+		t.Fatalf("report missing stylesheet link:\n%s", html)
+	}
+	if strings.Contains(html, "<style>") {
+		t.Fatalf("report should not inline styles when linking a stylesheet")
+	}
+	if !strings.Contains(html, "<h1>Release 1.0</h1>") {
+		t.Fatalf("report missing custom title:\n%s", html)
+	}
+}
+
+func TestMarkdownToHTML_ConvertInJapanese(t *testing.T) {
+	parser := &mockCaseParser{cases: []domain.Case{{MajorItem: "Setup", MinorItem: "Dependencies", Checkpoints: []string{"* [x] Installed"}, Priority: "P1"}}}
+	converter := NewMarkdownToHTML(parser, "")
+
+	var output bytes.Buffer
+	sources := []Source{{Name: "checks.md", Reader: strings.NewReader(""), Language: i18n.Japanese}}
+	if err := converter.Convert(sources, &output); err != nil {
+		t.Fatalf("Convert() returned an unexpected error: %v", err)
+	}
+
+	html := output.String()
+	for _, expected := range []string{`<html lang="ja">`, "<title>検査レポート</title>", "優先度: P1", "合格 1", ">合格</span>"} {
+		if !strings.Contains(html, expected) {
+			t.Fatalf("report missing %q:\n%s", expected, html)
+		}
+	}
+}
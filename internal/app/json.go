@@ -0,0 +1,120 @@
+package app
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"github.com/9renpoto/casemd/internal/core/domain"
+)
+
+// MarkdownToJSON orchestrates the conversion of Markdown test cases into a JSON document.
+type MarkdownToJSON struct {
+	parser CaseParser
+}
+
+// NewMarkdownToJSON wires the converter with the provided parser implementation.
+func NewMarkdownToJSON(parser CaseParser) *MarkdownToJSON {
+	return &MarkdownToJSON{parser: parser}
+}
+
+// Convert reads Markdown sources and writes one JSON document listing the cases of every source.
+func (c *MarkdownToJSON) Convert(sources []Source, output io.Writer) error {
+	if len(sources) == 0 {
+		return fmt.Errorf("no sources provided")
+	}
+
+	document := jsonDocument{Sources: make([]jsonSource, 0, len(sources))}
+	for _, source := range sources {
+		cases, err := parseSource(c.parser, source)
+		if err != nil {
+			return fmt.Errorf("parse %s: %w", source.Name, err)
+		}
+
+		converted := jsonSource{Name: source.Name, Cases: make([]jsonCase, 0, len(cases))}
+		for _, aCase := range cases {
+			converted.Cases = append(converted.Cases, newJSONCase(aCase))
+		}
+		document.Sources = append(document.Sources, converted)
+	}
+
+	encoder := json.NewEncoder(output)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(document); err != nil {
+		return fmt.Errorf("encode json: %w", err)
+	}
+	return nil
+}
+
+type jsonDocument struct {
+	Sources []jsonSource `json:"sources"`
+}
+
+type jsonSource struct {
+	Name  string     `json:"name"`
+	Cases []jsonCase `json:"cases"`
+}
+
+type jsonCase struct {
+	MajorItem       string   `json:"majorItem"`
+	MediumItem      string   `json:"mediumItem"`
+	MinorItem       string   `json:"minorItem"`
+	ValidationSteps []string `json:"validationSteps"`
+	Checkpoints     []string `json:"checkpoints"`
+	// Procedure pairs each validation step with its nested checkpoints.
+	Procedure       []jsonStep `json:"procedure,omitempty"`
+	Preconditions   []string   `json:"preconditions,omitempty"`
+	ExpectedResults []string   `json:"expectedResults,omitempty"`
+	Priority        string     `json:"priority,omitempty"`
+	TestType        string     `json:"testType,omitempty"`
+	Tags            []string   `json:"tags,omitempty"`
+	Effort          string     `json:"effort,omitempty"`
+	Result          string     `json:"result,omitempty"`
+	TestDate        string     `json:"testDate,omitempty"`
+	Tester          string     `json:"tester,omitempty"`
+	Notes           string     `json:"notes,omitempty"`
+	Line            int        `json:"line,omitempty"`
+	EndLine         int        `json:"endLine,omitempty"`
+}
+
+type jsonStep struct {
+	Action       string   `json:"action"`
+	Expectations []string `json:"expectations"`
+}
+
+func newJSONCase(aCase domain.Case) jsonCase {
+	converted := jsonCase{
+		MajorItem:       aCase.MajorItem,
+		MediumItem:      aCase.MediumItem,
+		MinorItem:       aCase.MinorItem,
+		ValidationSteps: aCase.ValidationSteps,
+		Checkpoints:     aCase.Checkpoints,
+		Preconditions:   aCase.Preconditions,
+		ExpectedResults: aCase.ExpectedResults,
+		Priority:        aCase.Priority,
+		TestType:        aCase.TestType,
+		Tags:            aCase.Tags,
+		Effort:          aCase.Effort,
+		Result:          aCase.Result,
+		TestDate:        aCase.TestDate,
+		Tester:          aCase.Tester,
+		Notes:           aCase.Notes,
+		Line:            aCase.Line,
+		EndLine:         aCase.EndLine,
+	}
+	// Keep empty lists as [] so consumers can iterate without null checks.
+	if converted.ValidationSteps == nil {
+		converted.ValidationSteps = []string{}
+	}
+	if converted.Checkpoints == nil {
+		converted.Checkpoints = []string{}
+	}
+	for _, step := range aCase.Steps {
+		expectations := step.Expectations
+		if expectations == nil {
+			expectations = []string{}
+		}
+		converted.Procedure = append(converted.Procedure, jsonStep{Action: step.Action, Expectations: expectations})
+	}
+	return converted
+}
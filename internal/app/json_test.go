@@ -0,0 +1,84 @@
+package app
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/9renpoto/casemd/internal/core/domain"
+)
+
+func TestMarkdownToJSON_Convert(t *testing.T) {
+	parser := &mockCaseParser{cases: []domain.Case{
+		{
+			MajorItem:       "Setup",
+			MediumItem:      "Environment",
+			MinorItem:       "Dependencies",
+			ValidationSteps: []string{"Install packages"},
+			Checkpoints:     []string{"* [x] Packages installed"},
+			Line:            3,
+			EndLine:         5,
+		},
+		{MajorItem: "Setup", MinorItem: "Empty"},
+	}}
+	converter := NewMarkdownToJSON(parser)
+
+	var output bytes.Buffer
+	err := converter.Convert([]Source{
+		{Name: "a.md", Reader: strings.NewReader("")},
+		{Name: "b.md", Reader: strings.NewReader("")},
+	}, &output)
+	if err != nil {
+		t.Fatalf("Convert returned error: %v", err)
+	}
+
+	var decoded struct {
+		Sources []struct {
+			Name  string           `json:"name"`
+			Cases []map[string]any `json:"cases"`
+		} `json:"sources"`
+	}
+	if err := json.Unmarshal(output.Bytes(), &decoded); err != nil {
+		t.Fatalf("decode output: %v\n%s", err, output.String())
+	}
+
+	if len(decoded.Sources) != 2 || decoded.Sources[0].Name != "a.md" || decoded.Sources[1].Name != "b.md" {
+		t.Fatalf("unexpected sources: %+v", decoded.Sources)
+	}
+
+	first := decoded.Sources[0].Cases[0]
+	expected := map[string]any{
+		"majorItem":       "Setup",
+		"mediumItem":      "Environment",
+		"minorItem":       "Dependencies",
+		"validationSteps": []any{"Install packages"},
+		"checkpoints":     []any{"* [x] Packages installed"},
+		"line":            float64(3),
+		"endLine":         float64(5),
+	}
+	if !reflect.DeepEqual(first, expected) {
+		t.Fatalf("unexpected first case:\n got: %#v\nwant: %#v", first, expected)
+	}
+
+	empty := decoded.Sources[0].Cases[1]
+	if !reflect.DeepEqual(empty["validationSteps"], []any{}) || !reflect.DeepEqual(empty["checkpoints"], []any{}) {
+		t.Fatalf("expected empty lists instead of null, got %#v", empty)
+	}
+}
+
+func TestMarkdownToJSON_ConvertErrors(t *testing.T) {
+	if err := NewMarkdownToJSON(&mockCaseParser{}).Convert(nil, &bytes.Buffer{}); err == nil {
+		t.Fatal("expected error for empty sources")
+	}
+
+	parseErr := errors.New("boom")
+	err := NewMarkdownToJSON(&mockCaseParser{err: parseErr}).Convert([]Source{
+		{Name: "broken.md", Reader: strings.NewReader("")},
+	}, &bytes.Buffer{})
+	if !errors.Is(err, parseErr) || !strings.Contains(err.Error(), "broken.md") {
+		t.Fatalf("expected wrapped parse error, got %v", err)
+	}
+}
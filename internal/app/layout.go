@@ -0,0 +1,114 @@
+package app
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/9renpoto/casemd/internal/core/domain"
+)
+
+// Layout decides how cases are split into CSV and spreadsheet rows.
+type Layout string
+
+const (
+	// CaseLayout writes one row per case.
+	CaseLayout Layout = "case"
+	// StepLayout writes one row per validation step, with the checkpoints
+	// nested under it. Checkpoints that belong to no step get a row of their
+	// own, and a case without steps keeps a single row.
+	StepLayout Layout = "step"
+)
+
+// Layouts lists the layouts accepted by ParseLayout.
+func Layouts() []Layout {
+	return []Layout{CaseLayout, StepLayout}
+}
+
+// ParseLayout returns the layout named name; an empty name is CaseLayout.
+func ParseLayout(name string) (Layout, error) {
+	name = strings.ToLower(strings.TrimSpace(name))
+	if name == "" {
+		return CaseLayout, nil
+	}
+	for _, layout := range Layouts() {
+		if string(layout) == name {
+			return layout, nil
+		}
+	}
+	return "", fmt.Errorf("unknown layout %q (available: %s, %s)", name, CaseLayout, StepLayout)
+}
+
+// rows splits cases into the rows written for the source.
+func (s Source) rows(cases []domain.Case) []domain.Case {
+	if s.Layout != StepLayout {
+		return cases
+	}
+	rows := make([]domain.Case, 0, len(cases))
+	for _, aCase := range cases {
+		rows = append(rows, stepRows(aCase)...)
+	}
+	return rows
+}
+
+// stepRows returns a copy of aCase per step, holding only that step and its
+// expectations.
+func stepRows(aCase domain.Case) []domain.Case {
+	steps := caseSteps(aCase)
+	if len(steps) == 0 {
+		return []domain.Case{aCase}
+	}
+
+	rows := make([]domain.Case, 0, len(steps)+1)
+	for _, step := range steps {
+		row := aCase
+		row.ValidationSteps = []string{step.Action}
+		row.Checkpoints = step.Expectations
+		row.Steps = []domain.Step{step}
+		rows = append(rows, row)
+	}
+	if loose := looseCheckpoints(aCase); len(loose) > 0 {
+		row := aCase
+		row.ValidationSteps = nil
+		row.Checkpoints = loose
+		row.Steps = nil
+		rows = append(rows, row)
+	}
+	return rows
+}
+
+// caseSteps returns the steps of aCase. Cases built without Steps, e.g.
+// imported from a spreadsheet, get one step per validation step with no
+// expectations.
+func caseSteps(aCase domain.Case) []domain.Step {
+	if len(aCase.Steps) > 0 {
+		return aCase.Steps
+	}
+	steps := make([]domain.Step, 0, len(aCase.ValidationSteps))
+	for _, action := range aCase.ValidationSteps {
+		steps = append(steps, domain.Step{Action: action})
+	}
+	return steps
+}
+
+// looseCheckpoints returns the checkpoints of aCase that are not nested under
+// any of its steps.
+func looseCheckpoints(aCase domain.Case) []string {
+	nested := make(map[string]int)
+	for _, step := range aCase.Steps {
+		for _, expectation := range step.Expectations {
+			text, _ := checkpointState(expectation)
+			nested[text]++
+		}
+	}
+
+	var loose []string
+	for _, checkpoint := range aCase.Checkpoints {
+		text, _ := checkpointState(checkpoint)
+		if nested[text] > 0 {
+			nested[text]--
+			continue
+		}
+		loose = append(loose, checkpoint)
+	}
+	return loose
+}
@@ -0,0 +1,98 @@
+package app
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/9renpoto/casemd/internal/core/domain"
+)
+
+var steppedCases = []domain.Case{
+	{
+		MajorItem:       "Account",
+		MinorItem:       "Sign in",
+		ValidationSteps: []string{"Open the sign-in page", "Submit valid credentials", "Wait"},
+		Checkpoints:     []string{"* [ ] Form is shown", "* [x] Dashboard opens", "* [ ] No errors are logged"},
+		Steps: []domain.Step{
+			{Action: "Open the sign-in page", Expectations: []string{"* [ ] Form is shown"}},
+			{Action: "Submit valid credentials", Expectations: []string{"* [x] Dashboard opens"}},
+			{Action: "Wait"},
+		},
+		Result: "OK",
+	},
+	{
+		MajorItem:   "Account",
+		MinorItem:   "Sign out",
+		Checkpoints: []string{"* [ ] Sign-in page opens"},
+	},
+}
+
+func TestParseLayout(t *testing.T) {
+	for name, expected := range map[string]Layout{"": CaseLayout, "case": CaseLayout, " Step ": StepLayout} {
+		layout, err := ParseLayout(name)
+		if err != nil || layout != expected {
+			t.Fatalf("ParseLayout(%q) = %q, %v; want %q", name, layout, err, expected)
+		}
+	}
+	if _, err := ParseLayout("sheet"); err == nil {
+		t.Fatal("expected an error for an unknown layout")
+	}
+}
+
+func TestMarkdownToCSV_StepLayout(t *testing.T) {
+	columns, err := ParseColumns("minor,steps,checkpoints,result")
+	if err != nil {
+		t.Fatalf("ParseColumns() returned an unexpected error: %v", err)
+	}
+
+	var output bytes.Buffer
+	sources := []Source{{Name: "account.md", Reader: strings.NewReader(""), Columns: columns, Layout: StepLayout}}
+	if err := NewMarkdownToCSV(&mockCaseParser{cases: steppedCases}).Convert(sources, &output); err != nil {
+		t.Fatalf("Convert() returned an unexpected error: %v", err)
+	}
+
+	expected := "Minor Item,Validation Steps,Checkpoints,Result\n" +
+		"Sign in,Open the sign-in page,* [ ] Form is shown,OK\n" +
+		"Sign in,Submit valid credentials,* [x] Dashboard opens,OK\n" +
+		"Sign in,Wait,,OK\n" +
+		"Sign in,,* [ ] No errors are logged,OK\n" +
+		"Sign out,,* [ ] Sign-in page opens,\n"
+	if output.String() != expected {
+		t.Fatalf("unexpected csv:\n%s", output.String())
+	}
+}
+
+func TestXLSXToMarkdown_JoinsStepRows(t *testing.T) {
+	var workbook bytes.Buffer
+	sources := []Source{{Name: "account.md", Reader: strings.NewReader(""), Layout: StepLayout}}
+	if err := NewMarkdownToSpreadsheet(&mockCaseParser{cases: steppedCases}).Convert(sources, &workbook); err != nil {
+		t.Fatalf("Convert() returned an unexpected error: %v", err)
+	}
+
+	var markdown bytes.Buffer
+	imports := []Source{{Name: "account.xlsx", Reader: &workbook, Layout: StepLayout}}
+	if err := NewXLSXToMarkdown().Convert(imports, &markdown); err != nil {
+		t.Fatalf("Convert() returned an unexpected error: %v", err)
+	}
+
+	expected := `# account
+
+## Account
+#### Sign in
+
+1. Open the sign-in page
+   * [ ] Form is shown
+2. Submit valid credentials
+   * [x] Dashboard opens
+3. Wait
+* [ ] No errors are logged
+
+#### Sign out
+
+* [ ] Sign-in page opens
+`
+	if markdown.String() != expected {
+		t.Fatalf("unexpected markdown:\n%s", markdown.String())
+	}
+}
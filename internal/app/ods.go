@@ -0,0 +1,156 @@
+package app
+
+import (
+	"archive/zip"
+	"bufio"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strings"
+)
+
+const odsMimeType = "application/vnd.oasis.opendocument.spreadsheet"
+
+func writeODS(w io.Writer, sheets []workbookSheet) error {
+	zipWriter := zip.NewWriter(w)
+
+	// The mimetype entry must come first and stay uncompressed so tools can
+	// sniff the format without inflating the archive.
+	mimeWriter, err := zipWriter.CreateHeader(&zip.FileHeader{Name: "mimetype", Method: zip.Store})
+	if err != nil {
+		zipWriter.Close()
+		return fmt.Errorf("create mimetype: %w", err)
+	}
+	if _, err := io.WriteString(mimeWriter, odsMimeType); err != nil {
+		zipWriter.Close()
+		return fmt.Errorf("write mimetype: %w", err)
+	}
+
+	if err := writeZipFile(zipWriter, "META-INF/manifest.xml", odsManifest); err != nil {
+		zipWriter.Close()
+		return err
+	}
+
+	if err := writeZipFile(zipWriter, "meta.xml", odsMeta); err != nil {
+		zipWriter.Close()
+		return err
+	}
+
+	if err := writeZipFile(zipWriter, "styles.xml", odsStyles); err != nil {
+		zipWriter.Close()
+		return err
+	}
+
+	err = writeZipEntry(zipWriter, "content.xml", func(writer *bufio.Writer) error {
+		return writeODSContent(writer, sheets)
+	})
+	if err != nil {
+		zipWriter.Close()
+		return err
+	}
+
+	return zipWriter.Close()
+}
+
+func writeODSContent(writer *bufio.Writer, sheets []workbookSheet) error {
+	writer.WriteString(xml.Header)
+	writer.WriteString(`<office:document-content xmlns:office="urn:oasis:names:tc:opendocument:xmlns:office:1.0" xmlns:style="urn:oasis:names:tc:opendocument:xmlns:style:1.0" xmlns:text="urn:oasis:names:tc:opendocument:xmlns:text:1.0" xmlns:table="urn:oasis:names:tc:opendocument:xmlns:table:1.0" xmlns:fo="urn:oasis:names:tc:opendocument:xmlns:xsl-fo-compatible:1.0" office:version="1.2">`)
+	writer.WriteString(`<office:automatic-styles>`)
+	writer.WriteString(`<style:style style:name="ce1" style:family="table-cell"><style:table-cell-properties fo:wrap-option="wrap" style:vertical-align="top"/></style:style>`)
+	writer.WriteString(`<style:style style:name="ce2" style:family="table-cell"><style:table-cell-properties style:vertical-align="top"/><style:text-properties fo:font-weight="bold"/></style:style>`)
+	writer.WriteString(`</office:automatic-styles>`)
+	writer.WriteString(`<office:body><office:spreadsheet>`)
+	for _, sheet := range sheets {
+		fmt.Fprintf(writer, `<table:table table:name="%s">`, xmlEscapeAttr(sheet.Name))
+		if sheet.Width > 0 {
+			fmt.Fprintf(writer, `<table:table-column table:number-columns-repeated="%d"/>`, sheet.Width)
+		}
+		for i := 0; i < sheet.Height; i++ {
+			if err := sheet.contextErr(); err != nil {
+				return err
+			}
+			style := "ce1"
+			if i == 0 {
+				style = "ce2"
+			}
+			writer.WriteString(`<table:table-row>`)
+			for _, value := range sheet.Row(i) {
+				if value == "" {
+					fmt.Fprintf(writer, `<table:table-cell table:style-name="%s"/>`, style)
+					continue
+				}
+				fmt.Fprintf(writer, `<table:table-cell table:style-name="%s" office:value-type="string">`, style)
+				for _, line := range strings.Split(value, "\n") {
+					writer.WriteString(`<text:p>`)
+					writeODSText(writer, line)
+					writer.WriteString(`</text:p>`)
+				}
+				writer.WriteString(`</table:table-cell>`)
+			}
+			writer.WriteString(`</table:table-row>`)
+		}
+		writer.WriteString(`</table:table>`)
+	}
+	writer.WriteString(`</office:spreadsheet></office:body></office:document-content>`)
+	return nil
+}
+
+// writeODSText writes one paragraph of cell text. ODF collapses runs of
+// white space and drops it at the start of a paragraph, so tabs become
+// <text:tab/> and every space that would be lost becomes <text:s/>.
+func writeODSText(writer *bufio.Writer, line string) {
+	for len(line) > 0 {
+		end := strings.IndexAny(line, " \t")
+		if end < 0 {
+			writeCellText(writer, line)
+			return
+		}
+		if end > 0 {
+			writeCellText(writer, line[:end])
+		}
+		// A single space survives between other characters.
+		literal := end > 0
+		line = line[end:]
+		if line[0] == '\t' {
+			writer.WriteString(`<text:tab/>`)
+			line = line[1:]
+			continue
+		}
+		spaces := len(line) - len(strings.TrimLeft(line, " "))
+		line = line[spaces:]
+		if literal && line != "" {
+			writer.WriteByte(' ')
+			spaces--
+		}
+		switch {
+		case spaces == 1:
+			writer.WriteString(`<text:s/>`)
+		case spaces > 1:
+			fmt.Fprintf(writer, `<text:s text:c="%d"/>`, spaces)
+		}
+	}
+}
+
+const odsManifest = `<?xml version="1.0" encoding="UTF-8"?>
+<manifest:manifest xmlns:manifest="urn:oasis:names:tc:opendocument:xmlns:manifest:1.0" manifest:version="1.2">
+  <manifest:file-entry manifest:full-path="/" manifest:version="1.2" manifest:media-type="application/vnd.oasis.opendocument.spreadsheet"/>
+  <manifest:file-entry manifest:full-path="content.xml" manifest:media-type="text/xml"/>
+  <manifest:file-entry manifest:full-path="styles.xml" manifest:media-type="text/xml"/>
+  <manifest:file-entry manifest:full-path="meta.xml" manifest:media-type="text/xml"/>
+</manifest:manifest>`
+
+const odsMeta = `<?xml version="1.0" encoding="UTF-8"?>
+<office:document-meta xmlns:office="urn:oasis:names:tc:opendocument:xmlns:office:1.0" xmlns:meta="urn:oasis:names:tc:opendocument:xmlns:meta:1.0" office:version="1.2">
+  <office:meta>
+    <meta:generator>casemd</meta:generator>
+  </office:meta>
+</office:document-meta>`
+
+const odsStyles = `<?xml version="1.0" encoding="UTF-8"?>
+<office:document-styles xmlns:office="urn:oasis:names:tc:opendocument:xmlns:office:1.0" xmlns:style="urn:oasis:names:tc:opendocument:xmlns:style:1.0" xmlns:fo="urn:oasis:names:tc:opendocument:xmlns:xsl-fo-compatible:1.0" office:version="1.2">
+  <office:styles>
+    <style:default-style style:family="table-cell">
+      <style:table-cell-properties style:vertical-align="top"/>
+    </style:default-style>
+  </office:styles>
+</office:document-styles>`
@@ -0,0 +1,213 @@
+package app
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/xml"
+	"io"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/9renpoto/casemd/internal/core/domain"
+)
+
+func TestMarkdownToODS_Convert(t *testing.T) {
+	parser := &mockCaseParser{cases: []domain.Case{
+		{
+			MajorItem:       "Setup",
+			MediumItem:      "Environment",
+			MinorItem:       "Dependencies",
+			ValidationSteps: []string{"Step 1", "Step 2"},
+			Checkpoints:     []string{"* [ ] Check <1>"},
+		},
+	}}
+	converter := NewMarkdownToODS(parser)
+
+	sources := []Source{
+		{Name: "alpha.md", Reader: strings.NewReader("")},
+		{Name: "beta.md", Reader: strings.NewReader("")},
+	}
+	var output bytes.Buffer
+	if err := converter.Convert(sources, &output); err != nil {
+		t.Fatalf("Convert() returned an unexpected error: %v", err)
+	}
+
+	zipReader, err := zip.NewReader(bytes.NewReader(output.Bytes()), int64(output.Len()))
+	if err != nil {
+		t.Fatalf("open zip: %v", err)
+	}
+
+	first := zipReader.File[0]
+	if first.Name != "mimetype" || first.Method != zip.Store {
+		t.Fatalf("mimetype must be the first stored entry, got %s (method %d)", first.Name, first.Method)
+	}
+	if got := readZipEntry(t, zipReader, "mimetype"); got != odsMimeType {
+		t.Fatalf("unexpected mimetype: %s", got)
+	}
+	for _, name := range []string{"META-INF/manifest.xml", "meta.xml", "styles.xml"} {
+		readZipEntry(t, zipReader, name)
+	}
+
+	var content odsDocument
+	if err := xml.Unmarshal([]byte(readZipEntry(t, zipReader, "content.xml")), &content); err != nil {
+		t.Fatalf("unmarshal content: %v", err)
+	}
+
+	tables := content.Body.Spreadsheet.Tables
+	if len(tables) != 2 || tables[0].Name != "alpha" || tables[1].Name != "beta" {
+		t.Fatalf("unexpected tables: %+v", tables)
+	}
+
+	rows := tables[0].values()
+	expectedRows := [][]string{
+		columnHeaders(DefaultColumns()),
+		caseRow(DefaultColumns(), parser.cases[0]),
+	}
+	if !reflect.DeepEqual(rows, expectedRows) {
+		t.Fatalf("unexpected rows: %#v", rows)
+	}
+}
+
+func TestMarkdownToODS_ConvertKeepsWhiteSpace(t *testing.T) {
+	steps := []string{"  indented", "a  b   c", "tab\there", "\t lead", "trailing  ", "end ", "one space"}
+	parser := &mockCaseParser{cases: []domain.Case{{MajorItem: "Setup", MinorItem: "Spaces", ValidationSteps: steps}}}
+	columns, err := ParseColumns("steps")
+	if err != nil {
+		t.Fatalf("ParseColumns() returned an unexpected error: %v", err)
+	}
+
+	var output bytes.Buffer
+	if err := NewMarkdownToODS(parser).Convert([]Source{{Name: "alpha.md", Reader: strings.NewReader(""), Columns: columns}}, &output); err != nil {
+		t.Fatalf("Convert() returned an unexpected error: %v", err)
+	}
+	zipReader, err := zip.NewReader(bytes.NewReader(output.Bytes()), int64(output.Len()))
+	if err != nil {
+		t.Fatalf("open zip: %v", err)
+	}
+	content := readZipEntry(t, zipReader, "content.xml")
+	for _, expected := range []string{`<text:p><text:s text:c="2"/>indented</text:p>`, `<text:p>a <text:s/>b <text:s text:c="2"/>c</text:p>`, `<text:p>tab<text:tab/>here</text:p>`} {
+		if !strings.Contains(content, expected) {
+			t.Fatalf("expected content.xml to contain %s", expected)
+		}
+	}
+
+	var document odsDocument
+	if err := xml.Unmarshal([]byte(content), &document); err != nil {
+		t.Fatalf("unmarshal content: %v", err)
+	}
+	if got := document.Body.Spreadsheet.Tables[0].values()[1][0]; got != strings.Join(steps, "\n") {
+		t.Fatalf("unexpected cell text: %q", got)
+	}
+}
+
+func readZipEntry(t *testing.T, zipReader *zip.Reader, name string) string {
+	t.Helper()
+
+	for _, file := range zipReader.File {
+		if file.Name != name {
+			continue
+		}
+		rc, err := file.Open()
+		if err != nil {
+			t.Fatalf("open %s: %v", name, err)
+		}
+		defer rc.Close()
+
+		content, err := io.ReadAll(rc)
+		if err != nil {
+			t.Fatalf("read %s: %v", name, err)
+		}
+		return string(content)
+	}
+
+	t.Fatalf("%s not found", name)
+	return ""
+}
+
+type odsDocument struct {
+	Body struct {
+		Spreadsheet struct {
+			Tables []odsTable `xml:"table"`
+		} `xml:"spreadsheet"`
+	} `xml:"body"`
+}
+
+type odsTable struct {
+	Name string `xml:"name,attr"`
+	Rows []struct {
+		Cells []struct {
+			Paragraphs []odsParagraph `xml:"p"`
+		} `xml:"table-cell"`
+	} `xml:"table-row"`
+}
+
+func (t odsTable) values() [][]string {
+	rows := make([][]string, len(t.Rows))
+	for i, row := range t.Rows {
+		values := make([]string, len(row.Cells))
+		for j, cell := range row.Cells {
+			lines := make([]string, len(cell.Paragraphs))
+			for k, paragraph := range cell.Paragraphs {
+				lines[k] = string(paragraph)
+			}
+			values[j] = strings.Join(lines, "\n")
+		}
+		rows[i] = values
+	}
+	return rows
+}
+
+// odsParagraph is the text of a <text:p> as spreadsheet software shows it:
+// white space in the character data collapses to one space and is dropped at
+// the start and end, while <text:s/> and <text:tab/> are kept.
+type odsParagraph string
+
+func (p *odsParagraph) UnmarshalXML(decoder *xml.Decoder, start xml.StartElement) error {
+	var text strings.Builder
+	// collapsible reports whether text ends in a space from character data.
+	collapsible := true
+	for {
+		token, err := decoder.Token()
+		if err != nil {
+			return err
+		}
+		switch token := token.(type) {
+		case xml.CharData:
+			for _, r := range string(token) {
+				if !strings.ContainsRune(" \t\r\n", r) {
+					text.WriteRune(r)
+					collapsible = false
+				} else if !collapsible {
+					text.WriteByte(' ')
+					collapsible = true
+				}
+			}
+		case xml.StartElement:
+			switch token.Name.Local {
+			case "s":
+				count := 1
+				for _, attr := range token.Attr {
+					if attr.Name.Local == "c" {
+						count, _ = strconv.Atoi(attr.Value)
+					}
+				}
+				text.WriteString(strings.Repeat(" ", count))
+			case "tab":
+				text.WriteByte('\t')
+			}
+			collapsible = false
+			if err := decoder.Skip(); err != nil {
+				return err
+			}
+		case xml.EndElement:
+			value := text.String()
+			if collapsible {
+				value = strings.TrimSuffix(value, " ")
+			}
+			*p = odsParagraph(value)
+			return nil
+		}
+	}
+}
@@ -0,0 +1,336 @@
+package app
+
+import (
+	"fmt"
+	"io"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/9renpoto/casemd/internal/core/domain"
+)
+
+// PlanStrategy decides how TestPlanner distributes cases among testers.
+type PlanStrategy string
+
+const (
+	// RoundRobinStrategy deals the cases out one at a time, in checklist
+	// order.
+	RoundRobinStrategy PlanStrategy = "round-robin"
+	// MajorItemStrategy keeps every major item with one tester and
+	// balances the number of cases.
+	MajorItemStrategy PlanStrategy = "major"
+	// EffortStrategy balances the estimated effort of the cases.
+	EffortStrategy PlanStrategy = "effort"
+)
+
+// PlanStrategies lists the strategies accepted by ParsePlanStrategy.
+func PlanStrategies() []PlanStrategy {
+	return []PlanStrategy{RoundRobinStrategy, MajorItemStrategy, EffortStrategy}
+}
+
+// ParsePlanStrategy returns the strategy named name; an empty name is
+// RoundRobinStrategy.
+func ParsePlanStrategy(name string) (PlanStrategy, error) {
+	name = strings.ToLower(strings.TrimSpace(name))
+	if name == "" {
+		return RoundRobinStrategy, nil
+	}
+	for _, strategy := range PlanStrategies() {
+		if string(strategy) == name {
+			return strategy, nil
+		}
+	}
+	return "", fmt.Errorf("unknown strategy %q (available: %s, %s, %s)", name, RoundRobinStrategy, MajorItemStrategy, EffortStrategy)
+}
+
+// SpreadsheetFormat selects the file format of the workbooks a plan writes.
+type SpreadsheetFormat string
+
+const (
+	XLSXFormat SpreadsheetFormat = "xlsx"
+	ODSFormat  SpreadsheetFormat = "ods"
+)
+
+// Plan is the share of a checklist set given to each tester.
+type Plan struct {
+	Assignments []Assignment
+}
+
+// Assignment lists the cases given to one tester, with Tester filled in.
+type Assignment struct {
+	Tester string
+	// Sheets holds one entry per source, in order, even when none of its
+	// cases were assigned, so every tester's workbook has the same sheets.
+	Sheets []PlanSheet
+	Cases  int
+	// Effort adds up the estimated effort of the cases. Cases without an
+	// estimate count as the average of those that have one.
+	Effort time.Duration
+}
+
+// PlanSheet holds the cases of one source assigned to a tester.
+type PlanSheet struct {
+	Name  string
+	Cases []domain.Case
+	// source carries the column, layout and language settings.
+	source Source
+}
+
+// TestPlanner splits a checklist set among testers.
+type TestPlanner struct {
+	parser CaseParser
+}
+
+// NewTestPlanner wires the planner with the provided parser implementation.
+func NewTestPlanner(parser CaseParser) *TestPlanner {
+	return &TestPlanner{parser: parser}
+}
+
+// Plan parses sources and assigns every case to one of testers.
+func (p *TestPlanner) Plan(sources []Source, testers []string, strategy PlanStrategy) (Plan, error) {
+	if len(sources) == 0 {
+		return Plan{}, fmt.Errorf("no sources provided")
+	}
+	if len(testers) == 0 {
+		return Plan{}, fmt.Errorf("no testers provided")
+	}
+	seen := make(map[string]bool, len(testers))
+	for _, tester := range testers {
+		if seen[tester] {
+			return Plan{}, fmt.Errorf("tester %q is listed more than once", tester)
+		}
+		seen[tester] = true
+	}
+
+	var cases []plannedCase
+	for index, source := range sources {
+		parsed, err := parseSource(p.parser, source)
+		if err != nil {
+			return Plan{}, fmt.Errorf("parse %s: %w", source.Name, err)
+		}
+		for _, aCase := range parsed {
+			cases = append(cases, plannedCase{source: index, aCase: aCase})
+		}
+	}
+	estimateEffort(cases)
+
+	var owners []int
+	switch strategy {
+	case MajorItemStrategy:
+		owners = assignByMajorItem(cases, len(testers))
+	case EffortStrategy:
+		weights := make([]float64, len(cases))
+		for i, planned := range cases {
+			weights[i] = planned.weight
+		}
+		owners = balance(weights, len(testers))
+	default:
+		owners = make([]int, len(cases))
+		for i := range cases {
+			owners[i] = i % len(testers)
+		}
+	}
+
+	plan := Plan{Assignments: make([]Assignment, len(testers))}
+	for t, tester := range testers {
+		sheets := make([]PlanSheet, len(sources))
+		for i, source := range sources {
+			sheets[i] = PlanSheet{Name: source.Name, source: source}
+		}
+		plan.Assignments[t] = Assignment{Tester: tester, Sheets: sheets}
+	}
+	for i, planned := range cases {
+		assignment := &plan.Assignments[owners[i]]
+		planned.aCase.Tester = assignment.Tester
+		sheet := &assignment.Sheets[planned.source]
+		sheet.Cases = append(sheet.Cases, planned.aCase)
+		assignment.Cases++
+		assignment.Effort += planned.effort
+	}
+	return plan, nil
+}
+
+// WriteWorkbook writes a workbook with one sheet per tester, holding the
+// tester's cases from every source. A sheet has a single header, so it fails
+// when the sources use different columns or layouts; Assignment.WriteWorkbook
+// writes one sheet per source instead.
+func (p Plan) WriteWorkbook(output io.Writer, format SpreadsheetFormat) error {
+	if len(p.Assignments) == 0 {
+		return fmt.Errorf("no testers provided")
+	}
+	parts := p.Assignments[0].Sheets
+	for _, part := range parts[1:] {
+		if !sameSheetLayout(parts[0].source, part.source) {
+			return fmt.Errorf("%s and %s use different columns or layouts; write a workbook per tester instead", parts[0].Name, part.Name)
+		}
+	}
+	sheets := make([]workbookSheet, 0, len(p.Assignments))
+	nameUsage := make(map[string]int)
+	finalNames := make(map[string]struct{})
+
+	for index, assignment := range p.Assignments {
+		base := sanitizeSheetName(assignment.Tester)
+		if base == "" {
+			base = fmt.Sprintf("Sheet%d", index+1)
+		}
+		var cases []domain.Case
+		for _, part := range assignment.Sheets {
+			cases = append(cases, part.Cases...)
+		}
+		name := ensureUniqueSheetName(base, nameUsage, finalNames)
+		sheets = append(sheets, newWorkbookSheet(name, assignment.Sheets[0].source, cases))
+	}
+	return writeSpreadsheet(output, format, sheets)
+}
+
+// WriteWorkbook writes the tester's workbook with one sheet per source, named
+// like the sheets of a full spreadsheet export.
+func (a Assignment) WriteWorkbook(output io.Writer, format SpreadsheetFormat) error {
+	if len(a.Sheets) == 0 {
+		return fmt.Errorf("no sources provided")
+	}
+	sheets := make([]workbookSheet, 0, len(a.Sheets))
+	nameUsage := make(map[string]int)
+	finalNames := make(map[string]struct{})
+
+	for index, part := range a.Sheets {
+		name := ensureUniqueSheetName(deriveSheetName(part.Name, index), nameUsage, finalNames)
+		sheets = append(sheets, newWorkbookSheet(name, part.source, part.Cases))
+	}
+	return writeSpreadsheet(output, format, sheets)
+}
+
+// sameSheetLayout reports whether the cases of a and b are written to the
+// same columns and rows.
+func sameSheetLayout(a, b Source) bool {
+	layout := func(source Source) Layout {
+		if source.Layout == "" {
+			return CaseLayout
+		}
+		return source.Layout
+	}
+	return layout(a) == layout(b) && slices.EqualFunc(a.columns(), b.columns(), func(x, y Column) bool {
+		return x.Key == y.Key && x.Header == y.Header
+	})
+}
+
+func writeSpreadsheet(output io.Writer, format SpreadsheetFormat, sheets []workbookSheet) error {
+	if format == ODSFormat {
+		return writeODS(output, sheets)
+	}
+	return writeWorkbook(output, sheets)
+}
+
+type plannedCase struct {
+	source int
+	aCase  domain.Case
+	// effort is the case's estimate, or the average estimate when it has
+	// none; weight is what the strategies balance.
+	effort time.Duration
+	weight float64
+}
+
+// estimateEffort fills in the effort of every case. Without any estimate in
+// the checklist set, each case weighs the same.
+func estimateEffort(cases []plannedCase) {
+	var total time.Duration
+	var known int
+	estimated := make([]bool, len(cases))
+	for i := range cases {
+		if effort, ok := ParseEffort(cases[i].aCase.Effort); ok {
+			cases[i].effort = effort
+			estimated[i] = true
+			total += effort
+			known++
+		}
+	}
+	var average time.Duration
+	if known > 0 {
+		average = total / time.Duration(known)
+	}
+	for i := range cases {
+		if !estimated[i] {
+			cases[i].effort = average
+		}
+		cases[i].weight = cases[i].effort.Minutes()
+		if known == 0 {
+			cases[i].weight = 1
+		}
+	}
+}
+
+var effortUnits = strings.NewReplacer("hours", "h", "hour", "h", "hrs", "h", "hr", "h", "時間", "h", "mins", "m", "min", "m", "分", "m")
+
+// ParseEffort reads an effort estimate such as "30m", "1h30m", "45 min" or
+// "2時間". A bare number counts minutes.
+func ParseEffort(value string) (time.Duration, bool) {
+	value = strings.ReplaceAll(strings.ToLower(strings.TrimSpace(value)), " ", "")
+	if value == "" {
+		return 0, false
+	}
+	if minutes, err := strconv.ParseFloat(value, 64); err == nil && minutes >= 0 {
+		return time.Duration(minutes * float64(time.Minute)), true
+	}
+	effort, err := time.ParseDuration(effortUnits.Replace(value))
+	if err != nil || effort < 0 {
+		return 0, false
+	}
+	return effort, true
+}
+
+// assignByMajorItem keeps the cases of a major item together and balances
+// the number of cases per tester.
+func assignByMajorItem(cases []plannedCase, testers int) []int {
+	type group struct {
+		source int
+		major  string
+	}
+	groupOf := make(map[group]int)
+	var sizes []float64
+	indexes := make([]int, len(cases))
+	for i, planned := range cases {
+		key := group{planned.source, planned.aCase.MajorItem}
+		index, ok := groupOf[key]
+		if !ok {
+			index = len(sizes)
+			groupOf[key] = index
+			sizes = append(sizes, 0)
+		}
+		sizes[index]++
+		indexes[i] = index
+	}
+
+	groupOwners := balance(sizes, testers)
+	owners := make([]int, len(cases))
+	for i, index := range indexes {
+		owners[i] = groupOwners[index]
+	}
+	return owners
+}
+
+// balance assigns weighted items to bins, heaviest first, each to the bin
+// with the least weight so far; ties go to the earlier bin.
+func balance(weights []float64, bins int) []int {
+	order := make([]int, len(weights))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(a, b int) bool { return weights[order[a]] > weights[order[b]] })
+
+	loads := make([]float64, bins)
+	owners := make([]int, len(weights))
+	for _, item := range order {
+		lightest := 0
+		for bin := range loads {
+			if loads[bin] < loads[lightest] {
+				lightest = bin
+			}
+		}
+		owners[item] = lightest
+		loads[lightest] += weights[item]
+	}
+	return owners
+}
@@ -0,0 +1,157 @@
+package app
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/9renpoto/casemd/internal/core/domain"
+)
+
+var planCases = []domain.Case{
+	{MajorItem: "Setup", MinorItem: "Install", Effort: "30m"},
+	{MajorItem: "Setup", MinorItem: "Configure", Effort: "30 min"},
+	{MajorItem: "Setup", MinorItem: "Upgrade"},
+	{MajorItem: "Execution", MinorItem: "Run", Effort: "2h"},
+	{MajorItem: "Report", MinorItem: "Export", Effort: "10"},
+}
+
+func assignedMinors(assignment Assignment) []string {
+	names := []string{}
+	for _, sheet := range assignment.Sheets {
+		for _, aCase := range sheet.Cases {
+			names = append(names, aCase.MinorItem)
+		}
+	}
+	return names
+}
+
+func TestParsePlanStrategy(t *testing.T) {
+	for name, expected := range map[string]PlanStrategy{"": RoundRobinStrategy, "Major": MajorItemStrategy, "effort": EffortStrategy} {
+		strategy, err := ParsePlanStrategy(name)
+		if err != nil || strategy != expected {
+			t.Fatalf("ParsePlanStrategy(%q) = %q, %v; want %q", name, strategy, err, expected)
+		}
+	}
+	if _, err := ParsePlanStrategy("random"); err == nil || err.Error() != `unknown strategy "random" (available: round-robin, major, effort)` {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+func TestParseEffort(t *testing.T) {
+	for value, expected := range map[string]time.Duration{
+		"30m":    30 * time.Minute,
+		"1h30m":  90 * time.Minute,
+		"45 min": 45 * time.Minute,
+		"1.5":    90 * time.Second,
+		"2時間":    2 * time.Hour,
+		"15分":    15 * time.Minute,
+	} {
+		if effort, ok := ParseEffort(value); !ok || effort != expected {
+			t.Fatalf("ParseEffort(%q) = %v, %v; want %v", value, effort, ok, expected)
+		}
+	}
+	for _, value := range []string{"", "soon", "-5"} {
+		if _, ok := ParseEffort(value); ok {
+			t.Fatalf("ParseEffort(%q) should fail", value)
+		}
+	}
+}
+
+func TestTestPlanner_Plan(t *testing.T) {
+	tests := map[PlanStrategy][][]string{
+		RoundRobinStrategy: {{"Install", "Upgrade", "Export"}, {"Configure", "Run"}},
+		MajorItemStrategy:  {{"Install", "Configure", "Upgrade"}, {"Run", "Export"}},
+		EffortStrategy:     {{"Run"}, {"Install", "Configure", "Upgrade", "Export"}},
+	}
+	for strategy, expected := range tests {
+		planner := NewTestPlanner(&mockCaseParser{cases: planCases})
+		plan, err := planner.Plan([]Source{{Name: "app.md", Reader: strings.NewReader("")}}, []string{"alice", "bob"}, strategy)
+		if err != nil {
+			t.Fatalf("Plan(%s) returned an unexpected error: %v", strategy, err)
+		}
+		for i, assignment := range plan.Assignments {
+			if names := assignedMinors(assignment); !reflect.DeepEqual(names, expected[i]) {
+				t.Fatalf("Plan(%s) gave %s %v, want %v", strategy, assignment.Tester, names, expected[i])
+			}
+			if assignment.Cases != len(expected[i]) {
+				t.Fatalf("Plan(%s) counted %d cases for %s", strategy, assignment.Cases, assignment.Tester)
+			}
+			for _, aCase := range assignment.Sheets[0].Cases {
+				if aCase.Tester != assignment.Tester {
+					t.Fatalf("expected the tester to be filled in, got %q", aCase.Tester)
+				}
+			}
+		}
+		if strategy == EffortStrategy {
+			// Upgrade has no estimate and counts as the average, 47m30s.
+			if alice, bob := plan.Assignments[0].Effort, plan.Assignments[1].Effort; alice != 2*time.Hour || bob != 117*time.Minute+30*time.Second {
+				t.Fatalf("unexpected efforts: %v, %v", alice, bob)
+			}
+		}
+	}
+}
+
+func TestTestPlanner_PlanRejectsDuplicateTesters(t *testing.T) {
+	planner := NewTestPlanner(&mockCaseParser{cases: planCases})
+	_, err := planner.Plan([]Source{{Name: "app.md", Reader: strings.NewReader("")}}, []string{"alice", "alice"}, RoundRobinStrategy)
+	if err == nil || err.Error() != `tester "alice" is listed more than once` {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+func TestPlan_WriteWorkbook(t *testing.T) {
+	planner := NewTestPlanner(&mockCaseParser{cases: planCases})
+	sources := []Source{{Name: "app.md", Reader: strings.NewReader("")}, {Name: "api.md", Reader: strings.NewReader("")}}
+	plan, err := planner.Plan(sources, []string{"alice", "bob"}, MajorItemStrategy)
+	if err != nil {
+		t.Fatalf("Plan() returned an unexpected error: %v", err)
+	}
+
+	var combined bytes.Buffer
+	if err := plan.WriteWorkbook(&combined, XLSXFormat); err != nil {
+		t.Fatalf("WriteWorkbook() returned an unexpected error: %v", err)
+	}
+	sheets, err := ReadWorkbookCases(combined.Bytes())
+	if err != nil {
+		t.Fatalf("ReadWorkbookCases() returned an unexpected error: %v", err)
+	}
+	if len(sheets) != 2 || sheets[0].Name != "alice" || sheets[1].Name != "bob" {
+		t.Fatalf("expected one sheet per tester, got %+v", sheets)
+	}
+	for _, sheet := range sheets {
+		for _, aCase := range sheet.Cases {
+			if aCase.Tester != sheet.Name {
+				t.Fatalf("sheet %s holds a case for %q", sheet.Name, aCase.Tester)
+			}
+		}
+	}
+
+	mixed, err := planner.Plan([]Source{
+		{Name: "app.md", Reader: strings.NewReader("")},
+		{Name: "api.md", Reader: strings.NewReader(""), Layout: StepLayout},
+	}, []string{"alice"}, RoundRobinStrategy)
+	if err != nil {
+		t.Fatalf("Plan() returned an unexpected error: %v", err)
+	}
+	if err := mixed.WriteWorkbook(&bytes.Buffer{}, XLSXFormat); err == nil || err.Error() != "app.md and api.md use different columns or layouts; write a workbook per tester instead" {
+		t.Fatalf("expected sources with different layouts to be rejected, got %v", err)
+	}
+
+	var own bytes.Buffer
+	if err := plan.Assignments[1].WriteWorkbook(&own, XLSXFormat); err != nil {
+		t.Fatalf("WriteWorkbook() returned an unexpected error: %v", err)
+	}
+	sheets, err = ReadWorkbookCases(own.Bytes())
+	if err != nil {
+		t.Fatalf("ReadWorkbookCases() returned an unexpected error: %v", err)
+	}
+	if len(sheets) != 2 || sheets[0].Name != "app" || sheets[1].Name != "api" {
+		t.Fatalf("expected one sheet per source, got %+v", sheets)
+	}
+	if got := len(sheets[0].Cases) + len(sheets[1].Cases); got != plan.Assignments[1].Cases {
+		t.Fatalf("expected %d cases in bob's workbook, got %d", plan.Assignments[1].Cases, got)
+	}
+}
@@ -0,0 +1,127 @@
+package app
+
+import (
+	"bytes"
+	"encoding/csv"
+	"fmt"
+	"io"
+
+	"github.com/9renpoto/casemd/internal/core/domain"
+	"github.com/9renpoto/casemd/internal/core/lint"
+	"github.com/9renpoto/casemd/internal/core/results"
+)
+
+// DocumentParser defines the behavior required to parse Markdown together with
+// source positions and diagnostics.
+type DocumentParser interface {
+	ParseDocument(r io.Reader) (domain.Document, error)
+}
+
+// Preview is the live-preview view of a Markdown source: the CSV rendering, the
+// source lines behind each data row, and parser plus lint diagnostics.
+type Preview struct {
+	CSV         string              `json:"csv"`
+	Rows        []PreviewRow        `json:"rows"`
+	Diagnostics []domain.Diagnostic `json:"diagnostics"`
+}
+
+// PreviewRow maps a CSV data row back to the Markdown lines that produced it.
+type PreviewRow struct {
+	Line    int `json:"line"`
+	EndLine int `json:"endLine"`
+}
+
+// MarkdownPreview orchestrates previews for interactive editors.
+type MarkdownPreview struct {
+	parser DocumentParser
+}
+
+// NewMarkdownPreview wires the preview use case with the provided parser implementation.
+func NewMarkdownPreview(parser DocumentParser) *MarkdownPreview {
+	return &MarkdownPreview{parser: parser}
+}
+
+// Preview parses a single source and returns its CSV rendering with row positions and diagnostics.
+func (p *MarkdownPreview) Preview(source Source) (Preview, error) {
+	document, err := parseDocument(p.parser, source)
+	if err != nil {
+		return Preview{}, fmt.Errorf("parse %s: %w", source.Name, err)
+	}
+	if source.Results != nil {
+		document.Cases = results.Merge(document.Cases, *source.Results)
+	}
+
+	columns := source.columns()
+	var buffer bytes.Buffer
+	writer := csv.NewWriter(&buffer)
+	if err := writer.Write(columnHeaders(columns)); err != nil {
+		return Preview{}, fmt.Errorf("write csv header: %w", err)
+	}
+
+	// Lint the whole document so a filter does not hide duplicates.
+	diagnostics := append(document.Diagnostics, lint.Check(document.Cases)...)
+	cases := source.Filter.Apply(document.Cases)
+
+	preview := Preview{Rows: make([]PreviewRow, 0, len(cases))}
+	for _, aCase := range source.rows(cases) {
+		if err := writer.Write(caseRow(columns, aCase)); err != nil {
+			return Preview{}, fmt.Errorf("write csv row: %w", err)
+		}
+		preview.Rows = append(preview.Rows, PreviewRow{Line: aCase.Line, EndLine: aCase.EndLine})
+	}
+	writer.Flush()
+	if err := writer.Error(); err != nil {
+		return Preview{}, fmt.Errorf("flush csv: %w", err)
+	}
+
+	preview.CSV = buffer.String()
+	preview.Diagnostics = diagnostics
+	if preview.Diagnostics == nil {
+		preview.Diagnostics = []domain.Diagnostic{}
+	}
+	return preview, nil
+}
+
+// SheetPreview is one tab of a workbook preview, named like the XLSX sheet the
+// source would become.
+type SheetPreview struct {
+	Name   string `json:"name"`
+	Source string `json:"source"`
+	CSV    string `json:"csv"`
+}
+
+// Workbook previews the sheets MarkdownToSpreadsheet would write for sources.
+func (p *MarkdownPreview) Workbook(sources []Source) ([]SheetPreview, error) {
+	sheets, err := buildWorkbookSheets(documentCaseParser{p.parser}, sources)
+	if err != nil {
+		return nil, err
+	}
+
+	previews := make([]SheetPreview, 0, len(sheets))
+	for index, sheet := range sheets {
+		var buffer bytes.Buffer
+		writer := csv.NewWriter(&buffer)
+		if err := writer.WriteAll(sheet.grid()); err != nil {
+			return nil, fmt.Errorf("write csv for %s: %w", sheet.Name, err)
+		}
+		previews = append(previews, SheetPreview{Name: sheet.Name, Source: sources[index].Name, CSV: buffer.String()})
+	}
+	return previews, nil
+}
+
+// documentCaseParser adapts a DocumentParser to the CaseParser used by the converters.
+type documentCaseParser struct {
+	parser DocumentParser
+}
+
+func (p documentCaseParser) Parse(r io.Reader) ([]domain.Case, error) {
+	document, err := p.parser.ParseDocument(r)
+	if err != nil {
+		return nil, err
+	}
+	return document.Cases, nil
+}
+
+func (p documentCaseParser) ParseDocumentWithIncludes(r io.Reader, includes Includes) (domain.Document, error) {
+	return parseDocument(p.parser, Source{Reader: r, Includes: includes})
+}
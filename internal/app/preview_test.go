@@ -0,0 +1,90 @@
+package app
+
+import (
+	"encoding/csv"
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/9renpoto/casemd/internal/core/domain"
+)
+
+type mockDocumentParser struct {
+	document domain.Document
+	err      error
+}
+
+func (m *mockDocumentParser) ParseDocument(r io.Reader) (domain.Document, error) {
+	return m.document, m.err
+}
+
+func TestMarkdownPreview_Preview(t *testing.T) {
+	parser := &mockDocumentParser{document: domain.Document{
+		Cases: []domain.Case{
+			{MajorItem: "Setup", MinorItem: "Dependencies", ValidationSteps: []string{"Install"}, Checkpoints: []string{"* [ ] Installed"}, Line: 3, EndLine: 5},
+			{MajorItem: "Setup", MinorItem: "Variables", ValidationSteps: []string{"Export"}, Line: 7, EndLine: 8},
+		},
+		Diagnostics: []domain.Diagnostic{{Line: 1, Severity: domain.SeverityWarning, Message: "ignored"}},
+	}}
+	previewer := NewMarkdownPreview(parser)
+
+	preview, err := previewer.Preview(Source{Name: "checks.md", Reader: strings.NewReader("")})
+	if err != nil {
+		t.Fatalf("Preview() returned an unexpected error: %v", err)
+	}
+
+	records, err := csv.NewReader(strings.NewReader(preview.CSV)).ReadAll()
+	if err != nil {
+		t.Fatalf("read csv: %v", err)
+	}
+	if len(records) != 3 {
+		t.Fatalf("expected header and 2 rows, got %d", len(records))
+	}
+
+	expectedRows := []PreviewRow{{Line: 3, EndLine: 5}, {Line: 7, EndLine: 8}}
+	if !reflect.DeepEqual(preview.Rows, expectedRows) {
+		t.Fatalf("unexpected rows: %#v", preview.Rows)
+	}
+
+	expectedDiagnostics := []domain.Diagnostic{
+		{Line: 1, Severity: domain.SeverityWarning, Message: "ignored"},
+		{Line: 7, Severity: domain.SeverityWarning, Message: `"Variables" has no checkpoints`},
+	}
+	if !reflect.DeepEqual(preview.Diagnostics, expectedDiagnostics) {
+		t.Fatalf("unexpected diagnostics: %#v", preview.Diagnostics)
+	}
+}
+
+func TestMarkdownPreview_Workbook(t *testing.T) {
+	parser := &mockDocumentParser{document: domain.Document{
+		Cases: []domain.Case{{MajorItem: "Setup", MinorItem: "Dependencies"}},
+	}}
+	previewer := NewMarkdownPreview(parser)
+
+	sheets, err := previewer.Workbook([]Source{
+		{Name: "checks/setup.md", Reader: strings.NewReader("")},
+		{Name: "other/setup.md", Reader: strings.NewReader("")},
+	})
+	if err != nil {
+		t.Fatalf("Workbook() returned an unexpected error: %v", err)
+	}
+
+	if len(sheets) != 2 {
+		t.Fatalf("expected 2 sheets, got %d", len(sheets))
+	}
+	if sheets[0].Name != "setup" || sheets[1].Name != "setup_2" {
+		t.Fatalf("expected unique sheet names, got %q and %q", sheets[0].Name, sheets[1].Name)
+	}
+	if sheets[1].Source != "other/setup.md" {
+		t.Fatalf("expected source name to be kept, got %q", sheets[1].Source)
+	}
+
+	records, err := csv.NewReader(strings.NewReader(sheets[0].CSV)).ReadAll()
+	if err != nil {
+		t.Fatalf("read csv: %v", err)
+	}
+	if len(records) != 2 || records[1][0] != "Setup" || records[1][2] != "Dependencies" {
+		t.Fatalf("unexpected sheet rows: %v", records)
+	}
+}
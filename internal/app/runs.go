@@ -0,0 +1,160 @@
+package app
+
+import (
+	"fmt"
+	"io"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/9renpoto/casemd/internal/core/domain"
+	"github.com/9renpoto/casemd/internal/core/results"
+)
+
+// RunRecorder captures the results held by checklists, or by the workbooks
+// testers filled in, as a named run.
+type RunRecorder struct {
+	parser CaseParser
+}
+
+// NewRunRecorder wires the recorder with the provided parser implementation.
+func NewRunRecorder(parser CaseParser) *RunRecorder {
+	return &RunRecorder{parser: parser}
+}
+
+// Record reads the results of every source into a run named name. Markdown
+// checklists give their checkpoint states and the Results merged into them;
+// sources named *.xlsx are read as workbooks laid out with the source's
+// columns and layout, each sheet standing for the checklist it was exported
+// from.
+func (r *RunRecorder) Record(name string, sources []Source, now time.Time) (domain.NamedRun, error) {
+	if len(sources) == 0 {
+		return domain.NamedRun{}, fmt.Errorf("no sources provided")
+	}
+
+	named := domain.NamedRun{Name: name, Recorded: now}
+	for _, source := range sources {
+		if !strings.EqualFold(path.Ext(source.Name), ".xlsx") {
+			cases, err := parseSource(r.parser, source)
+			if err != nil {
+				return domain.NamedRun{}, fmt.Errorf("parse %s: %w", source.Name, err)
+			}
+			named.Runs = append(named.Runs, runOf(source.Name, cases))
+			continue
+		}
+
+		data, err := io.ReadAll(source.reader())
+		if err != nil {
+			return domain.NamedRun{}, fmt.Errorf("read %s: %w", source.Name, err)
+		}
+		sheets, err := readWorkbookCases(data, source.Columns)
+		if err != nil {
+			return domain.NamedRun{}, fmt.Errorf("import %s: %w", source.Name, err)
+		}
+		for _, sheet := range sheets {
+			if source.Layout == StepLayout {
+				sheet.Cases = joinStepRows(sheet.Cases)
+			}
+			named.Runs = append(named.Runs, runOf(sheet.Name, source.Filter.Apply(sheet.Cases)))
+		}
+	}
+	return named, nil
+}
+
+func runOf(source string, cases []domain.Case) domain.Run {
+	run := domain.Run{Source: source, Cases: make([]domain.CaseResult, 0, len(cases))}
+	for _, aCase := range cases {
+		run.Cases = append(run.Cases, results.FromCase(aCase))
+	}
+	return run
+}
+
+// RunSummary counts the outcomes of the cases of a run.
+type RunSummary struct {
+	Cases   int
+	Passed  int
+	Failed  int
+	Skipped int
+}
+
+// SummarizeRun evaluates every case of named the way the CI reports do.
+func SummarizeRun(named domain.NamedRun) RunSummary {
+	var summary RunSummary
+	for _, run := range named.Runs {
+		for _, result := range run.Cases {
+			summary.Cases++
+			switch resultOutcome(result) {
+			case outcomePassed:
+				summary.Passed++
+			case outcomeFailed:
+				summary.Failed++
+			default:
+				summary.Skipped++
+			}
+		}
+	}
+	return summary
+}
+
+// RunChange is a case whose outcome differs between two runs. Before and
+// After are "passed", "failed" or "skipped", or empty when the run did not
+// record the case.
+type RunChange struct {
+	Source string
+	Case   string
+	Before string
+	After  string
+}
+
+// CompareRuns lists the cases whose outcome changed from before to after, in
+// the order of after, followed by the cases only before recorded.
+func CompareRuns(before, after domain.NamedRun) []RunChange {
+	var changes []RunChange
+	compared := make(map[string]bool)
+	for _, run := range after.Runs {
+		previous, ok := results.Find(before, run.Source)
+		if ok {
+			compared[previous.Source] = true
+		}
+		changes = append(changes, compareCases(run.Source, previous.Cases, run.Cases)...)
+	}
+	for _, run := range before.Runs {
+		if !compared[run.Source] {
+			changes = append(changes, compareCases(run.Source, run.Cases, nil)...)
+		}
+	}
+	return changes
+}
+
+// compareCases matches results by case key; duplicated keys pair up in
+// order, as results.Merge does.
+func compareCases(source string, before, after []domain.CaseResult) []RunChange {
+	pending := make(map[string][]domain.CaseResult)
+	for _, result := range before {
+		pending[result.Key()] = append(pending[result.Key()], result)
+	}
+
+	var changes []RunChange
+	for _, result := range after {
+		change := RunChange{Source: source, Case: result.Key(), After: resultOutcome(result).String()}
+		if queue := pending[result.Key()]; len(queue) > 0 {
+			change.Before = resultOutcome(queue[0]).String()
+			pending[result.Key()] = queue[1:]
+		}
+		if change.Before != change.After {
+			changes = append(changes, change)
+		}
+	}
+	for _, result := range before {
+		for _, removed := range pending[result.Key()] {
+			changes = append(changes, RunChange{Source: source, Case: removed.Key(), Before: resultOutcome(removed).String()})
+		}
+		delete(pending, result.Key())
+	}
+	return changes
+}
+
+func resultOutcome(result domain.CaseResult) caseOutcome {
+	outcome, _ := evaluateCase(domain.Case{Checkpoints: result.Checkpoints, Result: result.Result, Notes: result.Notes})
+	return outcome
+}
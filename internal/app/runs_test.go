@@ -0,0 +1,78 @@
+package app
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/9renpoto/casemd/internal/core/domain"
+)
+
+func TestRunRecorder_Record(t *testing.T) {
+	cases := []domain.Case{
+		{MajorItem: "Auth", MinorItem: "Password", Checkpoints: []string{"* [x] Signed in"}},
+		{MajorItem: "Auth", MinorItem: "Reset", Checkpoints: []string{"* [ ] Mail sent"}, Result: "NG", Tester: "alice", Notes: "no mail"},
+	}
+	parser := &mockCaseParser{cases: cases}
+
+	var workbook bytes.Buffer
+	if err := NewMarkdownToSpreadsheet(parser).Convert([]Source{{Name: "checks/login.md", Reader: strings.NewReader("")}}, &workbook); err != nil {
+		t.Fatalf("Convert() returned an unexpected error: %v", err)
+	}
+
+	now := time.Date(2026, 10, 18, 9, 0, 0, 0, time.UTC)
+	sources := []Source{
+		{Name: "checks/login.md", Reader: strings.NewReader("")},
+		{Name: "filled.xlsx", Reader: bytes.NewReader(workbook.Bytes())},
+	}
+	named, err := NewRunRecorder(parser).Record("rc1", sources, now)
+	if err != nil {
+		t.Fatalf("Record() returned an unexpected error: %v", err)
+	}
+
+	results := []domain.CaseResult{
+		{MajorItem: "Auth", MinorItem: "Password", Checkpoints: []string{"* [x] Signed in"}},
+		{MajorItem: "Auth", MinorItem: "Reset", Checkpoints: []string{"* [ ] Mail sent"}, Result: "NG", Tester: "alice", Notes: "no mail"},
+	}
+	expected := domain.NamedRun{Name: "rc1", Recorded: now, Runs: []domain.Run{
+		{Source: "checks/login.md", Cases: results},
+		{Source: "login", Cases: results},
+	}}
+	if !reflect.DeepEqual(named, expected) {
+		t.Fatalf("unexpected run:\n got: %#v\nwant: %#v", named, expected)
+	}
+
+	if summary := SummarizeRun(named); summary != (RunSummary{Cases: 4, Passed: 2, Failed: 2}) {
+		t.Fatalf("unexpected summary: %+v", summary)
+	}
+}
+
+func TestCompareRuns(t *testing.T) {
+	before := domain.NamedRun{Name: "rc1", Runs: []domain.Run{
+		{Source: "login.md", Cases: []domain.CaseResult{
+			{MajorItem: "Auth", MinorItem: "Password", Result: "NG"},
+			{MajorItem: "Auth", MinorItem: "Reset", Result: "OK"},
+			{MajorItem: "Auth", MinorItem: "Removed", Result: "OK"},
+		}},
+		{Source: "logout.md", Cases: []domain.CaseResult{{MajorItem: "Auth", MinorItem: "Button", Result: "OK"}}},
+	}}
+	after := domain.NamedRun{Name: "rc2", Runs: []domain.Run{
+		{Source: "login", Cases: []domain.CaseResult{
+			{MajorItem: "Auth", MinorItem: "Password", Checkpoints: []string{"* [x] Signed in"}},
+			{MajorItem: "Auth", MinorItem: "Reset", Result: "pass"},
+			{MajorItem: "Auth", MinorItem: "Added"},
+		}},
+	}}
+
+	expected := []RunChange{
+		{Source: "login", Case: "Auth /  / Password", Before: "failed", After: "passed"},
+		{Source: "login", Case: "Auth /  / Added", After: "skipped"},
+		{Source: "login", Case: "Auth /  / Removed", Before: "passed"},
+		{Source: "logout.md", Case: "Auth /  / Button", Before: "passed"},
+	}
+	if changes := CompareRuns(before, after); !reflect.DeepEqual(changes, expected) {
+		t.Fatalf("unexpected changes:\n got: %#v\nwant: %#v", changes, expected)
+	}
+}
@@ -0,0 +1,220 @@
+package app
+
+import (
+	"io"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/9renpoto/casemd/internal/core/domain"
+)
+
+// Histogram bucket bounds for conversion durations (seconds) and output sizes (bytes).
+var (
+	DurationBuckets = []float64{0.001, 0.005, 0.01, 0.05, 0.1, 0.5, 1, 5, 10}
+	SizeBuckets     = []float64{1 << 10, 8 << 10, 64 << 10, 512 << 10, 4 << 20, 32 << 20}
+)
+
+// Converter writes the conversion of Markdown sources to output.
+type Converter interface {
+	Convert(sources []Source, output io.Writer) error
+}
+
+// SourceParser is a parser offering both the case list used by the converters
+// and the document used by previews.
+type SourceParser interface {
+	CaseParser
+	DocumentParser
+}
+
+// Histogram counts observations into buckets with inclusive upper bounds;
+// values above the last bound are only reflected in Count and Sum.
+type Histogram struct {
+	Bounds []float64
+	Counts []uint64
+	Count  uint64
+	Sum    float64
+}
+
+// NewHistogram returns an empty histogram with the given ascending bounds.
+func NewHistogram(bounds []float64) Histogram {
+	return Histogram{Bounds: bounds, Counts: make([]uint64, len(bounds))}
+}
+
+// Observe records value.
+func (h *Histogram) Observe(value float64) {
+	index := sort.SearchFloat64s(h.Bounds, value)
+	if index < len(h.Counts) {
+		h.Counts[index]++
+	}
+	h.Count++
+	h.Sum += value
+}
+
+func (h Histogram) clone() Histogram {
+	h.Counts = append([]uint64(nil), h.Counts...)
+	return h
+}
+
+// ParseStats summarizes the Markdown parsed so far. Sources converted to
+// several formats are counted once per conversion.
+type ParseStats struct {
+	Sources int
+	Errors  int
+	Cases   int
+	Bytes   int64
+}
+
+// ConversionStats summarizes the conversions to one output format.
+type ConversionStats struct {
+	Format   string
+	Count    int
+	Errors   int
+	Duration Histogram
+	Size     Histogram
+}
+
+// StatsSnapshot is a point-in-time copy of Stats.
+type StatsSnapshot struct {
+	Parse ParseStats
+	// Conversions is sorted by format.
+	Conversions []ConversionStats
+}
+
+// Stats collects usage statistics of the parser and converters, for the CLI
+// --stats summary and the web server's /metrics. It is safe for concurrent use.
+type Stats struct {
+	mu          sync.Mutex
+	parse       ParseStats
+	conversions map[string]*ConversionStats
+}
+
+// NewStats returns empty statistics.
+func NewStats() *Stats {
+	return &Stats{conversions: make(map[string]*ConversionStats)}
+}
+
+// Parser wraps parser so every parsed source is counted.
+func (s *Stats) Parser(parser SourceParser) SourceParser {
+	return &statsParser{parser: parser, stats: s}
+}
+
+// Converter wraps converter so every conversion to format is timed and its
+// output measured. A nil converter stays nil.
+func (s *Stats) Converter(format string, converter Converter) Converter {
+	if converter == nil {
+		return nil
+	}
+	return &statsConverter{format: format, converter: converter, stats: s}
+}
+
+// Snapshot returns a copy of the statistics collected so far.
+func (s *Stats) Snapshot() StatsSnapshot {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	snapshot := StatsSnapshot{Parse: s.parse, Conversions: make([]ConversionStats, 0, len(s.conversions))}
+	for _, conversion := range s.conversions {
+		copied := *conversion
+		copied.Duration = conversion.Duration.clone()
+		copied.Size = conversion.Size.clone()
+		snapshot.Conversions = append(snapshot.Conversions, copied)
+	}
+	sort.Slice(snapshot.Conversions, func(i, j int) bool {
+		return snapshot.Conversions[i].Format < snapshot.Conversions[j].Format
+	})
+	return snapshot
+}
+
+func (s *Stats) recordParse(bytes int64, cases int, err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.parse.Sources++
+	s.parse.Bytes += bytes
+	if err != nil {
+		s.parse.Errors++
+		return
+	}
+	s.parse.Cases += cases
+}
+
+func (s *Stats) recordConversion(format string, elapsed time.Duration, size int64, err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	conversion, ok := s.conversions[format]
+	if !ok {
+		conversion = &ConversionStats{Format: format, Duration: NewHistogram(DurationBuckets), Size: NewHistogram(SizeBuckets)}
+		s.conversions[format] = conversion
+	}
+	conversion.Count++
+	conversion.Duration.Observe(elapsed.Seconds())
+	if err != nil {
+		conversion.Errors++
+		return
+	}
+	conversion.Size.Observe(float64(size))
+}
+
+type statsParser struct {
+	parser SourceParser
+	stats  *Stats
+}
+
+func (p *statsParser) Parse(r io.Reader) ([]domain.Case, error) {
+	counter := &countingReader{reader: r}
+	cases, err := p.parser.Parse(counter)
+	p.stats.recordParse(counter.n, len(cases), err)
+	return cases, err
+}
+
+func (p *statsParser) ParseDocument(r io.Reader) (domain.Document, error) {
+	counter := &countingReader{reader: r}
+	document, err := p.parser.ParseDocument(counter)
+	p.stats.recordParse(counter.n, len(document.Cases), err)
+	return document, err
+}
+
+func (p *statsParser) ParseDocumentWithIncludes(r io.Reader, includes Includes) (domain.Document, error) {
+	counter := &countingReader{reader: r}
+	document, err := parseDocument(p.parser, Source{Reader: counter, Includes: includes})
+	p.stats.recordParse(counter.n, len(document.Cases), err)
+	return document, err
+}
+
+type statsConverter struct {
+	format    string
+	converter Converter
+	stats     *Stats
+}
+
+func (c *statsConverter) Convert(sources []Source, output io.Writer) error {
+	counter := &countingWriter{writer: output}
+	start := time.Now()
+	err := c.converter.Convert(sources, counter)
+	c.stats.recordConversion(c.format, time.Since(start), counter.n, err)
+	return err
+}
+
+type countingReader struct {
+	reader io.Reader
+	n      int64
+}
+
+func (r *countingReader) Read(p []byte) (int, error) {
+	n, err := r.reader.Read(p)
+	r.n += int64(n)
+	return n, err
+}
+
+type countingWriter struct {
+	writer io.Writer
+	n      int64
+}
+
+func (w *countingWriter) Write(p []byte) (int, error) {
+	n, err := w.writer.Write(p)
+	w.n += int64(n)
+	return n, err
+}
@@ -0,0 +1,74 @@
+package app
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/9renpoto/casemd/internal/core/domain"
+)
+
+type mockSourceParser struct {
+	mockCaseParser
+}
+
+func (m *mockSourceParser) Parse(r io.Reader) ([]domain.Case, error) {
+	if _, err := io.ReadAll(r); err != nil {
+		return nil, err
+	}
+	return m.cases, m.err
+}
+
+func (m *mockSourceParser) ParseDocument(r io.Reader) (domain.Document, error) {
+	cases, err := m.Parse(r)
+	return domain.Document{Cases: cases}, err
+}
+
+func TestStatsCountsParsesAndConversions(t *testing.T) {
+	stats := NewStats()
+	parser := &mockSourceParser{mockCaseParser{cases: []domain.Case{{MajorItem: "Setup"}, {MajorItem: "Teardown"}}}}
+	csv := stats.Converter("csv", NewMarkdownToCSV(stats.Parser(parser)))
+
+	var output bytes.Buffer
+	if err := csv.Convert([]Source{{Name: "a.md", Reader: strings.NewReader("## Setup")}, {Name: "b.md", Reader: strings.NewReader("## B")}}, &output); err != nil {
+		t.Fatalf("Convert() returned an unexpected error: %v", err)
+	}
+	if _, err := NewMarkdownPreview(stats.Parser(parser)).Preview(Source{Name: "c.md", Reader: strings.NewReader("## C")}); err != nil {
+		t.Fatalf("Preview() returned an unexpected error: %v", err)
+	}
+	parser.err = errors.New("broken")
+	if err := csv.Convert([]Source{{Name: "d.md", Reader: strings.NewReader("")}}, io.Discard); err == nil {
+		t.Fatal("expected the parse error to be returned")
+	}
+
+	snapshot := stats.Snapshot()
+	if snapshot.Parse != (ParseStats{Sources: 4, Errors: 1, Cases: 6, Bytes: 16}) {
+		t.Fatalf("unexpected parse stats: %+v", snapshot.Parse)
+	}
+	if len(snapshot.Conversions) != 1 {
+		t.Fatalf("expected one format, got %+v", snapshot.Conversions)
+	}
+	conversion := snapshot.Conversions[0]
+	if conversion.Format != "csv" || conversion.Count != 2 || conversion.Errors != 1 || conversion.Duration.Count != 2 {
+		t.Fatalf("unexpected conversion stats: %+v", conversion)
+	}
+	if conversion.Size.Count != 1 || conversion.Size.Sum != float64(output.Len()) || conversion.Size.Counts[0] != 1 {
+		t.Fatalf("expected the successful output size to be observed, got %+v", conversion.Size)
+	}
+
+	if stats.Converter("xlsx", nil) != nil {
+		t.Fatal("expected a nil converter to stay nil")
+	}
+}
+
+func TestHistogramBucketsAreInclusive(t *testing.T) {
+	histogram := NewHistogram([]float64{1, 5})
+	for _, value := range []float64{0.5, 1, 3, 9} {
+		histogram.Observe(value)
+	}
+	if histogram.Counts[0] != 2 || histogram.Counts[1] != 1 || histogram.Count != 4 || histogram.Sum != 13.5 {
+		t.Fatalf("unexpected histogram: %+v", histogram)
+	}
+}
@@ -0,0 +1,310 @@
+package app
+
+import (
+	"bufio"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/9renpoto/casemd/internal/core/domain"
+)
+
+type caseOutcome int
+
+const (
+	outcomePassed caseOutcome = iota
+	outcomeFailed
+	outcomeSkipped
+)
+
+func (o caseOutcome) String() string {
+	switch o {
+	case outcomePassed:
+		return "passed"
+	case outcomeFailed:
+		return "failed"
+	default:
+		return "skipped"
+	}
+}
+
+// evaluateCase derives a pass/failure/skipped outcome for a case. A result
+// merged from Source.Results takes precedence; otherwise the checkpoint
+// markers decide.
+func evaluateCase(aCase domain.Case) (caseOutcome, string) {
+	if result := strings.TrimSpace(aCase.Result); result != "" {
+		switch strings.ToLower(result) {
+		case "pass", "passed", "ok", "success", "done":
+			return outcomePassed, ""
+		case "fail", "failed", "failure", "ng", "error":
+			if aCase.Notes != "" {
+				return outcomeFailed, aCase.Notes
+			}
+			return outcomeFailed, fmt.Sprintf("result recorded as %s", result)
+		case "skip", "skipped", "n/a", "na", "blocked", "pending":
+			return outcomeSkipped, fmt.Sprintf("result recorded as %s", result)
+		default:
+			return outcomeSkipped, fmt.Sprintf("unrecognized result %q", result)
+		}
+	}
+
+	if len(aCase.Checkpoints) == 0 {
+		return outcomeSkipped, "no checkpoints defined"
+	}
+
+	var unchecked []string
+	for _, checkpoint := range aCase.Checkpoints {
+		text, checked := checkpointState(checkpoint)
+		if !checked {
+			unchecked = append(unchecked, text)
+		}
+	}
+
+	switch len(unchecked) {
+	case 0:
+		return outcomePassed, ""
+	case len(aCase.Checkpoints):
+		return outcomeSkipped, "not executed"
+	default:
+		return outcomeFailed, "unchecked checkpoints: " + strings.Join(unchecked, "; ")
+	}
+}
+
+// checkpointState splits a task list item into its text and checked marker.
+func checkpointState(checkpoint string) (string, bool) {
+	trimmed := strings.TrimSpace(checkpoint)
+	trimmed = strings.TrimSpace(strings.TrimLeft(trimmed, "*-+"))
+	switch {
+	case strings.HasPrefix(trimmed, "[x]"), strings.HasPrefix(trimmed, "[X]"):
+		return strings.TrimSpace(trimmed[3:]), true
+	case strings.HasPrefix(trimmed, "[ ]"):
+		return strings.TrimSpace(trimmed[3:]), false
+	default:
+		return trimmed, false
+	}
+}
+
+func caseTitle(aCase domain.Case) string {
+	parts := make([]string, 0, 3)
+	for _, part := range []string{aCase.MajorItem, aCase.MediumItem, aCase.MinorItem} {
+		if part != "" {
+			parts = append(parts, part)
+		}
+	}
+	return strings.Join(parts, " / ")
+}
+
+func caseDetails(aCase domain.Case) string {
+	var builder strings.Builder
+	writeList := func(label string, items []string) {
+		if len(items) == 0 {
+			return
+		}
+		builder.WriteString(label + ":\n")
+		for _, item := range items {
+			builder.WriteString("- " + item + "\n")
+		}
+	}
+	writeList("Preconditions", aCase.Preconditions)
+	if steps := caseSteps(aCase); len(steps) > 0 {
+		builder.WriteString("Validation Steps:\n")
+		for i, step := range steps {
+			fmt.Fprintf(&builder, "%d. %s\n", i+1, step.Action)
+			for _, expectation := range step.Expectations {
+				builder.WriteString("   " + expectation + "\n")
+			}
+		}
+	}
+	if checkpoints := looseCheckpoints(aCase); len(checkpoints) > 0 {
+		builder.WriteString("Checkpoints:\n")
+		for _, checkpoint := range checkpoints {
+			builder.WriteString(checkpoint)
+			builder.WriteString("\n")
+		}
+	}
+	writeList("Expected Results", aCase.ExpectedResults)
+	return builder.String()
+}
+
+// caseProperties lists the priority, test type and tags of a case as
+// name/value pairs for report formats with structured metadata.
+func caseProperties(aCase domain.Case) [][2]string {
+	var properties [][2]string
+	if aCase.Priority != "" {
+		properties = append(properties, [2]string{"priority", aCase.Priority})
+	}
+	if aCase.TestType != "" {
+		properties = append(properties, [2]string{"type", aCase.TestType})
+	}
+	if len(aCase.Tags) > 0 {
+		properties = append(properties, [2]string{"tags", strings.Join(aCase.Tags, ", ")})
+	}
+	return properties
+}
+
+// MarkdownToJUnit orchestrates the conversion of Markdown test cases into a JUnit XML report.
+type MarkdownToJUnit struct {
+	parser CaseParser
+}
+
+// NewMarkdownToJUnit wires the converter with the provided parser implementation.
+func NewMarkdownToJUnit(parser CaseParser) *MarkdownToJUnit {
+	return &MarkdownToJUnit{parser: parser}
+}
+
+// Convert reads Markdown sources and writes a JUnit XML document with one test suite per major item.
+func (c *MarkdownToJUnit) Convert(sources []Source, output io.Writer) error {
+	if len(sources) == 0 {
+		return fmt.Errorf("no sources provided")
+	}
+
+	report := junitTestSuites{Name: "casemd"}
+	for _, source := range sources {
+		cases, err := parseSource(c.parser, source)
+		if err != nil {
+			return fmt.Errorf("parse %s: %w", source.Name, err)
+		}
+
+		var suite *junitTestSuite
+		for _, aCase := range cases {
+			if suite == nil || suite.Name != aCase.MajorItem {
+				report.Suites = append(report.Suites, junitTestSuite{Name: aCase.MajorItem, File: source.Name})
+				suite = &report.Suites[len(report.Suites)-1]
+			}
+
+			testCase := junitTestCase{
+				ClassName: aCase.MediumItem,
+				Name:      aCase.MinorItem,
+				File:      source.Name,
+				SystemOut: caseDetails(aCase),
+			}
+			for _, property := range caseProperties(aCase) {
+				testCase.Properties = append(testCase.Properties, junitProperty{Name: property[0], Value: property[1]})
+			}
+			outcome, message := evaluateCase(aCase)
+			switch outcome {
+			case outcomeFailed:
+				testCase.Failure = &junitMessage{Message: message, Type: "checkpoint"}
+				suite.Failures++
+			case outcomeSkipped:
+				testCase.Skipped = &junitMessage{Message: message}
+				suite.Skipped++
+			}
+			suite.Tests++
+			suite.Cases = append(suite.Cases, testCase)
+		}
+	}
+
+	for _, suite := range report.Suites {
+		report.Tests += suite.Tests
+		report.Failures += suite.Failures
+		report.Skipped += suite.Skipped
+	}
+
+	if _, err := io.WriteString(output, xml.Header); err != nil {
+		return fmt.Errorf("write junit header: %w", err)
+	}
+	encoder := xml.NewEncoder(output)
+	encoder.Indent("", "  ")
+	if err := encoder.Encode(report); err != nil {
+		return fmt.Errorf("encode junit report: %w", err)
+	}
+	if _, err := io.WriteString(output, "\n"); err != nil {
+		return fmt.Errorf("write junit report: %w", err)
+	}
+	return nil
+}
+
+type junitTestSuites struct {
+	XMLName  xml.Name         `xml:"testsuites"`
+	Name     string           `xml:"name,attr"`
+	Tests    int              `xml:"tests,attr"`
+	Failures int              `xml:"failures,attr"`
+	Skipped  int              `xml:"skipped,attr"`
+	Suites   []junitTestSuite `xml:"testsuite"`
+}
+
+type junitTestSuite struct {
+	Name     string          `xml:"name,attr"`
+	File     string          `xml:"file,attr,omitempty"`
+	Tests    int             `xml:"tests,attr"`
+	Failures int             `xml:"failures,attr"`
+	Skipped  int             `xml:"skipped,attr"`
+	Cases    []junitTestCase `xml:"testcase"`
+}
+
+type junitTestCase struct {
+	ClassName string `xml:"classname,attr"`
+	Name      string `xml:"name,attr"`
+	File      string `xml:"file,attr,omitempty"`
+	// Properties carries the priority, type and tags of the case, as
+	// understood by Jenkins, GitLab and most JUnit report viewers.
+	Properties []junitProperty `xml:"properties>property,omitempty"`
+	Failure    *junitMessage   `xml:"failure,omitempty"`
+	Skipped    *junitMessage   `xml:"skipped,omitempty"`
+	SystemOut  string          `xml:"system-out,omitempty"`
+}
+
+type junitProperty struct {
+	Name  string `xml:"name,attr"`
+	Value string `xml:"value,attr"`
+}
+
+type junitMessage struct {
+	Message string `xml:"message,attr,omitempty"`
+	Type    string `xml:"type,attr,omitempty"`
+}
+
+// MarkdownToTAP orchestrates the conversion of Markdown test cases into a TAP stream.
+type MarkdownToTAP struct {
+	parser CaseParser
+}
+
+// NewMarkdownToTAP wires the converter with the provided parser implementation.
+func NewMarkdownToTAP(parser CaseParser) *MarkdownToTAP {
+	return &MarkdownToTAP{parser: parser}
+}
+
+// Convert reads Markdown sources and writes a TAP version 13 stream with one test point per case.
+func (c *MarkdownToTAP) Convert(sources []Source, output io.Writer) error {
+	if len(sources) == 0 {
+		return fmt.Errorf("no sources provided")
+	}
+
+	var cases []domain.Case
+	for _, source := range sources {
+		parsed, err := parseSource(c.parser, source)
+		if err != nil {
+			return fmt.Errorf("parse %s: %w", source.Name, err)
+		}
+		cases = append(cases, parsed...)
+	}
+
+	writer := bufio.NewWriter(output)
+	fmt.Fprintf(writer, "TAP version 13\n1..%d\n", len(cases))
+	for i, aCase := range cases {
+		description := tapEscaper.Replace(caseTitle(aCase))
+		outcome, message := evaluateCase(aCase)
+		switch outcome {
+		case outcomePassed:
+			fmt.Fprintf(writer, "ok %d - %s\n", i+1, description)
+		case outcomeSkipped:
+			fmt.Fprintf(writer, "ok %d - %s # SKIP %s\n", i+1, description, message)
+		case outcomeFailed:
+			fmt.Fprintf(writer, "not ok %d - %s\n", i+1, description)
+			fmt.Fprintf(writer, "  ---\n  message: %q\n", message)
+			for _, property := range caseProperties(aCase) {
+				fmt.Fprintf(writer, "  %s: %q\n", property[0], property[1])
+			}
+			writer.WriteString("  ...\n")
+		}
+	}
+
+	if err := writer.Flush(); err != nil {
+		return fmt.Errorf("write tap report: %w", err)
+	}
+	return nil
+}
+
+var tapEscaper = strings.NewReplacer("#", `\#`, "\n", " ")
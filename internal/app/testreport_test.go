@@ -0,0 +1,141 @@
+package app
+
+import (
+	"bytes"
+	"encoding/xml"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/9renpoto/casemd/internal/core/domain"
+)
+
+func TestEvaluateCase(t *testing.T) {
+	tests := []struct {
+		name    string
+		aCase   domain.Case
+		outcome caseOutcome
+	}{
+		{name: "all checked", aCase: domain.Case{Checkpoints: []string{"* [x] A", "* [x] B"}}, outcome: outcomePassed},
+		{name: "none checked", aCase: domain.Case{Checkpoints: []string{"* [ ] A"}}, outcome: outcomeSkipped},
+		{name: "partially checked", aCase: domain.Case{Checkpoints: []string{"* [x] A", "* [ ] B"}}, outcome: outcomeFailed},
+		{name: "no checkpoints", aCase: domain.Case{}, outcome: outcomeSkipped},
+		{name: "explicit pass", aCase: domain.Case{Result: "OK", Checkpoints: []string{"* [ ] A"}}, outcome: outcomePassed},
+		{name: "explicit failure", aCase: domain.Case{Result: "NG", Checkpoints: []string{"* [x] A"}}, outcome: outcomeFailed},
+		{name: "explicit skip", aCase: domain.Case{Result: "blocked"}, outcome: outcomeSkipped},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			outcome, _ := evaluateCase(tt.aCase)
+			if outcome != tt.outcome {
+				t.Fatalf("evaluateCase() = %v, want %v", outcome, tt.outcome)
+			}
+		})
+	}
+}
+
+func TestMarkdownToJUnit_Convert(t *testing.T) {
+	parser := &mockCaseParser{cases: []domain.Case{
+		{MajorItem: "Setup", MediumItem: "Environment", MinorItem: "Dependencies", Checkpoints: []string{"* [x] Installed"}},
+		{MajorItem: "Setup", MediumItem: "Environment", MinorItem: "Variables", Checkpoints: []string{"* [x] Set", "* [ ] Exported"}, Priority: "P1", Tags: []string{"smoke", "env"}, ExpectedResults: []string{"Variables are exported"}},
+		{MajorItem: "Execution", MediumItem: "Workflow", MinorItem: "Run", Checkpoints: []string{"* [ ] Exit code is 0"}},
+	}}
+	converter := NewMarkdownToJUnit(parser)
+
+	var output bytes.Buffer
+	sources := []Source{{Name: "checks.md", Reader: strings.NewReader("")}}
+	if err := converter.Convert(sources, &output); err != nil {
+		t.Fatalf("Convert() returned an unexpected error: %v", err)
+	}
+
+	var report junitTestSuites
+	if err := xml.Unmarshal(output.Bytes(), &report); err != nil {
+		t.Fatalf("unmarshal report: %v", err)
+	}
+
+	if report.Tests != 3 || report.Failures != 1 || report.Skipped != 1 {
+		t.Fatalf("unexpected totals: tests=%d failures=%d skipped=%d", report.Tests, report.Failures, report.Skipped)
+	}
+	if len(report.Suites) != 2 {
+		t.Fatalf("expected 2 suites, got %d", len(report.Suites))
+	}
+	if report.Suites[0].Name != "Setup" || report.Suites[1].Name != "Execution" {
+		t.Fatalf("unexpected suite names: %q, %q", report.Suites[0].Name, report.Suites[1].Name)
+	}
+
+	variables := report.Suites[0].Cases[1]
+	if variables.Name != "Variables" || variables.ClassName != "Environment" {
+		t.Fatalf("unexpected test case: %+v", variables)
+	}
+	if variables.Failure == nil || !strings.Contains(variables.Failure.Message, "Exported") {
+		t.Fatalf("expected failure mentioning unchecked checkpoint, got %+v", variables.Failure)
+	}
+	expectedProperties := []junitProperty{{Name: "priority", Value: "P1"}, {Name: "tags", Value: "smoke, env"}}
+	if !reflect.DeepEqual(variables.Properties, expectedProperties) {
+		t.Fatalf("unexpected properties: %+v", variables.Properties)
+	}
+	if !strings.Contains(variables.SystemOut, "Expected Results:\n- Variables are exported") {
+		t.Fatalf("system-out misses expected results: %q", variables.SystemOut)
+	}
+	if report.Suites[1].Cases[0].Skipped == nil {
+		t.Fatalf("expected unexecuted case to be skipped")
+	}
+}
+
+func TestMarkdownToJUnit_ConvertAppliesRecordedResults(t *testing.T) {
+	parser := &mockCaseParser{cases: []domain.Case{
+		{MajorItem: "Setup", MinorItem: "Install", Checkpoints: []string{"* [ ] Installed"}},
+		{MajorItem: "Setup", MinorItem: "Configure", Checkpoints: []string{"* [x] Configured"}},
+	}}
+	results := &domain.Run{Cases: []domain.CaseResult{
+		{MajorItem: "Setup", MinorItem: "Install", Result: "OK"},
+		{MajorItem: "Setup", MinorItem: "Configure", Checkpoints: []string{"* [x] Configured"}, Result: "NG", Notes: "Config file is not written"},
+	}}
+
+	var output bytes.Buffer
+	sources := []Source{{Name: "checks.md", Reader: strings.NewReader(""), Results: results}}
+	if err := NewMarkdownToJUnit(parser).Convert(sources, &output); err != nil {
+		t.Fatalf("Convert() returned an unexpected error: %v", err)
+	}
+
+	var report junitTestSuites
+	if err := xml.Unmarshal(output.Bytes(), &report); err != nil {
+		t.Fatalf("unmarshal report: %v", err)
+	}
+	cases := report.Suites[0].Cases
+	if len(cases) != 2 || cases[0].Failure != nil || cases[0].Skipped != nil {
+		t.Fatalf("expected the recorded OK to pass the unticked case, got %+v", cases)
+	}
+	if cases[1].Failure == nil || cases[1].Failure.Message != "Config file is not written" {
+		t.Fatalf("expected the recorded NG to fail the ticked case, got %+v", cases[1])
+	}
+}
+
+func TestMarkdownToTAP_Convert(t *testing.T) {
+	parser := &mockCaseParser{cases: []domain.Case{
+		{MajorItem: "Setup", MinorItem: "Dependencies", Checkpoints: []string{"* [x] Installed"}},
+		{MajorItem: "Setup", MinorItem: "Issue #12", Checkpoints: []string{"* [x] A", "* [ ] B"}},
+		{MajorItem: "Execution", MinorItem: "Run", Checkpoints: []string{"* [ ] Exit code is 0"}},
+	}}
+	converter := NewMarkdownToTAP(parser)
+
+	var output bytes.Buffer
+	sources := []Source{{Name: "checks.md", Reader: strings.NewReader("")}}
+	if err := converter.Convert(sources, &output); err != nil {
+		t.Fatalf("Convert() returned an unexpected error: %v", err)
+	}
+
+	expected := `TAP version 13
+1..3
+ok 1 - Setup / Dependencies
+not ok 2 - Setup / Issue \#12
+  ---
+  message: "unchecked checkpoints: B"
+  ...
+ok 3 - Execution / Run # SKIP not executed
+`
+	if output.String() != expected {
+		t.Fatalf("unexpected TAP output:\n%s", output.String())
+	}
+}
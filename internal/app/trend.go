@@ -0,0 +1,414 @@
+package app
+
+import (
+	"fmt"
+	"html/template"
+	"io"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/9renpoto/casemd/internal/core/domain"
+	"github.com/9renpoto/casemd/internal/core/results"
+	"github.com/9renpoto/casemd/internal/i18n"
+)
+
+// TrendOptions tweaks a single trend report.
+type TrendOptions struct {
+	// StaleDays lists the cases last executed more than this many days
+	// before Now; zero leaves stale cases out of the report.
+	StaleDays int
+	// Now is when the report is made; the zero value uses time.Now.
+	Now      time.Time
+	Language i18n.Language
+}
+
+// TrendReporter aggregates recorded runs into flakiness, pass rate and
+// coverage figures.
+type TrendReporter struct {
+	parser     CaseParser
+	stylesheet string
+}
+
+// NewTrendReporter wires the reporter with the parser and the CSS inlined into HTML reports.
+func NewTrendReporter(parser CaseParser, stylesheet string) *TrendReporter {
+	return &TrendReporter{parser: parser, stylesheet: stylesheet}
+}
+
+// TrendReport summarizes how the cases fared across runs, oldest run first.
+type TrendReport struct {
+	Runs []string
+	// Flaky lists the cases that both passed and failed, those whose
+	// outcome flipped most often first.
+	Flaky      []FlakyCase
+	MajorItems []MajorItemTrend
+	// NeverRun lists the cases no run passed or failed.
+	NeverRun []TrendCase
+	// Stale lists the executed cases not executed within StaleDays, oldest
+	// first.
+	Stale []StaleCase
+
+	staleDays  int
+	language   i18n.Language
+	stylesheet string
+	// checklists holds a sheet per checklist, written before the report
+	// sheets of a workbook.
+	checklists []workbookSheet
+}
+
+// TrendCase identifies a case of a report by its checklist and hierarchy.
+type TrendCase struct {
+	Source string
+	Case   string
+}
+
+// FlakyCase counts the executions of a case with mixed outcomes.
+type FlakyCase struct {
+	TrendCase
+	Passed int
+	Failed int
+	// Flips counts the executions whose outcome differs from the one before.
+	Flips int
+	// History holds the outcome of the case in each run, empty when the run
+	// did not record it.
+	History []string
+}
+
+// MajorItemTrend counts the executions of the cases of a major item.
+type MajorItemTrend struct {
+	Source string
+	Major  string
+	Passed int
+	Failed int
+}
+
+// Percent returns the share of passed executions, rounded down.
+func (m MajorItemTrend) Percent() int {
+	if m.Passed+m.Failed == 0 {
+		return 0
+	}
+	return m.Passed * 100 / (m.Passed + m.Failed)
+}
+
+// StaleCase is a case whose last execution is older than the report allows.
+type StaleCase struct {
+	TrendCase
+	LastRun      string
+	LastExecuted time.Time
+	Days         int
+}
+
+type trendEntry struct {
+	recorded bool
+	outcome  caseOutcome
+	date     time.Time
+}
+
+type trendHistory struct {
+	TrendCase
+	major   string
+	entries []trendEntry
+}
+
+// Report aggregates runs. Cases are matched across runs by the SourceKey of
+// their checklist and their hierarchy. When checklists are given, the report
+// covers their cases only, so cases never recorded by any run show up as
+// never run and removed cases are left out.
+func (r *TrendReporter) Report(runs []domain.NamedRun, checklists []Source, options TrendOptions) (TrendReport, error) {
+	if len(runs) == 0 {
+		return TrendReport{}, fmt.Errorf("no runs provided")
+	}
+	now := options.Now
+	if now.IsZero() {
+		now = time.Now()
+	}
+	report := TrendReport{staleDays: options.StaleDays, language: options.Language, stylesheet: r.stylesheet}
+	if report.language == "" {
+		report.language = i18n.English
+	}
+
+	ordered := slices.Clone(runs)
+	sort.SliceStable(ordered, func(i, j int) bool { return ordered[i].Recorded.Before(ordered[j].Recorded) })
+
+	var tracked []*trendHistory
+	index := make(map[string]*trendHistory)
+	identify := func(source, key string) string {
+		return results.SourceKey(source) + "\x00" + key
+	}
+	track := func(source, major, key string) *trendHistory {
+		if history, ok := index[identify(source, key)]; ok {
+			return history
+		}
+		history := &trendHistory{TrendCase: TrendCase{Source: source, Case: key}, major: major, entries: make([]trendEntry, len(ordered))}
+		index[identify(source, key)] = history
+		tracked = append(tracked, history)
+		return history
+	}
+
+	for i, source := range checklists {
+		cases, err := parseSource(r.parser, source)
+		if err != nil {
+			return TrendReport{}, fmt.Errorf("parse %s: %w", source.Name, err)
+		}
+		report.checklists = append(report.checklists, newWorkbookSheet(deriveSheetName(source.Name, i), source, cases))
+		for _, aCase := range cases {
+			track(source.Name, aCase.MajorItem, aCase.Key())
+		}
+	}
+
+	for i, named := range ordered {
+		report.Runs = append(report.Runs, named.Name)
+		for _, run := range named.Runs {
+			for _, result := range run.Cases {
+				history, ok := index[identify(run.Source, result.Key())]
+				if !ok {
+					if len(checklists) > 0 {
+						continue
+					}
+					history = track(run.Source, result.MajorItem, result.Key())
+				}
+				if history.entries[i].recorded {
+					continue
+				}
+				date := named.Recorded
+				if tested, err := time.Parse(TestDateLayout, strings.TrimSpace(result.TestDate)); err == nil {
+					date = tested
+				}
+				history.entries[i] = trendEntry{recorded: true, outcome: resultOutcome(result), date: date}
+			}
+		}
+	}
+
+	majors := make(map[string]int)
+	for _, history := range tracked {
+		majorID := identify(history.Source, history.major)
+		if _, ok := majors[majorID]; !ok {
+			majors[majorID] = len(report.MajorItems)
+			report.MajorItems = append(report.MajorItems, MajorItemTrend{Source: history.Source, Major: history.major})
+		}
+		major := &report.MajorItems[majors[majorID]]
+
+		flaky := FlakyCase{TrendCase: history.TrendCase, History: make([]string, len(ordered))}
+		var last *trendEntry
+		for i := range history.entries {
+			entry := &history.entries[i]
+			if !entry.recorded {
+				continue
+			}
+			flaky.History[i] = entry.outcome.String()
+			if entry.outcome == outcomeSkipped {
+				continue
+			}
+			if last != nil && entry.outcome != last.outcome {
+				flaky.Flips++
+			}
+			if entry.outcome == outcomePassed {
+				flaky.Passed++
+			} else {
+				flaky.Failed++
+			}
+			last = entry
+		}
+		major.Passed += flaky.Passed
+		major.Failed += flaky.Failed
+
+		if flaky.Passed > 0 && flaky.Failed > 0 {
+			report.Flaky = append(report.Flaky, flaky)
+		}
+		if last == nil {
+			report.NeverRun = append(report.NeverRun, history.TrendCase)
+			continue
+		}
+		lastExecuted, lastRun := lastExecution(history.entries)
+		if days := int(now.Sub(lastExecuted).Hours() / 24); options.StaleDays > 0 && days > options.StaleDays {
+			report.Stale = append(report.Stale, StaleCase{TrendCase: history.TrendCase, LastRun: ordered[lastRun].Name, LastExecuted: lastExecuted, Days: days})
+		}
+	}
+
+	sort.SliceStable(report.Flaky, func(i, j int) bool { return report.Flaky[i].Flips > report.Flaky[j].Flips })
+	sort.SliceStable(report.Stale, func(i, j int) bool { return report.Stale[i].LastExecuted.Before(report.Stale[j].LastExecuted) })
+	return report, nil
+}
+
+// lastExecution returns the latest date a case passed or failed and the
+// index of the run that recorded it.
+func lastExecution(entries []trendEntry) (time.Time, int) {
+	var last time.Time
+	run := -1
+	for i, entry := range entries {
+		if entry.recorded && entry.outcome != outcomeSkipped && (run < 0 || !entry.date.Before(last)) {
+			last, run = entry.date, i
+		}
+	}
+	return last, run
+}
+
+// trendTable is one section of a report, rendered as a Markdown or HTML
+// table or as a sheet.
+type trendTable struct {
+	Title   string
+	Headers []string
+	Rows    [][]string
+}
+
+var trendHistorySymbols = map[string]string{
+	outcomePassed.String():  "✓",
+	outcomeFailed.String():  "✗",
+	outcomeSkipped.String(): "-",
+	"":                      "·",
+}
+
+func (r TrendReport) tables() []trendTable {
+	l := r.language
+	checklist, caseHeader := l.Text("trend.column.checklist"), l.Text("trend.column.case")
+	passed, failed := l.Text("trend.column.passed"), l.Text("trend.column.failed")
+
+	flaky := trendTable{Title: l.Text("trend.flaky"), Headers: []string{checklist, caseHeader, passed, failed, l.Text("trend.column.flips"), l.Text("trend.column.history")}}
+	for _, aCase := range r.Flaky {
+		var history strings.Builder
+		for _, outcome := range aCase.History {
+			history.WriteString(trendHistorySymbols[outcome])
+		}
+		flaky.Rows = append(flaky.Rows, []string{aCase.Source, aCase.Case, strconv.Itoa(aCase.Passed), strconv.Itoa(aCase.Failed), strconv.Itoa(aCase.Flips), history.String()})
+	}
+
+	majors := trendTable{Title: l.Text("trend.passRate"), Headers: []string{checklist, l.Text("column.major"), passed, failed, l.Text("trend.column.passRate")}}
+	for _, major := range r.MajorItems {
+		rate := "-"
+		if major.Passed+major.Failed > 0 {
+			rate = strconv.Itoa(major.Percent()) + "%"
+		}
+		majors.Rows = append(majors.Rows, []string{major.Source, major.Major, strconv.Itoa(major.Passed), strconv.Itoa(major.Failed), rate})
+	}
+
+	neverRun := trendTable{Title: l.Text("trend.neverRun"), Headers: []string{checklist, caseHeader}}
+	for _, aCase := range r.NeverRun {
+		neverRun.Rows = append(neverRun.Rows, []string{aCase.Source, aCase.Case})
+	}
+
+	tables := []trendTable{flaky, majors, neverRun}
+	if r.staleDays > 0 {
+		stale := trendTable{Title: l.Format("trend.stale", r.staleDays), Headers: []string{checklist, caseHeader, l.Text("trend.column.lastRun"), l.Text("trend.column.lastExecuted"), l.Text("trend.column.days")}}
+		for _, aCase := range r.Stale {
+			stale.Rows = append(stale.Rows, []string{aCase.Source, aCase.Case, aCase.LastRun, aCase.LastExecuted.Format(TestDateLayout), strconv.Itoa(aCase.Days)})
+		}
+		tables = append(tables, stale)
+	}
+	return tables
+}
+
+var markdownCellEscaper = strings.NewReplacer("|", `\|`, "\n", " ")
+
+// WriteMarkdown writes the report as a Markdown document with a table per
+// section.
+func (r TrendReport) WriteMarkdown(output io.Writer) error {
+	var builder strings.Builder
+	fmt.Fprintf(&builder, "# %s\n\n%s\n", r.language.Text("trend.title"), r.language.Format("trend.runs", strings.Join(r.Runs, ", ")))
+	for _, table := range r.tables() {
+		fmt.Fprintf(&builder, "\n## %s\n\n", table.Title)
+		if len(table.Rows) == 0 {
+			fmt.Fprintf(&builder, "%s\n", r.language.Text("trend.none"))
+			continue
+		}
+		writeMarkdownRow(&builder, table.Headers)
+		separators := make([]string, len(table.Headers))
+		for i := range separators {
+			separators[i] = "---"
+		}
+		writeMarkdownRow(&builder, separators)
+		for _, row := range table.Rows {
+			writeMarkdownRow(&builder, row)
+		}
+	}
+
+	if _, err := io.WriteString(output, builder.String()); err != nil {
+		return fmt.Errorf("write markdown: %w", err)
+	}
+	return nil
+}
+
+func writeMarkdownRow(builder *strings.Builder, cells []string) {
+	builder.WriteString("|")
+	for _, cell := range cells {
+		fmt.Fprintf(builder, " %s |", markdownCellEscaper.Replace(cell))
+	}
+	builder.WriteString("\n")
+}
+
+// WriteHTML writes the report as a self-contained HTML page.
+func (r TrendReport) WriteHTML(output io.Writer) error {
+	page := trendPage{
+		Title:      r.language.Text("trend.title"),
+		Runs:       r.language.Format("trend.runs", strings.Join(r.Runs, ", ")),
+		None:       r.language.Text("trend.none"),
+		Language:   r.language,
+		Stylesheet: template.CSS(r.stylesheet),
+		Tables:     r.tables(),
+	}
+	if err := trendTemplate.Execute(output, page); err != nil {
+		return fmt.Errorf("render html report: %w", err)
+	}
+	return nil
+}
+
+// WriteWorkbook writes a workbook with a sheet per checklist, when the report
+// was made with checklists, followed by a sheet per section.
+func (r TrendReport) WriteWorkbook(output io.Writer, format SpreadsheetFormat) error {
+	nameUsage := make(map[string]int)
+	finalNames := make(map[string]struct{})
+	sheets := make([]workbookSheet, 0, len(r.checklists)+4)
+	for _, sheet := range r.checklists {
+		sheet.Name = ensureUniqueSheetName(sheet.Name, nameUsage, finalNames)
+		sheets = append(sheets, sheet)
+	}
+	for _, table := range r.tables() {
+		rows := append([][]string{table.Headers}, table.Rows...)
+		sheets = append(sheets, tableSheet(ensureUniqueSheetName(sanitizeSheetName(table.Title), nameUsage, finalNames), rows))
+	}
+	return writeSpreadsheet(output, format, sheets)
+}
+
+type trendPage struct {
+	Title      string
+	Runs       string
+	None       string
+	Language   i18n.Language
+	Stylesheet template.CSS
+	Tables     []trendTable
+}
+
+var trendTemplate = template.Must(template.New("trend").Parse(`<!DOCTYPE html>
+<html lang="{{ .Language }}">
+  <head>
+    <meta charset="utf-8">
+    <title>{{ .Title }}</title>
+    <meta name="viewport" content="width=device-width,initial-scale=1">
+    <style>{{ .Stylesheet }}</style>
+  </head>
+  <body>
+    <main class="report">
+      <section>
+        <h1>{{ .Title }}</h1>
+        <p>{{ .Runs }}</p>
+      </section>
+      {{- range .Tables }}
+      <section>
+        <h2>{{ .Title }}</h2>
+        {{- if .Rows }}
+        <table>
+          <tr>{{ range .Headers }}<th>{{ . }}</th>{{ end }}</tr>
+          {{- range .Rows }}
+          <tr>{{ range . }}<td>{{ . }}</td>{{ end }}</tr>
+          {{- end }}
+        </table>
+        {{- else }}
+        <p>{{ $.None }}</p>
+        {{- end }}
+      </section>
+      {{- end }}
+    </main>
+  </body>
+</html>
+`))
@@ -0,0 +1,126 @@
+package app
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/9renpoto/casemd/internal/core/domain"
+	"github.com/9renpoto/casemd/internal/i18n"
+)
+
+func trendRuns() []domain.NamedRun {
+	day := func(d int) time.Time { return time.Date(2026, 10, d, 9, 0, 0, 0, time.UTC) }
+	return []domain.NamedRun{
+		{Name: "rc3", Recorded: day(10), Runs: []domain.Run{
+			{Source: "checks/login.md", Cases: []domain.CaseResult{
+				{MajorItem: "Auth", MinorItem: "Password", Result: "NG"},
+				{MajorItem: "Auth", MinorItem: "Reset", Result: "OK"},
+			}},
+			{Source: "setup.md", Cases: []domain.CaseResult{{MajorItem: "Setup", MinorItem: "Install", Result: "OK", TestDate: "2026-08-01"}}},
+		}},
+		{Name: "rc1", Recorded: day(1), Runs: []domain.Run{
+			{Source: "checks/login.md", Cases: []domain.CaseResult{
+				{MajorItem: "Auth", MinorItem: "Password", Result: "NG"},
+				{MajorItem: "Auth", MinorItem: "Reset", Result: "OK"},
+				{MajorItem: "Auth", MinorItem: "Logout"},
+			}},
+		}},
+		{Name: "rc2", Recorded: day(5), Runs: []domain.Run{
+			{Source: "login", Cases: []domain.CaseResult{
+				{MajorItem: "Auth", MinorItem: "Password", Checkpoints: []string{"* [x] Signed in"}},
+				{MajorItem: "Auth", MinorItem: "Reset", Result: "pass"},
+			}},
+		}},
+	}
+}
+
+func TestTrendReporter_Report(t *testing.T) {
+	options := TrendOptions{StaleDays: 30, Now: time.Date(2026, 10, 18, 9, 0, 0, 0, time.UTC)}
+	report, err := NewTrendReporter(&mockCaseParser{}, "").Report(trendRuns(), nil, options)
+	if err != nil {
+		t.Fatalf("Report() returned an unexpected error: %v", err)
+	}
+
+	if !reflect.DeepEqual(report.Runs, []string{"rc1", "rc2", "rc3"}) {
+		t.Fatalf("expected the runs oldest first, got %v", report.Runs)
+	}
+	password := TrendCase{Source: "checks/login.md", Case: "Auth /  / Password"}
+	if expected := []FlakyCase{{TrendCase: password, Passed: 1, Failed: 2, Flips: 2, History: []string{"failed", "passed", "failed"}}}; !reflect.DeepEqual(report.Flaky, expected) {
+		t.Fatalf("unexpected flaky cases: %+v", report.Flaky)
+	}
+	expectedMajors := []MajorItemTrend{
+		{Source: "checks/login.md", Major: "Auth", Passed: 4, Failed: 2},
+		{Source: "setup.md", Major: "Setup", Passed: 1},
+	}
+	if !reflect.DeepEqual(report.MajorItems, expectedMajors) || report.MajorItems[0].Percent() != 66 {
+		t.Fatalf("unexpected pass rates: %+v", report.MajorItems)
+	}
+	if expected := []TrendCase{{Source: "checks/login.md", Case: "Auth /  / Logout"}}; !reflect.DeepEqual(report.NeverRun, expected) {
+		t.Fatalf("unexpected never run cases: %+v", report.NeverRun)
+	}
+	if len(report.Stale) != 1 || report.Stale[0].Case != "Setup /  / Install" || report.Stale[0].LastRun != "rc3" || report.Stale[0].Days != 78 {
+		t.Fatalf("unexpected stale cases: %+v", report.Stale)
+	}
+
+	var markdown bytes.Buffer
+	if err := report.WriteMarkdown(&markdown); err != nil {
+		t.Fatalf("WriteMarkdown() returned an unexpected error: %v", err)
+	}
+	for _, line := range []string{
+		"# Trend Report\n\nRuns: rc1, rc2, rc3\n",
+		"| checks/login.md | Auth /  / Password | 1 | 2 | 2 | ✗✓✗ |\n",
+		"| checks/login.md | Auth | 4 | 2 | 66% |\n",
+		"## Not run in 30 days\n",
+	} {
+		if !strings.Contains(markdown.String(), line) {
+			t.Fatalf("expected %q in:\n%s", line, markdown.String())
+		}
+	}
+}
+
+func TestTrendReporter_ReportCoversTheChecklists(t *testing.T) {
+	parser := &mockCaseParser{cases: []domain.Case{
+		{MajorItem: "Auth", MinorItem: "Password"},
+		{MajorItem: "Auth", MinorItem: "Reset"},
+		{MajorItem: "Auth", MinorItem: "Sign up"},
+	}}
+	checklists := []Source{{Name: "login.md", Reader: strings.NewReader("")}}
+	report, err := NewTrendReporter(parser, "").Report(trendRuns(), checklists, TrendOptions{Language: i18n.Japanese})
+	if err != nil {
+		t.Fatalf("Report() returned an unexpected error: %v", err)
+	}
+
+	if expected := []TrendCase{{Source: "login.md", Case: "Auth /  / Sign up"}}; !reflect.DeepEqual(report.NeverRun, expected) {
+		t.Fatalf("unexpected never run cases: %+v", report.NeverRun)
+	}
+	if len(report.MajorItems) != 1 || report.MajorItems[0].Passed != 4 {
+		t.Fatalf("expected only the checklist's major items, got %+v", report.MajorItems)
+	}
+
+	var workbook bytes.Buffer
+	if err := report.WriteWorkbook(&workbook, XLSXFormat); err != nil {
+		t.Fatalf("WriteWorkbook() returned an unexpected error: %v", err)
+	}
+	sheets, err := ReadWorkbookCases(workbook.Bytes())
+	if err != nil {
+		t.Fatalf("ReadWorkbookCases() returned an unexpected error: %v", err)
+	}
+	var names []string
+	for _, sheet := range sheets {
+		names = append(names, sheet.Name)
+	}
+	if expected := []string{"login", "結果が安定しないケース", "大項目ごとの合格率", "一度も実施されていないケース"}; !reflect.DeepEqual(names, expected) {
+		t.Fatalf("unexpected sheets: %v", names)
+	}
+
+	var html bytes.Buffer
+	if err := report.WriteHTML(&html); err != nil {
+		t.Fatalf("WriteHTML() returned an unexpected error: %v", err)
+	}
+	if !strings.Contains(html.String(), `<html lang="ja">`) || !strings.Contains(html.String(), "<td>Auth /  / Sign up</td>") {
+		t.Fatalf("unexpected html:\n%s", html.String())
+	}
+}
@@ -0,0 +1,264 @@
+package app
+
+import (
+	"archive/zip"
+	"bufio"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// xlsxBufferSize sizes the buffer between the XML encoder and each zip entry,
+// so rows are flushed in chunks instead of accumulating a whole sheet in memory.
+const xlsxBufferSize = 32 * 1024
+
+func writeWorkbook(w io.Writer, sheets []workbookSheet) error {
+	zipWriter := zip.NewWriter(w)
+
+	if err := writeZipFile(zipWriter, "[Content_Types].xml", buildContentTypes(sheets)); err != nil {
+		zipWriter.Close()
+		return err
+	}
+
+	if err := writeZipFile(zipWriter, "_rels/.rels", rootRelationships); err != nil {
+		zipWriter.Close()
+		return err
+	}
+
+	if err := writeZipFile(zipWriter, "docProps/app.xml", appProperties); err != nil {
+		zipWriter.Close()
+		return err
+	}
+
+	if err := writeZipFile(zipWriter, "docProps/core.xml", coreProperties); err != nil {
+		zipWriter.Close()
+		return err
+	}
+
+	if err := writeZipFile(zipWriter, "xl/workbook.xml", buildWorkbookXML(sheets)); err != nil {
+		zipWriter.Close()
+		return err
+	}
+
+	if err := writeZipFile(zipWriter, "xl/_rels/workbook.xml.rels", buildWorkbookRelationships(sheets)); err != nil {
+		zipWriter.Close()
+		return err
+	}
+
+	shared := newSharedStrings()
+	for i, sheet := range sheets {
+		path := fmt.Sprintf("xl/worksheets/sheet%d.xml", i+1)
+		err := writeZipEntry(zipWriter, path, func(writer *bufio.Writer) error {
+			return writeWorksheet(writer, sheet, shared)
+		})
+		if err != nil {
+			zipWriter.Close()
+			return err
+		}
+	}
+
+	// The shared strings part is only complete once every sheet has been
+	// streamed, so it is written last.
+	if err := writeZipEntry(zipWriter, "xl/sharedStrings.xml", shared.write); err != nil {
+		zipWriter.Close()
+		return err
+	}
+
+	return zipWriter.Close()
+}
+
+func writeZipFile(zipWriter *zip.Writer, name, content string) error {
+	writer, err := zipWriter.Create(name)
+	if err != nil {
+		return fmt.Errorf("create %s: %w", name, err)
+	}
+	if _, err := writer.Write([]byte(content)); err != nil {
+		return fmt.Errorf("write %s: %w", name, err)
+	}
+	return nil
+}
+
+// writeZipEntry streams an entry through a buffered writer. bufio keeps the
+// first write error, so the callback can write freely and Flush reports it;
+// the callback only returns its own errors, such as a context that is done.
+func writeZipEntry(zipWriter *zip.Writer, name string, write func(writer *bufio.Writer) error) error {
+	entry, err := zipWriter.Create(name)
+	if err != nil {
+		return fmt.Errorf("create %s: %w", name, err)
+	}
+	buffered := bufio.NewWriterSize(entry, xlsxBufferSize)
+	if err := write(buffered); err != nil {
+		return fmt.Errorf("write %s: %w", name, err)
+	}
+	if err := buffered.Flush(); err != nil {
+		return fmt.Errorf("write %s: %w", name, err)
+	}
+	return nil
+}
+
+func buildContentTypes(sheets []workbookSheet) string {
+	var builder strings.Builder
+	builder.WriteString(xml.Header)
+	builder.WriteString(`<Types xmlns="http://schemas.openxmlformats.org/package/2006/content-types">`)
+	builder.WriteString(`<Default Extension="rels" ContentType="application/vnd.openxmlformats-package.relationships+xml"/>`)
+	builder.WriteString(`<Default Extension="xml" ContentType="application/xml"/>`)
+	builder.WriteString(`<Override PartName="/xl/workbook.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.sheet.main+xml"/>`)
+	for i := range sheets {
+		builder.WriteString(fmt.Sprintf(`<Override PartName="/xl/worksheets/sheet%d.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.worksheet+xml"/>`, i+1))
+	}
+	builder.WriteString(`<Override PartName="/xl/sharedStrings.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.sharedStrings+xml"/>`)
+	builder.WriteString(`</Types>`)
+	return builder.String()
+}
+
+func buildWorkbookXML(sheets []workbookSheet) string {
+	var builder strings.Builder
+	builder.WriteString(xml.Header)
+	builder.WriteString(`<workbook xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main" xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships">`)
+	builder.WriteString(`<sheets>`)
+	for i, sheet := range sheets {
+		builder.WriteString(fmt.Sprintf(`<sheet name="%s" sheetId="%d" r:id="rId%d"/>`, xmlEscapeAttr(sheet.Name), i+1, i+1))
+	}
+	builder.WriteString(`</sheets></workbook>`)
+	return builder.String()
+}
+
+func buildWorkbookRelationships(sheets []workbookSheet) string {
+	var builder strings.Builder
+	builder.WriteString(xml.Header)
+	builder.WriteString(`<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">`)
+	for i := range sheets {
+		builder.WriteString(fmt.Sprintf(`<Relationship Id="rId%d" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/worksheet" Target="worksheets/sheet%d.xml"/>`, i+1, i+1))
+	}
+	builder.WriteString(fmt.Sprintf(`<Relationship Id="rId%d" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/sharedStrings" Target="sharedStrings.xml"/>`, len(sheets)+1))
+	builder.WriteString(`</Relationships>`)
+	return builder.String()
+}
+
+func writeWorksheet(writer *bufio.Writer, sheet workbookSheet, shared *sharedStrings) error {
+	writer.WriteString(xml.Header)
+	writer.WriteString(`<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main">`)
+
+	if sheet.Width > 0 && sheet.Height > 0 {
+		fmt.Fprintf(writer, `<dimension ref="A1:%s%d"/>`, columnName(sheet.Width), sheet.Height)
+	}
+
+	sharedColumns := make(map[int]bool, len(sheet.SharedColumns))
+	for _, column := range sheet.SharedColumns {
+		sharedColumns[column] = true
+	}
+	var columnNames []string
+
+	writer.WriteString(`<sheetData>`)
+	for i := 0; i < sheet.Height; i++ {
+		if err := sheet.contextErr(); err != nil {
+			return err
+		}
+		row := sheet.Row(i)
+		rowNumber := strconv.Itoa(i + 1)
+		writer.WriteString(`<row r="`)
+		writer.WriteString(rowNumber)
+		writer.WriteString(`">`)
+		for j, value := range row {
+			for len(columnNames) <= j {
+				columnNames = append(columnNames, columnName(len(columnNames)+1))
+			}
+			writer.WriteString(`<c r="`)
+			writer.WriteString(columnNames[j])
+			writer.WriteString(rowNumber)
+			switch {
+			case value == "":
+				writer.WriteString(`"/>`)
+			case i == 0 || sharedColumns[j]:
+				writer.WriteString(`" t="s"><v>`)
+				writer.WriteString(strconv.Itoa(shared.add(value)))
+				writer.WriteString(`</v></c>`)
+			default:
+				writer.WriteString(`" t="inlineStr"><is><t>`)
+				writeCellText(writer, value)
+				writer.WriteString(`</t></is></c>`)
+			}
+		}
+		writer.WriteString(`</row>`)
+	}
+	writer.WriteString(`</sheetData></worksheet>`)
+	return nil
+}
+
+// writeCellText writes value as XML character data. xml.EscapeText also
+// replaces the runes XML 1.0 does not allow, such as control characters and
+// invalid UTF-8, which would otherwise make spreadsheet software reject the
+// file.
+func writeCellText(writer io.Writer, value string) {
+	// Errors of the buffered writer surface when it is flushed.
+	_ = xml.EscapeText(writer, []byte(value))
+}
+
+// sharedStrings accumulates the workbook-wide string table referenced by
+// cells of type "s".
+type sharedStrings struct {
+	index  map[string]int
+	values []string
+	count  int
+}
+
+func newSharedStrings() *sharedStrings {
+	return &sharedStrings{index: make(map[string]int)}
+}
+
+func (s *sharedStrings) add(value string) int {
+	s.count++
+	if position, ok := s.index[value]; ok {
+		return position
+	}
+	position := len(s.values)
+	s.index[value] = position
+	s.values = append(s.values, value)
+	return position
+}
+
+func (s *sharedStrings) write(writer *bufio.Writer) error {
+	writer.WriteString(xml.Header)
+	fmt.Fprintf(writer, `<sst xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main" count="%d" uniqueCount="%d">`, s.count, len(s.values))
+	for _, value := range s.values {
+		writer.WriteString(`<si><t>`)
+		writeCellText(writer, value)
+		writer.WriteString(`</t></si>`)
+	}
+	writer.WriteString(`</sst>`)
+	return nil
+}
+
+func columnName(n int) string {
+	const letters = "ABCDEFGHIJKLMNOPQRSTUVWXYZ"
+	if n <= 0 {
+		return "A"
+	}
+	result := ""
+	for n > 0 {
+		n--
+		result = string(letters[n%26]) + result
+		n /= 26
+	}
+	return result
+}
+
+const rootRelationships = `<?xml version="1.0" encoding="UTF-8"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">
+  <Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/officeDocument" Target="xl/workbook.xml"/>
+  <Relationship Id="rId2" Type="http://schemas.openxmlformats.org/package/2006/relationships/metadata/core-properties" Target="docProps/core.xml"/>
+  <Relationship Id="rId3" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/extended-properties" Target="docProps/app.xml"/>
+</Relationships>`
+
+const appProperties = `<?xml version="1.0" encoding="UTF-8"?>
+<Properties xmlns="http://schemas.openxmlformats.org/officeDocument/2006/extended-properties" xmlns:vt="http://schemas.openxmlformats.org/officeDocument/2006/docPropsVTypes">
+  <Application>casemd</Application>
+</Properties>`
+
+const coreProperties = `<?xml version="1.0" encoding="UTF-8"?>
+<cp:coreProperties xmlns:cp="http://schemas.openxmlformats.org/package/2006/metadata/core-properties" xmlns:dc="http://purl.org/dc/elements/1.1/" xmlns:dcterms="http://purl.org/dc/terms/" xmlns:dcmitype="http://purl.org/dc/dcmitype/" xmlns:xsi="http://www.w3.org/2001/XMLSchema-instance">
+  <dc:creator>casemd</dc:creator>
+  <cp:lastModifiedBy>casemd</cp:lastModifiedBy>
+</cp:coreProperties>`
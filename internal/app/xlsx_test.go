@@ -0,0 +1,120 @@
+package app
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/9renpoto/casemd/internal/core/domain"
+)
+
+func TestWriteWorkbookStopsWhenContextIsDone(t *testing.T) {
+	for name, write := range map[string]func(io.Writer, []workbookSheet) error{"xlsx": writeWorkbook, "ods": writeODS} {
+		ctx, cancel := context.WithCancel(context.Background())
+		rows := 0
+		sheet := workbookSheet{Name: "checks", Width: 1, Height: 1000, Context: ctx, Row: func(i int) []string {
+			// The deadline passes while the rows are being written.
+			rows++
+			if i == 1 {
+				cancel()
+			}
+			return []string{"Step"}
+		}}
+
+		err := write(io.Discard, []workbookSheet{sheet})
+		if !errors.Is(err, context.Canceled) {
+			t.Fatalf("%s: expected context.Canceled, got %v", name, err)
+		}
+		if rows != 2 {
+			t.Fatalf("%s: expected writing to stop after 2 rows, built %d", name, rows)
+		}
+	}
+}
+
+func TestWriteWorkbookEscapesInvalidXMLCharacters(t *testing.T) {
+	value := "Step\x01 one\x0b\x1f\xff & <two>\nthree"
+	shared := tableSheet("controls", [][]string{{"Header\x0c"}, {value}, {value}})
+	shared.SharedColumns = []int{0}
+	sheets := []workbookSheet{shared, tableSheet("inline", [][]string{{"Header"}, {value}})}
+
+	var output bytes.Buffer
+	if err := writeWorkbook(&output, sheets); err != nil {
+		t.Fatalf("writeWorkbook() returned an unexpected error: %v", err)
+	}
+	archive, err := zip.NewReader(bytes.NewReader(output.Bytes()), int64(output.Len()))
+	if err != nil {
+		t.Fatalf("open workbook: %v", err)
+	}
+
+	for _, file := range archive.File {
+		if !strings.HasSuffix(file.Name, ".xml") {
+			continue
+		}
+		reader, err := file.Open()
+		if err != nil {
+			t.Fatalf("open %s: %v", file.Name, err)
+		}
+		decoder := xml.NewDecoder(reader)
+		var text strings.Builder
+		for {
+			token, err := decoder.Token()
+			if errors.Is(err, io.EOF) {
+				break
+			}
+			if err != nil {
+				t.Fatalf("%s is not well-formed XML: %v", file.Name, err)
+			}
+			if data, ok := token.(xml.CharData); ok {
+				text.Write(data)
+			}
+		}
+		reader.Close()
+		if strings.ContainsAny(text.String(), "\x01\x0b\x0c\x1f") {
+			t.Fatalf("%s kept control characters: %q", file.Name, text.String())
+		}
+		if (file.Name == "xl/sharedStrings.xml" || file.Name == "xl/worksheets/sheet2.xml") && !strings.Contains(text.String(), "& <two>\nthree") {
+			t.Fatalf("%s lost the cell text: %q", file.Name, text.String())
+		}
+	}
+}
+
+// BenchmarkMarkdownToSpreadsheet_Convert converts parsed cases into a workbook
+// written to io.Discard, from layout to the zip stream. Each row is rendered
+// when it is written and dropped afterwards, so the memory held at once is
+// the parsed cases, the shared strings and one row; B/op is the total
+// allocated per conversion.
+func BenchmarkMarkdownToSpreadsheet_Convert(b *testing.B) {
+	for _, size := range []int{5_000, 50_000} {
+		b.Run(fmt.Sprintf("cases=%d", size), func(b *testing.B) {
+			converter := NewMarkdownToSpreadsheet(&mockCaseParser{cases: benchmarkCases(size)})
+
+			b.ReportAllocs()
+			for b.Loop() {
+				sources := []Source{{Name: "regression.md", Reader: strings.NewReader("")}}
+				if err := converter.Convert(sources, io.Discard); err != nil {
+					b.Fatalf("Convert() returned an unexpected error: %v", err)
+				}
+			}
+		})
+	}
+}
+
+func benchmarkCases(size int) []domain.Case {
+	cases := make([]domain.Case, 0, size)
+	for i := 0; i < size; i++ {
+		cases = append(cases, domain.Case{
+			MajorItem:       fmt.Sprintf("Major %d", i/1000),
+			MediumItem:      fmt.Sprintf("Medium %d", i/100),
+			MinorItem:       fmt.Sprintf("Regression case %d", i),
+			ValidationSteps: []string{"Open the application", "Navigate to the settings page", "Toggle the feature flag"},
+			Checkpoints:     []string{"* [ ] Setting persists after reload", "* [ ] Audit log records the change"},
+		})
+	}
+	return cases
+}
@@ -0,0 +1,621 @@
+package app
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"path"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/9renpoto/casemd/internal/core/domain"
+)
+
+// XLSXToMarkdown migrates existing inspection workbooks back into casemd Markdown.
+type XLSXToMarkdown struct{}
+
+// NewXLSXToMarkdown creates the XLSX importer.
+func NewXLSXToMarkdown() *XLSXToMarkdown {
+	return &XLSXToMarkdown{}
+}
+
+// Convert reads XLSX workbooks and writes one Markdown document section per sheet.
+func (c *XLSXToMarkdown) Convert(sources []Source, output io.Writer) error {
+	if len(sources) == 0 {
+		return fmt.Errorf("no sources provided")
+	}
+
+	first := true
+	for _, source := range sources {
+		data, err := io.ReadAll(source.Reader)
+		if err != nil {
+			return fmt.Errorf("read %s: %w", source.Name, err)
+		}
+
+		sheets, err := readWorkbookCases(data, source.Columns)
+		if err != nil {
+			return fmt.Errorf("import %s: %w", source.Name, err)
+		}
+
+		for _, sheet := range sheets {
+			if source.Layout == StepLayout {
+				sheet.Cases = joinStepRows(sheet.Cases)
+			}
+			if !first {
+				if _, err := io.WriteString(output, "\n"); err != nil {
+					return fmt.Errorf("write markdown: %w", err)
+				}
+			}
+			first = false
+			if err := WriteMarkdown(output, sheet.Name, sheet.Cases); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// WorkbookCases holds the cases reconstructed from a single worksheet.
+type WorkbookCases struct {
+	Name  string
+	Cases []domain.Case
+}
+
+// ReadWorkbookCases parses an XLSX workbook laid out with the casemd columns and
+// reconstructs its cases. Merged and blank heading cells inherit the value above
+// them, mirroring how grouped headings are presented in spreadsheets; other
+// merged cells only count on their first row.
+func ReadWorkbookCases(data []byte) ([]WorkbookCases, error) {
+	return readWorkbookCases(data, nil)
+}
+
+// readWorkbookCases is ReadWorkbookCases for workbooks whose columns were
+// renamed; columns are looked up by the headers of selected, and by their
+// default headers in any language otherwise.
+func readWorkbookCases(data []byte, selected []Column) ([]WorkbookCases, error) {
+	workbook, err := readWorkbookGrid(data)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]WorkbookCases, 0, len(workbook))
+	for _, sheet := range workbook {
+		result = append(result, WorkbookCases{Name: sheet.Name, Cases: casesFromGrid(sheet.Rows, sheet.Merges, selected)})
+	}
+	return result, nil
+}
+
+// joinStepRows merges consecutive rows of the same minor item, as written by
+// StepLayout, back into one case whose steps keep the checkpoints of their
+// row. Checkpoints of a row without a step belong to no step.
+func joinStepRows(rows []domain.Case) []domain.Case {
+	var cases []domain.Case
+	for _, row := range rows {
+		if len(cases) == 0 || cases[len(cases)-1].Key() != row.Key() {
+			aCase := row
+			aCase.ValidationSteps, aCase.Checkpoints, aCase.Steps = nil, nil, nil
+			cases = append(cases, aCase)
+		}
+		aCase := &cases[len(cases)-1]
+		aCase.ValidationSteps = append(aCase.ValidationSteps, row.ValidationSteps...)
+		aCase.Checkpoints = append(aCase.Checkpoints, row.Checkpoints...)
+		if len(row.ValidationSteps) == 1 {
+			aCase.Steps = append(aCase.Steps, domain.Step{Action: row.ValidationSteps[0], Expectations: row.Checkpoints})
+			continue
+		}
+		for _, action := range row.ValidationSteps {
+			aCase.Steps = append(aCase.Steps, domain.Step{Action: action})
+		}
+	}
+	return cases
+}
+
+// WriteMarkdown renders cases using the heading and list conventions the parser reads.
+func WriteMarkdown(w io.Writer, title string, cases []domain.Case) error {
+	var builder strings.Builder
+	separate := false
+	heading := func(level int, text string) {
+		if separate {
+			builder.WriteString("\n")
+			separate = false
+		}
+		fmt.Fprintf(&builder, "%s %s\n", strings.Repeat("#", level), text)
+	}
+
+	if title != "" {
+		heading(1, title)
+		separate = true
+	}
+
+	var major, medium string
+	for i, aCase := range cases {
+		if i == 0 || aCase.MajorItem != major {
+			major = aCase.MajorItem
+			medium = ""
+			heading(2, major)
+		}
+		if aCase.MediumItem != medium {
+			medium = aCase.MediumItem
+			heading(3, medium)
+		}
+		heading(4, aCase.MinorItem)
+		builder.WriteString("\n")
+		for _, field := range [][2]string{{"Priority", aCase.Priority}, {"Type", aCase.TestType}, {"Tags", strings.Join(aCase.Tags, ", ")}, {"Effort", aCase.Effort}} {
+			if field[1] != "" {
+				fmt.Fprintf(&builder, "%s: %s\n", field[0], field[1])
+			}
+		}
+		writeMarkdownSection(&builder, "Preconditions", aCase.Preconditions)
+		for j, step := range caseSteps(aCase) {
+			fmt.Fprintf(&builder, "%d. %s\n", j+1, step.Action)
+			for _, expectation := range step.Expectations {
+				builder.WriteString("   " + expectation + "\n")
+			}
+		}
+		for _, checkpoint := range looseCheckpoints(aCase) {
+			builder.WriteString(checkpoint)
+			builder.WriteString("\n")
+		}
+		writeMarkdownSection(&builder, "Expected results", aCase.ExpectedResults)
+		separate = true
+	}
+
+	if _, err := io.WriteString(w, builder.String()); err != nil {
+		return fmt.Errorf("write markdown: %w", err)
+	}
+	return nil
+}
+
+// writeMarkdownSection writes items as a bulleted sub-section of a case.
+func writeMarkdownSection(builder *strings.Builder, label string, items []string) {
+	if len(items) == 0 {
+		return
+	}
+	fmt.Fprintf(builder, "%s:\n", label)
+	for _, item := range items {
+		fmt.Fprintf(builder, "- %s\n", item)
+	}
+}
+
+var (
+	stepNumberRegex     = regexp.MustCompile(`^\d+[.)]\s+`)
+	checkpointTaskRegex = regexp.MustCompile(`^[*\-+]\s+\[([ xX])\]\s+(.*)$`)
+	cellReferenceRegex  = regexp.MustCompile(`^([A-Z]+)(\d+)$`)
+)
+
+func casesFromGrid(rows [][]string, merges []cellRange, selected []Column) []domain.Case {
+	headerIndex := -1
+	for i, row := range rows {
+		if !isBlankRow(row) {
+			headerIndex = i
+			break
+		}
+	}
+	if headerIndex < 0 {
+		return nil
+	}
+
+	columns := make(map[string]int)
+	for j, label := range rows[headerIndex] {
+		key := strings.ToLower(strings.TrimSpace(label))
+		if _, exists := columns[key]; !exists {
+			columns[key] = j
+		}
+	}
+	labels := fieldHeaders(selected)
+	index := func(key string) (int, bool) {
+		for _, label := range labels[key] {
+			if index, ok := columns[strings.ToLower(label)]; ok {
+				return index, true
+			}
+		}
+		return 0, false
+	}
+	cell := func(row []string, key string) string {
+		if index, ok := index(key); ok && index < len(row) {
+			return strings.TrimSpace(row[index])
+		}
+		return ""
+	}
+
+	// Only the headings spread over merged cells: a merged step or
+	// checkpoint cell would otherwise repeat on every row it spans.
+	for _, key := range []string{"major", "medium", "minor"} {
+		if column, ok := index(key); ok {
+			fillMerged(rows[headerIndex+1:], headerIndex+1, column, merges)
+		}
+	}
+
+	var cases []domain.Case
+	var major, medium string
+	for _, row := range rows[headerIndex+1:] {
+		if isBlankRow(row) {
+			continue
+		}
+
+		if value := cell(row, "major"); value != "" {
+			if value != major {
+				medium = ""
+			}
+			major = value
+		}
+		if value := cell(row, "medium"); value != "" {
+			medium = value
+		}
+
+		aCase := domain.Case{
+			MajorItem:  major,
+			MediumItem: medium,
+			MinorItem:  cell(row, "minor"),
+			Result:     cell(row, "result"),
+			TestDate:   cell(row, "date"),
+			Tester:     cell(row, "tester"),
+			Notes:      cell(row, "notes"),
+
+			Preconditions:   splitCellLines(cell(row, "preconditions")),
+			ExpectedResults: splitCellLines(cell(row, "expected")),
+			Priority:        cell(row, "priority"),
+			TestType:        cell(row, "type"),
+			Effort:          cell(row, "effort"),
+		}
+		for _, tag := range strings.Split(cell(row, "tags"), ",") {
+			if tag = strings.TrimSpace(tag); tag != "" {
+				aCase.Tags = append(aCase.Tags, tag)
+			}
+		}
+		for _, line := range splitCellLines(cell(row, "steps")) {
+			aCase.ValidationSteps = append(aCase.ValidationSteps, stepNumberRegex.ReplaceAllString(line, ""))
+		}
+		for _, line := range splitCellLines(cell(row, "checkpoints")) {
+			aCase.Checkpoints = append(aCase.Checkpoints, checkpointLine(line))
+		}
+		cases = append(cases, aCase)
+	}
+
+	return cases
+}
+
+// fillMerged copies the value of each merged range that spans column into
+// the column's cells of the rows below it. rows start at sheet row offset.
+func fillMerged(rows [][]string, offset, column int, merges []cellRange) {
+	for _, merge := range merges {
+		if column < merge.startColumn || column > merge.endColumn || merge.startRow < offset || merge.startRow-offset >= len(rows) {
+			continue
+		}
+		anchor := rows[merge.startRow-offset]
+		if merge.startColumn >= len(anchor) || anchor[merge.startColumn] == "" {
+			continue
+		}
+		value := anchor[merge.startColumn]
+		for row := merge.startRow - offset; row <= merge.endRow-offset && row < len(rows); row++ {
+			for len(rows[row]) <= column {
+				rows[row] = append(rows[row], "")
+			}
+			rows[row][column] = value
+		}
+	}
+}
+
+// checkpointLine rewrites a checkpoint cell line as the "* [ ]" or "* [x]"
+// task the parser accepts, whichever bullet or marker case it was typed with.
+func checkpointLine(line string) string {
+	matches := checkpointTaskRegex.FindStringSubmatch(line)
+	if matches == nil {
+		return "* [ ] " + strings.TrimLeft(line, "*-+ ")
+	}
+	return "* [" + strings.ToLower(matches[1]) + "] " + matches[2]
+}
+
+func splitCellLines(value string) []string {
+	var lines []string
+	for _, line := range strings.Split(strings.ReplaceAll(value, "\r\n", "\n"), "\n") {
+		if trimmed := strings.TrimSpace(line); trimmed != "" {
+			lines = append(lines, trimmed)
+		}
+	}
+	return lines
+}
+
+func isBlankRow(row []string) bool {
+	for _, value := range row {
+		if strings.TrimSpace(value) != "" {
+			return false
+		}
+	}
+	return true
+}
+
+type workbookGrid struct {
+	Name   string
+	Rows   [][]string
+	Merges []cellRange
+}
+
+// cellRange is a merged range of a worksheet, by 0-based row and column.
+type cellRange struct {
+	startRow, startColumn int
+	endRow, endColumn     int
+}
+
+func readWorkbookGrid(data []byte) ([]workbookGrid, error) {
+	zipReader, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return nil, fmt.Errorf("open workbook: %w", err)
+	}
+
+	files := make(map[string]*zip.File, len(zipReader.File))
+	for _, file := range zipReader.File {
+		files[file.Name] = file
+	}
+
+	var workbook xlsxWorkbook
+	if err := decodeZipXML(files, "xl/workbook.xml", &workbook); err != nil {
+		return nil, err
+	}
+
+	var relationships xlsxRelationships
+	if err := decodeZipXML(files, "xl/_rels/workbook.xml.rels", &relationships); err != nil {
+		return nil, err
+	}
+	targets := make(map[string]string, len(relationships.Items))
+	for _, relationship := range relationships.Items {
+		target := relationship.Target
+		if strings.HasPrefix(target, "/") {
+			target = strings.TrimPrefix(target, "/")
+		} else {
+			target = path.Join("xl", target)
+		}
+		targets[relationship.ID] = target
+	}
+
+	var shared []string
+	if _, ok := files["xl/sharedStrings.xml"]; ok {
+		var table xlsxSharedStrings
+		if err := decodeZipXML(files, "xl/sharedStrings.xml", &table); err != nil {
+			return nil, err
+		}
+		for _, item := range table.Items {
+			shared = append(shared, item.text())
+		}
+	}
+
+	grids := make([]workbookGrid, 0, len(workbook.Sheets))
+	for _, sheet := range workbook.Sheets {
+		target, ok := targets[sheet.RelationshipID]
+		if !ok {
+			return nil, fmt.Errorf("sheet %s: missing relationship %s", sheet.Name, sheet.RelationshipID)
+		}
+
+		var worksheet xlsxWorksheet
+		if err := decodeZipXML(files, target, &worksheet); err != nil {
+			return nil, err
+		}
+
+		rows, merges, err := worksheet.grid(shared)
+		if err != nil {
+			return nil, fmt.Errorf("sheet %s: %w", sheet.Name, err)
+		}
+		grids = append(grids, workbookGrid{Name: sheet.Name, Rows: rows, Merges: merges})
+	}
+
+	return grids, nil
+}
+
+// xlsxMaxPartBytes caps the decompressed size of each XML part read from a
+// workbook, so a small upload cannot inflate to gigabytes while decoding.
+const xlsxMaxPartBytes = 64 << 20
+
+var errWorkbookPartTooLarge = fmt.Errorf("workbook part is larger than %d bytes", xlsxMaxPartBytes)
+
+func decodeZipXML(files map[string]*zip.File, name string, target any) error {
+	file, ok := files[name]
+	if !ok {
+		return fmt.Errorf("workbook is missing %s", name)
+	}
+	if file.UncompressedSize64 > xlsxMaxPartBytes {
+		return fmt.Errorf("decode %s: %w", name, errWorkbookPartTooLarge)
+	}
+	rc, err := file.Open()
+	if err != nil {
+		return fmt.Errorf("open %s: %w", name, err)
+	}
+	defer rc.Close()
+
+	// The header may understate the size, so the reader is limited too.
+	limited := &io.LimitedReader{R: rc, N: xlsxMaxPartBytes + 1}
+	if err := xml.NewDecoder(limited).Decode(target); err != nil {
+		if limited.N <= 0 {
+			return fmt.Errorf("decode %s: %w", name, errWorkbookPartTooLarge)
+		}
+		return fmt.Errorf("decode %s: %w", name, err)
+	}
+	return nil
+}
+
+type xlsxWorkbook struct {
+	Sheets []struct {
+		Name           string `xml:"name,attr"`
+		RelationshipID string `xml:"http://schemas.openxmlformats.org/officeDocument/2006/relationships id,attr"`
+	} `xml:"sheets>sheet"`
+}
+
+type xlsxRelationships struct {
+	Items []struct {
+		ID     string `xml:"Id,attr"`
+		Target string `xml:"Target,attr"`
+	} `xml:"Relationship"`
+}
+
+type xlsxSharedStrings struct {
+	Items []xlsxRichText `xml:"si"`
+}
+
+// xlsxRichText covers both plain (<t>) and rich text runs (<r><t>).
+type xlsxRichText struct {
+	Text string `xml:"t"`
+	Runs []struct {
+		Text string `xml:"t"`
+	} `xml:"r"`
+}
+
+func (t xlsxRichText) text() string {
+	if len(t.Runs) == 0 {
+		return t.Text
+	}
+	var builder strings.Builder
+	builder.WriteString(t.Text)
+	for _, run := range t.Runs {
+		builder.WriteString(run.Text)
+	}
+	return builder.String()
+}
+
+type xlsxWorksheet struct {
+	Rows []struct {
+		Index int `xml:"r,attr"`
+		Cells []struct {
+			Reference string       `xml:"r,attr"`
+			Type      string       `xml:"t,attr"`
+			Value     string       `xml:"v"`
+			Inline    xlsxRichText `xml:"is"`
+		} `xml:"c"`
+	} `xml:"sheetData>row"`
+	MergeCells []struct {
+		Reference string `xml:"ref,attr"`
+	} `xml:"mergeCells>mergeCell"`
+}
+
+// Excel's sheet limits. References beyond them, and grids of more than
+// xlsxMaxCells cells, are rejected before anything is allocated for them, as
+// the dimensions come from an untrusted file.
+const (
+	xlsxMaxRows    = 1_048_576
+	xlsxMaxColumns = 16_384
+	xlsxMaxCells   = 1 << 22
+)
+
+var errWorkbookTooLarge = fmt.Errorf("worksheet holds more than %d cells", xlsxMaxCells)
+
+// grid returns the cell values by row and column, and the merged ranges.
+func (w xlsxWorksheet) grid(shared []string) ([][]string, []cellRange, error) {
+	var rows [][]string
+	cells := 0
+	// cells counts the allocated cells, and rows as one cell each.
+	set := func(row, column int, value string) error {
+		switch {
+		case row >= len(rows):
+			cells += row + 1 - len(rows) + column + 1
+		case column >= len(rows[row]):
+			cells += column + 1 - len(rows[row])
+		}
+		if cells > xlsxMaxCells {
+			return errWorkbookTooLarge
+		}
+		for len(rows) <= row {
+			rows = append(rows, nil)
+		}
+		for len(rows[row]) <= column {
+			rows[row] = append(rows[row], "")
+		}
+		rows[row][column] = value
+		return nil
+	}
+
+	nextRow := 0
+	for _, row := range w.Rows {
+		rowIndex := nextRow
+		if row.Index > 0 {
+			rowIndex = row.Index - 1
+		}
+		if rowIndex >= xlsxMaxRows {
+			return nil, nil, fmt.Errorf("row %d is beyond the last row of a worksheet", rowIndex+1)
+		}
+		nextRow = rowIndex + 1
+
+		nextColumn := 0
+		for _, cell := range row.Cells {
+			columnIndex := nextColumn
+			if cell.Reference != "" {
+				column, _, err := parseCellReference(cell.Reference)
+				if err != nil {
+					return nil, nil, err
+				}
+				columnIndex = column
+			}
+			if columnIndex >= xlsxMaxColumns {
+				return nil, nil, fmt.Errorf("cell %s is beyond the last column of a worksheet", cell.Reference)
+			}
+			nextColumn = columnIndex + 1
+
+			var value string
+			switch cell.Type {
+			case "s":
+				index, err := strconv.Atoi(strings.TrimSpace(cell.Value))
+				if err != nil || index < 0 || index >= len(shared) {
+					return nil, nil, fmt.Errorf("cell %s: invalid shared string index %q", cell.Reference, cell.Value)
+				}
+				value = shared[index]
+			case "inlineStr":
+				value = cell.Inline.text()
+			default:
+				value = cell.Value
+			}
+			if value != "" {
+				if err := set(rowIndex, columnIndex, value); err != nil {
+					return nil, nil, err
+				}
+			}
+		}
+	}
+
+	var merges []cellRange
+	merged := 0
+	for _, merge := range w.MergeCells {
+		start, end, found := strings.Cut(merge.Reference, ":")
+		if !found {
+			continue
+		}
+		startColumn, startRow, err := parseCellReference(start)
+		if err != nil {
+			return nil, nil, err
+		}
+		endColumn, endRow, err := parseCellReference(end)
+		if err != nil {
+			return nil, nil, err
+		}
+		if endRow < startRow || endColumn < startColumn {
+			return nil, nil, fmt.Errorf("invalid merged range %q", merge.Reference)
+		}
+		// Bound the cells the merges span, which filling them may visit.
+		if merged += (endRow - startRow + 1) * (endColumn - startColumn + 1); merged > xlsxMaxCells {
+			return nil, nil, errWorkbookTooLarge
+		}
+		merges = append(merges, cellRange{startRow: startRow, startColumn: startColumn, endRow: endRow, endColumn: endColumn})
+	}
+
+	return rows, merges, nil
+}
+
+// parseCellReference converts an A1-style reference into zero-based column and row indexes.
+func parseCellReference(reference string) (int, int, error) {
+	matches := cellReferenceRegex.FindStringSubmatch(strings.ReplaceAll(reference, "$", ""))
+	if matches == nil {
+		return 0, 0, fmt.Errorf("invalid cell reference %q", reference)
+	}
+
+	column := 0
+	for _, letter := range matches[1] {
+		if column = column*26 + int(letter-'A'+1); column > xlsxMaxColumns {
+			return 0, 0, fmt.Errorf("cell reference %q is beyond the last column of a worksheet", reference)
+		}
+	}
+	row, err := strconv.Atoi(matches[2])
+	if err != nil || row < 1 || row > xlsxMaxRows {
+		return 0, 0, fmt.Errorf("invalid cell reference %q", reference)
+	}
+	return column - 1, row - 1, nil
+}
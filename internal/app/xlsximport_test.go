@@ -0,0 +1,307 @@
+package app
+
+import (
+	"archive/zip"
+	"bytes"
+	"compress/flate"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/9renpoto/casemd/internal/core/domain"
+	"github.com/9renpoto/casemd/internal/core/parser"
+)
+
+func TestReadWorkbookCasesRoundTrip(t *testing.T) {
+	cases := []domain.Case{
+		{
+			MajorItem:       "Setup",
+			MediumItem:      "Environment",
+			MinorItem:       "Dependencies",
+			ValidationSteps: []string{"Install required packages", "Confirm default configurations"},
+			Checkpoints:     []string{"* [ ] Packages installed successfully", "* [x] Defaults match specification"},
+		},
+		{
+			MajorItem:       "Setup",
+			MediumItem:      "Configuration",
+			MinorItem:       "CLI defaults",
+			ValidationSteps: []string{"Inspect generated spreadsheet path"},
+			Checkpoints:     []string{"* [ ] Output file lands in build/"},
+			Result:          "OK",
+			Tester:          "qa",
+		},
+	}
+	converter := NewMarkdownToSpreadsheet(&mockCaseParser{cases: cases})
+
+	var workbook bytes.Buffer
+	sources := []Source{{Name: "notes.md", Reader: strings.NewReader("")}}
+	if err := converter.Convert(sources, &workbook); err != nil {
+		t.Fatalf("Convert() returned an unexpected error: %v", err)
+	}
+
+	sheets, err := ReadWorkbookCases(workbook.Bytes())
+	if err != nil {
+		t.Fatalf("ReadWorkbookCases() returned an unexpected error: %v", err)
+	}
+	if len(sheets) != 1 || sheets[0].Name != "notes" {
+		t.Fatalf("unexpected sheets: %+v", sheets)
+	}
+	if !reflect.DeepEqual(sheets[0].Cases, cases) {
+		t.Fatalf("unexpected cases:\n got: %#v\nwant: %#v", sheets[0].Cases, cases)
+	}
+}
+
+func TestReadWorkbookCasesRoundTripsCheckpointVariants(t *testing.T) {
+	cases := []domain.Case{{
+		MajorItem:       "Setup",
+		MinorItem:       "Dependencies",
+		ValidationSteps: []string{"Install"},
+		Checkpoints:     []string{"- [x] Dash done", "+ [ ] Plus open", "* [X] Upper done", "-   [ ] Spaced dash", "Plain text"},
+	}}
+	var workbook bytes.Buffer
+	if err := NewMarkdownToSpreadsheet(&mockCaseParser{cases: cases}).Convert([]Source{{Name: "legacy.md", Reader: strings.NewReader("")}}, &workbook); err != nil {
+		t.Fatalf("Convert() returned an unexpected error: %v", err)
+	}
+	sheets, err := ReadWorkbookCases(workbook.Bytes())
+	if err != nil {
+		t.Fatalf("ReadWorkbookCases() returned an unexpected error: %v", err)
+	}
+
+	var markdown bytes.Buffer
+	if err := WriteMarkdown(&markdown, "Legacy", sheets[0].Cases); err != nil {
+		t.Fatalf("WriteMarkdown() returned an unexpected error: %v", err)
+	}
+	document, err := parser.ParseDocument(&markdown)
+	if err != nil {
+		t.Fatalf("ParseDocument() returned an unexpected error: %v", err)
+	}
+	if len(document.Diagnostics) != 0 {
+		t.Fatalf("unexpected diagnostics: %+v", document.Diagnostics)
+	}
+
+	expected := []string{"* [x] Dash done", "* [ ] Plus open", "* [x] Upper done", "* [ ] Spaced dash", "* [ ] Plain text"}
+	if len(document.Cases) != 1 || !reflect.DeepEqual(document.Cases[0].Checkpoints, expected) {
+		t.Fatalf("unexpected cases after the round trip: %+v", document.Cases)
+	}
+}
+
+func TestReadWorkbookCasesRoundTripsMergedStepCells(t *testing.T) {
+	inline := func(reference, text string) string {
+		return `<c r="` + reference + `" t="inlineStr"><is><t>` + text + `</t></is></c>`
+	}
+	worksheet := `<sheetData>` +
+		`<row r="1">` + inline("A1", "Major Item") + inline("B1", "Medium Item") + inline("C1", "Minor Item") + inline("D1", "Validation Steps") + inline("E1", "Checkpoints") + `</row>` +
+		`<row r="2">` + inline("A2", "Account") + inline("C2", "Sign in") + inline("D2", "1. Open the sign-in page") + inline("E2", "* [ ] Form is shown") + `</row>` +
+		`<row r="3">` + inline("C3", "Sign out") + `</row>` +
+		`</sheetData><mergeCells><mergeCell ref="A2:A3"/><mergeCell ref="D2:E3"/></mergeCells>`
+	sheets, err := ReadWorkbookCases(workbookWithSheet(t, worksheet))
+	if err != nil {
+		t.Fatalf("ReadWorkbookCases() returned an unexpected error: %v", err)
+	}
+
+	var markdown bytes.Buffer
+	if err := WriteMarkdown(&markdown, "", sheets[0].Cases); err != nil {
+		t.Fatalf("WriteMarkdown() returned an unexpected error: %v", err)
+	}
+	cases, err := parser.Parse(&markdown)
+	if err != nil {
+		t.Fatalf("Parse() returned an unexpected error: %v", err)
+	}
+
+	if len(cases) != 2 || cases[1].MajorItem != "Account" || cases[1].MinorItem != "Sign out" {
+		t.Fatalf("expected the major item to span both cases, got %+v", cases)
+	}
+	if !reflect.DeepEqual(cases[0].ValidationSteps, []string{"Open the sign-in page"}) || !reflect.DeepEqual(cases[0].Checkpoints, []string{"* [ ] Form is shown"}) {
+		t.Fatalf("unexpected steps of the first case: %+v", cases[0])
+	}
+	if len(cases[1].ValidationSteps) != 0 || len(cases[1].Checkpoints) != 0 {
+		t.Fatalf("merged step and checkpoint cells were repeated: %+v", cases[1])
+	}
+}
+
+func TestReadWorkbookCasesHandlesMergedCellsAndRichText(t *testing.T) {
+	files := map[string]string{
+		"xl/workbook.xml":            `<workbook xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main" xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships"><sheets><sheet name="Legacy" sheetId="1" r:id="rId1"/></sheets></workbook>`,
+		"xl/_rels/workbook.xml.rels": `<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships"><Relationship Id="rId1" Type="worksheet" Target="/xl/worksheets/legacy.xml"/></Relationships>`,
+		"xl/sharedStrings.xml": `<sst xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main">` +
+			`<si><t>Major Item</t></si><si><t>Medium Item</t></si><si><t>Minor Item</t></si><si><t>Validation Steps</t></si><si><t>Checkpoints</t></si>` +
+			`<si><r><t>Set</t></r><r><t>up</t></r></si><si><t>Environment</t></si></sst>`,
+		"xl/worksheets/legacy.xml": `<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main"><sheetData>` +
+			`<row r="1"><c r="A1" t="s"><v>0</v></c><c r="B1" t="s"><v>1</v></c><c r="C1" t="s"><v>2</v></c><c r="D1" t="s"><v>3</v></c><c r="E1" t="s"><v>4</v></c></row>` +
+			`<row r="2"><c r="A2" t="s"><v>5</v></c><c r="B2" t="s"><v>6</v></c><c r="C2" t="inlineStr"><is><t>Dependencies</t></is></c><c r="D2" t="inlineStr"><is><t>1. Install
+2. Verify</t></is></c><c r="E2" t="inlineStr"><is><t>Installed</t></is></c></row>` +
+			`<row r="3"><c r="C3" t="inlineStr"><is><t>Variables</t></is></c><c r="E3" t="inlineStr"><is><t>* [x] Exported</t></is></c></row>` +
+			`</sheetData><mergeCells count="2"><mergeCell ref="A2:A3"/><mergeCell ref="B2:B3"/></mergeCells></worksheet>`,
+	}
+
+	var buffer bytes.Buffer
+	zipWriter := zip.NewWriter(&buffer)
+	for name, content := range files {
+		if err := writeZipFile(zipWriter, name, content); err != nil {
+			t.Fatalf("write %s: %v", name, err)
+		}
+	}
+	if err := zipWriter.Close(); err != nil {
+		t.Fatalf("close zip: %v", err)
+	}
+
+	sheets, err := ReadWorkbookCases(buffer.Bytes())
+	if err != nil {
+		t.Fatalf("ReadWorkbookCases() returned an unexpected error: %v", err)
+	}
+
+	expected := []domain.Case{
+		{
+			MajorItem:       "Setup",
+			MediumItem:      "Environment",
+			MinorItem:       "Dependencies",
+			ValidationSteps: []string{"Install", "Verify"},
+			Checkpoints:     []string{"* [ ] Installed"},
+		},
+		{
+			MajorItem:   "Setup",
+			MediumItem:  "Environment",
+			MinorItem:   "Variables",
+			Checkpoints: []string{"* [x] Exported"},
+		},
+	}
+	if len(sheets) != 1 || sheets[0].Name != "Legacy" {
+		t.Fatalf("unexpected sheets: %+v", sheets)
+	}
+	if !reflect.DeepEqual(sheets[0].Cases, expected) {
+		t.Fatalf("unexpected cases:\n got: %#v\nwant: %#v", sheets[0].Cases, expected)
+	}
+}
+
+// workbookWithSheet zips a minimal workbook around a single worksheet, and
+// lets extra add entries of its own.
+func workbookWithSheet(t *testing.T, worksheet string, extra ...func(*zip.Writer) error) []byte {
+	t.Helper()
+
+	files := map[string]string{
+		"xl/workbook.xml":            `<workbook xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main" xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships"><sheets><sheet name="Sheet" sheetId="1" r:id="rId1"/></sheets></workbook>`,
+		"xl/_rels/workbook.xml.rels": `<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships"><Relationship Id="rId1" Type="worksheet" Target="worksheets/sheet1.xml"/></Relationships>`,
+		"xl/worksheets/sheet1.xml":   `<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main">` + worksheet + `</worksheet>`,
+	}
+	var buffer bytes.Buffer
+	zipWriter := zip.NewWriter(&buffer)
+	for name, content := range files {
+		if err := writeZipFile(zipWriter, name, content); err != nil {
+			t.Fatalf("write %s: %v", name, err)
+		}
+	}
+	for _, add := range extra {
+		if err := add(zipWriter); err != nil {
+			t.Fatalf("add entry: %v", err)
+		}
+	}
+	if err := zipWriter.Close(); err != nil {
+		t.Fatalf("close zip: %v", err)
+	}
+	return buffer.Bytes()
+}
+
+func TestReadWorkbookCasesRejectsOversizedSheets(t *testing.T) {
+	cell := `<c r="A1" t="inlineStr"><is><t>Major Item</t></is></c>`
+	for name, worksheet := range map[string]string{
+		"whole sheet merged":   `<sheetData><row r="1">` + cell + `</row></sheetData><mergeCells><mergeCell ref="A1:XFD1048576"/></mergeCells>`,
+		"merge beyond limits":  `<sheetData><row r="1">` + cell + `</row></sheetData><mergeCells><mergeCell ref="A1:XFE2"/></mergeCells>`,
+		"too many merged":      `<sheetData><row r="1">` + cell + `</row></sheetData><mergeCells><mergeCell ref="A1:Z200000"/></mergeCells>`,
+		"column beyond limits": `<sheetData><row r="1"><c r="XFE1" t="inlineStr"><is><t>x</t></is></c></row></sheetData>`,
+		"row beyond limits":    `<sheetData><row r="1048577"><c t="inlineStr"><is><t>x</t></is></c></row></sheetData>`,
+	} {
+		if _, err := ReadWorkbookCases(workbookWithSheet(t, worksheet)); err == nil {
+			t.Fatalf("%s: expected an error", name)
+		}
+	}
+	// Each row reaching the last column allocates 16384 cells.
+	var wide strings.Builder
+	wide.WriteString(`<sheetData>`)
+	for row := 1; row <= 300; row++ {
+		fmt.Fprintf(&wide, `<row r="%d"><c r="XFD%d" t="inlineStr"><is><t>x</t></is></c></row>`, row, row)
+	}
+	wide.WriteString(`</sheetData>`)
+	if _, err := ReadWorkbookCases(workbookWithSheet(t, wide.String())); !errors.Is(err, errWorkbookTooLarge) {
+		t.Fatalf("too many cells: expected errWorkbookTooLarge, got %v", err)
+	}
+}
+
+func TestReadWorkbookCasesRejectsOversizedParts(t *testing.T) {
+	sheet := `<sheetData><row r="1"><c r="A1" t="s"><v>0</v></c></row></sheetData>`
+	// rawSharedStrings stores data as sharedStrings.xml under header,
+	// which need not tell the truth about the size.
+	rawSharedStrings := func(header zip.FileHeader, data []byte) func(*zip.Writer) error {
+		return func(zipWriter *zip.Writer) error {
+			header.Name = "xl/sharedStrings.xml"
+			header.CRC32 = crc32.ChecksumIEEE(data)
+			header.CompressedSize64 = uint64(len(data))
+			writer, err := zipWriter.CreateRaw(&header)
+			if err != nil {
+				return err
+			}
+			_, err = writer.Write(data)
+			return err
+		}
+	}
+
+	declared := rawSharedStrings(zip.FileHeader{Method: zip.Store, UncompressedSize64: xlsxMaxPartBytes + 1}, []byte(`<sst/>`))
+	if _, err := ReadWorkbookCases(workbookWithSheet(t, sheet, declared)); !errors.Is(err, errWorkbookPartTooLarge) {
+		t.Fatalf("declared size: expected errWorkbookPartTooLarge, got %v", err)
+	}
+
+	// The header claims a size within the cap, but the entry inflates past it.
+	var compressed bytes.Buffer
+	deflater, err := flate.NewWriter(&compressed, flate.BestSpeed)
+	if err != nil {
+		t.Fatalf("create deflater: %v", err)
+	}
+	deflater.Write([]byte(`<sst xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main">`))
+	deflater.Write(bytes.Repeat([]byte(" "), xlsxMaxPartBytes))
+	deflater.Write([]byte(`<si><t>x</t></si></sst>`))
+	if err := deflater.Close(); err != nil {
+		t.Fatalf("close deflater: %v", err)
+	}
+	understated := rawSharedStrings(zip.FileHeader{Method: zip.Deflate, UncompressedSize64: xlsxMaxPartBytes}, compressed.Bytes())
+	if _, err := ReadWorkbookCases(workbookWithSheet(t, sheet, understated)); err == nil {
+		t.Fatal("understated size: expected an error")
+	}
+}
+
+func TestWriteMarkdown(t *testing.T) {
+	cases := []domain.Case{
+		{MajorItem: "Setup", MediumItem: "Environment", MinorItem: "Dependencies", ValidationSteps: []string{"Install"}, Checkpoints: []string{"* [ ] Installed"}},
+		{MajorItem: "Setup", MediumItem: "Environment", MinorItem: "Variables", ValidationSteps: []string{"Export"}},
+		{MajorItem: "Execution", MediumItem: "Workflow", MinorItem: "Run", Checkpoints: []string{"* [x] Exit code is 0"}},
+	}
+
+	var output bytes.Buffer
+	if err := WriteMarkdown(&output, "Inspection Sheet", cases); err != nil {
+		t.Fatalf("WriteMarkdown() returned an unexpected error: %v", err)
+	}
+
+	expected := `# Inspection Sheet
+
+## Setup
+### Environment
+#### Dependencies
+
+1. Install
+* [ ] Installed
+
+#### Variables
+
+1. Export
+
+## Execution
+### Workflow
+#### Run
+
+* [x] Exit code is 0
+`
+	if output.String() != expected {
+		t.Fatalf("unexpected markdown:\n%s", output.String())
+	}
+}
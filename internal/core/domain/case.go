@@ -7,4 +7,41 @@ type Case struct {
 	MinorItem       string
 	ValidationSteps []string
 	Checkpoints     []string
+	// Steps pairs each validation step with the checkpoints nested under it
+	// (an indented "* [ ]" below "1. ..."). ValidationSteps and Checkpoints
+	// still list every step and checkpoint, so outputs that do not need the
+	// pairing can ignore Steps.
+	Steps []Step
+
+	// Preconditions, ExpectedResults, Priority, TestType, Tags and Effort
+	// come from definition lines such as "Priority: P1" or "Tags: smoke,
+	// regression", or from sub-sections, under the minor item heading.
+	Preconditions   []string
+	ExpectedResults []string
+	Priority        string
+	TestType        string
+	Tags            []string
+	// Effort is the estimated time to run the case as written, e.g. "30m".
+	Effort string
+
+	// Execution fields are empty when parsed from Markdown and are filled in
+	// when results recorded elsewhere are merged into the case.
+	Result   string
+	TestDate string
+	Tester   string
+	Notes    string
+
+	// Line and EndLine are the 1-based source lines spanned by the case, from
+	// its minor item heading to the last line that belongs to it. Zero when
+	// unknown.
+	Line    int
+	EndLine int
+}
+
+// Step is one validation step with the checkpoints that verify it.
+type Step struct {
+	Action string
+	// Expectations holds checkpoint lines with their marker, like
+	// Case.Checkpoints.
+	Expectations []string
 }
@@ -0,0 +1,24 @@
+package domain
+
+// Severity ranks how serious a diagnostic is.
+type Severity string
+
+const (
+	SeverityError   Severity = "error"
+	SeverityWarning Severity = "warning"
+	SeverityInfo    Severity = "info"
+)
+
+// Diagnostic points at a source line that was ignored or looks suspicious.
+type Diagnostic struct {
+	Line     int      `json:"line"`
+	Severity Severity `json:"severity"`
+	Message  string   `json:"message"`
+}
+
+// Document is the parsed form of a Markdown checklist.
+type Document struct {
+	Title       string
+	Cases       []Case
+	Diagnostics []Diagnostic
+}
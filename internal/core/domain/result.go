@@ -0,0 +1,51 @@
+package domain
+
+import (
+	"strings"
+	"time"
+)
+
+// CaseResult records how a single case went during a test run. Cases are
+// matched to results by their Major/Medium/Minor hierarchy.
+type CaseResult struct {
+	MajorItem  string `json:"majorItem"`
+	MediumItem string `json:"mediumItem,omitempty"`
+	MinorItem  string `json:"minorItem"`
+	// Checkpoints holds the checkpoint lines with the state the tester
+	// ticked, e.g. "* [x] Exit code is 0".
+	Checkpoints []string `json:"checkpoints,omitempty"`
+	Result      string   `json:"result,omitempty"`
+	TestDate    string   `json:"testDate,omitempty"`
+	Tester      string   `json:"tester,omitempty"`
+	Notes       string   `json:"notes,omitempty"`
+}
+
+// Key identifies the case the result belongs to.
+func (r CaseResult) Key() string {
+	return caseKey(r.MajorItem, r.MediumItem, r.MinorItem)
+}
+
+// Run is the set of results recorded while executing one checklist.
+type Run struct {
+	// Source is the Markdown file the run was recorded against.
+	Source string       `json:"source,omitempty"`
+	Cases  []CaseResult `json:"cases"`
+}
+
+// NamedRun is one execution of a checklist set, kept under its name so later
+// exports can fill in the results again.
+type NamedRun struct {
+	Name     string    `json:"name"`
+	Recorded time.Time `json:"recorded"`
+	// Runs holds the results of each checklist of the set.
+	Runs []Run `json:"runs"`
+}
+
+// Key identifies the case by its position in the heading hierarchy.
+func (c Case) Key() string {
+	return caseKey(c.MajorItem, c.MediumItem, c.MinorItem)
+}
+
+func caseKey(major, medium, minor string) string {
+	return strings.Join([]string{major, medium, minor}, " / ")
+}
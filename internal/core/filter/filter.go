@@ -0,0 +1,463 @@
+// Package filter selects cases with expressions such as
+// `tag:smoke && priority<=P2` or hierarchy paths such as
+// "Setup/Environment/*".
+package filter
+
+import (
+	"fmt"
+	"path"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/9renpoto/casemd/internal/core/domain"
+)
+
+// Filter matches cases. The zero Filter matches every case.
+type Filter struct {
+	root node
+}
+
+// Match reports whether aCase is selected.
+func (f Filter) Match(aCase domain.Case) bool {
+	return f.root == nil || f.root.match(aCase)
+}
+
+// Apply returns the selected cases in their original order.
+func (f Filter) Apply(cases []domain.Case) []domain.Case {
+	if f.root == nil {
+		return cases
+	}
+	selected := make([]domain.Case, 0, len(cases))
+	for _, aCase := range cases {
+		if f.root.match(aCase) {
+			selected = append(selected, aCase)
+		}
+	}
+	return selected
+}
+
+// And returns a filter matching the cases both f and other match.
+func (f Filter) And(other Filter) Filter {
+	switch {
+	case f.root == nil:
+		return other
+	case other.root == nil:
+		return f
+	}
+	return Filter{root: andNode{f.root, other.root}}
+}
+
+// Fields lists the fields an expression can test.
+func Fields() []string {
+	return []string{"tag", "priority", "type", "major", "medium", "minor", "path", "result", "date", "tester"}
+}
+
+// Parse compiles an expression made of terms joined by "&&", "||", "!" and
+// parentheses. A term compares a field with a value: "field:value" (or "=")
+// matches case-insensitively and accepts "*" and "?" wildcards, "!=" negates
+// it, and "<", "<=", ">" and ">=" order priorities by their number (P1 < P2)
+// and dates as written. "tag" matches when any tag does, and "path" matches
+// the hierarchy like Select. Values with spaces or operators are quoted. An
+// empty expression matches every case.
+func Parse(expression string) (Filter, error) {
+	if strings.TrimSpace(expression) == "" {
+		return Filter{}, nil
+	}
+	p := &exprParser{input: []rune(expression)}
+	root, err := p.parseOr()
+	if err != nil {
+		return Filter{}, err
+	}
+	if next := p.peek(); next.kind != tokenEnd {
+		return Filter{}, p.unexpected(next)
+	}
+	return Filter{root: root}, nil
+}
+
+// Select compiles a hierarchy path such as "Setup/Environment/*": one
+// segment each for the major, medium and minor item, matched
+// case-insensitively with "*" and "?" wildcards. A shorter path selects
+// everything below it, so "Setup" selects every case of that major item.
+// A case without a medium item has an empty middle segment, which "*"
+// matches. An empty path matches every case.
+func Select(pattern string) (Filter, error) {
+	if strings.TrimSpace(pattern) == "" {
+		return Filter{}, nil
+	}
+	term, err := newPathTerm(pattern)
+	if err != nil {
+		return Filter{}, err
+	}
+	return Filter{root: term}, nil
+}
+
+type node interface {
+	match(aCase domain.Case) bool
+}
+
+type andNode struct{ left, right node }
+
+func (n andNode) match(aCase domain.Case) bool { return n.left.match(aCase) && n.right.match(aCase) }
+
+type orNode struct{ left, right node }
+
+func (n orNode) match(aCase domain.Case) bool { return n.left.match(aCase) || n.right.match(aCase) }
+
+type notNode struct{ operand node }
+
+func (n notNode) match(aCase domain.Case) bool { return !n.operand.match(aCase) }
+
+// term compares one field of a case with a value.
+type term struct {
+	field    string
+	operator string
+	value    string
+}
+
+func (t term) match(aCase domain.Case) bool {
+	values := fieldValues(t.field, aCase)
+	switch t.operator {
+	case ":", "=":
+		return anyGlob(t.value, values)
+	case "!=":
+		return !anyGlob(t.value, values)
+	}
+	for _, value := range values {
+		if value == "" {
+			continue
+		}
+		order := compareValues(t.field, value, t.value)
+		switch t.operator {
+		case "<":
+			return order < 0
+		case "<=":
+			return order <= 0
+		case ">":
+			return order > 0
+		case ">=":
+			return order >= 0
+		}
+	}
+	return false
+}
+
+func fieldValues(field string, aCase domain.Case) []string {
+	switch field {
+	case "tag":
+		return aCase.Tags
+	case "priority":
+		return []string{aCase.Priority}
+	case "type":
+		return []string{aCase.TestType}
+	case "major":
+		return []string{aCase.MajorItem}
+	case "medium":
+		return []string{aCase.MediumItem}
+	case "minor":
+		return []string{aCase.MinorItem}
+	case "result":
+		return []string{aCase.Result}
+	case "date":
+		return []string{aCase.TestDate}
+	case "tester":
+		return []string{aCase.Tester}
+	}
+	return nil
+}
+
+// anyGlob reports whether pattern matches one of values, ignoring case.
+func anyGlob(pattern string, values []string) bool {
+	pattern = strings.ToLower(pattern)
+	for _, value := range values {
+		if matched, _ := path.Match(pattern, strings.ToLower(value)); matched {
+			return true
+		}
+	}
+	return false
+}
+
+var rankRegex = regexp.MustCompile(`^\pL*\s*(\d+)$`)
+
+// compareValues orders two values of field. Priorities that end in a number
+// compare by it, so P2 < P10; anything else compares as text.
+func compareValues(field, a, b string) int {
+	if field == "priority" {
+		rankA, okA := rank(a)
+		rankB, okB := rank(b)
+		if okA && okB {
+			return rankA - rankB
+		}
+	}
+	return strings.Compare(strings.ToLower(a), strings.ToLower(b))
+}
+
+func rank(value string) (int, bool) {
+	match := rankRegex.FindStringSubmatch(strings.TrimSpace(value))
+	if match == nil {
+		return 0, false
+	}
+	n, err := strconv.Atoi(match[1])
+	return n, err == nil
+}
+
+// pathTerm matches the major/medium/minor hierarchy segment by segment.
+type pathTerm struct {
+	segments []string
+}
+
+func newPathTerm(pattern string) (pathTerm, error) {
+	segments := strings.Split(strings.ToLower(strings.Trim(strings.TrimSpace(pattern), "/")), "/")
+	if len(segments) > 3 {
+		return pathTerm{}, fmt.Errorf("path %q has more than three segments (major/medium/minor)", pattern)
+	}
+	for _, segment := range segments {
+		if _, err := path.Match(segment, ""); err != nil {
+			return pathTerm{}, fmt.Errorf("path %q: %w", pattern, err)
+		}
+	}
+	return pathTerm{segments: segments}, nil
+}
+
+func (t pathTerm) match(aCase domain.Case) bool {
+	hierarchy := []string{aCase.MajorItem, aCase.MediumItem, aCase.MinorItem}
+	for i, segment := range t.segments {
+		if matched, _ := path.Match(segment, strings.ToLower(hierarchy[i])); !matched {
+			return false
+		}
+	}
+	return true
+}
+
+type tokenKind int
+
+const (
+	tokenEnd tokenKind = iota
+	tokenAnd
+	tokenOr
+	tokenNot
+	tokenOpen
+	tokenClose
+	tokenTerm
+)
+
+type token struct {
+	kind   tokenKind
+	text   string
+	column int
+}
+
+// exprParser is a recursive descent parser over the runes of an expression.
+type exprParser struct {
+	input []rune
+	pos   int
+	next  *token
+}
+
+func (p *exprParser) parseOr() (node, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	for p.peek().kind == tokenOr {
+		p.take()
+		right, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		left = orNode{left, right}
+	}
+	return left, nil
+}
+
+func (p *exprParser) parseAnd() (node, error) {
+	left, err := p.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+	for p.peek().kind == tokenAnd {
+		p.take()
+		right, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		left = andNode{left, right}
+	}
+	return left, nil
+}
+
+func (p *exprParser) parseUnary() (node, error) {
+	next := p.peek()
+	switch next.kind {
+	case tokenNot:
+		p.take()
+		operand, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return notNode{operand}, nil
+	case tokenOpen:
+		p.take()
+		inner, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if closing := p.peek(); closing.kind != tokenClose {
+			return nil, p.unexpected(closing)
+		}
+		p.take()
+		return inner, nil
+	case tokenTerm:
+		p.take()
+		return p.parseTerm(next)
+	}
+	return nil, p.unexpected(next)
+}
+
+var (
+	operatorRegex  = regexp.MustCompile(`^([A-Za-z]+)\s*(!=|<=|>=|[:=<>])`)
+	fieldNameRegex = regexp.MustCompile(`^[A-Za-z]+$`)
+)
+
+func (p *exprParser) parseTerm(t token) (node, error) {
+	match := operatorRegex.FindStringSubmatch(t.text)
+	if match == nil {
+		return nil, fmt.Errorf("expected field:value at column %d, got %q", t.column, t.text)
+	}
+	field, operator := strings.ToLower(match[1]), match[2]
+	if field == "tags" {
+		field = "tag"
+	}
+	if !isField(field) {
+		return nil, fmt.Errorf("unknown field %q at column %d (available: %s)", match[1], t.column, strings.Join(Fields(), ", "))
+	}
+
+	value, err := unquote(strings.TrimSpace(t.text[len(match[0]):]))
+	if err != nil {
+		return nil, fmt.Errorf("%v at column %d", err, t.column)
+	}
+	if value == "" && !strings.ContainsAny(t.text[len(match[0]):], `"'`) {
+		return nil, fmt.Errorf("missing value after %s%s at column %d", match[1], operator, t.column)
+	}
+
+	if field == "path" {
+		if operator != ":" && operator != "=" {
+			return nil, fmt.Errorf("path only supports \":\" at column %d", t.column)
+		}
+		return newPathTerm(value)
+	}
+	if strings.ContainsAny(operator, "<>") && field != "priority" && field != "date" {
+		return nil, fmt.Errorf("%s cannot be ordered with %s at column %d; only priority and date can", field, operator, t.column)
+	}
+	if _, err := path.Match(strings.ToLower(value), ""); err != nil {
+		return nil, fmt.Errorf("%s at column %d: %w", field, t.column, err)
+	}
+	return term{field: field, operator: operator, value: value}, nil
+}
+
+func isField(name string) bool {
+	for _, field := range Fields() {
+		if field == name {
+			return true
+		}
+	}
+	return false
+}
+
+func unquote(value string) (string, error) {
+	if value == "" || (value[0] != '"' && value[0] != '\'') {
+		return value, nil
+	}
+	if len(value) < 2 || value[len(value)-1] != value[0] {
+		return "", fmt.Errorf("unterminated quote")
+	}
+	return value[1 : len(value)-1], nil
+}
+
+func (p *exprParser) unexpected(t token) error {
+	if t.kind == tokenEnd {
+		return fmt.Errorf("unexpected end of expression")
+	}
+	return fmt.Errorf("unexpected %q at column %d", t.text, t.column)
+}
+
+func (p *exprParser) peek() token {
+	if p.next == nil {
+		next := p.scan()
+		p.next = &next
+	}
+	return *p.next
+}
+
+func (p *exprParser) take() token {
+	next := p.peek()
+	p.next = nil
+	return next
+}
+
+// scan reads the next token. A term runs from a field name to the end of
+// its value: up to the closing quote, or else up to whitespace, a
+// parenthesis, "&&" or "||".
+func (p *exprParser) scan() token {
+	for p.pos < len(p.input) && unicode.IsSpace(p.input[p.pos]) {
+		p.pos++
+	}
+	start := p.pos
+	if start >= len(p.input) {
+		return token{kind: tokenEnd, column: start + 1}
+	}
+
+	emit := func(kind tokenKind, width int) token {
+		p.pos += width
+		return token{kind: kind, text: string(p.input[start:p.pos]), column: start + 1}
+	}
+	switch {
+	case p.hasPrefix("&&"):
+		return emit(tokenAnd, 2)
+	case p.hasPrefix("||"):
+		return emit(tokenOr, 2)
+	case p.input[start] == '!':
+		return emit(tokenNot, 1)
+	case p.input[start] == '(':
+		return emit(tokenOpen, 1)
+	case p.input[start] == ')':
+		return emit(tokenClose, 1)
+	}
+
+	var quote rune
+	for p.pos < len(p.input) {
+		r := p.input[p.pos]
+		if quote != 0 {
+			if r == quote {
+				quote = 0
+			}
+			p.pos++
+			continue
+		}
+		if unicode.IsSpace(r) && !p.beforeValue(start) || r == '(' || r == ')' || p.hasPrefix("&&") || p.hasPrefix("||") {
+			break
+		}
+		if r == '"' || r == '\'' {
+			quote = r
+		}
+		p.pos++
+	}
+	return token{kind: tokenTerm, text: string(p.input[start:p.pos]), column: start + 1}
+}
+
+// beforeValue reports whether the term starting at start has not reached
+// its value yet, so spaces around the operator, as in "priority <= P2",
+// stay part of the term.
+func (p *exprParser) beforeValue(start int) bool {
+	text := strings.TrimRightFunc(string(p.input[start:p.pos]), unicode.IsSpace)
+	if match := operatorRegex.FindStringIndex(text); match != nil {
+		return match[1] == len(text)
+	}
+	rest := strings.TrimLeftFunc(string(p.input[p.pos:]), unicode.IsSpace)
+	return fieldNameRegex.MatchString(text) && strings.IndexAny(rest, ":=<>!") == 0
+}
+
+func (p *exprParser) hasPrefix(prefix string) bool {
+	return strings.HasPrefix(string(p.input[p.pos:]), prefix)
+}
@@ -0,0 +1,96 @@
+package filter
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/9renpoto/casemd/internal/core/domain"
+)
+
+var cases = []domain.Case{
+	{MajorItem: "Setup", MediumItem: "Environment", MinorItem: "Dependencies", Priority: "P1", Tags: []string{"smoke"}},
+	{MajorItem: "Setup", MediumItem: "Environment", MinorItem: "Variables", Priority: "P3", Tags: []string{"regression"}},
+	{MajorItem: "Setup", MinorItem: "Sign in page", Priority: "P10", TestType: "UI", Tags: []string{"smoke", "ui"}},
+	{MajorItem: "Execution", MediumItem: "Workflow", MinorItem: "CLI run", Result: "NG", TestDate: "2024-05-02"},
+}
+
+func minors(selected []domain.Case) []string {
+	names := []string{}
+	for _, aCase := range selected {
+		names = append(names, aCase.MinorItem)
+	}
+	return names
+}
+
+func TestParse(t *testing.T) {
+	for expression, expected := range map[string][]string{
+		"":                          {"Dependencies", "Variables", "Sign in page", "CLI run"},
+		"tag:smoke && priority<=P2": {"Dependencies"},
+		"tags:SMOKE":                {"Dependencies", "Sign in page"},
+		"priority > P2":             {"Variables", "Sign in page"},
+		"priority >= P3 && !tag:ui": {"Variables"},
+		"tag:smoke || result:ng":    {"Dependencies", "Sign in page", "CLI run"},
+		"(tag:smoke || tag:regression) && medium:env*": {"Dependencies", "Variables"},
+		`minor:"sign in *"`:                            {"Sign in page"},
+		"tag!=smoke":                                   {"Variables", "CLI run"},
+		`priority:""`:                                  {"CLI run"},
+		"path:Setup/*/sign*":                           {"Sign in page"},
+		"date>=2024-05-01 && type!=ui":                 {"CLI run"},
+	} {
+		selected, err := Parse(expression)
+		if err != nil {
+			t.Fatalf("Parse(%q) returned an unexpected error: %v", expression, err)
+		}
+		if names := minors(selected.Apply(cases)); !reflect.DeepEqual(names, expected) {
+			t.Fatalf("Parse(%q) selected %v, want %v", expression, names, expected)
+		}
+	}
+}
+
+func TestParseReportsErrors(t *testing.T) {
+	for expression, message := range map[string]string{
+		"owner:me":         `unknown field "owner" at column 1 (available: tag, priority, type, major, medium, minor, path, result, date, tester)`,
+		"tag:smoke &&":     "unexpected end of expression",
+		"tag:smoke && ) ":  `unexpected ")" at column 14`,
+		"(tag:smoke":       "unexpected end of expression",
+		"tag<smoke":        "tag cannot be ordered with < at column 1; only priority and date can",
+		"smoke":            `expected field:value at column 1, got "smoke"`,
+		"tag:":             "missing value after tag: at column 1",
+		`minor:"sign in`:   "unterminated quote at column 1",
+		"path:a/b/c/d":     `path "a/b/c/d" has more than three segments (major/medium/minor)`,
+		"tag:smoke tag:ui": `unexpected "tag:ui" at column 11`,
+	} {
+		_, err := Parse(expression)
+		if err == nil || err.Error() != message {
+			t.Fatalf("Parse(%q) returned %v, want %q", expression, err, message)
+		}
+	}
+}
+
+func TestSelect(t *testing.T) {
+	for pattern, expected := range map[string][]string{
+		"Setup/Environment/*":  {"Dependencies", "Variables"},
+		"setup":                {"Dependencies", "Variables", "Sign in page"},
+		"Setup/*/Sign in page": {"Sign in page"},
+		"/Execution/":          {"CLI run"},
+	} {
+		selected, err := Select(pattern)
+		if err != nil {
+			t.Fatalf("Select(%q) returned an unexpected error: %v", pattern, err)
+		}
+		if names := minors(selected.Apply(cases)); !reflect.DeepEqual(names, expected) {
+			t.Fatalf("Select(%q) selected %v, want %v", pattern, names, expected)
+		}
+	}
+}
+
+func TestAnd(t *testing.T) {
+	expression, _ := Parse("tag:smoke")
+	selection, _ := Select("Setup/Environment")
+	if names := minors(expression.And(selection).Apply(cases)); !reflect.DeepEqual(names, []string{"Dependencies"}) {
+		t.Fatalf("unexpected selection: %v", names)
+	}
+	if names := minors(Filter{}.And(selection).Apply(cases)); !reflect.DeepEqual(names, []string{"Dependencies", "Variables"}) {
+		t.Fatalf("unexpected selection: %v", names)
+	}
+}
@@ -0,0 +1,43 @@
+package lint
+
+import (
+	"fmt"
+
+	"github.com/9renpoto/casemd/internal/core/domain"
+)
+
+// Check reviews parsed cases for content that converts fine but is likely a
+// mistake, such as cases without steps or duplicated minor items.
+func Check(cases []domain.Case) []domain.Diagnostic {
+	var diagnostics []domain.Diagnostic
+	seen := make(map[[3]string]int)
+
+	for _, aCase := range cases {
+		report := func(severity domain.Severity, format string, args ...any) {
+			diagnostics = append(diagnostics, domain.Diagnostic{
+				Line:     aCase.Line,
+				Severity: severity,
+				Message:  fmt.Sprintf(format, args...),
+			})
+		}
+
+		if aCase.MinorItem == "" {
+			report(domain.SeverityError, "minor item heading is empty")
+		}
+		if len(aCase.ValidationSteps) == 0 {
+			report(domain.SeverityWarning, "%q has no validation steps", aCase.MinorItem)
+		}
+		if len(aCase.Checkpoints) == 0 {
+			report(domain.SeverityWarning, "%q has no checkpoints", aCase.MinorItem)
+		}
+
+		key := [3]string{aCase.MajorItem, aCase.MediumItem, aCase.MinorItem}
+		if line, exists := seen[key]; exists {
+			report(domain.SeverityWarning, "%q duplicates the minor item on line %d", aCase.MinorItem, line)
+		} else {
+			seen[key] = aCase.Line
+		}
+	}
+
+	return diagnostics
+}
@@ -0,0 +1,28 @@
+package lint
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/9renpoto/casemd/internal/core/domain"
+)
+
+func TestCheck(t *testing.T) {
+	cases := []domain.Case{
+		{MajorItem: "Setup", MinorItem: "Complete", ValidationSteps: []string{"Step"}, Checkpoints: []string{"* [ ] Check"}, Line: 3},
+		{MajorItem: "Setup", MinorItem: "No steps", Checkpoints: []string{"* [ ] Check"}, Line: 7},
+		{MajorItem: "Setup", MinorItem: "Complete", ValidationSteps: []string{"Step"}, Checkpoints: []string{"* [ ] Check"}, Line: 10},
+		{MajorItem: "Setup", ValidationSteps: []string{"Step"}, Line: 14},
+	}
+
+	expected := []domain.Diagnostic{
+		{Line: 7, Severity: domain.SeverityWarning, Message: `"No steps" has no validation steps`},
+		{Line: 10, Severity: domain.SeverityWarning, Message: `"Complete" duplicates the minor item on line 3`},
+		{Line: 14, Severity: domain.SeverityError, Message: "minor item heading is empty"},
+		{Line: 14, Severity: domain.SeverityWarning, Message: `"" has no checkpoints`},
+	}
+
+	if diagnostics := Check(cases); !reflect.DeepEqual(diagnostics, expected) {
+		t.Fatalf("Check() = %#v, want %#v", diagnostics, expected)
+	}
+}
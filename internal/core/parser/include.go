@@ -0,0 +1,95 @@
+package parser
+
+import (
+	"fmt"
+	"io"
+	"path"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/9renpoto/casemd/internal/core/domain"
+)
+
+var (
+	includeRegex    = regexp.MustCompile(`^<!--\s*include:\s*(.*?)\s*-->$`)
+	snippetRegex    = regexp.MustCompile(`^<!--\s*snippet:\s*(.*?)\s*-->$`)
+	snippetEndRegex = regexp.MustCompile(`^<!--\s*(?:/|end\s+)snippet\s*-->$`)
+)
+
+// Includes locates the documents named by include directives such as
+// "<!-- include: shared/login.md#Login -->".
+type Includes struct {
+	// Name is the slash-separated path of the parsed document; relative
+	// include paths resolve against its directory.
+	Name string
+	// Open opens the document at a slash-separated path. Without it only
+	// the snippets and minor items of the parsed document can be included.
+	Open func(name string) (io.ReadCloser, error)
+}
+
+// parsedDocument is a document together with the step snippets it defines.
+type parsedDocument struct {
+	document domain.Document
+	snippets map[string]domain.Case
+}
+
+// steps returns the snippet, else the first minor item, called name.
+func (d parsedDocument) steps(name string) (domain.Case, bool) {
+	if snippet, ok := d.snippets[name]; ok {
+		return snippet, true
+	}
+	for _, aCase := range d.document.Cases {
+		if aCase.MinorItem == name {
+			return aCase, true
+		}
+	}
+	return domain.Case{}, false
+}
+
+// includer expands the include directives of one parse.
+type includer struct {
+	includes Includes
+	// stack holds the documents being parsed, outermost first, to detect
+	// include cycles.
+	stack  []string
+	parsed map[string]parsedDocument
+}
+
+// document parses the document target refers to from the document named
+// current. It returns a problem instead when the document cannot be read.
+func (p *includer) document(current, target string) (string, parsedDocument, string) {
+	name := path.Join(path.Dir(current), target)
+	if p.includes.Open == nil {
+		return name, parsedDocument{}, fmt.Sprintf("include of %s cannot be resolved without the location of the document", target)
+	}
+	if i := slices.Index(p.stack, name); i >= 0 {
+		cycle := append(slices.Clone(p.stack[i:]), name)
+		return name, parsedDocument{}, "include cycle: " + strings.Join(cycle, " → ")
+	}
+	if parsed, ok := p.parsed[name]; ok {
+		return name, parsed, ""
+	}
+
+	file, err := p.includes.Open(name)
+	if err != nil {
+		return name, parsedDocument{}, fmt.Sprintf("cannot open included document %s: %v", name, err)
+	}
+	defer file.Close()
+	parsed, err := p.parse(file, name)
+	if err != nil {
+		return name, parsedDocument{}, fmt.Sprintf("cannot read included document %s: %v", name, err)
+	}
+	p.parsed[name] = parsed
+	return name, parsed, ""
+}
+
+// appendSteps adds the validation steps and checkpoints of from to target.
+func appendSteps(target *domain.Case, from domain.Case) {
+	target.ValidationSteps = append(target.ValidationSteps, from.ValidationSteps...)
+	target.Checkpoints = append(target.Checkpoints, from.Checkpoints...)
+	for _, step := range from.Steps {
+		step.Expectations = slices.Clone(step.Expectations)
+		target.Steps = append(target.Steps, step)
+	}
+}
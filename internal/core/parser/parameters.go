@@ -0,0 +1,152 @@
+package parser
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/9renpoto/casemd/internal/core/domain"
+)
+
+var (
+	tableSeparatorRegex = regexp.MustCompile(`^:?-+:?$`)
+	placeholderRegex    = regexp.MustCompile(`\{\{\s*([^{}]*?)\s*\}\}`)
+)
+
+// parameterTable is a Markdown table written under a minor item. The case is
+// expanded into one case per row, with the row's values replacing the
+// {{name}} placeholders of its steps and checkpoints.
+type parameterTable struct {
+	line  int
+	names []string
+	rows  [][]string
+	// separated reports whether the "| --- |" row below the header was
+	// read; closed whether a line that is not part of the table followed.
+	separated bool
+	closed    bool
+}
+
+// splitTableRow returns the trimmed cells of a "| a | b |" line.
+func splitTableRow(line string) []string {
+	line = strings.TrimSpace(line)
+	line = strings.TrimPrefix(line, "|")
+	line = strings.TrimSuffix(line, "|")
+	cells := strings.Split(line, "|")
+	for i, cell := range cells {
+		cells[i] = strings.TrimSpace(cell)
+	}
+	return cells
+}
+
+// add reads one line of the table and reports a problem, if any.
+func (t *parameterTable) add(line string) string {
+	cells := splitTableRow(line)
+	if t.names == nil {
+		for i, name := range cells {
+			if name == "" {
+				return "parameter table has a column without a name; line is ignored"
+			}
+			if slices.Contains(cells[:i], name) {
+				return fmt.Sprintf("parameter %q is listed more than once; line is ignored", name)
+			}
+		}
+		t.names = cells
+		return ""
+	}
+	if !t.separated && len(t.rows) == 0 && slices.IndexFunc(cells, func(cell string) bool { return !tableSeparatorRegex.MatchString(cell) }) < 0 {
+		t.separated = true
+		return ""
+	}
+	if len(cells) != len(t.names) {
+		return fmt.Sprintf("parameter row has %d cells but the table has %d columns; row is ignored", len(cells), len(t.names))
+	}
+	t.rows = append(t.rows, cells)
+	return ""
+}
+
+// expand returns aCase once per row of the table, or aCase itself without a
+// table, and reports the placeholders no column fills. Expanded cases share
+// no slices, so changing one leaves its siblings intact.
+func (t *parameterTable) expand(aCase domain.Case) ([]domain.Case, []string) {
+	if t == nil || len(t.names) == 0 {
+		return []domain.Case{aCase}, nil
+	}
+	if len(t.rows) == 0 {
+		return []domain.Case{aCase}, []string{"parameter table has no rows; the case is not expanded"}
+	}
+
+	var problems []string
+	for _, name := range placeholders(aCase) {
+		if !slices.Contains(t.names, name) {
+			problems = append(problems, fmt.Sprintf("placeholder {{%s}} has no column in the parameter table", name))
+		}
+	}
+
+	// A minor item without placeholders is told apart by its parameters.
+	named := placeholderRegex.MatchString(aCase.MinorItem)
+	cases := make([]domain.Case, 0, len(t.rows))
+	for _, row := range t.rows {
+		values := make(map[string]string, len(t.names))
+		pairs := make([]string, len(t.names))
+		for i, name := range t.names {
+			values[name] = row[i]
+			pairs[i] = name + ": " + row[i]
+		}
+		replace := func(text string) string {
+			return placeholderRegex.ReplaceAllStringFunc(text, func(placeholder string) string {
+				if value, ok := values[placeholderRegex.FindStringSubmatch(placeholder)[1]]; ok {
+					return value
+				}
+				return placeholder
+			})
+		}
+		replaceAll := func(texts []string) []string {
+			if texts == nil {
+				return nil
+			}
+			replaced := make([]string, len(texts))
+			for i, text := range texts {
+				replaced[i] = replace(text)
+			}
+			return replaced
+		}
+
+		expanded := aCase
+		expanded.MinorItem = replace(aCase.MinorItem)
+		if !named {
+			expanded.MinorItem += " (" + strings.Join(pairs, ", ") + ")"
+		}
+		expanded.ValidationSteps = replaceAll(aCase.ValidationSteps)
+		expanded.Checkpoints = replaceAll(aCase.Checkpoints)
+		expanded.Preconditions = replaceAll(aCase.Preconditions)
+		expanded.ExpectedResults = replaceAll(aCase.ExpectedResults)
+		expanded.Tags = slices.Clone(aCase.Tags)
+		if aCase.Steps != nil {
+			expanded.Steps = make([]domain.Step, len(aCase.Steps))
+			for i, step := range aCase.Steps {
+				expanded.Steps[i] = domain.Step{Action: replace(step.Action), Expectations: replaceAll(step.Expectations)}
+			}
+		}
+		cases = append(cases, expanded)
+	}
+	return cases, problems
+}
+
+// placeholders returns the distinct placeholder names used by aCase, in
+// order of appearance.
+func placeholders(aCase domain.Case) []string {
+	var names []string
+	texts := append([]string{aCase.MinorItem}, aCase.ValidationSteps...)
+	texts = append(texts, aCase.Checkpoints...)
+	texts = append(texts, aCase.Preconditions...)
+	texts = append(texts, aCase.ExpectedResults...)
+	for _, text := range texts {
+		for _, matches := range placeholderRegex.FindAllStringSubmatch(text, -1) {
+			if !slices.Contains(names, matches[1]) {
+				names = append(names, matches[1])
+			}
+		}
+	}
+	return names
+}
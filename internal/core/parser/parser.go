@@ -2,7 +2,9 @@ package parser
 
 import (
 	"bufio"
+	"fmt"
 	"io"
+	"path"
 	"regexp"
 	"strings"
 
@@ -10,51 +12,422 @@ import (
 )
 
 var (
-	orderedListRegex = regexp.MustCompile(`^\d+\.\s+(.*)`)
-	taskListRegex    = regexp.MustCompile(`^\*\s+\[[ x]\]\s+(.*)`)
+	orderedListRegex     = regexp.MustCompile(`^\d+\.\s+(.*)`)
+	taskListRegex        = regexp.MustCompile(`^\*\s+\[[ x]\]\s+(.*)`)
+	unsupportedTaskRegex = regexp.MustCompile(`^([-+*])\s+\[[ xX]\]\s+`)
+	deepHeadingRegex     = regexp.MustCompile(`^#{5,}\s`)
+	definitionRegex      = regexp.MustCompile(`^(\pL[\pL -]*?)\s*[:：]\s*(.*)$`)
+	bulletRegex          = regexp.MustCompile(`^[-*+]\s+(.*)`)
 )
 
+// caseField is a case attribute written under a minor item, either as a
+// definition line ("Priority: P1") or as a sub-section ("Preconditions:" or
+// "##### Preconditions") whose following lines or bullets are its items.
+type caseField struct {
+	name   string
+	list   func(*domain.Case) *[]string
+	scalar func(*domain.Case) *string
+	// separators split an inline value into several items, e.g. the tags
+	// in "Tags: smoke, regression"; any of its characters separates.
+	separators string
+}
+
+var (
+	preconditionsField = &caseField{name: "preconditions", list: func(c *domain.Case) *[]string { return &c.Preconditions }}
+	expectedField      = &caseField{name: "expected results", list: func(c *domain.Case) *[]string { return &c.ExpectedResults }}
+	priorityField      = &caseField{name: "priority", scalar: func(c *domain.Case) *string { return &c.Priority }}
+	testTypeField      = &caseField{name: "test type", scalar: func(c *domain.Case) *string { return &c.TestType }}
+	tagsField          = &caseField{name: "tags", list: func(c *domain.Case) *[]string { return &c.Tags }, separators: ",、"}
+	effortField        = &caseField{name: "effort", scalar: func(c *domain.Case) *string { return &c.Effort }}
+)
+
+// caseFieldLabels maps the lower-cased labels the parser recognizes to fields.
+var caseFieldLabels = map[string]*caseField{
+	"preconditions":    preconditionsField,
+	"precondition":     preconditionsField,
+	"pre-conditions":   preconditionsField,
+	"prerequisites":    preconditionsField,
+	"expected":         expectedField,
+	"expected result":  expectedField,
+	"expected results": expectedField,
+	"priority":         priorityField,
+	"type":             testTypeField,
+	"test type":        testTypeField,
+	"tags":             tagsField,
+	"tag":              tagsField,
+	"effort":           effortField,
+	"estimate":         effortField,
+	// Japanese labels, as used by sheets written in the eval-spec-maker
+	// format.
+	"前提条件":  preconditionsField,
+	"事前条件":  preconditionsField,
+	"期待結果":  expectedField,
+	"期待値":   expectedField,
+	"優先度":   priorityField,
+	"種別":    testTypeField,
+	"テスト種別": testTypeField,
+	"タグ":    tagsField,
+	"工数":    effortField,
+	"見積":    effortField,
+}
+
+// definitionLine reports the field and inline value of a line such as
+// "Priority: P1". Labels that are not recognized are ordinary text.
+func definitionLine(line string) (*caseField, string, bool) {
+	matches := definitionRegex.FindStringSubmatch(line)
+	if matches == nil {
+		return nil, "", false
+	}
+	field, ok := caseFieldLabels[strings.ToLower(matches[1])]
+	return field, strings.TrimSpace(matches[2]), ok
+}
+
+// subSection reports the field introduced by a heading such as
+// "##### Expected results".
+func subSection(line string) (*caseField, bool) {
+	heading, ok := strings.CutPrefix(line, "##### ")
+	if !ok {
+		return nil, false
+	}
+	field, ok := caseFieldLabels[strings.ToLower(strings.TrimSpace(heading))]
+	return field, ok
+}
+
+// add records one item of field on aCase and reports a problem, if any.
+func (f *caseField) add(aCase *domain.Case, item string) string {
+	if f.scalar != nil {
+		target := f.scalar(aCase)
+		previous := *target
+		*target = item
+		if previous != "" {
+			return fmt.Sprintf("%s is set more than once; the last value is used", f.name)
+		}
+		return ""
+	}
+
+	items := []string{item}
+	if f.separators != "" {
+		items = strings.FieldsFunc(item, func(r rune) bool { return strings.ContainsRune(f.separators, r) })
+	}
+	target := f.list(aCase)
+	for _, value := range items {
+		if value = strings.TrimSpace(value); value != "" {
+			*target = append(*target, value)
+		}
+	}
+	return ""
+}
+
 // Parse extracts test cases from a Markdown reader.
 func Parse(r io.Reader) ([]domain.Case, error) {
+	document, err := ParseDocument(r)
+	if err != nil {
+		return nil, err
+	}
+	return document.Cases, nil
+}
+
+// ParseDocument extracts test cases together with their source lines and
+// diagnostics for lines that were ignored.
+func ParseDocument(r io.Reader) (domain.Document, error) {
+	return ParseDocumentWithIncludes(r, Includes{})
+}
+
+// ParseDocumentWithIncludes is ParseDocument for a document whose include
+// directives are resolved with includes:
+//
+//   - "<!-- include: shared/login.md#Login -->" under a minor item appends
+//     the steps and checkpoints of the Login snippet or minor item of
+//     shared/login.md; "<!-- include: #Login -->" refers to a snippet or
+//     minor item written above it in the same document.
+//   - "<!-- include: shared/login.md -->" adds every case of the document.
+//   - "<!-- snippet: Login -->" and "<!-- /snippet -->" enclose named steps
+//     that are only added to the cases including them.
+//
+// Include problems are reported as error diagnostics on the including line.
+func ParseDocumentWithIncludes(r io.Reader, includes Includes) (domain.Document, error) {
+	p := &includer{includes: includes, parsed: map[string]parsedDocument{}}
+	parsed, err := p.parse(r, path.Clean(includes.Name))
+	if err != nil {
+		return domain.Document{}, err
+	}
+	return parsed.document, nil
+}
+
+// parse parses the document called name, expanding its includes.
+func (p *includer) parse(r io.Reader, name string) (parsedDocument, error) {
+	p.stack = append(p.stack, name)
+	defer func() { p.stack = p.stack[:len(p.stack)-1] }()
+
 	scanner := bufio.NewScanner(r)
-	var cases []domain.Case
+	var document domain.Document
+	snippets := map[string]domain.Case{}
+	// snippet is the snippet being defined; its steps are collected in
+	// currentCase while outer holds the case the snippet interrupted.
+	var snippet *domain.Case
+	var outer *domain.Case
 	var currentCase *domain.Case
 	var majorItem, mediumItem string
+	lineNumber := 0
+	// section is the field whose items follow an "Expected:" line or a
+	// "##### Expected" heading; a blank line after its first item ends it.
+	var section *caseField
+	var sectionItems bool
+	// stepOpen reports whether indented checkpoints still belong to the
+	// last validation step.
+	var stepOpen bool
+	// params is the parameter table of the current case, if it has one.
+	var params *parameterTable
+
+	warn := func(format string, args ...any) {
+		document.Diagnostics = append(document.Diagnostics, domain.Diagnostic{
+			Line:     lineNumber,
+			Severity: domain.SeverityWarning,
+			Message:  fmt.Sprintf(format, args...),
+		})
+	}
+	fail := func(format string, args ...any) {
+		document.Diagnostics = append(document.Diagnostics, domain.Diagnostic{
+			Line:     lineNumber,
+			Severity: domain.SeverityError,
+			Message:  fmt.Sprintf(format, args...),
+		})
+	}
+	// finishCase adds the current case, expanded once per parameter row.
+	finishCase := func() {
+		if currentCase == nil {
+			return
+		}
+		cases, problems := params.expand(*currentCase)
+		for _, problem := range problems {
+			document.Diagnostics = append(document.Diagnostics, domain.Diagnostic{
+				Line:     params.line,
+				Severity: domain.SeverityWarning,
+				Message:  problem,
+			})
+		}
+		document.Cases = append(document.Cases, cases...)
+		currentCase, params = nil, nil
+	}
+	closeSnippet := func() {
+		if _, ok := snippets[snippet.MinorItem]; ok {
+			warn("snippet %q is defined more than once; the last definition is used", snippet.MinorItem)
+		}
+		snippets[snippet.MinorItem] = *currentCase
+		currentCase, snippet, outer = outer, nil, nil
+	}
+	// include resolves target, reporting the errors of the included
+	// document on the including line.
+	include := func(target string) (string, parsedDocument, bool) {
+		included, parsed, problem := p.document(name, target)
+		if problem != "" {
+			fail("%s", problem)
+			return included, parsed, false
+		}
+		for _, diagnostic := range parsed.document.Diagnostics {
+			if diagnostic.Severity == domain.SeverityError {
+				fail("in %s line %d: %s", included, diagnostic.Line, diagnostic.Message)
+			}
+		}
+		return included, parsed, true
+	}
 
 	for scanner.Scan() {
+		lineNumber++
 		line := scanner.Text()
 		trimmedLine := strings.TrimSpace(line)
+		if params != nil && !strings.HasPrefix(trimmedLine, "|") {
+			params.closed = true
+		}
+		if strings.HasPrefix(line, "#") || orderedListRegex.MatchString(trimmedLine) || taskListRegex.MatchString(trimmedLine) {
+			section = nil
+		}
+		if strings.HasPrefix(line, "#") {
+			stepOpen = false
+			if snippet != nil {
+				warn("snippet %q is not closed before this heading; it ends here", snippet.MinorItem)
+				closeSnippet()
+			}
+		}
 
-		if strings.HasPrefix(line, "## ") {
+		if matches := snippetRegex.FindStringSubmatch(trimmedLine); matches != nil {
+			section, stepOpen = nil, false
+			if snippet != nil {
+				warn("snippet %q is not closed before snippet %q; it ends here", snippet.MinorItem, matches[1])
+				closeSnippet()
+			}
+			if matches[1] == "" {
+				fail("snippet needs a name")
+				continue
+			}
+			outer = currentCase
+			currentCase = &domain.Case{MinorItem: matches[1], Line: lineNumber, EndLine: lineNumber}
+			snippet = currentCase
+		} else if snippetEndRegex.MatchString(trimmedLine) {
+			section, stepOpen = nil, false
+			if snippet == nil {
+				warn("snippet end without a snippet is ignored")
+				continue
+			}
+			closeSnippet()
+		} else if matches := includeRegex.FindStringSubmatch(trimmedLine); matches != nil {
+			section, stepOpen = nil, false
+			target, stepsName, _ := strings.Cut(matches[1], "#")
+			target, stepsName = strings.TrimSpace(target), strings.TrimSpace(stepsName)
+			if stepsName == "" {
+				if target == "" {
+					fail("include needs a document, a #snippet or both")
+					continue
+				}
+				if snippet != nil {
+					fail("snippet %q cannot include the cases of %s", snippet.MinorItem, target)
+					continue
+				}
+				_, included, ok := include(target)
+				if !ok {
+					continue
+				}
+				finishCase()
+				for _, aCase := range included.document.Cases {
+					if aCase.MajorItem == "" {
+						aCase.MajorItem, aCase.MediumItem = majorItem, mediumItem
+					}
+					aCase.Line, aCase.EndLine = lineNumber, lineNumber
+					document.Cases = append(document.Cases, aCase)
+				}
+				continue
+			}
+			if currentCase == nil {
+				fail("include of #%s outside a minor item (####) or snippet is ignored", stepsName)
+				continue
+			}
+			from, origin := parsedDocument{document: document, snippets: snippets}, "this document"
+			if target != "" {
+				included, parsed, ok := include(target)
+				if !ok {
+					continue
+				}
+				from, origin = parsed, included
+			}
+			steps, ok := from.steps(stepsName)
+			if !ok {
+				fail("%s has no snippet or minor item %q", origin, stepsName)
+				continue
+			}
+			appendSteps(currentCase, steps)
+			currentCase.EndLine = lineNumber
+		} else if strings.HasPrefix(line, "# ") {
+			if document.Title == "" {
+				document.Title = strings.TrimPrefix(line, "# ")
+			}
+		} else if strings.HasPrefix(line, "## ") {
 			majorItem = strings.TrimPrefix(line, "## ")
 			mediumItem = "" // Reset on new major item
 		} else if strings.HasPrefix(line, "### ") {
 			mediumItem = strings.TrimPrefix(line, "### ")
 		} else if strings.HasPrefix(line, "#### ") {
-			if currentCase != nil {
-				cases = append(cases, *currentCase)
+			finishCase()
+			if majorItem == "" {
+				warn("minor item %q appears before any major item (##) heading", strings.TrimPrefix(line, "#### "))
 			}
 			currentCase = &domain.Case{
 				MajorItem:  majorItem,
 				MediumItem: mediumItem,
 				MinorItem:  strings.TrimPrefix(line, "#### "),
+				Line:       lineNumber,
+				EndLine:    lineNumber,
+			}
+		} else if matches := orderedListRegex.FindStringSubmatch(trimmedLine); len(matches) > 1 {
+			if currentCase == nil {
+				warn("validation step outside a minor item (####) is ignored")
+				continue
 			}
-		} else if matches := orderedListRegex.FindStringSubmatch(trimmedLine); len(matches) > 1 && currentCase != nil {
 			currentCase.ValidationSteps = append(currentCase.ValidationSteps, matches[1])
-		} else if matches := taskListRegex.FindStringSubmatch(trimmedLine); len(matches) > 1 && currentCase != nil {
+			currentCase.Steps = append(currentCase.Steps, domain.Step{Action: matches[1]})
+			currentCase.EndLine = lineNumber
+			stepOpen = true
+		} else if matches := taskListRegex.FindStringSubmatch(trimmedLine); len(matches) > 1 {
+			if currentCase == nil {
+				warn("checkpoint outside a minor item (####) is ignored")
+				continue
+			}
 			// The regex captures the content, but the original spec wants the `* [ ]` part.
 			// Let's just use the trimmed line for checkpoints to preserve the marker.
 			currentCase.Checkpoints = append(currentCase.Checkpoints, trimmedLine)
+			currentCase.EndLine = lineNumber
+			if stepOpen && line != strings.TrimLeft(line, " \t") {
+				step := &currentCase.Steps[len(currentCase.Steps)-1]
+				step.Expectations = append(step.Expectations, trimmedLine)
+			} else {
+				stepOpen = false
+			}
+		} else if unsupportedTaskRegex.MatchString(trimmedLine) {
+			warn("checkpoint must be written as \"* [ ]\" or \"* [x]\"; line is ignored")
+		} else if field, ok := subSection(line); ok {
+			if currentCase == nil {
+				warn("%s outside a minor item (####) is ignored", field.name)
+				continue
+			}
+			section, sectionItems = field, false
+			currentCase.EndLine = lineNumber
+		} else if strings.HasPrefix(trimmedLine, "|") && currentCase != nil {
+			section, stepOpen = nil, false
+			switch {
+			case snippet != nil:
+				warn("parameter tables are not supported in snippets; line is ignored")
+			case params != nil && params.closed:
+				warn("a minor item takes a single parameter table; line is ignored")
+			default:
+				if params == nil {
+					params = &parameterTable{line: lineNumber}
+				}
+				if problem := params.add(trimmedLine); problem != "" {
+					warn("%s", problem)
+				}
+				currentCase.EndLine = lineNumber
+			}
+		} else if deepHeadingRegex.MatchString(line) {
+			warn("headings deeper than #### are not supported; line is ignored")
+		} else if field, value, ok := definitionLine(trimmedLine); ok {
+			section, stepOpen = nil, false
+			if currentCase == nil {
+				warn("%s outside a minor item (####) is ignored", field.name)
+				continue
+			}
+			currentCase.EndLine = lineNumber
+			if value == "" {
+				section, sectionItems = field, false
+				continue
+			}
+			if problem := field.add(currentCase, value); problem != "" {
+				warn("%s", problem)
+			}
+		} else if trimmedLine == "" {
+			if sectionItems {
+				section = nil
+			}
+		} else if section != nil {
+			item := trimmedLine
+			if matches := bulletRegex.FindStringSubmatch(trimmedLine); len(matches) > 1 {
+				item = matches[1]
+			}
+			if problem := section.add(currentCase, item); problem != "" {
+				warn("%s", problem)
+			}
+			sectionItems = true
+			currentCase.EndLine = lineNumber
 		}
 	}
 
-	if currentCase != nil {
-		cases = append(cases, *currentCase)
+	if snippet != nil {
+		warn("snippet %q is not closed before the end of the document", snippet.MinorItem)
+		closeSnippet()
 	}
+	finishCase()
 
 	if err := scanner.Err(); err != nil {
-		return nil, err
+		return parsedDocument{}, err
 	}
 
-	return cases, nil
+	return parsedDocument{document: document, snippets: snippets}, nil
 }
@@ -1,6 +1,8 @@
 package parser
 
 import (
+	"io"
+	"os"
 	"reflect"
 	"strings"
 	"testing"
@@ -54,6 +56,9 @@ func TestParse(t *testing.T) {
 			MinorItem:       "Dependencies",
 			ValidationSteps: []string{"Install required packages", "Confirm default configurations"},
 			Checkpoints:     []string{"* [ ] Packages installed successfully", "* [ ] Defaults match specification"},
+			Steps:           []domain.Step{{Action: "Install required packages"}, {Action: "Confirm default configurations"}},
+			Line:            5,
+			EndLine:         9,
 		},
 		{
 			MajorItem:       "Setup",
@@ -61,6 +66,9 @@ func TestParse(t *testing.T) {
 			MinorItem:       "Environment variables",
 			ValidationSteps: []string{"Validate required environment variables are set"},
 			Checkpoints:     []string{"* [ ] Variables align with deployment checklist"},
+			Steps:           []domain.Step{{Action: "Validate required environment variables are set"}},
+			Line:            11,
+			EndLine:         13,
 		},
 		{
 			MajorItem:       "Setup",
@@ -68,6 +76,9 @@ func TestParse(t *testing.T) {
 			MinorItem:       "CLI defaults",
 			ValidationSteps: []string{"Inspect generated CSV path"},
 			Checkpoints:     []string{"* [ ] Output file lands in build/", "* [ ] Delimiter is comma"},
+			Steps:           []domain.Step{{Action: "Inspect generated CSV path"}},
+			Line:            16,
+			EndLine:         19,
 		},
 		{
 			MajorItem:       "Execution",
@@ -75,6 +86,9 @@ func TestParse(t *testing.T) {
 			MinorItem:       "CLI run",
 			ValidationSteps: []string{"Run casemd with sample.md"},
 			Checkpoints:     []string{"* [ ] Exit code is 0", "* [ ] CSV file exists"},
+			Steps:           []domain.Step{{Action: "Run casemd with sample.md"}},
+			Line:            23,
+			EndLine:         26,
 		},
 		{
 			MajorItem:       "Execution",
@@ -82,6 +96,9 @@ func TestParse(t *testing.T) {
 			MinorItem:       "Post-run cleanup",
 			ValidationSteps: []string{"Remove temporary files from build/"},
 			Checkpoints:     []string{"* [ ] No leftover artifacts"},
+			Steps:           []domain.Step{{Action: "Remove temporary files from build/"}},
+			Line:            28,
+			EndLine:         30,
 		},
 		{
 			MajorItem:       "Execution",
@@ -89,6 +106,9 @@ func TestParse(t *testing.T) {
 			MinorItem:       "Error handling",
 			ValidationSteps: []string{"Run casemd without --input"},
 			Checkpoints:     []string{"* [ ] CLI prints actionable error", "* [ ] Exit code is 1"},
+			Steps:           []domain.Step{{Action: "Run casemd without --input"}},
+			Line:            33,
+			EndLine:         36,
 		},
 	}
 
@@ -102,3 +122,493 @@ func TestParse(t *testing.T) {
 		t.Errorf("Parse() returned %+v, want %+v", actualCases, expectedCases)
 	}
 }
+
+func TestParseDocumentReportsDiagnostics(t *testing.T) {
+	markdown := `# Release checklist
+1. Orphan step
+
+#### Orphan case
+- [ ] Dash checkpoint
+
+## Setup
+##### Too deep
+`
+
+	document, err := ParseDocument(strings.NewReader(markdown))
+	if err != nil {
+		t.Fatalf("ParseDocument() returned an unexpected error: %v", err)
+	}
+
+	if document.Title != "Release checklist" {
+		t.Fatalf("unexpected title: %q", document.Title)
+	}
+	if len(document.Cases) != 1 || document.Cases[0].Line != 4 {
+		t.Fatalf("unexpected cases: %+v", document.Cases)
+	}
+
+	var lines []int
+	for _, diagnostic := range document.Diagnostics {
+		if diagnostic.Severity != domain.SeverityWarning {
+			t.Fatalf("unexpected severity: %+v", diagnostic)
+		}
+		lines = append(lines, diagnostic.Line)
+	}
+	if expected := []int{2, 4, 5, 8}; !reflect.DeepEqual(lines, expected) {
+		t.Fatalf("diagnostics on lines %v, want %v: %+v", lines, expected, document.Diagnostics)
+	}
+}
+
+func TestParseDocumentReadsCaseFields(t *testing.T) {
+	markdown := `## Account
+#### Sign in
+Priority: P1
+Type: functional
+Tags: smoke, regression
+
+Preconditions:
+- A user exists
+- The user is signed out
+
+1. Open the sign-in page
+* [ ] Form is shown
+
+##### Expected results
+The dashboard opens
+- The user name is shown
+
+#### Sign out
+Expected: The sign-in page opens
+Priority: P3
+priority: P2
+Build: 42
+`
+
+	document, err := ParseDocument(strings.NewReader(markdown))
+	if err != nil {
+		t.Fatalf("ParseDocument() returned an unexpected error: %v", err)
+	}
+
+	expectedCases := []domain.Case{
+		{
+			MajorItem:       "Account",
+			MinorItem:       "Sign in",
+			ValidationSteps: []string{"Open the sign-in page"},
+			Checkpoints:     []string{"* [ ] Form is shown"},
+			Steps:           []domain.Step{{Action: "Open the sign-in page"}},
+			Preconditions:   []string{"A user exists", "The user is signed out"},
+			ExpectedResults: []string{"The dashboard opens", "The user name is shown"},
+			Priority:        "P1",
+			TestType:        "functional",
+			Tags:            []string{"smoke", "regression"},
+			Line:            2,
+			EndLine:         16,
+		},
+		{
+			MajorItem:       "Account",
+			MinorItem:       "Sign out",
+			ExpectedResults: []string{"The sign-in page opens"},
+			Priority:        "P2",
+			Line:            18,
+			EndLine:         21,
+		},
+	}
+	if !reflect.DeepEqual(document.Cases, expectedCases) {
+		t.Fatalf("ParseDocument() returned %+v, want %+v", document.Cases, expectedCases)
+	}
+
+	expectedDiagnostics := []domain.Diagnostic{
+		{Line: 21, Severity: domain.SeverityWarning, Message: "priority is set more than once; the last value is used"},
+	}
+	if !reflect.DeepEqual(document.Diagnostics, expectedDiagnostics) {
+		t.Fatalf("unexpected diagnostics: %+v", document.Diagnostics)
+	}
+}
+
+func TestParseDocumentReadsJapaneseLabels(t *testing.T) {
+	markdown := `## アカウント
+#### ログイン
+優先度：P1
+種別: 機能
+タグ: スモーク、回帰
+
+前提条件:
+- ユーザーが存在する
+
+1. ログイン画面を開く
+
+##### 期待結果
+ダッシュボードが開く
+`
+
+	document, err := ParseDocument(strings.NewReader(markdown))
+	if err != nil {
+		t.Fatalf("ParseDocument() returned an unexpected error: %v", err)
+	}
+
+	expectedCases := []domain.Case{{
+		MajorItem:       "アカウント",
+		MinorItem:       "ログイン",
+		ValidationSteps: []string{"ログイン画面を開く"},
+		Steps:           []domain.Step{{Action: "ログイン画面を開く"}},
+		Preconditions:   []string{"ユーザーが存在する"},
+		ExpectedResults: []string{"ダッシュボードが開く"},
+		Priority:        "P1",
+		TestType:        "機能",
+		Tags:            []string{"スモーク", "回帰"},
+		Line:            2,
+		EndLine:         13,
+	}}
+	if !reflect.DeepEqual(document.Cases, expectedCases) {
+		t.Fatalf("ParseDocument() returned %+v, want %+v", document.Cases, expectedCases)
+	}
+	if len(document.Diagnostics) != 0 {
+		t.Fatalf("unexpected diagnostics: %+v", document.Diagnostics)
+	}
+}
+
+func TestParseDocumentKeepsUnknownLabelsAsText(t *testing.T) {
+	markdown := `## アカウント
+#### ログイン
+1. 注意：管理者でログインする
+   * [ ] 注意：警告が表示される
+
+前提条件:
+- 注意：本番環境では実行しない
+Note: staging only
+
+期待結果：ダッシュボードが開く
+`
+
+	document, err := ParseDocument(strings.NewReader(markdown))
+	if err != nil {
+		t.Fatalf("ParseDocument() returned an unexpected error: %v", err)
+	}
+
+	expectedCases := []domain.Case{{
+		MajorItem:       "アカウント",
+		MinorItem:       "ログイン",
+		ValidationSteps: []string{"注意：管理者でログインする"},
+		Checkpoints:     []string{"* [ ] 注意：警告が表示される"},
+		Steps:           []domain.Step{{Action: "注意：管理者でログインする", Expectations: []string{"* [ ] 注意：警告が表示される"}}},
+		Preconditions:   []string{"注意：本番環境では実行しない", "Note: staging only"},
+		ExpectedResults: []string{"ダッシュボードが開く"},
+		Line:            2,
+		EndLine:         10,
+	}}
+	if !reflect.DeepEqual(document.Cases, expectedCases) {
+		t.Fatalf("ParseDocument() returned %+v, want %+v", document.Cases, expectedCases)
+	}
+	if len(document.Diagnostics) != 0 {
+		t.Fatalf("unexpected diagnostics: %+v", document.Diagnostics)
+	}
+}
+
+func TestParseNestsCheckpointsUnderSteps(t *testing.T) {
+	markdown := `## Account
+#### Sign in
+1. Open the sign-in page
+   * [ ] Form is shown
+   * [x] Password field is masked
+
+2. Submit valid credentials
+	* [ ] Dashboard opens
+* [ ] No errors are logged
+  * [ ] Not nested after a top-level checkpoint
+3. Sign out
+`
+
+	cases, err := Parse(strings.NewReader(markdown))
+	if err != nil {
+		t.Fatalf("Parse() returned an unexpected error: %v", err)
+	}
+	if len(cases) != 1 {
+		t.Fatalf("expected one case, got %+v", cases)
+	}
+
+	expectedSteps := []domain.Step{
+		{Action: "Open the sign-in page", Expectations: []string{"* [ ] Form is shown", "* [x] Password field is masked"}},
+		{Action: "Submit valid credentials", Expectations: []string{"* [ ] Dashboard opens"}},
+		{Action: "Sign out"},
+	}
+	if !reflect.DeepEqual(cases[0].Steps, expectedSteps) {
+		t.Fatalf("unexpected steps: %+v", cases[0].Steps)
+	}
+	expectedCheckpoints := []string{
+		"* [ ] Form is shown",
+		"* [x] Password field is masked",
+		"* [ ] Dashboard opens",
+		"* [ ] No errors are logged",
+		"* [ ] Not nested after a top-level checkpoint",
+	}
+	if !reflect.DeepEqual(cases[0].Checkpoints, expectedCheckpoints) {
+		t.Fatalf("unexpected checkpoints: %+v", cases[0].Checkpoints)
+	}
+}
+
+// memoryIncludes serves the documents of files as included documents.
+func memoryIncludes(name string, files map[string]string) Includes {
+	return Includes{Name: name, Open: func(name string) (io.ReadCloser, error) {
+		content, ok := files[name]
+		if !ok {
+			return nil, os.ErrNotExist
+		}
+		return io.NopCloser(strings.NewReader(content)), nil
+	}}
+}
+
+func TestParseDocumentWithIncludesExpandsSharedSteps(t *testing.T) {
+	files := map[string]string{
+		"shared/login.md": `## Shared
+#### Login
+1. Open the sign-in page
+2. Submit valid credentials
+   * [ ] Dashboard opens
+`,
+		"shared/cases.md": `#### Health check
+1. Open /health
+`,
+	}
+	markdown := `# Checkout
+
+<!-- snippet: Pay -->
+1. Pay with the test card
+* [ ] Receipt is shown
+<!-- /snippet -->
+
+## Orders
+#### Place an order
+<!-- include: ../shared/login.md#Login -->
+<!-- include: #Pay -->
+3. Sign out
+<!-- include: ../shared/cases.md -->
+`
+
+	document, err := ParseDocumentWithIncludes(strings.NewReader(markdown), memoryIncludes("checks/checkout.md", files))
+	if err != nil {
+		t.Fatalf("ParseDocumentWithIncludes() returned an unexpected error: %v", err)
+	}
+	if len(document.Diagnostics) != 0 {
+		t.Fatalf("unexpected diagnostics: %+v", document.Diagnostics)
+	}
+
+	expectedCases := []domain.Case{
+		{
+			MajorItem:       "Orders",
+			MinorItem:       "Place an order",
+			ValidationSteps: []string{"Open the sign-in page", "Submit valid credentials", "Pay with the test card", "Sign out"},
+			Steps: []domain.Step{
+				{Action: "Open the sign-in page"},
+				{Action: "Submit valid credentials", Expectations: []string{"* [ ] Dashboard opens"}},
+				{Action: "Pay with the test card"},
+				{Action: "Sign out"},
+			},
+			Checkpoints: []string{"* [ ] Dashboard opens", "* [ ] Receipt is shown"},
+			Line:        9,
+			EndLine:     12,
+		},
+		{
+			MajorItem:       "Orders",
+			MinorItem:       "Health check",
+			ValidationSteps: []string{"Open /health"},
+			Steps:           []domain.Step{{Action: "Open /health"}},
+			Line:            13,
+			EndLine:         13,
+		},
+	}
+	if !reflect.DeepEqual(document.Cases, expectedCases) {
+		t.Fatalf("ParseDocumentWithIncludes() returned %+v, want %+v", document.Cases, expectedCases)
+	}
+}
+
+func TestParseDocumentWithIncludesReportsProblems(t *testing.T) {
+	files := map[string]string{
+		"a.md": "## A\n#### Step\n<!-- include: b.md#Step -->\n",
+		"b.md": "## B\n#### Step\n<!-- include: a.md#Step -->\n",
+	}
+	markdown := `<!-- include: #Login -->
+## Main
+#### Case
+<!-- include: a.md#Step -->
+<!-- include: missing.md#Login -->
+<!-- include: #Unknown -->
+<!-- snippet: Open -->
+1. Open
+#### Next
+`
+
+	document, err := ParseDocumentWithIncludes(strings.NewReader(markdown), memoryIncludes("main.md", files))
+	if err != nil {
+		t.Fatalf("ParseDocumentWithIncludes() returned an unexpected error: %v", err)
+	}
+
+	expected := []domain.Diagnostic{
+		{Line: 1, Severity: domain.SeverityError, Message: "include of #Login outside a minor item (####) or snippet is ignored"},
+		{Line: 4, Severity: domain.SeverityError, Message: "in a.md line 3: in b.md line 3: include cycle: a.md → b.md → a.md"},
+		{Line: 5, Severity: domain.SeverityError, Message: "cannot open included document missing.md: file does not exist"},
+		{Line: 6, Severity: domain.SeverityError, Message: "this document has no snippet or minor item \"Unknown\""},
+		{Line: 9, Severity: domain.SeverityWarning, Message: "snippet \"Open\" is not closed before this heading; it ends here"},
+	}
+	if !reflect.DeepEqual(document.Diagnostics, expected) {
+		t.Fatalf("unexpected diagnostics:\n got: %+v\nwant: %+v", document.Diagnostics, expected)
+	}
+
+	document, err = ParseDocument(strings.NewReader("## Main\n#### Case\n<!-- include: a.md#Step -->\n"))
+	if err != nil {
+		t.Fatalf("ParseDocument() returned an unexpected error: %v", err)
+	}
+	if len(document.Diagnostics) != 1 || document.Diagnostics[0].Line != 3 || document.Diagnostics[0].Severity != domain.SeverityError {
+		t.Fatalf("expected an unresolved include, got %+v", document.Diagnostics)
+	}
+}
+
+func TestParseDocumentExpandsParameterTables(t *testing.T) {
+	markdown := `## Account
+#### Sign in
+| browser | locale |
+| ------- | :----: |
+| chrome  | en     |
+| safari  | ja     |
+
+1. Open the sign-in page in {{browser}}
+2. Switch the language to {{locale}}
+   * [ ] Labels are shown in {{locale}}
+#### Sign out on {{browser}}
+| browser |
+| --- |
+| firefox |
+1. Press {{button}}
+#### Plain
+1. Keep {{literal}} placeholders without a table
+`
+
+	document, err := ParseDocument(strings.NewReader(markdown))
+	if err != nil {
+		t.Fatalf("ParseDocument() returned an unexpected error: %v", err)
+	}
+
+	expectedCases := []domain.Case{
+		{
+			MajorItem:       "Account",
+			MinorItem:       "Sign in (browser: chrome, locale: en)",
+			ValidationSteps: []string{"Open the sign-in page in chrome", "Switch the language to en"},
+			Steps: []domain.Step{
+				{Action: "Open the sign-in page in chrome"},
+				{Action: "Switch the language to en", Expectations: []string{"* [ ] Labels are shown in en"}},
+			},
+			Checkpoints: []string{"* [ ] Labels are shown in en"},
+			Line:        2,
+			EndLine:     10,
+		},
+		{
+			MajorItem:       "Account",
+			MinorItem:       "Sign in (browser: safari, locale: ja)",
+			ValidationSteps: []string{"Open the sign-in page in safari", "Switch the language to ja"},
+			Steps: []domain.Step{
+				{Action: "Open the sign-in page in safari"},
+				{Action: "Switch the language to ja", Expectations: []string{"* [ ] Labels are shown in ja"}},
+			},
+			Checkpoints: []string{"* [ ] Labels are shown in ja"},
+			Line:        2,
+			EndLine:     10,
+		},
+		{
+			MajorItem:       "Account",
+			MinorItem:       "Sign out on firefox",
+			ValidationSteps: []string{"Press {{button}}"},
+			Steps:           []domain.Step{{Action: "Press {{button}}"}},
+			Line:            11,
+			EndLine:         15,
+		},
+		{
+			MajorItem:       "Account",
+			MinorItem:       "Plain",
+			ValidationSteps: []string{"Keep {{literal}} placeholders without a table"},
+			Steps:           []domain.Step{{Action: "Keep {{literal}} placeholders without a table"}},
+			Line:            16,
+			EndLine:         17,
+		},
+	}
+	if !reflect.DeepEqual(document.Cases, expectedCases) {
+		t.Fatalf("ParseDocument() returned %+v, want %+v", document.Cases, expectedCases)
+	}
+
+	expectedDiagnostics := []domain.Diagnostic{
+		{Line: 12, Severity: domain.SeverityWarning, Message: "placeholder {{button}} has no column in the parameter table"},
+	}
+	if !reflect.DeepEqual(document.Diagnostics, expectedDiagnostics) {
+		t.Fatalf("unexpected diagnostics: %+v", document.Diagnostics)
+	}
+}
+
+func TestParseDocumentExpandsParameterTablesIntoIndependentCases(t *testing.T) {
+	markdown := `## Account
+#### Sign in
+Tags: smoke
+Preconditions:
+- A {{role}} exists
+Expected: The {{role}} dashboard opens
+| role |
+| --- |
+| admin |
+| guest |
+
+1. Sign in as {{role}}
+   * [ ] Welcome {{role}}
+`
+
+	parse := func() []domain.Case {
+		document, err := ParseDocument(strings.NewReader(markdown))
+		if err != nil {
+			t.Fatalf("ParseDocument() returned an unexpected error: %v", err)
+		}
+		if len(document.Cases) != 2 {
+			t.Fatalf("expected two cases, got %+v", document.Cases)
+		}
+		return document.Cases
+	}
+	cases, expected := parse(), parse()
+
+	changed := &cases[0]
+	changed.Tags[0] = "changed"
+	changed.Preconditions[0] = "changed"
+	changed.ExpectedResults[0] = "changed"
+	changed.ValidationSteps[0] = "changed"
+	changed.Checkpoints[0] = "changed"
+	changed.Steps[0].Action = "changed"
+	changed.Steps[0].Expectations[0] = "changed"
+	changed.Tags = append(changed.Tags[:1], "added")
+	if !reflect.DeepEqual(cases[1], expected[1]) {
+		t.Fatalf("changing one expanded case changed its sibling: %+v, want %+v", cases[1], expected[1])
+	}
+}
+
+func TestParseDocumentReportsParameterTableProblems(t *testing.T) {
+	markdown := `## Account
+#### Sign in
+| browser | locale |
+| --- | --- |
+| chrome |
+| safari | ja |
+
+| device |
+1. Open {{browser}}
+#### Header only
+| browser |
+`
+
+	document, err := ParseDocument(strings.NewReader(markdown))
+	if err != nil {
+		t.Fatalf("ParseDocument() returned an unexpected error: %v", err)
+	}
+	if len(document.Cases) != 2 || document.Cases[0].MinorItem != "Sign in (browser: safari, locale: ja)" || document.Cases[1].MinorItem != "Header only" {
+		t.Fatalf("unexpected cases: %+v", document.Cases)
+	}
+
+	var lines []int
+	for _, diagnostic := range document.Diagnostics {
+		lines = append(lines, diagnostic.Line)
+	}
+	if expected := []int{5, 8, 11}; !reflect.DeepEqual(lines, expected) {
+		t.Fatalf("diagnostics on lines %v, want %v: %+v", lines, expected, document.Diagnostics)
+	}
+}
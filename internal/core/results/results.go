@@ -0,0 +1,162 @@
+package results
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/9renpoto/casemd/internal/core/domain"
+)
+
+// SidecarSuffix is appended to a checklist's base name to locate the results
+// recorded for it, e.g. login.md -> login.results.json.
+const SidecarSuffix = ".results.json"
+
+var checkpointRegex = regexp.MustCompile(`^\*\s+\[[ xX]\]\s+(.*)`)
+
+// SidecarPath returns the results file that sits next to markdownPath.
+func SidecarPath(markdownPath string) string {
+	return strings.TrimSuffix(markdownPath, filepath.Ext(markdownPath)) + SidecarSuffix
+}
+
+// Read decodes a results file.
+func Read(r io.Reader) (domain.Run, error) {
+	var run domain.Run
+	if err := json.NewDecoder(r).Decode(&run); err != nil {
+		return domain.Run{}, fmt.Errorf("decode results: %w", err)
+	}
+	return run, nil
+}
+
+// Write encodes run as an indented results file.
+func Write(w io.Writer, run domain.Run) error {
+	if run.Cases == nil {
+		run.Cases = []domain.CaseResult{}
+	}
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(run); err != nil {
+		return fmt.Errorf("encode results: %w", err)
+	}
+	return nil
+}
+
+// FromCase captures the execution state currently held by a case.
+func FromCase(aCase domain.Case) domain.CaseResult {
+	return domain.CaseResult{
+		MajorItem:   aCase.MajorItem,
+		MediumItem:  aCase.MediumItem,
+		MinorItem:   aCase.MinorItem,
+		Checkpoints: append([]string(nil), aCase.Checkpoints...),
+		Result:      aCase.Result,
+		TestDate:    aCase.TestDate,
+		Tester:      aCase.Tester,
+		Notes:       aCase.Notes,
+	}
+}
+
+// Merge returns a copy of cases with the execution fields and checkpoint
+// states recorded in run. Results are matched by hierarchy; when a minor item
+// is duplicated, results apply to the duplicates in order. Checkpoints are
+// matched by text, so a checkpoint edited since the run keeps its Markdown
+// state; the expectations nested under steps get the same states.
+func Merge(cases []domain.Case, run domain.Run) []domain.Case {
+	pending := make(map[string][]domain.CaseResult)
+	for _, result := range run.Cases {
+		pending[result.Key()] = append(pending[result.Key()], result)
+	}
+
+	merged := make([]domain.Case, len(cases))
+	for i, aCase := range cases {
+		merged[i] = aCase
+		queue := pending[aCase.Key()]
+		if len(queue) == 0 {
+			continue
+		}
+		result := queue[0]
+		pending[aCase.Key()] = queue[1:]
+
+		merged[i].Result = result.Result
+		merged[i].TestDate = result.TestDate
+		merged[i].Tester = result.Tester
+		merged[i].Notes = result.Notes
+		merged[i].Checkpoints = mergeCheckpoints(aCase.Checkpoints, result.Checkpoints)
+		merged[i].Steps = mergeSteps(aCase.Steps, result.Checkpoints)
+	}
+	return merged
+}
+
+func mergeSteps(steps []domain.Step, recorded []string) []domain.Step {
+	if len(steps) == 0 {
+		return steps
+	}
+
+	merged := make([]domain.Step, len(steps))
+	for i, step := range steps {
+		merged[i] = domain.Step{Action: step.Action, Expectations: mergeCheckpoints(step.Expectations, recorded)}
+	}
+	return merged
+}
+
+func mergeCheckpoints(checkpoints, recorded []string) []string {
+	if len(checkpoints) == 0 {
+		return checkpoints
+	}
+
+	states := make(map[string]string, len(recorded))
+	for _, line := range recorded {
+		if text := checkpointText(line); text != "" {
+			states[text] = line
+		}
+	}
+
+	merged := make([]string, len(checkpoints))
+	for i, line := range checkpoints {
+		merged[i] = line
+		if recordedLine, ok := states[checkpointText(line)]; ok {
+			merged[i] = recordedLine
+		}
+	}
+	return merged
+}
+
+func checkpointText(line string) string {
+	matches := checkpointRegex.FindStringSubmatch(strings.TrimSpace(line))
+	if len(matches) < 2 {
+		return ""
+	}
+	return matches[1]
+}
+
+// Find returns the results named holds for the checklist at source: those
+// recorded against the same path, or else against the only file or sheet
+// with the same SourceKey, so a run recorded from a workbook whose sheet is
+// named "login" applies to checks/login.md.
+func Find(named domain.NamedRun, source string) (domain.Run, bool) {
+	for _, run := range named.Runs {
+		if filepath.Clean(run.Source) == filepath.Clean(source) {
+			return run, true
+		}
+	}
+
+	var found []domain.Run
+	for _, run := range named.Runs {
+		if SourceKey(run.Source) == SourceKey(source) {
+			found = append(found, run)
+		}
+	}
+	if len(found) != 1 {
+		return domain.Run{}, false
+	}
+	return found[0], true
+}
+
+// SourceKey identifies a checklist across runs by its base name without
+// directory or extension, which is also the name of its exported sheet.
+func SourceKey(path string) string {
+	base := filepath.Base(filepath.ToSlash(path))
+	return strings.TrimSuffix(base, filepath.Ext(base))
+}
@@ -0,0 +1,102 @@
+package results
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/9renpoto/casemd/internal/core/domain"
+)
+
+func TestSidecarPath(t *testing.T) {
+	for input, expected := range map[string]string{
+		"notes.md":             "notes.results.json",
+		"checks/login.md":      "checks/login.results.json",
+		"checks/v1.2/login.md": "checks/v1.2/login.results.json",
+		"README":               "README.results.json",
+	} {
+		if got := SidecarPath(input); got != expected {
+			t.Fatalf("SidecarPath(%q) = %q, want %q", input, got, expected)
+		}
+	}
+}
+
+func TestMerge(t *testing.T) {
+	cases := []domain.Case{
+		{MajorItem: "Setup", MinorItem: "Install", Checkpoints: []string{"* [ ] Installed", "* [ ] Configured"}, Steps: []domain.Step{{Action: "Install", Expectations: []string{"* [ ] Installed"}}, {Action: "Configure"}}},
+		{MajorItem: "Setup", MinorItem: "Duplicate", Checkpoints: []string{"* [ ] First"}},
+		{MajorItem: "Setup", MinorItem: "Duplicate", Checkpoints: []string{"* [ ] First"}},
+		{MajorItem: "Setup", MinorItem: "Untouched", Checkpoints: []string{"* [ ] Pending"}},
+	}
+	run := domain.Run{Cases: []domain.CaseResult{
+		{MajorItem: "Setup", MinorItem: "Install", Checkpoints: []string{"* [x] Installed", "* [x] Renamed since the run"}, Result: "fail", TestDate: "2026-10-01", Tester: "alice", Notes: "config missing"},
+		{MajorItem: "Setup", MinorItem: "Duplicate", Result: "pass"},
+		{MajorItem: "Setup", MinorItem: "Duplicate", Result: "skip"},
+		{MajorItem: "Removed", MinorItem: "Case", Result: "pass"},
+	}}
+
+	merged := Merge(cases, run)
+
+	expected := []domain.Case{
+		{MajorItem: "Setup", MinorItem: "Install", Checkpoints: []string{"* [x] Installed", "* [ ] Configured"}, Steps: []domain.Step{{Action: "Install", Expectations: []string{"* [x] Installed"}}, {Action: "Configure"}}, Result: "fail", TestDate: "2026-10-01", Tester: "alice", Notes: "config missing"},
+		{MajorItem: "Setup", MinorItem: "Duplicate", Checkpoints: []string{"* [ ] First"}, Result: "pass"},
+		{MajorItem: "Setup", MinorItem: "Duplicate", Checkpoints: []string{"* [ ] First"}, Result: "skip"},
+		{MajorItem: "Setup", MinorItem: "Untouched", Checkpoints: []string{"* [ ] Pending"}},
+	}
+	if !reflect.DeepEqual(merged, expected) {
+		t.Fatalf("unexpected merge result:\n got: %#v\nwant: %#v", merged, expected)
+	}
+	if cases[0].Checkpoints[0] != "* [ ] Installed" || cases[0].Steps[0].Expectations[0] != "* [ ] Installed" {
+		t.Fatal("Merge must not modify its input")
+	}
+}
+
+func TestReadWriteRoundTrip(t *testing.T) {
+	run := domain.Run{Source: "login.md", Cases: []domain.CaseResult{
+		{MajorItem: "Auth", MediumItem: "Login", MinorItem: "Password", Checkpoints: []string{"* [x] Signed in"}, Result: "pass", Tester: "bob"},
+	}}
+
+	var buffer bytes.Buffer
+	if err := Write(&buffer, run); err != nil {
+		t.Fatalf("Write returned error: %v", err)
+	}
+	decoded, err := Read(&buffer)
+	if err != nil {
+		t.Fatalf("Read returned error: %v", err)
+	}
+	if !reflect.DeepEqual(decoded, run) {
+		t.Fatalf("round trip mismatch:\n got: %#v\nwant: %#v", decoded, run)
+	}
+
+	if _, err := Read(strings.NewReader("{")); err == nil {
+		t.Fatal("expected error for malformed results")
+	}
+}
+
+func TestFind(t *testing.T) {
+	named := domain.NamedRun{Name: "rc1", Runs: []domain.Run{
+		{Source: "checks/login.md", Cases: []domain.CaseResult{{MinorItem: "Password", Result: "pass"}}},
+		{Source: "logout", Cases: []domain.CaseResult{{MinorItem: "Button", Result: "fail"}}},
+		{Source: "a/setup.md"},
+		{Source: "b/setup.md"},
+	}}
+
+	for source, expected := range map[string]string{
+		"checks/login.md":   "checks/login.md",
+		"./checks/login.md": "checks/login.md",
+		"other/login.md":    "checks/login.md",
+		"checks/logout.md":  "logout",
+		"b/setup.md":        "b/setup.md",
+	} {
+		run, ok := Find(named, source)
+		if !ok || run.Source != expected {
+			t.Fatalf("Find(%q) = %q, %v; want %q", source, run.Source, ok, expected)
+		}
+	}
+	for _, source := range []string{"c/setup.md", "signup.md"} {
+		if _, ok := Find(named, source); ok {
+			t.Fatalf("Find(%q) should not match", source)
+		}
+	}
+}
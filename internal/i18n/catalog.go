@@ -0,0 +1,278 @@
+package i18n
+
+// catalogs holds the built-in messages. English is complete; other catalogs
+// fall back to it for missing keys. Keys are grouped by prefix: column.* for
+// sheet headers, cli.* and output.* for CLI output, count.* for counted
+// nouns, report.* for the HTML report, trend.* for the trend report and ui.*
+// for the web UI, whose messages use %s placeholders only so the browser
+// script can fill them.
+var catalogs = map[Language]map[string]string{
+	English: {
+		"column.major":         "Major Item",
+		"column.medium":        "Medium Item",
+		"column.minor":         "Minor Item",
+		"column.preconditions": "Preconditions",
+		"column.steps":         "Validation Steps",
+		"column.checkpoints":   "Checkpoints",
+		"column.expected":      "Expected Results",
+		"column.priority":      "Priority",
+		"column.type":          "Test Type",
+		"column.tags":          "Tags",
+		"column.effort":        "Effort",
+		"column.result":        "Result",
+		"column.date":          "Test Date",
+		"column.tester":        "Tester",
+		"column.notes":         "Notes",
+
+		"cli.usage":          "casemd converts Markdown inspection sheets into CSV files, Excel workbooks, CI test reports, HTML reports, and Google Spreadsheets.",
+		"cli.usage.import":   "casemd import migrates existing checklists into casemd Markdown.",
+		"cli.usage.plan":     "casemd plan splits the cases of a checklist set among testers and writes a workbook for each of them.",
+		"cli.usage.runs":     "casemd runs records the results of a test run under a name, lists the recorded runs and compares two of them.",
+		"cli.usage.report":   "casemd report summarizes flakiness, pass rates and coverage across recorded runs.",
+		"cli.usage.serve":    "casemd serve runs the web UI for previewing, executing and exporting checklists.",
+		"cli.usageHeading":   "Usage:",
+		"cli.flagsHeading":   "Flags:",
+		"cli.written":        "%s written to %s",
+		"cli.googleCreated":  "Google Spreadsheet created with ID %s",
+		"cli.statsParsed":    "Parsed %s (%s): %s, %s",
+		"cli.statsConvert":   "%s: %s in %s, %s written, %s",
+		"cli.planAssigned":   "%s: %s",
+		"cli.planEffort":     "%s: %s, about %s",
+		"cli.runRecorded":    "Run %s recorded in %s: %s",
+		"cli.runSummary":     "%s  %s  %s: %s, %s, %s",
+		"cli.noRuns":         "No runs recorded in %s",
+		"cli.runChange":      "%s: %s: %s → %s",
+		"cli.runNotRecorded": "not recorded",
+		"cli.noRunChanges":   "No outcome changed between %s and %s",
+
+		"output.csv":             "CSV",
+		"output.xlsx":            "Spreadsheet",
+		"output.ods":             "Spreadsheet",
+		"output.junit":           "JUnit XML",
+		"output.tap":             "TAP",
+		"output.html":            "HTML report",
+		"output.markdown":        "Markdown",
+		"output.trend":           "Trend report",
+		"count.source.one":       "%d source",
+		"count.source.other":     "%d sources",
+		"count.case.one":         "%d case",
+		"count.case.other":       "%d cases",
+		"count.error.one":        "%d error",
+		"count.error.other":      "%d errors",
+		"count.conversion.one":   "%d conversion",
+		"count.conversion.other": "%d conversions",
+
+		"trend.title":               "Trend Report",
+		"trend.runs":                "Runs: %s",
+		"trend.none":                "None.",
+		"trend.flaky":               "Flaky cases",
+		"trend.passRate":            "Pass rate by major item",
+		"trend.neverRun":            "Never run",
+		"trend.stale":               "Not run in %d days",
+		"trend.column.checklist":    "Checklist",
+		"trend.column.case":         "Case",
+		"trend.column.passed":       "Passed",
+		"trend.column.failed":       "Failed",
+		"trend.column.flips":        "Flips",
+		"trend.column.history":      "History",
+		"trend.column.passRate":     "Pass Rate",
+		"trend.column.lastRun":      "Last Run",
+		"trend.column.lastExecuted": "Last Executed",
+		"trend.column.days":         "Days",
+
+		"report.title":           "Inspection Report",
+		"report.checkpoints":     "%d/%d checkpoints (%d%%)",
+		"report.passed":          "%d passed",
+		"report.failed":          "%d failed",
+		"report.skipped":         "%d skipped",
+		"report.status.passed":   "passed",
+		"report.status.failed":   "failed",
+		"report.status.skipped":  "skipped",
+		"report.priority":        "Priority: %s",
+		"report.type":            "Type: %s",
+		"report.preconditions":   "Preconditions",
+		"report.steps":           "Validation Steps",
+		"report.checkpointsList": "Checkpoints",
+		"report.expected":        "Expected Results",
+
+		"ui.title":                  "casemd Preview",
+		"ui.heading":                "casemd Markdown Preview",
+		"ui.intro":                  "This lightweight UI helps debug Markdown parsing by converting content to CSV on demand.",
+		"ui.checklistFiles":         "Checklist files",
+		"ui.workspaceNotice":        "Pick a file to preview it, or open a folder as a workbook. Previews refresh when files change on disk.",
+		"ui.loadingFiles":           "Loading files...",
+		"ui.sourceName":             "Source Name",
+		"ui.markdownContent":        "Markdown Content",
+		"ui.previewCSV":             "Preview CSV",
+		"ui.livePreview":            "Live preview",
+		"ui.files":                  "Markdown Files (optional, downloads only)",
+		"ui.download":               "Download %s",
+		"ui.execute":                "Execute checklist",
+		"ui.diagnostics":            "Diagnostics",
+		"ui.diagnosticsPlaceholder": "Enable live preview to lint while you type.",
+		"ui.csvPreview":             "CSV Preview",
+		"ui.csvPreviewNotice":       "Click a row to highlight the Markdown lines that produced it.",
+		"ui.waiting":                "Waiting for preview...",
+		"ui.execution":              "Execution",
+		"ui.executionNotice":        "Tick checkpoints and record a result per case. Saving writes the results file next to the checklist; export with %s to fill the spreadsheet columns.",
+		"ui.tester":                 "Tester",
+		"ui.notes":                  "Notes",
+		"ui.signedInAs":             "Signed in as %s; results you change are recorded under this name.",
+		"ui.yourName":               "Your name",
+		"ui.saveResults":            "Save results",
+		"ui.converting":             "Converting...",
+		"ui.done":                   "Done",
+		"ui.noData":                 "No data available.",
+		"ui.noRows":                 "No rows parsed.",
+		"ui.lines":                  "Lines %s-%s",
+		"ui.noProblems":             "No problems found.",
+		"ui.diagnosticLine":         "Line %s: %s",
+		"ui.updating":               "Updating...",
+		"ui.live":                   "Live",
+		"ui.reconnecting":           "Reconnecting...",
+		"ui.preparingDownload":      "Preparing download...",
+		"ui.downloaded":             "Downloaded",
+		"ui.noFiles":                "No Markdown files found.",
+		"ui.workbook":               "Workbook",
+		"ui.showing":                "Showing %s",
+		"ui.workbookOf":             "Workbook of %s",
+		"ui.notRun":                 "Not run",
+		"ui.loading":                "Loading...",
+		"ui.saving":                 "Saving...",
+		"ui.savedTo":                "Saved to %s",
+	},
+	Japanese: {
+		"column.major":         "大項目",
+		"column.medium":        "中項目",
+		"column.minor":         "小項目",
+		"column.preconditions": "前提条件",
+		"column.steps":         "確認手順",
+		"column.checkpoints":   "確認項目",
+		"column.expected":      "期待結果",
+		"column.priority":      "優先度",
+		"column.type":          "種別",
+		"column.tags":          "タグ",
+		"column.effort":        "工数",
+		"column.result":        "結果",
+		"column.date":          "実施日",
+		"column.tester":        "実施者",
+		"column.notes":         "備考",
+
+		"cli.usage":          "casemd は Markdown の検査表を CSV ファイル、Excel ブック、CI 用テストレポート、HTML レポート、Google スプレッドシートに変換します。",
+		"cli.usage.import":   "casemd import は既存のチェックリストを casemd の Markdown に移行します。",
+		"cli.usage.plan":     "casemd plan はチェックリストのケースをテスト担当者に割り振り、担当者ごとのブックを書き出します。",
+		"cli.usage.runs":     "casemd runs はテストの実施結果を名前を付けて記録し、記録した実施回の一覧表示と比較を行います。",
+		"cli.usage.report":   "casemd report は記録した実施回をまたいで不安定なケース、合格率、網羅率を集計します。",
+		"cli.usage.serve":    "casemd serve はチェックリストのプレビュー、実施、書き出しを行う Web UI を起動します。",
+		"cli.usageHeading":   "使い方:",
+		"cli.flagsHeading":   "フラグ:",
+		"cli.written":        "%s を %s に書き出しました",
+		"cli.googleCreated":  "Google スプレッドシートを作成しました (ID: %s)",
+		"cli.statsParsed":    "%s を解析しました (%s): %s、%s",
+		"cli.statsConvert":   "%s: %s (%s)、%s 書き出し、%s",
+		"cli.planAssigned":   "%s: %s",
+		"cli.planEffort":     "%s: %s (見積 %s)",
+		"cli.runRecorded":    "実施回 %s を %s に記録しました: %s",
+		"cli.runSummary":     "%s  %s  %s: %s、%s、%s",
+		"cli.noRuns":         "%s に記録された実施回はありません",
+		"cli.runChange":      "%s: %s: %s → %s",
+		"cli.runNotRecorded": "記録なし",
+		"cli.noRunChanges":   "%s と %s で結果が変わったケースはありません",
+
+		"output.csv":             "CSV",
+		"output.xlsx":            "スプレッドシート",
+		"output.ods":             "スプレッドシート",
+		"output.junit":           "JUnit XML",
+		"output.tap":             "TAP",
+		"output.html":            "HTML レポート",
+		"output.markdown":        "Markdown",
+		"output.trend":           "傾向レポート",
+		"count.source.one":       "ソース %d 件",
+		"count.source.other":     "ソース %d 件",
+		"count.case.one":         "ケース %d 件",
+		"count.case.other":       "ケース %d 件",
+		"count.error.one":        "エラー %d 件",
+		"count.error.other":      "エラー %d 件",
+		"count.conversion.one":   "変換 %d 回",
+		"count.conversion.other": "変換 %d 回",
+
+		"trend.title":               "傾向レポート",
+		"trend.runs":                "実施回: %s",
+		"trend.none":                "なし",
+		"trend.flaky":               "結果が安定しないケース",
+		"trend.passRate":            "大項目ごとの合格率",
+		"trend.neverRun":            "一度も実施されていないケース",
+		"trend.stale":               "%d 日以上実施されていないケース",
+		"trend.column.checklist":    "チェックリスト",
+		"trend.column.case":         "ケース",
+		"trend.column.passed":       "合格",
+		"trend.column.failed":       "不合格",
+		"trend.column.flips":        "結果の反転",
+		"trend.column.history":      "履歴",
+		"trend.column.passRate":     "合格率",
+		"trend.column.lastRun":      "最終実施回",
+		"trend.column.lastExecuted": "最終実施日",
+		"trend.column.days":         "経過日数",
+
+		"report.title":           "検査レポート",
+		"report.checkpoints":     "確認項目 %d/%d (%d%%)",
+		"report.passed":          "合格 %d",
+		"report.failed":          "不合格 %d",
+		"report.skipped":         "未実施 %d",
+		"report.status.passed":   "合格",
+		"report.status.failed":   "不合格",
+		"report.status.skipped":  "未実施",
+		"report.priority":        "優先度: %s",
+		"report.type":            "種別: %s",
+		"report.preconditions":   "前提条件",
+		"report.steps":           "確認手順",
+		"report.checkpointsList": "確認項目",
+		"report.expected":        "期待結果",
+
+		"ui.title":                  "casemd プレビュー",
+		"ui.heading":                "casemd Markdown プレビュー",
+		"ui.intro":                  "Markdown をその場で CSV に変換して、解析結果を確認できます。",
+		"ui.checklistFiles":         "チェックリスト",
+		"ui.workspaceNotice":        "ファイルを選ぶとプレビューし、フォルダーはブックとして開きます。ファイルが変更されるとプレビューを更新します。",
+		"ui.loadingFiles":           "ファイルを読み込んでいます...",
+		"ui.sourceName":             "ソース名",
+		"ui.markdownContent":        "Markdown の内容",
+		"ui.previewCSV":             "CSV をプレビュー",
+		"ui.livePreview":            "ライブプレビュー",
+		"ui.files":                  "Markdown ファイル (任意、ダウンロード用)",
+		"ui.download":               "%s をダウンロード",
+		"ui.execute":                "チェックリストを実施",
+		"ui.diagnostics":            "診断",
+		"ui.diagnosticsPlaceholder": "ライブプレビューを有効にすると入力中に検査します。",
+		"ui.csvPreview":             "CSV プレビュー",
+		"ui.csvPreviewNotice":       "行をクリックすると、その行になった Markdown の行を強調表示します。",
+		"ui.waiting":                "プレビューを待っています...",
+		"ui.execution":              "実施",
+		"ui.executionNotice":        "確認項目にチェックを付け、ケースごとに結果を記録します。保存するとチェックリストの隣に結果ファイルを書き出します。スプレッドシートの列に反映するには %s を付けてエクスポートします。",
+		"ui.tester":                 "実施者",
+		"ui.notes":                  "備考",
+		"ui.signedInAs":             "%s としてサインインしています。変更した結果はこの名前で記録されます。",
+		"ui.yourName":               "名前",
+		"ui.saveResults":            "結果を保存",
+		"ui.converting":             "変換しています...",
+		"ui.done":                   "完了",
+		"ui.noData":                 "データがありません。",
+		"ui.noRows":                 "行がありません。",
+		"ui.lines":                  "%s-%s 行目",
+		"ui.noProblems":             "問題は見つかりませんでした。",
+		"ui.diagnosticLine":         "%s 行目: %s",
+		"ui.updating":               "更新しています...",
+		"ui.live":                   "ライブ",
+		"ui.reconnecting":           "再接続しています...",
+		"ui.preparingDownload":      "ダウンロードを準備しています...",
+		"ui.downloaded":             "ダウンロードしました",
+		"ui.noFiles":                "Markdown ファイルがありません。",
+		"ui.workbook":               "ブック",
+		"ui.showing":                "%s を表示しています",
+		"ui.workbookOf":             "%s のブック",
+		"ui.notRun":                 "未実施",
+		"ui.loading":                "読み込んでいます...",
+		"ui.saving":                 "保存しています...",
+		"ui.savedTo":                "%s に保存しました",
+	},
+}
@@ -0,0 +1,113 @@
+// Package i18n holds the message catalogs used for sheet headers, CLI output
+// and the web UI, and picks a language from flags, the locale environment or
+// HTTP headers.
+package i18n
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Language identifies a message catalog.
+type Language string
+
+const (
+	// English is the default language.
+	English Language = "en"
+	// Japanese follows the wording of the eval-spec-maker sheets.
+	Japanese Language = "ja"
+)
+
+// Languages lists the languages with a catalog.
+func Languages() []Language {
+	return []Language{English, Japanese}
+}
+
+// Parse returns the language of a tag such as "ja", "ja-JP" or a POSIX
+// locale such as "ja_JP.UTF-8". It reports false for languages without a
+// catalog.
+func Parse(tag string) (Language, bool) {
+	tag = strings.ToLower(strings.TrimSpace(tag))
+	if index := strings.IndexAny(tag, "-_.@"); index >= 0 {
+		tag = tag[:index]
+	}
+	for _, language := range Languages() {
+		if string(language) == tag {
+			return language, true
+		}
+	}
+	return "", false
+}
+
+// FromEnvironment picks the language of the locale environment: LC_ALL,
+// then LC_MESSAGES, then LANG, like POSIX programs do. The first variable
+// that is set decides; English is used when it names another language.
+func FromEnvironment(getenv func(string) string) Language {
+	for _, name := range []string{"LC_ALL", "LC_MESSAGES", "LANG"} {
+		if value := getenv(name); value != "" {
+			if language, ok := Parse(value); ok {
+				return language
+			}
+			return English
+		}
+	}
+	return English
+}
+
+// FromAcceptLanguage picks the first language of an Accept-Language header
+// that has a catalog, in the order the client listed them.
+func FromAcceptLanguage(header string) (Language, bool) {
+	for _, part := range strings.Split(header, ",") {
+		tag, _, _ := strings.Cut(part, ";")
+		if language, ok := Parse(tag); ok {
+			return language, true
+		}
+	}
+	return "", false
+}
+
+// catalog returns the messages of l, falling back to English.
+func (l Language) catalog() map[string]string {
+	if messages, ok := catalogs[l]; ok {
+		return messages
+	}
+	return catalogs[English]
+}
+
+// Text returns the message with key. Messages missing from a catalog fall
+// back to English, and unknown keys are returned as they are.
+func (l Language) Text(key string) string {
+	if message, ok := l.catalog()[key]; ok {
+		return message
+	}
+	if message, ok := catalogs[English][key]; ok {
+		return message
+	}
+	return key
+}
+
+// Format formats the message with key like fmt.Sprintf.
+func (l Language) Format(key string, args ...any) string {
+	return fmt.Sprintf(l.Text(key), args...)
+}
+
+// Count formats n with the noun whose singular and plural messages are
+// "count.<noun>.one" and "count.<noun>.other", e.g. "1 case" or "3 cases".
+func (l Language) Count(n int, noun string) string {
+	if n == 1 {
+		return l.Format("count."+noun+".one", n)
+	}
+	return l.Format("count."+noun+".other", n)
+}
+
+// Messages returns the messages whose key starts with prefix, e.g. the
+// strings handed to the web UI's script.
+func (l Language) Messages(prefix string) map[string]string {
+	messages := make(map[string]string)
+	for key := range catalogs[English] {
+		if strings.HasPrefix(key, prefix) {
+			messages[key] = l.Text(key)
+		}
+	}
+	return messages
+}
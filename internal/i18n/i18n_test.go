@@ -0,0 +1,82 @@
+package i18n
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestCatalogsHaveTheSameKeysAndPlaceholders(t *testing.T) {
+	for _, language := range Languages() {
+		messages := catalogs[language]
+		for key, english := range catalogs[English] {
+			message, ok := messages[key]
+			if !ok {
+				t.Errorf("%s: missing %q", language, key)
+				continue
+			}
+			for _, verb := range []string{"%s", "%d", "%%"} {
+				if strings.Count(message, verb) != strings.Count(english, verb) {
+					t.Errorf("%s: %q uses %s differently than English: %q", language, key, verb, message)
+				}
+			}
+		}
+		for key := range messages {
+			if _, ok := catalogs[English][key]; !ok {
+				t.Errorf("%s: %q is not in the English catalog", language, key)
+			}
+		}
+	}
+}
+
+func TestParse(t *testing.T) {
+	for tag, expected := range map[string]Language{"ja": Japanese, "ja-JP": Japanese, "ja_JP.UTF-8": Japanese, " EN_us ": English} {
+		if language, ok := Parse(tag); !ok || language != expected {
+			t.Fatalf("Parse(%q) = %q, %v; want %q", tag, language, ok, expected)
+		}
+	}
+	for _, tag := range []string{"", "C", "fr_FR.UTF-8"} {
+		if language, ok := Parse(tag); ok {
+			t.Fatalf("Parse(%q) = %q, want no language", tag, language)
+		}
+	}
+}
+
+func TestFromEnvironment(t *testing.T) {
+	for _, tc := range []struct {
+		env      map[string]string
+		expected Language
+	}{
+		{map[string]string{}, English},
+		{map[string]string{"LANG": "ja_JP.UTF-8"}, Japanese},
+		{map[string]string{"LC_MESSAGES": "C", "LANG": "ja_JP.UTF-8"}, English},
+		{map[string]string{"LC_ALL": "ja_JP.UTF-8", "LANG": "en_US.UTF-8"}, Japanese},
+	} {
+		if language := FromEnvironment(func(name string) string { return tc.env[name] }); language != tc.expected {
+			t.Fatalf("FromEnvironment(%v) = %q, want %q", tc.env, language, tc.expected)
+		}
+	}
+}
+
+func TestFromAcceptLanguage(t *testing.T) {
+	if language, ok := FromAcceptLanguage("fr-CH, ja;q=0.9, en;q=0.8"); !ok || language != Japanese {
+		t.Fatalf("FromAcceptLanguage() = %q, %v; want ja", language, ok)
+	}
+	if _, ok := FromAcceptLanguage("fr-CH, de"); ok {
+		t.Fatal("expected no language for fr and de")
+	}
+}
+
+func TestText(t *testing.T) {
+	if text := Japanese.Text("column.steps"); text != "確認手順" {
+		t.Fatalf("unexpected header: %q", text)
+	}
+	if text := Language("fr").Text("column.steps"); text != "Validation Steps" {
+		t.Fatalf("expected the English fallback, got %q", text)
+	}
+	if text := English.Text("no.such.key"); text != "no.such.key" {
+		t.Fatalf("expected the key for unknown messages, got %q", text)
+	}
+	if text := English.Count(1, "case") + ", " + English.Count(2, "case"); text != "1 case, 2 cases" {
+		t.Fatalf("unexpected counts: %q", text)
+	}
+}
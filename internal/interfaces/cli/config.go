@@ -0,0 +1,160 @@
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/9renpoto/casemd/internal/app"
+	"github.com/9renpoto/casemd/internal/core/filter"
+	"github.com/9renpoto/casemd/internal/i18n"
+)
+
+// configFile holds the settings read from the JSON file passed with
+// --config. Flags take precedence over it.
+type configFile struct {
+	Columns []configColumn `json:"columns"`
+	Layout  string         `json:"layout"`
+	Lang    string         `json:"lang"`
+	Filter  string         `json:"filter"`
+	Select  string         `json:"select"`
+}
+
+// configColumn is one entry of the "columns" list: a field, optionally with
+// its own header, or a header alone for a blank column.
+type configColumn struct {
+	Field  string `json:"field"`
+	Header string `json:"header"`
+}
+
+// loadConfig reads the config file at path; an empty path yields no settings.
+func loadConfig(path string) (configFile, error) {
+	var config configFile
+	if path == "" {
+		return config, nil
+	}
+	file, err := os.Open(path)
+	if err != nil {
+		return config, fmt.Errorf("open config file %s: %w", path, err)
+	}
+	defer file.Close()
+
+	decoder := json.NewDecoder(file)
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&config); err != nil {
+		return configFile{}, fmt.Errorf("read config file %s: %w", path, err)
+	}
+	return config, nil
+}
+
+// settingFlags holds the values of the flags that override the config file.
+type settingFlags struct {
+	columns   string
+	layout    string
+	lang      string
+	filter    string
+	selection string
+}
+
+// settings resolves the sheet settings from the flags, falling back to the
+// config file and then to the locale environment for the language.
+func (c configFile) settings(flags settingFlags) (sheetSettings, error) {
+	columns, err := c.columns(flags.columns)
+	if err != nil {
+		return sheetSettings{}, err
+	}
+	layout, err := c.layout(flags.layout)
+	if err != nil {
+		return sheetSettings{}, err
+	}
+	language, err := c.language(flags.lang)
+	if err != nil {
+		return sheetSettings{}, err
+	}
+	selected, err := c.filter(flags.filter, flags.selection)
+	if err != nil {
+		return sheetSettings{}, err
+	}
+	return sheetSettings{columns: columns, layout: layout, language: language, filter: selected}, nil
+}
+
+// columns returns the columns selected by spec, the value of --columns, or
+// else by the config file; nil keeps the defaults.
+func (c configFile) columns(spec string) ([]app.Column, error) {
+	if spec != "" {
+		columns, err := app.ParseColumns(spec)
+		if err != nil {
+			return nil, fmt.Errorf("parse --columns: %w", err)
+		}
+		return columns, nil
+	}
+	if len(c.Columns) == 0 {
+		return nil, nil
+	}
+
+	specs := make([]app.ColumnSpec, 0, len(c.Columns))
+	for _, column := range c.Columns {
+		specs = append(specs, app.ColumnSpec{Key: column.Field, Header: column.Header})
+	}
+	columns, err := app.BuildColumns(specs)
+	if err != nil {
+		return nil, fmt.Errorf("config columns: %w", err)
+	}
+	return columns, nil
+}
+
+// layout returns the layout named by --layout, or else by the config file.
+func (c configFile) layout(name string) (app.Layout, error) {
+	if name != "" {
+		layout, err := app.ParseLayout(name)
+		if err != nil {
+			return "", fmt.Errorf("parse --layout: %w", err)
+		}
+		return layout, nil
+	}
+	layout, err := app.ParseLayout(c.Layout)
+	if err != nil {
+		return "", fmt.Errorf("config layout: %w", err)
+	}
+	return layout, nil
+}
+
+// language returns the language named by --lang, or else by the config
+// file, or else by the locale environment.
+func (c configFile) language(name string) (i18n.Language, error) {
+	source := "parse --lang"
+	if name == "" {
+		name, source = c.Lang, "config lang"
+	}
+	if name == "" {
+		return i18n.FromEnvironment(os.Getenv), nil
+	}
+	language, ok := i18n.Parse(name)
+	if !ok {
+		return "", fmt.Errorf("%s: unknown language %q (available: %s, %s)", source, name, i18n.English, i18n.Japanese)
+	}
+	return language, nil
+}
+
+// filter returns the cases selected by both --filter and --select. Each flag
+// replaces the matching setting of the config file on its own.
+func (c configFile) filter(expression, selection string) (filter.Filter, error) {
+	source := "parse --filter"
+	if expression == "" {
+		expression, source = c.Filter, "config filter"
+	}
+	byExpression, err := filter.Parse(expression)
+	if err != nil {
+		return filter.Filter{}, fmt.Errorf("%s: %w", source, err)
+	}
+
+	source = "parse --select"
+	if selection == "" {
+		selection, source = c.Select, "config select"
+	}
+	byPath, err := filter.Select(selection)
+	if err != nil {
+		return filter.Filter{}, fmt.Errorf("%s: %w", source, err)
+	}
+	return byExpression.And(byPath), nil
+}
@@ -0,0 +1,115 @@
+package cli
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+var (
+	errMissingImportSource    = errors.New("missing required flag: --from")
+	errMissingImportInput     = errors.New("missing input: pass --input or file arguments")
+	errMissingImportConverter = errors.New("import requested but converter is not configured")
+	errConflictingOutputs     = errors.New("--output and --output-dir cannot be combined")
+)
+
+// runImport converts existing checklists in other formats into casemd Markdown.
+func (t *Tool) runImport(args []string) error {
+	fs := flag.NewFlagSet("casemd import", flag.ContinueOnError)
+	fs.SetOutput(t.stderr)
+
+	var inputPaths multiValueFlag
+	var from string
+	var outputPath string
+	var outputDir string
+	var flags settingFlags
+	var configPath string
+
+	fs.StringVar(&from, "from", "", "Format of the files to import (supported: xlsx)")
+	fs.Var(&inputPaths, "input", "Path to a file to import (repeat flag or pass paths as arguments)")
+	fs.StringVar(&outputPath, "output", "", "Path to the Markdown destination file (defaults to stdout)")
+	fs.StringVar(&outputDir, "output-dir", "", "Directory receiving one Markdown file per input")
+	fs.StringVar(&flags.layout, "layout", "", "Row layout of the workbooks: case (the default) or step, which joins consecutive rows of the same minor item into one case")
+	fs.StringVar(&flags.columns, "columns", "", "Columns the workbooks were written with, to read renamed headers (same syntax as casemd --columns)")
+	fs.StringVar(&configPath, "config", os.Getenv("CASEMD_CONFIG"), "JSON file with default columns, layout and language (defaults to CASEMD_CONFIG); flags override it")
+	fs.StringVar(&flags.lang, "lang", "", "Language of the messages: en or ja (defaults to LC_ALL, LC_MESSAGES or LANG); headers of either language are read")
+
+	fs.Usage = func() {
+		t.printUsage(fs, flags.lang, "cli.usage.import", "casemd import --from xlsx [flags] [files...]")
+	}
+
+	if parseErr := fs.Parse(args); parseErr != nil {
+		if errors.Is(parseErr, flag.ErrHelp) {
+			return nil
+		}
+		return parseErr
+	}
+
+	paths := append([]string(inputPaths), fs.Args()...)
+
+	if from == "" {
+		fs.Usage()
+		return errMissingImportSource
+	}
+	if len(paths) == 0 {
+		fs.Usage()
+		return errMissingImportInput
+	}
+	if outputPath != "" && outputDir != "" {
+		return errConflictingOutputs
+	}
+	config, err := loadConfig(configPath)
+	if err != nil {
+		return err
+	}
+	settings, err := config.settings(flags)
+	if err != nil {
+		return err
+	}
+	t.language = settings.language
+
+	var converter Converter
+	switch strings.ToLower(from) {
+	case "xlsx":
+		converter = t.converters.XLSXImport
+	default:
+		return fmt.Errorf("unsupported import format: %s", from)
+	}
+	if converter == nil {
+		return errMissingImportConverter
+	}
+
+	inputs, err := readInputFiles(paths)
+	if err != nil {
+		return err
+	}
+	inputs.apply(settings)
+
+	if outputDir != "" {
+		for _, input := range inputs {
+			base := strings.TrimSuffix(filepath.Base(input.name), filepath.Ext(input.name))
+			output := fileOutput{
+				path:      filepath.Join(outputDir, base+".md"),
+				label:     "Markdown",
+				format:    "markdown",
+				converter: converter,
+			}
+			if err := t.writeOutput(output, inputCollection{input}); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	if outputPath != "" {
+		return t.writeOutput(fileOutput{path: outputPath, label: "Markdown", format: "markdown", converter: converter}, inputs)
+	}
+
+	if err := converter.Convert(inputs.asSources(), t.stdout); err != nil {
+		return fmt.Errorf("convert %s to markdown: %w", from, err)
+	}
+	return nil
+}
@@ -0,0 +1,219 @@
+package cli
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/9renpoto/casemd/internal/app"
+	"github.com/9renpoto/casemd/internal/core/filter"
+)
+
+var (
+	errMissingPlanInput  = errors.New("missing input: pass --input or file arguments")
+	errMissingTesters    = errors.New("missing required flag: --testers")
+	errMissingPlanOutput = errors.New("missing required flag: --output or --output-dir")
+	errMissingPlanner    = errors.New("plan requested but planner is not configured")
+)
+
+// Planner splits a checklist set among testers from the CLI layer.
+type Planner interface {
+	Plan(sources []app.Source, testers []string, strategy app.PlanStrategy) (app.Plan, error)
+}
+
+// runPlan assigns the cases of the inputs to testers and writes their
+// workbooks.
+func (t *Tool) runPlan(args []string) error {
+	fs := flag.NewFlagSet("casemd plan", flag.ContinueOnError)
+	fs.SetOutput(t.stderr)
+
+	var inputPaths multiValueFlag
+	var testerList string
+	var strategyName string
+	var outputPath string
+	var outputDir string
+	var format string
+	var withResults bool
+	var flags settingFlags
+	var configPath string
+
+	fs.Var(&inputPaths, "input", "Path to a Markdown source file (repeat flag or pass paths as arguments)")
+	fs.StringVar(&testerList, "testers", "", "Comma-separated names of the testers to share the cases among")
+	fs.StringVar(&strategyName, "strategy", "", "How to share the cases: round-robin (the default), major (keep each major item with one tester) or effort (balance the Effort estimates)")
+	fs.StringVar(&outputPath, "output", "", "Path to a workbook with one sheet per tester")
+	fs.StringVar(&outputDir, "output-dir", "", "Directory receiving one workbook per tester, named after the tester")
+	fs.StringVar(&format, "format", "", "Workbook format: xlsx or ods (defaults to the --output extension, else xlsx)")
+	fs.BoolVar(&withResults, "with-results", false, "Fill Result, Test Date, Notes and checkpoint states from the <name>.results.json file next to each input")
+	fs.StringVar(&flags.columns, "columns", "", "Comma-separated workbook columns (same syntax as casemd --columns)")
+	fs.StringVar(&flags.layout, "layout", "", "Workbook rows: case (the default) or step")
+	fs.StringVar(&configPath, "config", os.Getenv("CASEMD_CONFIG"), "JSON file with default columns, layout, language, filter and selection (defaults to CASEMD_CONFIG); flags override it")
+	fs.StringVar(&flags.lang, "lang", "", "Language of the messages and sheet headers: en or ja (defaults to LC_ALL, LC_MESSAGES or LANG)")
+	fs.StringVar(&flags.filter, "filter", "", "Plan only the cases matching this expression (fields: "+strings.Join(filter.Fields(), ", ")+")")
+	fs.StringVar(&flags.selection, "select", "", "Plan only the cases below this Major/Medium/Minor path")
+
+	fs.Usage = func() {
+		t.printUsage(fs, flags.lang, "cli.usage.plan", "casemd plan --testers alice,bob --output-dir plan/ [flags] [files...]")
+	}
+
+	if parseErr := fs.Parse(args); parseErr != nil {
+		if errors.Is(parseErr, flag.ErrHelp) {
+			return nil
+		}
+		return parseErr
+	}
+
+	paths := append([]string(inputPaths), fs.Args()...)
+	var testers []string
+	for _, tester := range strings.Split(testerList, ",") {
+		if tester = strings.TrimSpace(tester); tester != "" {
+			testers = append(testers, tester)
+		}
+	}
+
+	if len(paths) == 0 {
+		fs.Usage()
+		return errMissingPlanInput
+	}
+	if len(testers) == 0 {
+		fs.Usage()
+		return errMissingTesters
+	}
+	if outputPath == "" && outputDir == "" {
+		fs.Usage()
+		return errMissingPlanOutput
+	}
+	if outputPath != "" && outputDir != "" {
+		return errConflictingOutputs
+	}
+	if t.converters.Planner == nil {
+		return errMissingPlanner
+	}
+
+	strategy, err := app.ParsePlanStrategy(strategyName)
+	if err != nil {
+		return fmt.Errorf("parse --strategy: %w", err)
+	}
+	if format == "" {
+		format = "xlsx"
+		if strings.EqualFold(filepath.Ext(outputPath), ".ods") {
+			format = "ods"
+		}
+	}
+	var spreadsheetFormat app.SpreadsheetFormat
+	switch strings.ToLower(format) {
+	case "xlsx":
+		spreadsheetFormat = app.XLSXFormat
+	case "ods":
+		spreadsheetFormat = app.ODSFormat
+	default:
+		return fmt.Errorf("unsupported spreadsheet format: %s", format)
+	}
+
+	config, err := loadConfig(configPath)
+	if err != nil {
+		return err
+	}
+	settings, err := config.settings(flags)
+	if err != nil {
+		return err
+	}
+	t.language = settings.language
+
+	inputs, err := readInputFiles(paths)
+	if err != nil {
+		return err
+	}
+	if withResults {
+		if err := inputs.loadResults(); err != nil {
+			return err
+		}
+	}
+	inputs.apply(settings)
+
+	plan, err := t.converters.Planner.Plan(inputs.asSources(), testers, strategy)
+	if err != nil {
+		return fmt.Errorf("plan: %w", err)
+	}
+
+	if outputDir != "" {
+		owners := make(map[string]string, len(plan.Assignments))
+		for _, assignment := range plan.Assignments {
+			name := testerFileName(assignment.Tester)
+			if owner, taken := owners[strings.ToLower(name)]; taken {
+				return fmt.Errorf("testers %q and %q would both be written to %s", owner, assignment.Tester, name)
+			}
+			owners[strings.ToLower(name)] = assignment.Tester
+		}
+		for _, assignment := range plan.Assignments {
+			path := filepath.Join(outputDir, testerFileName(assignment.Tester)+"."+string(spreadsheetFormat))
+			if err := t.writeWorkbook(path, string(spreadsheetFormat), func(file *os.File) error {
+				return assignment.WriteWorkbook(file, spreadsheetFormat)
+			}); err != nil {
+				return err
+			}
+		}
+	} else if err := t.writeWorkbook(outputPath, string(spreadsheetFormat), func(file *os.File) error {
+		return plan.WriteWorkbook(file, spreadsheetFormat)
+	}); err != nil {
+		return err
+	}
+
+	l := t.language
+	for _, assignment := range plan.Assignments {
+		if assignment.Effort > 0 {
+			fmt.Fprintln(t.stdout, l.Format("cli.planEffort", assignment.Tester, l.Count(assignment.Cases, "case"), formatEffort(assignment.Effort)))
+			continue
+		}
+		fmt.Fprintln(t.stdout, l.Format("cli.planAssigned", assignment.Tester, l.Count(assignment.Cases, "case")))
+	}
+	return nil
+}
+
+// writeWorkbook creates the file at path and fills it with write.
+func (t *Tool) writeWorkbook(path, format string, write func(*os.File) error) error {
+	if err := ensureParentDirectory(path); err != nil {
+		return err
+	}
+	file, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("create spreadsheet output file: %w", err)
+	}
+	if err := write(file); err != nil {
+		file.Close()
+		return fmt.Errorf("write spreadsheet %s: %w", path, err)
+	}
+	if err := file.Close(); err != nil {
+		return fmt.Errorf("close spreadsheet output file: %w", err)
+	}
+	fmt.Fprintln(t.stdout, t.language.Format("cli.written", t.language.Text("output."+format), path))
+	return nil
+}
+
+var unsafeFileNameCharacters = strings.NewReplacer("/", "_", "\\", "_", ":", "_", "*", "_", "?", "_", "\"", "_", "<", "_", ">", "_", "|", "_")
+
+// testerFileName turns a tester's name into a file name without separators
+// or characters that some file systems reject.
+func testerFileName(tester string) string {
+	name := strings.Trim(unsafeFileNameCharacters.Replace(tester), ". ")
+	if name == "" {
+		return "tester"
+	}
+	return name
+}
+
+// formatEffort prints an effort rounded to the minute, e.g. 1h30m.
+func formatEffort(effort time.Duration) string {
+	text := effort.Round(time.Minute).String()
+	text = strings.TrimSuffix(text, "0s")
+	if strings.HasSuffix(text, "h0m") {
+		text = strings.TrimSuffix(text, "0m")
+	}
+	if text == "" {
+		return "0m"
+	}
+	return text
+}
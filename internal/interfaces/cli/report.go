@@ -0,0 +1,219 @@
+package cli
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/9renpoto/casemd/internal/app"
+	"github.com/9renpoto/casemd/internal/core/domain"
+	"github.com/9renpoto/casemd/internal/core/results"
+)
+
+var (
+	errMissingTrendReporter = errors.New("report requested but the trend reporter is not configured")
+	errMissingReportRuns    = errors.New("no runs to report on: record runs with casemd runs record or pass run files")
+)
+
+// TrendReporter aggregates runs from the CLI layer.
+type TrendReporter interface {
+	Report(runs []domain.NamedRun, checklists []app.Source, options app.TrendOptions) (app.TrendReport, error)
+}
+
+// runReport aggregates recorded runs, run files and filled-in workbooks into
+// a trend report.
+func (t *Tool) runReport(args []string) error {
+	fs := flag.NewFlagSet("casemd report", flag.ContinueOnError)
+	fs.SetOutput(t.stderr)
+
+	var inputPaths multiValueFlag
+	var runNames string
+	var staleDays int
+	var format string
+	var outputPath string
+	var flags settingFlags
+	var configPath string
+
+	fs.Var(&inputPaths, "input", "Run file (.json), filled-in workbook (.xlsx) or Markdown checklist to report on (repeat flag or pass paths as arguments)")
+	fs.StringVar(&runNames, "runs", "", "Comma-separated runs of --runs-dir to report on (defaults to every recorded run when no run file or workbook is passed)")
+	runsDir := runsDirFlag(fs)
+	fs.IntVar(&staleDays, "stale-days", 30, "List the cases last executed more than this many days ago; 0 turns the list off")
+	fs.StringVar(&format, "format", "", "Report format: markdown, html, xlsx or ods (defaults to the --output extension, else markdown)")
+	fs.StringVar(&outputPath, "output", "", "Path to the report destination file (defaults to stdout for markdown and html)")
+	fs.StringVar(&flags.columns, "columns", "", "Columns of the workbooks and of the checklist sheets (same syntax as casemd --columns)")
+	fs.StringVar(&flags.layout, "layout", "", "Row layout of the workbooks and of the checklist sheets: case (the default) or step")
+	fs.StringVar(&configPath, "config", os.Getenv("CASEMD_CONFIG"), "JSON file with default columns, layout, language, filter and selection (defaults to CASEMD_CONFIG); flags override it")
+	fs.StringVar(&flags.lang, "lang", "", "Language of the messages and of the report: en or ja (defaults to LC_ALL, LC_MESSAGES or LANG)")
+	fs.StringVar(&flags.filter, "filter", "", "Report only on the checklist and workbook cases matching this expression")
+	fs.StringVar(&flags.selection, "select", "", "Report only on the checklist and workbook cases below this Major/Medium/Minor path")
+
+	fs.Usage = func() {
+		t.printUsage(fs, flags.lang, "cli.usage.report", "casemd report [flags] [runs.json|workbook.xlsx|checklist.md...]")
+	}
+
+	if parseErr := fs.Parse(args); parseErr != nil {
+		if errors.Is(parseErr, flag.ErrHelp) {
+			return nil
+		}
+		return parseErr
+	}
+	if staleDays < 0 {
+		return fmt.Errorf("--stale-days cannot be negative: %d", staleDays)
+	}
+	if t.converters.TrendReporter == nil {
+		return errMissingTrendReporter
+	}
+
+	if format == "" {
+		format = "markdown"
+		switch ext := strings.ToLower(filepath.Ext(outputPath)); ext {
+		case ".html", ".htm":
+			format = "html"
+		case ".xlsx", ".ods":
+			format = ext[1:]
+		}
+	}
+	format = strings.ToLower(format)
+	switch format {
+	case "markdown", "html":
+	case "xlsx", "ods":
+		if outputPath == "" {
+			return fmt.Errorf("--output is required for the %s format", format)
+		}
+	default:
+		return fmt.Errorf("unsupported report format: %s", format)
+	}
+
+	config, err := loadConfig(configPath)
+	if err != nil {
+		return err
+	}
+	settings, err := config.settings(flags)
+	if err != nil {
+		return err
+	}
+	t.language = settings.language
+
+	inputs, err := readInputFiles(append([]string(inputPaths), fs.Args()...))
+	if err != nil {
+		return err
+	}
+	inputs.apply(settings)
+
+	var runs []domain.NamedRun
+	var checklists inputCollection
+	for _, input := range inputs {
+		switch strings.ToLower(filepath.Ext(input.name)) {
+		case ".json":
+			var run domain.NamedRun
+			if err := json.Unmarshal(input.data, &run); err != nil {
+				return fmt.Errorf("read run file %s: %w", input.name, err)
+			}
+			runs = append(runs, run)
+		case ".xlsx":
+			run, err := t.workbookRun(input)
+			if err != nil {
+				return err
+			}
+			runs = append(runs, run)
+		default:
+			checklists = append(checklists, input)
+		}
+	}
+
+	if runNames != "" || len(runs) == 0 {
+		store, err := t.openRunStore(*runsDir)
+		if err != nil {
+			return err
+		}
+		stored, err := storedRuns(store, runNames)
+		if err != nil {
+			return err
+		}
+		runs = append(runs, stored...)
+	}
+	if len(runs) == 0 {
+		return errMissingReportRuns
+	}
+
+	report, err := t.converters.TrendReporter.Report(runs, checklists.asSources(), app.TrendOptions{StaleDays: staleDays, Language: settings.language})
+	if err != nil {
+		return fmt.Errorf("report: %w", err)
+	}
+
+	write := func(output io.Writer) error {
+		switch format {
+		case "html":
+			return report.WriteHTML(output)
+		case "xlsx":
+			return report.WriteWorkbook(output, app.XLSXFormat)
+		case "ods":
+			return report.WriteWorkbook(output, app.ODSFormat)
+		default:
+			return report.WriteMarkdown(output)
+		}
+	}
+	if outputPath == "" {
+		return write(t.stdout)
+	}
+
+	if err := ensureParentDirectory(outputPath); err != nil {
+		return err
+	}
+	file, err := os.Create(outputPath)
+	if err != nil {
+		return fmt.Errorf("create report output file: %w", err)
+	}
+	if err := write(file); err != nil {
+		file.Close()
+		return err
+	}
+	if err := file.Close(); err != nil {
+		return fmt.Errorf("close report output file: %w", err)
+	}
+	fmt.Fprintln(t.stdout, t.language.Format("cli.written", t.language.Text("output.trend"), outputPath))
+	return nil
+}
+
+// workbookRun records a filled-in workbook as a run named after the file and
+// dated by its modification time.
+func (t *Tool) workbookRun(input inputFile) (domain.NamedRun, error) {
+	if t.converters.RunRecorder == nil {
+		return domain.NamedRun{}, errMissingRunRecorder
+	}
+	info, err := os.Stat(input.name)
+	if err != nil {
+		return domain.NamedRun{}, fmt.Errorf("open input file %s: %w", input.name, err)
+	}
+	name := results.SourceKey(input.name)
+	run, err := t.converters.RunRecorder.Record(name, inputCollection{input}.asSources(), info.ModTime())
+	if err != nil {
+		return domain.NamedRun{}, fmt.Errorf("record run %s: %w", name, err)
+	}
+	return run, nil
+}
+
+// storedRuns loads the runs named in the comma-separated names, or every
+// stored run when names is empty.
+func storedRuns(store RunStore, names string) ([]domain.NamedRun, error) {
+	if names == "" {
+		return store.List()
+	}
+	var runs []domain.NamedRun
+	for _, name := range strings.Split(names, ",") {
+		if name = strings.TrimSpace(name); name == "" {
+			continue
+		}
+		run, err := store.Load(name)
+		if err != nil {
+			return nil, err
+		}
+		runs = append(runs, run)
+	}
+	return runs, nil
+}
@@ -0,0 +1,300 @@
+package cli
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/9renpoto/casemd/internal/app"
+	"github.com/9renpoto/casemd/internal/core/domain"
+	"github.com/9renpoto/casemd/internal/core/results"
+)
+
+// defaultRunsDir holds the runs when neither --runs-dir nor CASEMD_RUNS_DIR
+// name a directory.
+const defaultRunsDir = ".casemd/runs"
+
+var (
+	errMissingRunsCommand = errors.New("missing runs command: record, list or compare")
+	errMissingRunName     = errors.New("missing required flag: --name")
+	errMissingRunInput    = errors.New("missing input: pass --input or file arguments")
+	errMissingRunStore    = errors.New("runs requested but the run store is not configured")
+	errMissingRunRecorder = errors.New("runs record requested but the recorder is not configured")
+	errConflictingResults = errors.New("--with-results and --run cannot be combined")
+)
+
+// RunRecorder captures named runs from the CLI layer.
+type RunRecorder interface {
+	Record(name string, sources []app.Source, now time.Time) (domain.NamedRun, error)
+}
+
+// RunStore keeps the named runs for the CLI layer.
+type RunStore interface {
+	Save(run domain.NamedRun, overwrite bool) error
+	Load(name string) (domain.NamedRun, error)
+	List() ([]domain.NamedRun, error)
+}
+
+// runsDirFlag registers --runs-dir on fs.
+func runsDirFlag(fs *flag.FlagSet) *string {
+	dir := os.Getenv("CASEMD_RUNS_DIR")
+	if dir == "" {
+		dir = defaultRunsDir
+	}
+	return fs.String("runs-dir", dir, "Directory holding the recorded runs (defaults to CASEMD_RUNS_DIR, else "+defaultRunsDir+")")
+}
+
+// openRunStore returns the store kept in dir.
+func (t *Tool) openRunStore(dir string) (RunStore, error) {
+	if t.converters.RunStore == nil {
+		return nil, errMissingRunStore
+	}
+	return t.converters.RunStore(dir), nil
+}
+
+// runRuns dispatches the runs subcommands.
+func (t *Tool) runRuns(args []string) error {
+	if len(args) == 0 || strings.HasPrefix(args[0], "-") {
+		fs := flag.NewFlagSet("casemd runs", flag.ContinueOnError)
+		fs.SetOutput(t.stderr)
+		t.printUsage(fs, "", "cli.usage.runs", runsUsages...)
+		if len(args) > 0 && (args[0] == "-h" || args[0] == "--help") {
+			return nil
+		}
+		return errMissingRunsCommand
+	}
+
+	switch args[0] {
+	case "record":
+		return t.runRecord(args[1:])
+	case "list":
+		return t.runList(args[1:])
+	case "compare":
+		return t.runCompare(args[1:])
+	default:
+		return fmt.Errorf("unknown runs command %q (available: record, list, compare)", args[0])
+	}
+}
+
+var runsUsages = []string{
+	"casemd runs record --name rc1 [flags] [files...]",
+	"casemd runs list [flags]",
+	"casemd runs compare [flags] <before> <after>",
+}
+
+// runRecord stores the results held by the inputs as a named run.
+func (t *Tool) runRecord(args []string) error {
+	fs := flag.NewFlagSet("casemd runs record", flag.ContinueOnError)
+	fs.SetOutput(t.stderr)
+
+	var inputPaths multiValueFlag
+	var name string
+	var force bool
+	var withResults bool
+	var flags settingFlags
+	var configPath string
+
+	fs.Var(&inputPaths, "input", "Path to a Markdown checklist or a filled-in XLSX workbook (repeat flag or pass paths as arguments)")
+	fs.StringVar(&name, "name", "", "Name of the run, e.g. rc1 or 2026-10-18")
+	fs.BoolVar(&force, "force", false, "Replace a run recorded under the same name")
+	fs.BoolVar(&withResults, "with-results", false, "Include the <name>.results.json file next to each Markdown input")
+	runsDir := runsDirFlag(fs)
+	fs.StringVar(&flags.columns, "columns", "", "Columns the workbooks were written with, to read renamed headers (same syntax as casemd --columns)")
+	fs.StringVar(&flags.layout, "layout", "", "Row layout of the workbooks: case (the default) or step")
+	fs.StringVar(&configPath, "config", os.Getenv("CASEMD_CONFIG"), "JSON file with default columns, layout, language, filter and selection (defaults to CASEMD_CONFIG); flags override it")
+	fs.StringVar(&flags.lang, "lang", "", "Language of the messages: en or ja (defaults to LC_ALL, LC_MESSAGES or LANG)")
+	fs.StringVar(&flags.filter, "filter", "", "Record only the cases matching this expression")
+	fs.StringVar(&flags.selection, "select", "", "Record only the cases below this Major/Medium/Minor path")
+
+	fs.Usage = func() {
+		t.printUsage(fs, flags.lang, "cli.usage.runs", runsUsages[0])
+	}
+
+	if parseErr := fs.Parse(args); parseErr != nil {
+		if errors.Is(parseErr, flag.ErrHelp) {
+			return nil
+		}
+		return parseErr
+	}
+
+	paths := append([]string(inputPaths), fs.Args()...)
+	if name == "" {
+		fs.Usage()
+		return errMissingRunName
+	}
+	if len(paths) == 0 {
+		fs.Usage()
+		return errMissingRunInput
+	}
+	if t.converters.RunRecorder == nil {
+		return errMissingRunRecorder
+	}
+	store, err := t.openRunStore(*runsDir)
+	if err != nil {
+		return err
+	}
+
+	config, err := loadConfig(configPath)
+	if err != nil {
+		return err
+	}
+	settings, err := config.settings(flags)
+	if err != nil {
+		return err
+	}
+	t.language = settings.language
+
+	inputs, err := readInputFiles(paths)
+	if err != nil {
+		return err
+	}
+	if withResults {
+		if err := inputs.loadResults(); err != nil {
+			return err
+		}
+	}
+	inputs.apply(settings)
+
+	run, err := t.converters.RunRecorder.Record(name, inputs.asSources(), time.Now())
+	if err != nil {
+		return fmt.Errorf("record run %s: %w", name, err)
+	}
+	if err := store.Save(run, force); err != nil {
+		return err
+	}
+	fmt.Fprintln(t.stdout, t.language.Format("cli.runRecorded", name, *runsDir, t.language.Count(app.SummarizeRun(run).Cases, "case")))
+	return nil
+}
+
+// runList prints the recorded runs, oldest first.
+func (t *Tool) runList(args []string) error {
+	fs := flag.NewFlagSet("casemd runs list", flag.ContinueOnError)
+	fs.SetOutput(t.stderr)
+
+	var lang string
+	runsDir := runsDirFlag(fs)
+	fs.StringVar(&lang, "lang", "", "Language of the messages: en or ja (defaults to LC_ALL, LC_MESSAGES or LANG)")
+	fs.Usage = func() {
+		t.printUsage(fs, lang, "cli.usage.runs", runsUsages[1])
+	}
+
+	if parseErr := fs.Parse(args); parseErr != nil {
+		if errors.Is(parseErr, flag.ErrHelp) {
+			return nil
+		}
+		return parseErr
+	}
+	if fs.NArg() > 0 {
+		return fmt.Errorf("unexpected positional arguments: %v", fs.Args())
+	}
+	if err := t.setLanguage(lang); err != nil {
+		return err
+	}
+	store, err := t.openRunStore(*runsDir)
+	if err != nil {
+		return err
+	}
+
+	runs, err := store.List()
+	if err != nil {
+		return err
+	}
+	l := t.language
+	if len(runs) == 0 {
+		fmt.Fprintln(t.stdout, l.Format("cli.noRuns", *runsDir))
+		return nil
+	}
+	for _, run := range runs {
+		summary := app.SummarizeRun(run)
+		recorded := run.Recorded.Local().Format("2006-01-02 15:04")
+		fmt.Fprintln(t.stdout, l.Format("cli.runSummary", run.Name, recorded, l.Count(summary.Cases, "case"),
+			l.Format("report.passed", summary.Passed), l.Format("report.failed", summary.Failed), l.Format("report.skipped", summary.Skipped)))
+	}
+	return nil
+}
+
+// runCompare prints the cases whose outcome changed between two runs.
+func (t *Tool) runCompare(args []string) error {
+	fs := flag.NewFlagSet("casemd runs compare", flag.ContinueOnError)
+	fs.SetOutput(t.stderr)
+
+	var lang string
+	runsDir := runsDirFlag(fs)
+	fs.StringVar(&lang, "lang", "", "Language of the messages: en or ja (defaults to LC_ALL, LC_MESSAGES or LANG)")
+	fs.Usage = func() {
+		t.printUsage(fs, lang, "cli.usage.runs", runsUsages[2])
+	}
+
+	if parseErr := fs.Parse(args); parseErr != nil {
+		if errors.Is(parseErr, flag.ErrHelp) {
+			return nil
+		}
+		return parseErr
+	}
+	if fs.NArg() != 2 {
+		fs.Usage()
+		return fmt.Errorf("compare takes two run names, got %d", fs.NArg())
+	}
+	if err := t.setLanguage(lang); err != nil {
+		return err
+	}
+	store, err := t.openRunStore(*runsDir)
+	if err != nil {
+		return err
+	}
+
+	before, err := store.Load(fs.Arg(0))
+	if err != nil {
+		return err
+	}
+	after, err := store.Load(fs.Arg(1))
+	if err != nil {
+		return err
+	}
+
+	l := t.language
+	changes := app.CompareRuns(before, after)
+	if len(changes) == 0 {
+		fmt.Fprintln(t.stdout, l.Format("cli.noRunChanges", before.Name, after.Name))
+		return nil
+	}
+	outcome := func(name string) string {
+		if name == "" {
+			return l.Text("cli.runNotRecorded")
+		}
+		return l.Text("report.status." + name)
+	}
+	for _, change := range changes {
+		fmt.Fprintln(t.stdout, l.Format("cli.runChange", change.Source, change.Case, outcome(change.Before), outcome(change.After)))
+	}
+	return nil
+}
+
+// setLanguage applies --lang for the commands without a config file.
+func (t *Tool) setLanguage(name string) error {
+	language, err := configFile{}.language(name)
+	if err != nil {
+		return err
+	}
+	t.language = language
+	return nil
+}
+
+// loadRun attaches the results run holds for each input. At least one input
+// must have results in the run.
+func (c inputCollection) loadRun(run domain.NamedRun) error {
+	found := false
+	for i, input := range c {
+		if recorded, ok := results.Find(run, input.name); ok {
+			c[i].results = &recorded
+			found = true
+		}
+	}
+	if !found {
+		return fmt.Errorf("run %s holds no results for the inputs", run.Name)
+	}
+	return nil
+}
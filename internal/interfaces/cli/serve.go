@@ -0,0 +1,143 @@
+package cli
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/9renpoto/casemd/internal/i18n"
+)
+
+var (
+	errMissingWebServer   = errors.New("serve requested but the web server is not configured")
+	errConflictingSignIns = errors.New("choose one of --auth-token-file, --htpasswd and --oidc-issuer")
+)
+
+// WebServer runs the web UI for casemd serve until ctx is done.
+type WebServer interface {
+	Serve(ctx context.Context, config ServeConfig) error
+}
+
+// ServeConfig is the web UI configuration read from the serve flags and
+// environment.
+type ServeConfig struct {
+	// Addr is a TCP address such as ":3000", or "unix:" followed by the
+	// path of a Unix socket.
+	Addr string
+	// CertFile and KeyFile serve HTTPS when both are set.
+	CertFile string
+	KeyFile  string
+	BasePath string
+	// Workspace is the directory of checklists to browse; empty without
+	// one.
+	Workspace      string
+	AssetsDir      string
+	DrainTimeout   time.Duration
+	ReadyGrace     time.Duration
+	ConvertTimeout time.Duration
+	BodyLimit      int
+	RateLimit      int
+	AccessLog      bool
+	// Language is empty to follow each browser's Accept-Language.
+	Language   i18n.Language
+	SessionKey []byte
+	// At most one sign-in method is set; the UI is open without one.
+	TokenFile        string
+	Htpasswd         string
+	OIDCIssuer       string
+	OIDCClientID     string
+	OIDCClientSecret string
+	OIDCRedirectURL  string
+}
+
+// runServe starts the web UI and serves it until SIGINT or SIGTERM.
+func (t *Tool) runServe(args []string) error {
+	fs := flag.NewFlagSet("casemd serve", flag.ContinueOnError)
+	fs.SetOutput(t.stderr)
+
+	config := ServeConfig{
+		Addr:     os.Getenv("CASEMD_WEB_ADDR"),
+		CertFile: os.Getenv("CASEMD_TLS_CERT"),
+		KeyFile:  os.Getenv("CASEMD_TLS_KEY"),
+		BasePath: os.Getenv("CASEMD_BASE_PATH"),
+	}
+	var lang string
+
+	fs.StringVar(&config.Addr, "addr", config.Addr, "Address to listen on, or unix:/path/to.sock (defaults to CASEMD_WEB_ADDR or :3000)")
+	fs.StringVar(&config.CertFile, "tls-cert", config.CertFile, "Serve HTTPS with this certificate file (requires --tls-key)")
+	fs.StringVar(&config.KeyFile, "tls-key", config.KeyFile, "Private key file for --tls-cert")
+	fs.StringVar(&config.BasePath, "base-path", config.BasePath, "Serve every route below this path prefix, e.g. /casemd behind a reverse proxy")
+	fs.DurationVar(&config.DrainTimeout, "drain-timeout", 10*time.Second, "How long to wait for requests in flight on SIGINT or SIGTERM")
+	fs.DurationVar(&config.ReadyGrace, "ready-grace", 0, "How long to keep serving with /readyz reporting 503 on SIGINT or SIGTERM before closing the listener")
+	fs.StringVar(&config.AssetsDir, "assets-dir", "", "Serve templates and static files from this directory instead of the embedded copies")
+	fs.IntVar(&config.BodyLimit, "max-body-bytes", 4<<20, "Reject request bodies larger than this many bytes")
+	fs.DurationVar(&config.ConvertTimeout, "convert-timeout", 10*time.Second, "Abort conversions that take longer than this (0 disables)")
+	fs.IntVar(&config.RateLimit, "rate-limit", 600, "Requests per minute allowed per client IP (0 disables)")
+	fs.BoolVar(&config.AccessLog, "access-log", true, "Log one line per request to stderr")
+	fs.StringVar(&lang, "lang", os.Getenv("CASEMD_LANG"), "Language of the UI and sheet headers: en or ja (defaults to CASEMD_LANG, else each browser's Accept-Language)")
+	fs.StringVar(&config.TokenFile, "auth-token-file", "", "Require a bearer token; the file lists user:token lines")
+	fs.StringVar(&config.Htpasswd, "htpasswd", "", "Require HTTP basic sign-in against this htpasswd file (bcrypt entries only)")
+	fs.StringVar(&config.OIDCIssuer, "oidc-issuer", "", "Sign in through this OpenID Connect provider (client secret from CASEMD_OIDC_CLIENT_SECRET)")
+	fs.StringVar(&config.OIDCClientID, "oidc-client-id", "", "Client ID registered with the OpenID Connect provider")
+	fs.StringVar(&config.OIDCRedirectURL, "oidc-redirect-url", "", "Callback URL registered with the OpenID Connect provider, e.g. https://casemd.example.com/auth/callback")
+
+	fs.Usage = func() {
+		t.printUsage(fs, lang, "cli.usage.serve", "casemd serve [flags] [dir]")
+	}
+
+	if parseErr := fs.Parse(args); parseErr != nil {
+		if errors.Is(parseErr, flag.ErrHelp) {
+			return nil
+		}
+		return parseErr
+	}
+
+	// A directory argument enables workspace mode. Anything else is the
+	// listen address, which used to be the only positional argument.
+	if fs.NArg() > 0 {
+		if info, err := os.Stat(fs.Arg(0)); err == nil && info.IsDir() {
+			config.Workspace = fs.Arg(0)
+		} else {
+			config.Addr = fs.Arg(0)
+		}
+	}
+	if config.Addr == "" {
+		config.Addr = ":3000"
+	}
+
+	selected := 0
+	for _, value := range []string{config.TokenFile, config.Htpasswd, config.OIDCIssuer} {
+		if value != "" {
+			selected++
+		}
+	}
+	if selected > 1 {
+		return errConflictingSignIns
+	}
+	if config.OIDCIssuer != "" {
+		config.OIDCClientSecret = os.Getenv("CASEMD_OIDC_CLIENT_SECRET")
+	}
+	if key := os.Getenv("CASEMD_SESSION_KEY"); key != "" {
+		config.SessionKey = []byte(key)
+	}
+
+	if lang != "" {
+		language, ok := i18n.Parse(lang)
+		if !ok {
+			return fmt.Errorf("parse --lang: unknown language %q (available: %s, %s)", lang, i18n.English, i18n.Japanese)
+		}
+		config.Language = language
+	}
+
+	if t.converters.WebServer == nil {
+		return errMissingWebServer
+	}
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	return t.converters.WebServer.Serve(ctx, config)
+}
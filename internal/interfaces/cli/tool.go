@@ -10,16 +10,26 @@ import (
 	"os"
 	"path/filepath"
 	"strings"
+	"time"
 
 	"github.com/9renpoto/casemd/internal/app"
+	"github.com/9renpoto/casemd/internal/core/domain"
+	"github.com/9renpoto/casemd/internal/core/filter"
+	"github.com/9renpoto/casemd/internal/core/results"
+	"github.com/9renpoto/casemd/internal/i18n"
 )
 
 var (
 	errMissingInput                = errors.New("missing required flag: --input")
-	errMissingOutput               = errors.New("missing required flag: --csv-output, --spreadsheet-output, or --google-spreadsheet-title")
+	errMissingOutput               = errors.New("missing required flag: --csv-output, --spreadsheet-output, --junit-output, --tap-output, --html-output, or --google-spreadsheet-title")
 	errMissingCSVConverter         = errors.New("csv output requested but converter is not configured")
 	errMissingSpreadsheetConverter = errors.New("spreadsheet output requested but converter is not configured")
+	errMissingODSConverter         = errors.New("ods output requested but converter is not configured")
 	errMissingGoogleConverter      = errors.New("google spreadsheet requested but converter is not configured")
+	errMissingJUnitConverter       = errors.New("junit output requested but converter is not configured")
+	errMissingTAPConverter         = errors.New("tap output requested but converter is not configured")
+	errMissingHTMLConverter        = errors.New("html output requested but converter is not configured")
+	errMissingStats                = errors.New("stats requested but not configured")
 )
 
 // Converter drives Markdown transformations from the CLI layer.
@@ -32,39 +42,109 @@ type GoogleSpreadsheetCreator interface {
 	Create(ctx context.Context, title string, sources []app.Source) (string, error)
 }
 
+// HTMLConverter renders HTML reports from the CLI layer.
+type HTMLConverter interface {
+	ConvertWithOptions(sources []app.Source, output io.Writer, options app.HTMLReportOptions) error
+}
+
+// Converters groups the use cases the CLI dispatches to. Nil entries report an
+// error when the matching output flag is used.
+type Converters struct {
+	CSV           Converter
+	Spreadsheet   Converter
+	ODS           Converter
+	JUnit         Converter
+	TAP           Converter
+	HTML          HTMLConverter
+	XLSXImport    Converter
+	Google        GoogleSpreadsheetCreator
+	Planner       Planner
+	RunRecorder   RunRecorder
+	TrendReporter TrendReporter
+	WebServer     WebServer
+	// RunStore opens the run store kept in a directory.
+	RunStore func(dir string) RunStore
+	// Stats times every output conversion and is printed by --stats; wrap
+	// the parser of the converters with the same Stats to include parse
+	// counts.
+	Stats *app.Stats
+}
+
 // Tool represents the CLI adapter that receives user input and dispatches commands.
 type Tool struct {
-	stdout               io.Writer
-	stderr               io.Writer
-	csvConverter         Converter
-	spreadsheetConverter Converter
-	googleConverter      GoogleSpreadsheetCreator
+	stdout     io.Writer
+	stderr     io.Writer
+	converters Converters
+	// language of the messages printed by the running command.
+	language i18n.Language
 }
 
-// New creates a CLI tool with the provided output streams and conversion use case.
-func New(stdout, stderr io.Writer, csvConverter, spreadsheetConverter Converter, googleConverter GoogleSpreadsheetCreator) *Tool {
-	return &Tool{stdout: stdout, stderr: stderr, csvConverter: csvConverter, spreadsheetConverter: spreadsheetConverter, googleConverter: googleConverter}
+// New creates a CLI tool with the provided output streams and conversion use cases.
+func New(stdout, stderr io.Writer, converters Converters) *Tool {
+	return &Tool{stdout: stdout, stderr: stderr, converters: converters}
 }
 
 // Run parses CLI arguments, validates required options, and executes the conversion pipeline.
 func (t *Tool) Run(args []string) (err error) {
+	t.language = i18n.FromEnvironment(os.Getenv)
+	if len(args) > 0 && args[0] == "import" {
+		return t.runImport(args[1:])
+	}
+	if len(args) > 0 && args[0] == "plan" {
+		return t.runPlan(args[1:])
+	}
+	if len(args) > 0 && args[0] == "runs" {
+		return t.runRuns(args[1:])
+	}
+	if len(args) > 0 && args[0] == "report" {
+		return t.runReport(args[1:])
+	}
+	if len(args) > 0 && args[0] == "serve" {
+		return t.runServe(args[1:])
+	}
+
 	fs := flag.NewFlagSet("casemd", flag.ContinueOnError)
 	fs.SetOutput(t.stderr)
 
 	var inputPaths multiValueFlag
 	var csvOutputPath string
 	var spreadsheetOutputPath string
+	var spreadsheetFormat string
+	var junitOutputPath string
+	var tapOutputPath string
+	var htmlOutputPath string
+	var htmlTitle string
+	var htmlStylesheetHref string
 	var googleSpreadsheetTitle string
+	var withResults bool
+	var runName string
+	var flags settingFlags
+	var configPath string
+	var printStats bool
 
 	fs.Var(&inputPaths, "input", "Path to the Markdown source file (repeat flag for multiple files)")
 	fs.StringVar(&csvOutputPath, "csv-output", "", "Path to the CSV destination file")
 	fs.StringVar(&spreadsheetOutputPath, "spreadsheet-output", "", "Path to the spreadsheet destination file")
+	fs.StringVar(&spreadsheetFormat, "spreadsheet-format", "", "Spreadsheet format: xlsx or ods (defaults to the --spreadsheet-output extension)")
+	fs.StringVar(&junitOutputPath, "junit-output", "", "Path to the JUnit XML report destination file")
+	fs.StringVar(&tapOutputPath, "tap-output", "", "Path to the TAP report destination file")
+	fs.StringVar(&htmlOutputPath, "html-output", "", "Path to the HTML report destination file")
+	fs.StringVar(&htmlTitle, "html-title", "", "Heading for the HTML report")
+	fs.StringVar(&htmlStylesheetHref, "html-stylesheet-href", "", "Link this stylesheet from the HTML report instead of inlining the default styles")
 	fs.StringVar(&googleSpreadsheetTitle, "google-spreadsheet-title", "", "Title for the Google Spreadsheet to create")
+	fs.BoolVar(&withResults, "with-results", false, "Fill Result, Test Date, Tester, Notes and checkpoint states from the <name>.results.json file next to each input")
+	fs.StringVar(&runName, "run", "", "Fill Result, Test Date, Tester, Notes and checkpoint states from this run recorded with casemd runs record")
+	runsDir := runsDirFlag(fs)
+	fs.StringVar(&flags.columns, "columns", "", "Comma-separated CSV and spreadsheet columns; key=Label renames a column, +Label adds a blank column, and \"default\" stands for the nine default columns (available: "+strings.Join(app.ColumnKeys(), ", ")+")")
+	fs.StringVar(&flags.layout, "layout", "", "CSV and spreadsheet rows: case (one row per case, the default) or step (one row per validation step with its nested checkpoints)")
+	fs.StringVar(&configPath, "config", os.Getenv("CASEMD_CONFIG"), "JSON file with default columns, layout, language, filter and selection (defaults to CASEMD_CONFIG); flags override it")
+	fs.StringVar(&flags.lang, "lang", "", "Language of the messages, sheet headers and HTML report: en or ja (defaults to LC_ALL, LC_MESSAGES or LANG)")
+	fs.StringVar(&flags.filter, "filter", "", "Convert only the cases matching this expression, e.g. 'tag:smoke && priority<=P2' (fields: "+strings.Join(filter.Fields(), ", ")+")")
+	fs.StringVar(&flags.selection, "select", "", "Convert only the cases below this Major/Medium/Minor path, e.g. \"Setup/Environment/*\"")
+	fs.BoolVar(&printStats, "stats", false, "Print parse and conversion statistics when done")
 
 	fs.Usage = func() {
-		fmt.Fprintf(t.stderr, "casemd converts Markdown inspection sheets into CSV files, Excel workbooks, and Google Spreadsheets.\n\n")
-		fmt.Fprintf(t.stderr, "Usage:\n  casemd [flags]\n\nFlags:\n")
-		fs.PrintDefaults()
+		t.printUsage(fs, flags.lang, "cli.usage", "casemd [flags]", "casemd import --from xlsx [flags] [files...]", "casemd plan --testers alice,bob --output-dir plan/ [flags] [files...]", "casemd runs record|list|compare [flags]", "casemd report [flags] [files...]", "casemd serve [flags] [dir]")
 	}
 
 	if parseErr := fs.Parse(args); parseErr != nil {
@@ -83,76 +163,195 @@ func (t *Tool) Run(args []string) (err error) {
 		return errMissingInput
 	}
 
-	if csvOutputPath == "" && spreadsheetOutputPath == "" && googleSpreadsheetTitle == "" {
+	if csvOutputPath == "" && spreadsheetOutputPath == "" && junitOutputPath == "" && tapOutputPath == "" && htmlOutputPath == "" && googleSpreadsheetTitle == "" {
 		fs.Usage()
 		return errMissingOutput
 	}
 
+	if printStats && t.converters.Stats == nil {
+		return errMissingStats
+	}
+
+	if withResults && runName != "" {
+		return errConflictingResults
+	}
+
+	spreadsheetOutput, formatErr := t.spreadsheetOutput(spreadsheetOutputPath, spreadsheetFormat)
+	if formatErr != nil {
+		return formatErr
+	}
+
+	config, configErr := loadConfig(configPath)
+	if configErr != nil {
+		return configErr
+	}
+	settings, settingsErr := config.settings(flags)
+	if settingsErr != nil {
+		return settingsErr
+	}
+	t.language = settings.language
+
 	inputs, readErr := readInputFiles([]string(inputPaths))
 	if readErr != nil {
 		return readErr
 	}
-
-	if csvOutputPath != "" {
-		if t.csvConverter == nil {
-			return errMissingCSVConverter
-		}
-		if err := ensureParentDirectory(csvOutputPath); err != nil {
+	if withResults {
+		if err := inputs.loadResults(); err != nil {
 			return err
 		}
-
-		csvFile, createErr := os.Create(csvOutputPath)
-		if createErr != nil {
-			return fmt.Errorf("create CSV output file: %w", createErr)
+	}
+	if runName != "" {
+		store, err := t.openRunStore(*runsDir)
+		if err != nil {
+			return err
 		}
-		if convertErr := t.csvConverter.Convert(inputs.asSources(), csvFile); convertErr != nil {
-			if closeErr := csvFile.Close(); closeErr != nil {
-				return fmt.Errorf("close CSV output file: %w", closeErr)
-			}
-			return fmt.Errorf("convert markdown to CSV: %w", convertErr)
+		run, err := store.Load(runName)
+		if err != nil {
+			return err
 		}
-		if closeErr := csvFile.Close(); closeErr != nil {
-			return fmt.Errorf("close CSV output file: %w", closeErr)
+		if err := inputs.loadRun(run); err != nil {
+			return err
 		}
-		fmt.Fprintf(t.stdout, "CSV written to %s\n", csvOutputPath)
 	}
+	inputs.apply(settings)
 
-	if spreadsheetOutputPath != "" {
-		if t.spreadsheetConverter == nil {
-			return errMissingSpreadsheetConverter
+	outputs := []fileOutput{
+		{path: csvOutputPath, label: "CSV", format: "csv", converter: t.converters.CSV, missing: errMissingCSVConverter},
+		spreadsheetOutput,
+		{path: junitOutputPath, label: "JUnit XML", format: "junit", converter: t.converters.JUnit, missing: errMissingJUnitConverter},
+		{path: tapOutputPath, label: "TAP", format: "tap", converter: t.converters.TAP, missing: errMissingTAPConverter},
+		{path: htmlOutputPath, label: "HTML report", format: "html", missing: errMissingHTMLConverter},
+	}
+	if html := t.converters.HTML; html != nil {
+		options := app.HTMLReportOptions{Title: htmlTitle, StylesheetHref: htmlStylesheetHref}
+		outputs[len(outputs)-1].converter = converterFunc(func(sources []app.Source, output io.Writer) error {
+			return html.ConvertWithOptions(sources, output, options)
+		})
+	}
+	for _, output := range outputs {
+		if output.path == "" {
+			continue
 		}
-		if err := ensureParentDirectory(spreadsheetOutputPath); err != nil {
+		if err := t.writeOutput(output, inputs); err != nil {
 			return err
 		}
-
-		spreadsheetFile, createErr := os.Create(spreadsheetOutputPath)
-		if createErr != nil {
-			return fmt.Errorf("create spreadsheet output file: %w", createErr)
-		}
-		if convertErr := t.spreadsheetConverter.Convert(inputs.asSources(), spreadsheetFile); convertErr != nil {
-			if closeErr := spreadsheetFile.Close(); closeErr != nil {
-				return fmt.Errorf("close spreadsheet output file: %w", closeErr)
-			}
-			return fmt.Errorf("convert markdown to spreadsheet: %w", convertErr)
-		}
-		if closeErr := spreadsheetFile.Close(); closeErr != nil {
-			return fmt.Errorf("close spreadsheet output file: %w", closeErr)
-		}
-		fmt.Fprintf(t.stdout, "Spreadsheet written to %s\n", spreadsheetOutputPath)
 	}
 
 	if googleSpreadsheetTitle != "" {
-		if t.googleConverter == nil {
+		if t.converters.Google == nil {
 			return errMissingGoogleConverter
 		}
 
-		id, err := t.googleConverter.Create(context.Background(), googleSpreadsheetTitle, inputs.asSources())
+		id, err := t.converters.Google.Create(context.Background(), googleSpreadsheetTitle, inputs.asSources())
 		if err != nil {
 			return fmt.Errorf("create google spreadsheet: %w", err)
 		}
-		fmt.Fprintf(t.stdout, "Google Spreadsheet created with ID %s\n", id)
+		fmt.Fprintln(t.stdout, t.language.Format("cli.googleCreated", id))
+	}
+
+	if printStats {
+		t.writeStats(t.converters.Stats.Snapshot())
+	}
+	return nil
+}
+
+// printUsage prints the description with key, the usage lines and the flags
+// of fs, in the language named by --lang when it was parsed already.
+func (t *Tool) printUsage(fs *flag.FlagSet, langName, key string, usages ...string) {
+	language := t.language
+	if parsed, ok := i18n.Parse(langName); ok {
+		language = parsed
+	}
+	fmt.Fprintf(t.stderr, "%s\n\n%s\n", language.Text(key), language.Text("cli.usageHeading"))
+	for _, usage := range usages {
+		fmt.Fprintf(t.stderr, "  %s\n", usage)
+	}
+	fmt.Fprintf(t.stderr, "\n%s\n", language.Text("cli.flagsHeading"))
+	fs.PrintDefaults()
+}
+
+// writeStats prints the --stats summary.
+func (t *Tool) writeStats(snapshot app.StatsSnapshot) {
+	parse := snapshot.Parse
+	l := t.language
+	fmt.Fprintln(t.stdout, l.Format("cli.statsParsed", l.Count(parse.Sources, "source"), formatBytes(float64(parse.Bytes)), l.Count(parse.Cases, "case"), l.Count(parse.Errors, "error")))
+	for _, conversion := range snapshot.Conversions {
+		elapsed := time.Duration(conversion.Duration.Sum * float64(time.Second)).Round(time.Microsecond)
+		fmt.Fprintln(t.stdout, l.Format("cli.statsConvert", conversion.Format, l.Count(conversion.Count, "conversion"), elapsed, formatBytes(conversion.Size.Sum), l.Count(conversion.Errors, "error")))
+	}
+}
+
+func formatBytes(n float64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%d B", int64(n))
+	}
+	exponent := 0
+	for n >= unit*unit && exponent < 2 {
+		n /= unit
+		exponent++
+	}
+	return fmt.Sprintf("%.1f %ciB", n/unit, "KMG"[exponent])
+}
+
+func (t *Tool) spreadsheetOutput(path, format string) (fileOutput, error) {
+	if format == "" {
+		format = "xlsx"
+		if strings.EqualFold(filepath.Ext(path), ".ods") {
+			format = "ods"
+		}
+	}
+
+	switch strings.ToLower(format) {
+	case "xlsx":
+		return fileOutput{path: path, label: "spreadsheet", format: "xlsx", converter: t.converters.Spreadsheet, missing: errMissingSpreadsheetConverter}, nil
+	case "ods":
+		return fileOutput{path: path, label: "spreadsheet", format: "ods", converter: t.converters.ODS, missing: errMissingODSConverter}, nil
+	default:
+		return fileOutput{}, fmt.Errorf("unsupported spreadsheet format: %s", format)
+	}
+}
+
+type converterFunc func(sources []app.Source, output io.Writer) error
+
+func (f converterFunc) Convert(sources []app.Source, output io.Writer) error {
+	return f(sources, output)
+}
+
+type fileOutput struct {
+	path      string
+	label     string
+	format    string
+	converter Converter
+	missing   error
+}
+
+func (t *Tool) writeOutput(output fileOutput, inputs inputCollection) error {
+	if output.converter == nil {
+		return output.missing
+	}
+	converter := output.converter
+	if t.converters.Stats != nil {
+		converter = t.converters.Stats.Converter(output.format, converter)
+	}
+	if err := ensureParentDirectory(output.path); err != nil {
+		return err
 	}
 
+	file, createErr := os.Create(output.path)
+	if createErr != nil {
+		return fmt.Errorf("create %s output file: %w", output.label, createErr)
+	}
+	if convertErr := converter.Convert(inputs.asSources(), file); convertErr != nil {
+		if closeErr := file.Close(); closeErr != nil {
+			return fmt.Errorf("close %s output file: %w", output.label, closeErr)
+		}
+		return fmt.Errorf("convert markdown to %s: %w", output.label, convertErr)
+	}
+	if closeErr := file.Close(); closeErr != nil {
+		return fmt.Errorf("close %s output file: %w", output.label, closeErr)
+	}
+	fmt.Fprintln(t.stdout, t.language.Format("cli.written", t.language.Text("output."+output.format), output.path))
 	return nil
 }
 
@@ -173,8 +372,10 @@ func (m *multiValueFlag) Set(value string) error {
 type inputCollection []inputFile
 
 type inputFile struct {
-	name string
-	data []byte
+	name     string
+	data     []byte
+	results  *domain.Run
+	settings sheetSettings
 }
 
 func readInputFiles(paths []string) (inputCollection, error) {
@@ -189,14 +390,59 @@ func readInputFiles(paths []string) (inputCollection, error) {
 	return inputCollection(inputs), nil
 }
 
+// loadResults attaches the results file recorded next to each input, if any.
+func (c inputCollection) loadResults() error {
+	for i, input := range c {
+		path := results.SidecarPath(input.name)
+		file, err := os.Open(path)
+		if errors.Is(err, os.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return fmt.Errorf("open results file %s: %w", path, err)
+		}
+		run, err := results.Read(file)
+		file.Close()
+		if err != nil {
+			return fmt.Errorf("read results file %s: %w", path, err)
+		}
+		c[i].results = &run
+	}
+	return nil
+}
+
+// sheetSettings shape the sheets converted from every input.
+type sheetSettings struct {
+	// columns are the selected columns; nil keeps the defaults.
+	columns  []app.Column
+	layout   app.Layout
+	language i18n.Language
+	filter   filter.Filter
+}
+
+// apply sets the sheet settings of every input.
+func (c inputCollection) apply(settings sheetSettings) {
+	for i := range c {
+		c[i].settings = settings
+	}
+}
+
 func (c inputCollection) asSources() []app.Source {
 	sources := make([]app.Source, 0, len(c))
 	for _, input := range c {
-		sources = append(sources, app.Source{Name: input.name, Reader: bytes.NewReader(input.data)})
+		sources = append(sources, app.Source{Name: input.name, Reader: bytes.NewReader(input.data), Results: input.results, Columns: input.settings.columns, Layout: input.settings.layout, Language: input.settings.language, Filter: input.settings.filter, Includes: input.includes()})
 	}
 	return sources
 }
 
+// includes resolves the include directives of the input against the file
+// system, relative to the input's directory.
+func (f inputFile) includes() app.Includes {
+	return app.Includes{Name: filepath.ToSlash(f.name), Open: func(name string) (io.ReadCloser, error) {
+		return os.Open(filepath.FromSlash(name))
+	}}
+}
+
 func ensureParentDirectory(path string) error {
 	dir := filepath.Dir(path)
 	if dir == "." || dir == "" {
@@ -4,12 +4,19 @@ import (
 	"bytes"
 	"context"
 	"errors"
+	"fmt"
+	"io"
 	"os"
 	"path/filepath"
+	"reflect"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/9renpoto/casemd/internal/app"
+	"github.com/9renpoto/casemd/internal/core/domain"
+	"github.com/9renpoto/casemd/internal/i18n"
+	"github.com/9renpoto/casemd/internal/interfaces/runstore"
 )
 
 type mockGoogleSpreadsheetCreator struct {
@@ -37,7 +44,7 @@ func TestToolRunCreatesGoogleSpreadsheet(t *testing.T) {
 	var stderr bytes.Buffer
 	creator := &mockGoogleSpreadsheetCreator{id: "sheet-id"}
 
-	tool := New(&stdout, &stderr, nil, nil, creator)
+	tool := New(&stdout, &stderr, Converters{Google: creator})
 	if err := tool.Run([]string{"--input", inputPath, "--google-spreadsheet-title", "Casemd Export"}); err != nil {
 		t.Fatalf("Run() returned an unexpected error: %v", err)
 	}
@@ -62,10 +69,663 @@ func TestToolRunRequiresGoogleConverter(t *testing.T) {
 
 	var stdout bytes.Buffer
 	var stderr bytes.Buffer
-	tool := New(&stdout, &stderr, nil, nil, nil)
+	tool := New(&stdout, &stderr, Converters{})
 
 	err := tool.Run([]string{"--input", inputPath, "--google-spreadsheet-title", "Casemd Export"})
 	if !errors.Is(err, errMissingGoogleConverter) {
 		t.Fatalf("expected errMissingGoogleConverter, got %v", err)
 	}
 }
+
+type stubConverter struct {
+	output  string
+	sources []app.Source
+}
+
+func (s *stubConverter) Convert(sources []app.Source, output io.Writer) error {
+	s.sources = sources
+	_, err := io.WriteString(output, s.output)
+	return err
+}
+
+func TestToolRunWritesReportOutputs(t *testing.T) {
+	dir := t.TempDir()
+	inputPath := filepath.Join(dir, "case.md")
+	if err := os.WriteFile(inputPath, []byte("# Case"), 0o644); err != nil {
+		t.Fatalf("write input file: %v", err)
+	}
+
+	var stdout bytes.Buffer
+	var stderr bytes.Buffer
+	junit := &stubConverter{output: "<testsuites/>"}
+	tap := &stubConverter{output: "TAP version 13\n"}
+	tool := New(&stdout, &stderr, Converters{JUnit: junit, TAP: tap})
+
+	junitPath := filepath.Join(dir, "reports", "casemd.xml")
+	tapPath := filepath.Join(dir, "reports", "casemd.tap")
+	if err := tool.Run([]string{"--input", inputPath, "--junit-output", junitPath, "--tap-output", tapPath, "--lang", "en"}); err != nil {
+		t.Fatalf("Run() returned an unexpected error: %v", err)
+	}
+
+	for path, expected := range map[string]string{junitPath: junit.output, tapPath: tap.output} {
+		content, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatalf("read %s: %v", path, err)
+		}
+		if string(content) != expected {
+			t.Fatalf("unexpected content in %s: %q", path, content)
+		}
+	}
+	if !strings.Contains(stdout.String(), "JUnit XML written to "+junitPath) {
+		t.Fatalf("stdout missing junit message: %s", stdout.String())
+	}
+}
+
+func TestToolRunAttachesRecordedResults(t *testing.T) {
+	dir := t.TempDir()
+	recordedPath := filepath.Join(dir, "recorded.md")
+	plainPath := filepath.Join(dir, "plain.md")
+	for path, content := range map[string]string{
+		recordedPath: "## Setup\n#### Install",
+		plainPath:    "## Setup\n#### Install",
+		filepath.Join(dir, "recorded.results.json"): `{"cases":[{"majorItem":"Setup","minorItem":"Install","result":"pass","tester":"alice"}]}`,
+	} {
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatalf("write %s: %v", path, err)
+		}
+	}
+
+	csv := &stubConverter{}
+	tool := New(&bytes.Buffer{}, &bytes.Buffer{}, Converters{CSV: csv})
+	args := []string{"--input", recordedPath, "--input", plainPath, "--csv-output", filepath.Join(dir, "out.csv")}
+
+	if err := tool.Run(args); err != nil {
+		t.Fatalf("Run() returned an unexpected error: %v", err)
+	}
+	if csv.sources[0].Results != nil {
+		t.Fatal("results must only be attached with --with-results")
+	}
+
+	if err := tool.Run(append(args, "--with-results")); err != nil {
+		t.Fatalf("Run() returned an unexpected error: %v", err)
+	}
+	run := csv.sources[0].Results
+	if run == nil || len(run.Cases) != 1 || run.Cases[0].Tester != "alice" {
+		t.Fatalf("expected recorded results to be attached, got %+v", run)
+	}
+	if csv.sources[1].Results != nil {
+		t.Fatalf("expected no results for an input without a results file, got %+v", csv.sources[1].Results)
+	}
+}
+
+func TestToolRunSelectsColumns(t *testing.T) {
+	dir := t.TempDir()
+	inputPath := filepath.Join(dir, "case.md")
+	if err := os.WriteFile(inputPath, []byte("# Case"), 0o644); err != nil {
+		t.Fatalf("write input file: %v", err)
+	}
+
+	csv := &stubConverter{}
+	tool := New(&bytes.Buffer{}, &bytes.Buffer{}, Converters{CSV: csv})
+	args := []string{"--input", inputPath, "--csv-output", filepath.Join(dir, "out.csv")}
+
+	if err := tool.Run(append(args, "--columns", "minor,priority,tags", "--layout", "step")); err != nil {
+		t.Fatalf("Run() returned an unexpected error: %v", err)
+	}
+	var keys []string
+	for _, column := range csv.sources[0].Columns {
+		keys = append(keys, column.Key)
+	}
+	if strings.Join(keys, ",") != "minor,priority,tags" {
+		t.Fatalf("unexpected columns: %v", keys)
+	}
+	if layout := csv.sources[0].Layout; layout != app.StepLayout {
+		t.Fatalf("unexpected layout: %q", layout)
+	}
+
+	err := tool.Run(append(args, "--columns", "minor,owner"))
+	if err == nil || !strings.Contains(err.Error(), `unknown column "owner"`) {
+		t.Fatalf("expected an unknown column error, got %v", err)
+	}
+	err = tool.Run(append(args, "--layout", "sheet"))
+	if err == nil || !strings.Contains(err.Error(), "parse --layout") {
+		t.Fatalf("expected a layout error, got %v", err)
+	}
+}
+
+func TestToolRunReadsConfigFile(t *testing.T) {
+	dir := t.TempDir()
+	inputPath := filepath.Join(dir, "case.md")
+	if err := os.WriteFile(inputPath, []byte("# Case"), 0o644); err != nil {
+		t.Fatalf("write input file: %v", err)
+	}
+	configPath := filepath.Join(dir, "casemd.json")
+	config := `{"columns": [{"field": "minor", "header": "Case"}, {"field": "steps"}, {"header": "Ticket"}], "layout": "step"}`
+	if err := os.WriteFile(configPath, []byte(config), 0o644); err != nil {
+		t.Fatalf("write config file: %v", err)
+	}
+
+	csv := &stubConverter{}
+	tool := New(&bytes.Buffer{}, &bytes.Buffer{}, Converters{CSV: csv})
+	args := []string{"--input", inputPath, "--csv-output", filepath.Join(dir, "out.csv"), "--config", configPath}
+
+	if err := tool.Run(args); err != nil {
+		t.Fatalf("Run() returned an unexpected error: %v", err)
+	}
+	var headers []string
+	for _, column := range csv.sources[0].Columns {
+		headers = append(headers, column.Header)
+	}
+	if strings.Join(headers, ",") != "Case,Validation Steps,Ticket" || csv.sources[0].Layout != app.StepLayout {
+		t.Fatalf("unexpected settings: %v, %q", headers, csv.sources[0].Layout)
+	}
+
+	if err := tool.Run(append(args, "--columns", "minor", "--layout", "case")); err != nil {
+		t.Fatalf("Run() returned an unexpected error: %v", err)
+	}
+	if columns := csv.sources[0].Columns; len(columns) != 1 || columns[0].Header != "Minor Item" || csv.sources[0].Layout != app.CaseLayout {
+		t.Fatalf("expected flags to override the config file, got %+v, %q", columns, csv.sources[0].Layout)
+	}
+
+	if err := os.WriteFile(configPath, []byte(`{"colums": []}`), 0o644); err != nil {
+		t.Fatalf("write config file: %v", err)
+	}
+	if err := tool.Run(args); err == nil || !strings.Contains(err.Error(), "read config file") {
+		t.Fatalf("expected a config file error, got %v", err)
+	}
+}
+
+func TestToolRunFiltersCases(t *testing.T) {
+	dir := t.TempDir()
+	inputPath := filepath.Join(dir, "case.md")
+	if err := os.WriteFile(inputPath, []byte("# Case"), 0o644); err != nil {
+		t.Fatalf("write input file: %v", err)
+	}
+	configPath := filepath.Join(dir, "casemd.json")
+	if err := os.WriteFile(configPath, []byte(`{"filter": "tag:smoke", "select": "Setup"}`), 0o644); err != nil {
+		t.Fatalf("write config file: %v", err)
+	}
+
+	smoke := domain.Case{MajorItem: "Setup", MinorItem: "Install", Priority: "P1", Tags: []string{"smoke"}}
+	regression := domain.Case{MajorItem: "Setup", MinorItem: "Configure", Priority: "P3", Tags: []string{"regression"}}
+	other := domain.Case{MajorItem: "Execution", MinorItem: "Run", Priority: "P1", Tags: []string{"smoke"}}
+	selected := func(source app.Source) []string {
+		var names []string
+		for _, aCase := range []domain.Case{smoke, regression, other} {
+			if source.Filter.Match(aCase) {
+				names = append(names, aCase.MinorItem)
+			}
+		}
+		return names
+	}
+
+	csv := &stubConverter{}
+	tool := New(&bytes.Buffer{}, &bytes.Buffer{}, Converters{CSV: csv})
+	args := []string{"--input", inputPath, "--csv-output", filepath.Join(dir, "out.csv")}
+
+	if err := tool.Run(append(args, "--filter", "priority<=P2", "--select", "*/*/I*")); err != nil {
+		t.Fatalf("Run() returned an unexpected error: %v", err)
+	}
+	if names := selected(csv.sources[0]); !reflect.DeepEqual(names, []string{"Install"}) {
+		t.Fatalf("unexpected selection: %v", names)
+	}
+
+	if err := tool.Run(append(args, "--config", configPath, "--filter", "priority>P2")); err != nil {
+		t.Fatalf("Run() returned an unexpected error: %v", err)
+	}
+	if names := selected(csv.sources[0]); !reflect.DeepEqual(names, []string{"Configure"}) {
+		t.Fatalf("expected --filter to replace the config filter but keep its selection, got %v", names)
+	}
+
+	err := tool.Run(append(args, "--filter", "tag:smoke &&"))
+	if err == nil || err.Error() != "parse --filter: unexpected end of expression" {
+		t.Fatalf("expected a filter error, got %v", err)
+	}
+}
+
+func TestToolRunLocalizesOutput(t *testing.T) {
+	dir := t.TempDir()
+	inputPath := filepath.Join(dir, "case.md")
+	if err := os.WriteFile(inputPath, []byte("# Case"), 0o644); err != nil {
+		t.Fatalf("write input file: %v", err)
+	}
+	csvPath := filepath.Join(dir, "out.csv")
+
+	var stdout bytes.Buffer
+	csv := &stubConverter{}
+	tool := New(&stdout, &bytes.Buffer{}, Converters{CSV: csv})
+
+	t.Setenv("LC_ALL", "")
+	t.Setenv("LC_MESSAGES", "")
+	t.Setenv("LANG", "ja_JP.UTF-8")
+	if err := tool.Run([]string{"--input", inputPath, "--csv-output", csvPath}); err != nil {
+		t.Fatalf("Run() returned an unexpected error: %v", err)
+	}
+	if csv.sources[0].Language != i18n.Japanese || !strings.Contains(stdout.String(), "CSV を "+csvPath+" に書き出しました") {
+		t.Fatalf("expected Japanese from LANG, got %q: %s", csv.sources[0].Language, stdout.String())
+	}
+
+	stdout.Reset()
+	if err := tool.Run([]string{"--input", inputPath, "--csv-output", csvPath, "--lang", "en"}); err != nil {
+		t.Fatalf("Run() returned an unexpected error: %v", err)
+	}
+	if csv.sources[0].Language != i18n.English || !strings.Contains(stdout.String(), "CSV written to "+csvPath) {
+		t.Fatalf("expected --lang to override LANG, got %q: %s", csv.sources[0].Language, stdout.String())
+	}
+
+	err := tool.Run([]string{"--input", inputPath, "--csv-output", csvPath, "--lang", "fr"})
+	if err == nil || !strings.Contains(err.Error(), `unknown language "fr"`) {
+		t.Fatalf("expected an unknown language error, got %v", err)
+	}
+}
+
+func TestToolRunRequiresJUnitConverter(t *testing.T) {
+	dir := t.TempDir()
+	inputPath := filepath.Join(dir, "case.md")
+	if err := os.WriteFile(inputPath, []byte("# Case"), 0o644); err != nil {
+		t.Fatalf("write input file: %v", err)
+	}
+
+	var stdout bytes.Buffer
+	var stderr bytes.Buffer
+	tool := New(&stdout, &stderr, Converters{})
+
+	err := tool.Run([]string{"--input", inputPath, "--junit-output", filepath.Join(dir, "out.xml")})
+	if !errors.Is(err, errMissingJUnitConverter) {
+		t.Fatalf("expected errMissingJUnitConverter, got %v", err)
+	}
+}
+
+func TestToolRunPrintsStats(t *testing.T) {
+	dir := t.TempDir()
+	inputPath := filepath.Join(dir, "case.md")
+	if err := os.WriteFile(inputPath, []byte("# Case"), 0o644); err != nil {
+		t.Fatalf("write input file: %v", err)
+	}
+
+	var stdout bytes.Buffer
+	var stderr bytes.Buffer
+	tool := New(&stdout, &stderr, Converters{CSV: &stubConverter{output: "Major Item\n"}, Stats: app.NewStats()})
+
+	if err := tool.Run([]string{"--input", inputPath, "--csv-output", filepath.Join(dir, "out.csv"), "--stats", "--lang", "en"}); err != nil {
+		t.Fatalf("Run() returned an unexpected error: %v", err)
+	}
+	if !strings.Contains(stdout.String(), "csv: 1 conversion in ") || !strings.Contains(stdout.String(), "11 B written, 0 errors") {
+		t.Fatalf("stdout missing csv stats: %s", stdout.String())
+	}
+
+	tool = New(&stdout, &stderr, Converters{CSV: &stubConverter{}})
+	err := tool.Run([]string{"--input", inputPath, "--csv-output", filepath.Join(dir, "out.csv"), "--stats"})
+	if !errors.Is(err, errMissingStats) {
+		t.Fatalf("expected errMissingStats, got %v", err)
+	}
+}
+
+func TestToolRunSelectsSpreadsheetFormat(t *testing.T) {
+	dir := t.TempDir()
+	inputPath := filepath.Join(dir, "case.md")
+	if err := os.WriteFile(inputPath, []byte("# Case"), 0o644); err != nil {
+		t.Fatalf("write input file: %v", err)
+	}
+
+	tests := []struct {
+		name     string
+		args     []string
+		expected string
+	}{
+		{name: "xlsx extension", args: []string{"--spreadsheet-output", filepath.Join(dir, "out.xlsx")}, expected: "xlsx"},
+		{name: "ods extension", args: []string{"--spreadsheet-output", filepath.Join(dir, "out.ods")}, expected: "ods"},
+		{name: "explicit format", args: []string{"--spreadsheet-output", filepath.Join(dir, "out.bin"), "--spreadsheet-format", "ods"}, expected: "ods"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var stdout bytes.Buffer
+			var stderr bytes.Buffer
+			xlsx := &stubConverter{output: "xlsx"}
+			ods := &stubConverter{output: "ods"}
+			tool := New(&stdout, &stderr, Converters{Spreadsheet: xlsx, ODS: ods})
+
+			if err := tool.Run(append([]string{"--input", inputPath}, tt.args...)); err != nil {
+				t.Fatalf("Run() returned an unexpected error: %v", err)
+			}
+
+			content, err := os.ReadFile(tt.args[1])
+			if err != nil {
+				t.Fatalf("read output: %v", err)
+			}
+			if string(content) != tt.expected {
+				t.Fatalf("expected %s output, got %q", tt.expected, content)
+			}
+		})
+	}
+}
+
+func TestToolRunRejectsUnknownSpreadsheetFormat(t *testing.T) {
+	dir := t.TempDir()
+	inputPath := filepath.Join(dir, "case.md")
+	if err := os.WriteFile(inputPath, []byte("# Case"), 0o644); err != nil {
+		t.Fatalf("write input file: %v", err)
+	}
+
+	var stdout bytes.Buffer
+	var stderr bytes.Buffer
+	tool := New(&stdout, &stderr, Converters{Spreadsheet: &stubConverter{}})
+
+	err := tool.Run([]string{"--input", inputPath, "--spreadsheet-output", filepath.Join(dir, "out.xlsx"), "--spreadsheet-format", "numbers"})
+	if err == nil || !strings.Contains(err.Error(), "unsupported spreadsheet format") {
+		t.Fatalf("expected unsupported format error, got %v", err)
+	}
+}
+
+func TestToolRunImportWritesOneFilePerInput(t *testing.T) {
+	dir := t.TempDir()
+	var inputs []string
+	for _, name := range []string{"alpha.xlsx", "beta.xlsx"} {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte("workbook"), 0o644); err != nil {
+			t.Fatalf("write input file: %v", err)
+		}
+		inputs = append(inputs, path)
+	}
+
+	var stdout bytes.Buffer
+	var stderr bytes.Buffer
+	importer := &stubConverter{output: "# Imported\n"}
+	tool := New(&stdout, &stderr, Converters{XLSXImport: importer})
+
+	outputDir := filepath.Join(dir, "markdown")
+	args := append([]string{"import", "--from", "xlsx", "--output-dir", outputDir}, inputs...)
+	if err := tool.Run(args); err != nil {
+		t.Fatalf("Run() returned an unexpected error: %v", err)
+	}
+
+	for _, name := range []string{"alpha.md", "beta.md"} {
+		content, err := os.ReadFile(filepath.Join(outputDir, name))
+		if err != nil {
+			t.Fatalf("read %s: %v", name, err)
+		}
+		if string(content) != importer.output {
+			t.Fatalf("unexpected content in %s: %q", name, content)
+		}
+	}
+}
+
+func TestToolRunImportRejectsUnknownFormat(t *testing.T) {
+	var stdout bytes.Buffer
+	var stderr bytes.Buffer
+	tool := New(&stdout, &stderr, Converters{XLSXImport: &stubConverter{}})
+
+	err := tool.Run([]string{"import", "--from", "numbers", "legacy.numbers"})
+	if err == nil || !strings.Contains(err.Error(), "unsupported import format") {
+		t.Fatalf("expected unsupported import format error, got %v", err)
+	}
+}
+
+type stubCaseParser []domain.Case
+
+func (p stubCaseParser) Parse(io.Reader) ([]domain.Case, error) {
+	return p, nil
+}
+
+func TestToolRunPlanWritesOneWorkbookPerTester(t *testing.T) {
+	dir := t.TempDir()
+	inputPath := filepath.Join(dir, "case.md")
+	if err := os.WriteFile(inputPath, []byte("# Case"), 0o644); err != nil {
+		t.Fatalf("write input file: %v", err)
+	}
+
+	var stdout bytes.Buffer
+	planner := app.NewTestPlanner(stubCaseParser{
+		{MajorItem: "Setup", MinorItem: "Install", Effort: "1h"},
+		{MajorItem: "Setup", MinorItem: "Configure", Effort: "30m"},
+		{MajorItem: "Execution", MinorItem: "Run", Effort: "45m"},
+	})
+	tool := New(&stdout, &bytes.Buffer{}, Converters{Planner: planner})
+
+	outputDir := filepath.Join(dir, "plan")
+	args := []string{"plan", "--lang", "en", "--testers", "alice, qa/bob", "--strategy", "major", "--output-dir", outputDir, inputPath}
+	if err := tool.Run(args); err != nil {
+		t.Fatalf("Run() returned an unexpected error: %v", err)
+	}
+
+	for _, name := range []string{"alice.xlsx", "qa_bob.xlsx"} {
+		if _, err := os.Stat(filepath.Join(outputDir, name)); err != nil {
+			t.Fatalf("expected %s to be written: %v", name, err)
+		}
+	}
+	if !strings.Contains(stdout.String(), "alice: 2 cases, about 1h30m\n") || !strings.Contains(stdout.String(), "qa/bob: 1 case, about 45m\n") {
+		t.Fatalf("unexpected summary: %q", stdout.String())
+	}
+}
+
+func TestToolRunPlanValidatesFlags(t *testing.T) {
+	tool := New(&bytes.Buffer{}, &bytes.Buffer{}, Converters{Planner: app.NewTestPlanner(stubCaseParser{})})
+
+	for _, test := range []struct {
+		args []string
+		err  string
+	}{
+		{[]string{"plan", "--output", "plan.xlsx", "case.md"}, errMissingTesters.Error()},
+		{[]string{"plan", "--testers", "alice", "case.md"}, errMissingPlanOutput.Error()},
+		{[]string{"plan", "--testers", "alice", "--output", "plan.xlsx", "--output-dir", "plan", "case.md"}, errConflictingOutputs.Error()},
+		{[]string{"plan", "--testers", "alice", "--output", "plan.xlsx", "--strategy", "random", "case.md"}, `parse --strategy: unknown strategy "random" (available: round-robin, major, effort)`},
+	} {
+		err := tool.Run(test.args)
+		if err == nil || err.Error() != test.err {
+			t.Fatalf("Run(%v) returned %v, want %q", test.args, err, test.err)
+		}
+	}
+}
+
+func TestToolRunRecordsListsAndComparesRuns(t *testing.T) {
+	dir := t.TempDir()
+	inputPath := filepath.Join(dir, "login.md")
+	if err := os.WriteFile(inputPath, []byte("# Login"), 0o644); err != nil {
+		t.Fatalf("write input file: %v", err)
+	}
+	runsDir := filepath.Join(dir, "runs")
+	openStore := func(dir string) RunStore { return runstore.New(dir) }
+
+	var stdout bytes.Buffer
+	for _, run := range [][2]string{{"rc1", "NG"}, {"rc2", "OK"}} {
+		name, result := run[0], run[1]
+		recorder := app.NewRunRecorder(stubCaseParser{{MajorItem: "Auth", MinorItem: "Password", Result: result}})
+		tool := New(&stdout, &bytes.Buffer{}, Converters{RunRecorder: recorder, RunStore: openStore})
+		if err := tool.Run([]string{"runs", "record", "--lang", "en", "--runs-dir", runsDir, "--name", name, inputPath}); err != nil {
+			t.Fatalf("Run(runs record %s) returned an unexpected error: %v", name, err)
+		}
+	}
+	if !strings.Contains(stdout.String(), "Run rc1 recorded in "+runsDir+": 1 case\n") {
+		t.Fatalf("unexpected output: %q", stdout.String())
+	}
+
+	tool := New(&stdout, &bytes.Buffer{}, Converters{RunRecorder: app.NewRunRecorder(stubCaseParser{}), RunStore: openStore})
+	err := tool.Run([]string{"runs", "record", "--runs-dir", runsDir, "--name", "rc1", inputPath})
+	if !errors.Is(err, runstore.ErrRunExists) {
+		t.Fatalf("expected recording rc1 twice to fail, got %v", err)
+	}
+
+	stdout.Reset()
+	if err := tool.Run([]string{"runs", "compare", "--lang", "en", "--runs-dir", runsDir, "rc1", "rc2"}); err != nil {
+		t.Fatalf("Run(runs compare) returned an unexpected error: %v", err)
+	}
+	if expected := inputPath + ": Auth /  / Password: failed → passed\n"; stdout.String() != expected {
+		t.Fatalf("unexpected comparison: %q, want %q", stdout.String(), expected)
+	}
+
+	stdout.Reset()
+	if err := tool.Run([]string{"runs", "list", "--lang", "en", "--runs-dir", runsDir}); err != nil {
+		t.Fatalf("Run(runs list) returned an unexpected error: %v", err)
+	}
+	if lines := strings.Split(strings.TrimSpace(stdout.String()), "\n"); len(lines) != 2 || !strings.HasSuffix(lines[0], "1 case: 0 passed, 1 failed, 0 skipped") {
+		t.Fatalf("unexpected listing: %q", stdout.String())
+	}
+
+	csv := &stubConverter{}
+	tool = New(&bytes.Buffer{}, &bytes.Buffer{}, Converters{CSV: csv, RunStore: openStore})
+	if err := tool.Run([]string{"--input", inputPath, "--csv-output", filepath.Join(dir, "out.csv"), "--runs-dir", runsDir, "--run", "rc2"}); err != nil {
+		t.Fatalf("Run() returned an unexpected error: %v", err)
+	}
+	if results := csv.sources[0].Results; results == nil || results.Cases[0].Result != "OK" {
+		t.Fatalf("expected the results of rc2, got %+v", results)
+	}
+	err = tool.Run([]string{"--input", inputPath, "--csv-output", filepath.Join(dir, "out.csv"), "--with-results", "--run", "rc2"})
+	if !errors.Is(err, errConflictingResults) {
+		t.Fatalf("expected a conflict error, got %v", err)
+	}
+}
+
+func TestToolRunReportAggregatesRuns(t *testing.T) {
+	dir := t.TempDir()
+	runsDir := filepath.Join(dir, "runs")
+	store := runstore.New(runsDir)
+	for i, result := range []string{"NG", "OK"} {
+		run := domain.NamedRun{Name: fmt.Sprintf("rc%d", i+1), Recorded: time.Now().Add(time.Duration(i-2) * time.Hour), Runs: []domain.Run{
+			{Source: "login.md", Cases: []domain.CaseResult{{MajorItem: "Auth", MinorItem: "Password", Result: result}}},
+		}}
+		if err := store.Save(run, false); err != nil {
+			t.Fatalf("save run: %v", err)
+		}
+	}
+	checklist := filepath.Join(dir, "login.md")
+	if err := os.WriteFile(checklist, []byte("# Login"), 0o644); err != nil {
+		t.Fatalf("write input file: %v", err)
+	}
+
+	var stdout bytes.Buffer
+	reporter := app.NewTrendReporter(stubCaseParser{{MajorItem: "Auth", MinorItem: "Password"}, {MajorItem: "Auth", MinorItem: "Sign up"}}, "")
+	tool := New(&stdout, &bytes.Buffer{}, Converters{TrendReporter: reporter, RunStore: func(dir string) RunStore { return runstore.New(dir) }})
+
+	if err := tool.Run([]string{"report", "--lang", "en", "--runs-dir", runsDir, checklist}); err != nil {
+		t.Fatalf("Run() returned an unexpected error: %v", err)
+	}
+	for _, line := range []string{"Runs: rc1, rc2\n", "| " + checklist + " | Auth /  / Password | 1 | 1 | 1 | ✗✓ |\n", "| " + checklist + " | Auth /  / Sign up |\n"} {
+		if !strings.Contains(stdout.String(), line) {
+			t.Fatalf("expected %q in:\n%s", line, stdout.String())
+		}
+	}
+
+	stdout.Reset()
+	output := filepath.Join(dir, "trend.xlsx")
+	if err := tool.Run([]string{"report", "--lang", "en", "--runs-dir", runsDir, "--runs", "rc2", "--output", output}); err != nil {
+		t.Fatalf("Run() returned an unexpected error: %v", err)
+	}
+	if stdout.String() != "Trend report written to "+output+"\n" {
+		t.Fatalf("unexpected output: %q", stdout.String())
+	}
+
+	err := tool.Run([]string{"report", "--runs-dir", runsDir, "--format", "xlsx"})
+	if err == nil || err.Error() != "--output is required for the xlsx format" {
+		t.Fatalf("expected a missing output error, got %v", err)
+	}
+}
+
+type stubWebServer struct {
+	config ServeConfig
+	served bool
+}
+
+func (s *stubWebServer) Serve(ctx context.Context, config ServeConfig) error {
+	s.config = config
+	s.served = true
+	return nil
+}
+
+func clearServeEnvironment(t *testing.T) {
+	t.Helper()
+	for _, name := range []string{"CASEMD_WEB_ADDR", "CASEMD_TLS_CERT", "CASEMD_TLS_KEY", "CASEMD_BASE_PATH", "CASEMD_LANG", "CASEMD_SESSION_KEY", "CASEMD_OIDC_CLIENT_SECRET"} {
+		t.Setenv(name, "")
+	}
+}
+
+func TestToolRunServeReadsFlagsAndEnvironment(t *testing.T) {
+	clearServeEnvironment(t)
+	t.Setenv("CASEMD_WEB_ADDR", "unix:/run/casemd.sock")
+	t.Setenv("CASEMD_BASE_PATH", "/ignored")
+	t.Setenv("CASEMD_LANG", "ja")
+	t.Setenv("CASEMD_SESSION_KEY", "session-key")
+	t.Setenv("CASEMD_OIDC_CLIENT_SECRET", "client-secret")
+	dir := t.TempDir()
+
+	server := &stubWebServer{}
+	tool := New(&bytes.Buffer{}, &bytes.Buffer{}, Converters{WebServer: server})
+	err := tool.Run([]string{"serve", "--base-path", "/casemd", "--ready-grace", "5s", "--convert-timeout", "0", "--rate-limit", "60", "--access-log=false",
+		"--oidc-issuer", "https://issuer.example.com", "--oidc-client-id", "casemd", "--oidc-redirect-url", "https://casemd.example.com/casemd/auth/callback", dir})
+	if err != nil {
+		t.Fatalf("Run() returned an unexpected error: %v", err)
+	}
+
+	want := ServeConfig{
+		Addr:             "unix:/run/casemd.sock",
+		BasePath:         "/casemd",
+		Workspace:        dir,
+		DrainTimeout:     10 * time.Second,
+		ReadyGrace:       5 * time.Second,
+		BodyLimit:        4 << 20,
+		RateLimit:        60,
+		Language:         i18n.Japanese,
+		SessionKey:       []byte("session-key"),
+		OIDCIssuer:       "https://issuer.example.com",
+		OIDCClientID:     "casemd",
+		OIDCClientSecret: "client-secret",
+		OIDCRedirectURL:  "https://casemd.example.com/casemd/auth/callback",
+	}
+	if !server.served || !reflect.DeepEqual(server.config, want) {
+		t.Fatalf("unexpected serve config:\n got %+v\nwant %+v", server.config, want)
+	}
+}
+
+func TestToolRunServeTakesAnAddressArgument(t *testing.T) {
+	clearServeEnvironment(t)
+
+	for _, test := range []struct {
+		args []string
+		addr string
+	}{
+		{[]string{"serve"}, ":3000"},
+		{[]string{"serve", "--addr", ":8080"}, ":8080"},
+		{[]string{"serve", "--addr", ":8080", "127.0.0.1:9000"}, "127.0.0.1:9000"},
+	} {
+		server := &stubWebServer{}
+		tool := New(&bytes.Buffer{}, &bytes.Buffer{}, Converters{WebServer: server})
+		if err := tool.Run(test.args); err != nil {
+			t.Fatalf("Run(%v) returned an unexpected error: %v", test.args, err)
+		}
+		if server.config.Addr != test.addr || server.config.Workspace != "" {
+			t.Fatalf("Run(%v) served %q with workspace %q, want %q without one", test.args, server.config.Addr, server.config.Workspace, test.addr)
+		}
+		if !server.config.AccessLog || server.config.ConvertTimeout != 10*time.Second {
+			t.Fatalf("Run(%v) changed the defaults: %+v", test.args, server.config)
+		}
+	}
+}
+
+func TestToolRunServeValidatesFlags(t *testing.T) {
+	clearServeEnvironment(t)
+
+	for _, test := range []struct {
+		args []string
+		err  string
+	}{
+		{[]string{"serve", "--auth-token-file", "tokens", "--htpasswd", "htpasswd"}, errConflictingSignIns.Error()},
+		{[]string{"serve", "--auth-token-file", "tokens", "--oidc-issuer", "https://issuer.example.com"}, errConflictingSignIns.Error()},
+		{[]string{"serve", "--htpasswd", "htpasswd", "--oidc-issuer", "https://issuer.example.com"}, errConflictingSignIns.Error()},
+		{[]string{"serve", "--lang", "fr"}, `parse --lang: unknown language "fr" (available: en, ja)`},
+	} {
+		server := &stubWebServer{}
+		tool := New(&bytes.Buffer{}, &bytes.Buffer{}, Converters{WebServer: server})
+		err := tool.Run(test.args)
+		if err == nil || err.Error() != test.err {
+			t.Fatalf("Run(%v) returned %v, want %q", test.args, err, test.err)
+		}
+		if server.served {
+			t.Fatalf("Run(%v) started the server", test.args)
+		}
+	}
+
+	tool := New(&bytes.Buffer{}, &bytes.Buffer{}, Converters{})
+	if err := tool.Run([]string{"serve"}); !errors.Is(err, errMissingWebServer) {
+		t.Fatalf("expected errMissingWebServer, got %v", err)
+	}
+}
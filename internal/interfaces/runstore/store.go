@@ -0,0 +1,147 @@
+package runstore
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/9renpoto/casemd/internal/core/domain"
+)
+
+const runFileSuffix = ".json"
+
+var (
+	// ErrRunExists is returned by Save when a run of the same name is stored
+	// already and may not be replaced.
+	ErrRunExists = errors.New("run already recorded")
+	// ErrRunNotFound is returned by Load for a name with no stored run.
+	ErrRunNotFound = errors.New("run not found")
+)
+
+// runNameRegex keeps run names usable as file names on every platform.
+var runNameRegex = regexp.MustCompile(`^[\p{L}\p{N}][\p{L}\p{N}._-]*$`)
+
+// Store keeps named runs in a directory, one JSON file per run, so the
+// results survive the next export of the checklists.
+type Store struct {
+	dir string
+}
+
+// New returns the store kept in dir. The directory is created by the first
+// Save.
+func New(dir string) *Store {
+	return &Store{dir: dir}
+}
+
+// Dir returns the directory holding the runs.
+func (s *Store) Dir() string {
+	return s.dir
+}
+
+func (s *Store) path(name string) (string, error) {
+	if !runNameRegex.MatchString(name) {
+		return "", fmt.Errorf("invalid run name %q: use letters, digits, '.', '_' and '-'", name)
+	}
+	return filepath.Join(s.dir, name+runFileSuffix), nil
+}
+
+// Save stores run under its name. An existing run of the same name is
+// replaced only when overwrite is set.
+func (s *Store) Save(run domain.NamedRun, overwrite bool) error {
+	path, err := s.path(run.Name)
+	if err != nil {
+		return err
+	}
+	if !overwrite {
+		if _, err := os.Stat(path); err == nil {
+			return fmt.Errorf("save run %s: %w", run.Name, ErrRunExists)
+		}
+	}
+	if err := os.MkdirAll(s.dir, 0o755); err != nil {
+		return fmt.Errorf("create runs directory: %w", err)
+	}
+
+	if run.Runs == nil {
+		run.Runs = []domain.Run{}
+	}
+	content, err := json.MarshalIndent(run, "", "  ")
+	if err != nil {
+		return fmt.Errorf("encode run %s: %w", run.Name, err)
+	}
+
+	// Write next to the target and rename, so readers never see half a run.
+	file, err := os.CreateTemp(s.dir, "."+run.Name+"-*")
+	if err != nil {
+		return fmt.Errorf("save run %s: %w", run.Name, err)
+	}
+	_, writeErr := file.Write(append(content, '\n'))
+	closeErr := file.Close()
+	if err := errors.Join(writeErr, closeErr); err != nil {
+		os.Remove(file.Name())
+		return fmt.Errorf("save run %s: %w", run.Name, err)
+	}
+	if err := os.Rename(file.Name(), path); err != nil {
+		os.Remove(file.Name())
+		return fmt.Errorf("save run %s: %w", run.Name, err)
+	}
+	return nil
+}
+
+// Load reads the run stored under name.
+func (s *Store) Load(name string) (domain.NamedRun, error) {
+	path, err := s.path(name)
+	if err != nil {
+		return domain.NamedRun{}, err
+	}
+	run, err := readRun(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return domain.NamedRun{}, fmt.Errorf("load run %s: %w", name, ErrRunNotFound)
+	}
+	return run, err
+}
+
+// List returns the stored runs, oldest first. A missing directory holds no
+// runs.
+func (s *Store) List() ([]domain.NamedRun, error) {
+	entries, err := os.ReadDir(s.dir)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("list runs: %w", err)
+	}
+
+	var runs []domain.NamedRun
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || strings.HasPrefix(name, ".") || !strings.HasSuffix(name, runFileSuffix) {
+			continue
+		}
+		run, err := readRun(filepath.Join(s.dir, name))
+		if err != nil {
+			return nil, err
+		}
+		runs = append(runs, run)
+	}
+	sort.SliceStable(runs, func(i, j int) bool { return runs[i].Recorded.Before(runs[j].Recorded) })
+	return runs, nil
+}
+
+func readRun(path string) (domain.NamedRun, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return domain.NamedRun{}, fmt.Errorf("open run file %s: %w", path, err)
+	}
+	defer file.Close()
+
+	var run domain.NamedRun
+	if err := json.NewDecoder(file).Decode(&run); err != nil {
+		return domain.NamedRun{}, fmt.Errorf("read run file %s: %w", path, err)
+	}
+	return run, nil
+}
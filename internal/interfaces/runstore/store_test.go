@@ -0,0 +1,73 @@
+package runstore
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/9renpoto/casemd/internal/core/domain"
+)
+
+func TestStoreSaveLoadList(t *testing.T) {
+	store := New(filepath.Join(t.TempDir(), "runs"))
+
+	if runs, err := store.List(); err != nil || len(runs) != 0 {
+		t.Fatalf("List() on a missing directory = %v, %v", runs, err)
+	}
+
+	later := domain.NamedRun{Name: "rc2", Recorded: time.Date(2026, 10, 2, 9, 0, 0, 0, time.UTC), Runs: []domain.Run{
+		{Source: "login.md", Cases: []domain.CaseResult{{MajorItem: "Auth", MinorItem: "Password", Result: "fail"}}},
+	}}
+	earlier := domain.NamedRun{Name: "rc1", Recorded: time.Date(2026, 10, 1, 9, 0, 0, 0, time.UTC), Runs: []domain.Run{}}
+	for _, run := range []domain.NamedRun{later, earlier} {
+		if err := store.Save(run, false); err != nil {
+			t.Fatalf("Save(%s) returned an unexpected error: %v", run.Name, err)
+		}
+	}
+
+	loaded, err := store.Load("rc2")
+	if err != nil {
+		t.Fatalf("Load() returned an unexpected error: %v", err)
+	}
+	if !reflect.DeepEqual(loaded, later) {
+		t.Fatalf("unexpected run:\n got: %#v\nwant: %#v", loaded, later)
+	}
+
+	runs, err := store.List()
+	if err != nil {
+		t.Fatalf("List() returned an unexpected error: %v", err)
+	}
+	if len(runs) != 2 || runs[0].Name != "rc1" || runs[1].Name != "rc2" {
+		t.Fatalf("expected the runs oldest first, got %+v", runs)
+	}
+	entries, _ := os.ReadDir(store.Dir())
+	if len(entries) != 2 {
+		t.Fatalf("expected no temporary files to remain, got %d entries", len(entries))
+	}
+}
+
+func TestStoreErrors(t *testing.T) {
+	store := New(t.TempDir())
+	run := domain.NamedRun{Name: "rc1"}
+	if err := store.Save(run, false); err != nil {
+		t.Fatalf("Save() returned an unexpected error: %v", err)
+	}
+
+	if err := store.Save(run, false); !errors.Is(err, ErrRunExists) {
+		t.Fatalf("expected ErrRunExists, got %v", err)
+	}
+	if err := store.Save(run, true); err != nil {
+		t.Fatalf("Save() with overwrite returned an unexpected error: %v", err)
+	}
+	if _, err := store.Load("rc9"); !errors.Is(err, ErrRunNotFound) {
+		t.Fatalf("expected ErrRunNotFound, got %v", err)
+	}
+	for _, name := range []string{"", "../rc1", ".hidden", "a/b"} {
+		if err := store.Save(domain.NamedRun{Name: name}, true); err == nil {
+			t.Fatalf("expected %q to be rejected", name)
+		}
+	}
+}
@@ -0,0 +1,90 @@
+package web
+
+import (
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+
+	"github.com/9renpoto/casemd/internal/app"
+)
+
+// Parser turns Markdown into the structured document served by the parse API.
+type Parser interface {
+	Parse(source app.Source) (app.StructuredDocument, error)
+}
+
+// apiVersionPrefix versions the API for other services; breaking changes go
+// under a new prefix.
+const apiVersionPrefix = "/api/v1"
+
+// openAPISpec describes the versioned API. Contract tests send the examples
+// it contains and check the responses against its schemas.
+//
+//go:embed openapi.json
+var openAPISpec []byte
+
+func (s *Server) registerAPIRoutes(router fiber.Router) {
+	api := router.Group(apiVersionPrefix)
+
+	api.Get("/openapi.json", func(c *fiber.Ctx) error {
+		spec, err := s.openAPISpec()
+		if err != nil {
+			return fiber.NewError(fiber.StatusInternalServerError, fmt.Sprintf("load openapi spec: %v", err))
+		}
+		c.Set(fiber.HeaderContentType, fiber.MIMEApplicationJSONCharsetUTF8)
+		return c.Send(spec)
+	})
+
+	api.Post("/parse", func(c *fiber.Ctx) error {
+		if s.parser == nil {
+			return fiber.NewError(fiber.StatusServiceUnavailable, "parser is not configured")
+		}
+
+		source, err := readParseSource(c)
+		if err != nil {
+			return err
+		}
+		if source.Filter, err = queryFilter(c); err != nil {
+			return err
+		}
+		ctx, cancel := s.conversionContext(c)
+		defer cancel()
+		source.Context = ctx
+
+		document, err := s.parser.Parse(source)
+		if err != nil {
+			return s.conversionError(err, fiber.StatusUnprocessableEntity, "parse markdown")
+		}
+		return c.JSON(document)
+	})
+}
+
+// readParseSource accepts either the {"name", "markdown"} JSON body used by
+// /api/preview or raw Markdown named by the "name" query parameter.
+func readParseSource(c *fiber.Ctx) (app.Source, error) {
+	var payload previewRequest
+	if strings.HasPrefix(string(c.Request().Header.ContentType()), "text/") {
+		payload = previewRequest{Name: c.Query("name"), Markdown: string(c.Body())}
+	} else if err := c.BodyParser(&payload); err != nil {
+		return app.Source{}, fiber.NewError(fiber.StatusBadRequest, fmt.Sprintf("parse request body: %v", err))
+	}
+
+	if strings.TrimSpace(payload.Markdown) == "" {
+		return app.Source{}, fiber.NewError(fiber.StatusBadRequest, "markdown content cannot be empty")
+	}
+	return app.Source{Name: payload.Name, Reader: strings.NewReader(payload.Markdown)}, nil
+}
+
+// openAPISpec returns the embedded specification with its server URL set to
+// where the API is mounted.
+func (s *Server) openAPISpec() ([]byte, error) {
+	var spec map[string]any
+	if err := json.Unmarshal(openAPISpec, &spec); err != nil {
+		return nil, err
+	}
+	spec["servers"] = []map[string]string{{"url": s.basePath + apiVersionPrefix}}
+	return json.MarshalIndent(spec, "", "  ")
+}
@@ -0,0 +1,263 @@
+package web_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http/httptest"
+	"sort"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+
+	"github.com/9renpoto/casemd/internal/app"
+	"github.com/9renpoto/casemd/internal/core/domain"
+	"github.com/9renpoto/casemd/internal/core/parser"
+	"github.com/9renpoto/casemd/internal/interfaces/web"
+)
+
+type markdownParser struct{}
+
+func (markdownParser) ParseDocument(r io.Reader) (domain.Document, error) {
+	return parser.ParseDocument(r)
+}
+
+func newAPIServer() *web.Server {
+	return web.NewServer(&stubConverter{}, web.Options{Parser: app.NewMarkdownDocument(markdownParser{})})
+}
+
+// TestAPIContract sends every request example in the OpenAPI specification
+// and checks that the response status is documented for the operation and
+// that the body matches the documented schema.
+func TestAPIContract(t *testing.T) {
+	server := newAPIServer()
+	resp := send(t, server, httptest.NewRequest(fiber.MethodGet, "/api/v1/openapi.json", nil))
+	if resp.StatusCode != fiber.StatusOK {
+		t.Fatalf("openapi.json returned %d", resp.StatusCode)
+	}
+	var spec openAPIDocument
+	if err := json.NewDecoder(resp.Body).Decode(&spec); err != nil {
+		t.Fatalf("decode spec: %v", err)
+	}
+	if len(spec.Servers) != 1 || spec.Servers[0].URL != "/api/v1" {
+		t.Fatalf("unexpected servers: %+v", spec.Servers)
+	}
+
+	tested := 0
+	for path, operations := range spec.Paths {
+		for method, operation := range operations {
+			method = strings.ToUpper(method)
+			for _, request := range operation.requests() {
+				t.Run(operation.OperationID+"/"+request.name, func(t *testing.T) {
+					req := httptest.NewRequest(method, spec.Servers[0].URL+path, bytes.NewReader(request.body))
+					if request.contentType != "" {
+						req.Header.Set("Content-Type", request.contentType)
+					}
+					resp := send(t, server, req)
+					body, _ := io.ReadAll(resp.Body)
+
+					response, ok := operation.Responses[strconv.Itoa(resp.StatusCode)]
+					if !ok {
+						t.Fatalf("status %d is not documented: %s", resp.StatusCode, body)
+					}
+					response = spec.resolveResponse(response)
+					mediaType := strings.TrimSpace(strings.Split(resp.Header.Get("Content-Type"), ";")[0])
+					content, ok := response.Content[mediaType]
+					if !ok {
+						t.Fatalf("content type %q is not documented for status %d", mediaType, resp.StatusCode)
+					}
+
+					var value any = string(body)
+					if mediaType == fiber.MIMEApplicationJSON {
+						if err := json.Unmarshal(body, &value); err != nil {
+							t.Fatalf("decode response: %v", err)
+						}
+					}
+					if err := spec.validate(content.Schema, value, "response"); err != nil {
+						t.Fatalf("response does not match schema: %v\n%s", err, body)
+					}
+				})
+				tested++
+			}
+		}
+	}
+	if tested < 5 {
+		t.Fatalf("expected the spec to describe at least 5 requests, found %d", tested)
+	}
+}
+
+func TestParseEndpointReturnsStructuredDocument(t *testing.T) {
+	server := newAPIServer()
+	markdown := "# Sheet\n\n## Setup\n### Environment\n#### Dependencies\n\n1. Install\n* [x] Installed\n* [ ] Defaults\n"
+	req := httptest.NewRequest(fiber.MethodPost, "/api/v1/parse?name=notes.md", strings.NewReader(markdown))
+	req.Header.Set("Content-Type", "text/markdown")
+	resp := send(t, server, req)
+	if resp.StatusCode != fiber.StatusOK {
+		t.Fatalf("expected 200, got %d", resp.StatusCode)
+	}
+
+	var document app.StructuredDocument
+	if err := json.NewDecoder(resp.Body).Decode(&document); err != nil {
+		t.Fatalf("decode response: %v", err)
+	}
+	if document.Name != "notes.md" || document.Title != "Sheet" || len(document.MajorItems) != 1 {
+		t.Fatalf("unexpected document: %+v", document)
+	}
+	cases := document.MajorItems[0].MediumItems[0].Cases
+	if len(cases) != 1 || cases[0].MinorItem != "Dependencies" || cases[0].Line != 5 {
+		t.Fatalf("unexpected cases: %+v", cases)
+	}
+	expected := []app.Checkpoint{{Text: "Installed", Checked: true}, {Text: "Defaults"}}
+	if fmt.Sprint(cases[0].Checkpoints) != fmt.Sprint(expected) {
+		t.Fatalf("unexpected checkpoints: %+v", cases[0].Checkpoints)
+	}
+
+	unconfigured := web.NewServer(&stubConverter{}, web.Options{})
+	req = httptest.NewRequest(fiber.MethodPost, "/api/v1/parse", strings.NewReader(markdown))
+	req.Header.Set("Content-Type", "text/markdown")
+	if resp := send(t, unconfigured, req); resp.StatusCode != fiber.StatusServiceUnavailable {
+		t.Fatalf("expected 503 without a parser, got %d", resp.StatusCode)
+	}
+}
+
+// openAPIDocument is the subset of OpenAPI 3.0 the contract test reads.
+type openAPIDocument struct {
+	Servers []struct {
+		URL string `json:"url"`
+	} `json:"servers"`
+	Paths      map[string]map[string]openAPIOperation `json:"paths"`
+	Components struct {
+		Schemas   map[string]*openAPISchema  `json:"schemas"`
+		Responses map[string]openAPIResponse `json:"responses"`
+	} `json:"components"`
+}
+
+type openAPIOperation struct {
+	OperationID string `json:"operationId"`
+	RequestBody *struct {
+		Content map[string]struct {
+			Examples map[string]struct {
+				Value any `json:"value"`
+			} `json:"examples"`
+		} `json:"content"`
+	} `json:"requestBody"`
+	Responses map[string]openAPIResponse `json:"responses"`
+}
+
+type openAPIResponse struct {
+	Ref     string `json:"$ref"`
+	Content map[string]struct {
+		Schema *openAPISchema `json:"schema"`
+	} `json:"content"`
+}
+
+type openAPISchema struct {
+	Ref        string                    `json:"$ref"`
+	Type       string                    `json:"type"`
+	Required   []string                  `json:"required"`
+	Properties map[string]*openAPISchema `json:"properties"`
+	Items      *openAPISchema            `json:"items"`
+	Enum       []any                     `json:"enum"`
+}
+
+type exampleRequest struct {
+	name        string
+	contentType string
+	body        []byte
+}
+
+// requests lists one request per body example, or a bodiless request for
+// operations without a request body.
+func (o openAPIOperation) requests() []exampleRequest {
+	if o.RequestBody == nil {
+		return []exampleRequest{{name: "default"}}
+	}
+	var requests []exampleRequest
+	for contentType, content := range o.RequestBody.Content {
+		for name, example := range content.Examples {
+			body, ok := example.Value.(string)
+			if !ok {
+				data, _ := json.Marshal(example.Value)
+				body = string(data)
+			}
+			requests = append(requests, exampleRequest{name: contentType + "/" + name, contentType: contentType, body: []byte(body)})
+		}
+	}
+	sort.Slice(requests, func(i, j int) bool { return requests[i].name < requests[j].name })
+	return requests
+}
+
+func (d openAPIDocument) resolveResponse(response openAPIResponse) openAPIResponse {
+	if name, ok := strings.CutPrefix(response.Ref, "#/components/responses/"); ok {
+		return d.Components.Responses[name]
+	}
+	return response
+}
+
+// validate checks value against the subset of JSON Schema used by the spec.
+func (d openAPIDocument) validate(schema *openAPISchema, value any, path string) error {
+	if schema == nil {
+		return nil
+	}
+	if name, ok := strings.CutPrefix(schema.Ref, "#/components/schemas/"); ok {
+		resolved, found := d.Components.Schemas[name]
+		if !found {
+			return fmt.Errorf("%s: unknown schema %s", path, schema.Ref)
+		}
+		return d.validate(resolved, value, path)
+	}
+
+	switch schema.Type {
+	case "object":
+		object, ok := value.(map[string]any)
+		if !ok {
+			return fmt.Errorf("%s: expected an object, got %T", path, value)
+		}
+		for _, name := range schema.Required {
+			if _, ok := object[name]; !ok {
+				return fmt.Errorf("%s: missing required property %q", path, name)
+			}
+		}
+		for name, property := range object {
+			if err := d.validate(schema.Properties[name], property, path+"."+name); err != nil {
+				return err
+			}
+		}
+	case "array":
+		items, ok := value.([]any)
+		if !ok {
+			return fmt.Errorf("%s: expected an array, got %T", path, value)
+		}
+		for i, item := range items {
+			if err := d.validate(schema.Items, item, fmt.Sprintf("%s[%d]", path, i)); err != nil {
+				return err
+			}
+		}
+	case "string":
+		if _, ok := value.(string); !ok {
+			return fmt.Errorf("%s: expected a string, got %T", path, value)
+		}
+	case "integer":
+		number, ok := value.(float64)
+		if !ok || number != float64(int64(number)) {
+			return fmt.Errorf("%s: expected an integer, got %v", path, value)
+		}
+	case "boolean":
+		if _, ok := value.(bool); !ok {
+			return fmt.Errorf("%s: expected a boolean, got %T", path, value)
+		}
+	}
+
+	if len(schema.Enum) > 0 {
+		for _, allowed := range schema.Enum {
+			if allowed == value {
+				return nil
+			}
+		}
+		return fmt.Errorf("%s: %v is not one of %v", path, value, schema.Enum)
+	}
+	return nil
+}
@@ -0,0 +1,349 @@
+package web
+
+import (
+	"bufio"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/middleware/csrf"
+	"golang.org/x/crypto/bcrypt"
+)
+
+const (
+	sessionCookieName = "casemd_session"
+	sessionLifetime   = 12 * time.Hour
+	userLocalsKey     = "casemd.user"
+	csrfLocalsKey     = "casemd.csrf"
+	csrfHeaderName    = "X-Csrf-Token"
+	// tokenSignInPath is where a browser trades an access token for a
+	// session cookie, below the base path.
+	tokenSignInPath = "/auth/token"
+)
+
+var errNoCredentials = errors.New("no credentials provided")
+
+// User is the person signed in to the web UI.
+type User struct {
+	Name string `json:"name"`
+}
+
+// Authenticator identifies the user behind a request. The server remembers a
+// successful sign-in in a session cookie, so Authenticate only runs for
+// requests without a valid session.
+type Authenticator interface {
+	// Authenticate returns the user the request's credentials belong to.
+	Authenticate(c *fiber.Ctx) (User, error)
+	// Challenge answers a request that could not be authenticated, e.g. with
+	// a 401 or a redirect to a sign-in page.
+	Challenge(c *fiber.Ctx) error
+}
+
+// callbackAuthenticator is implemented by authenticators that complete the
+// sign-in on a route of their own, such as OIDC redirects.
+type callbackAuthenticator interface {
+	CallbackPath() string
+	// Callback finishes the sign-in and returns the user together with the
+	// path to send the browser back to.
+	Callback(c *fiber.Ctx) (User, string, error)
+}
+
+// exchangeAuthenticator is implemented by authenticators that let a browser
+// trade a credential passed in the URL for a session, once, on
+// tokenSignInPath. No other route accepts credentials in the URL, where they
+// would end up in browser history, logs and Referer headers.
+type exchangeAuthenticator interface {
+	Exchange(c *fiber.Ctx) (User, error)
+}
+
+// csrfToken returns the token the UI must echo in the X-Csrf-Token header of
+// mutating requests; it is empty when authentication is disabled.
+func csrfToken(c *fiber.Ctx) string {
+	token, _ := c.Locals(csrfLocalsKey).(string)
+	return token
+}
+
+// currentUser returns the user signed in for the request, if any.
+func currentUser(c *fiber.Ctx) (User, bool) {
+	user, ok := c.Locals(userLocalsKey).(User)
+	return user, ok
+}
+
+// sessionCodec signs session cookies so they cannot be forged.
+type sessionCodec struct {
+	key []byte
+}
+
+type sessionPayload struct {
+	Name    string `json:"name"`
+	Expires int64  `json:"exp"`
+}
+
+func newSessionCodec(key []byte) sessionCodec {
+	if len(key) == 0 {
+		// Sessions then last until the server restarts.
+		key = make([]byte, 32)
+		if _, err := rand.Read(key); err != nil {
+			panic(fmt.Sprintf("generate session key: %v", err))
+		}
+	}
+	return sessionCodec{key: key}
+}
+
+func (s sessionCodec) sign(data string) string {
+	mac := hmac.New(sha256.New, s.key)
+	mac.Write([]byte(data))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+func (s sessionCodec) encode(user User, now time.Time) string {
+	payload, _ := json.Marshal(sessionPayload{Name: user.Name, Expires: now.Add(sessionLifetime).Unix()})
+	data := base64.RawURLEncoding.EncodeToString(payload)
+	return data + "." + s.sign(data)
+}
+
+func (s sessionCodec) decode(value string, now time.Time) (User, bool) {
+	data, signature, found := strings.Cut(value, ".")
+	if !found || !hmac.Equal([]byte(signature), []byte(s.sign(data))) {
+		return User{}, false
+	}
+	raw, err := base64.RawURLEncoding.DecodeString(data)
+	if err != nil {
+		return User{}, false
+	}
+	var payload sessionPayload
+	if err := json.Unmarshal(raw, &payload); err != nil || payload.Name == "" || now.Unix() >= payload.Expires {
+		return User{}, false
+	}
+	return User{Name: payload.Name}, true
+}
+
+func (s *Server) startSession(c *fiber.Ctx, user User) {
+	c.Cookie(&fiber.Cookie{
+		Name:     sessionCookieName,
+		Value:    s.sessions.encode(user, time.Now()),
+		Path:     s.cookiePath(),
+		MaxAge:   int(sessionLifetime.Seconds()),
+		HTTPOnly: true,
+		Secure:   c.Protocol() == "https",
+		SameSite: fiber.CookieSameSiteLaxMode,
+	})
+}
+
+// authenticate is the middleware guarding every route except health and
+// readiness checks and the authenticator's own callback.
+func (s *Server) authenticate(c *fiber.Ctx) error {
+	if user, ok := s.sessions.decode(c.Cookies(sessionCookieName), time.Now()); ok {
+		c.Locals(userLocalsKey, user)
+		return c.Next()
+	}
+
+	user, err := s.auth.Authenticate(c)
+	if err != nil {
+		return s.auth.Challenge(c)
+	}
+	c.Locals(userLocalsKey, user)
+	s.startSession(c, user)
+	return c.Next()
+}
+
+func (s *Server) registerAuthRoutes(router fiber.Router) {
+	if callback, ok := s.auth.(callbackAuthenticator); ok {
+		router.Get(callback.CallbackPath(), func(c *fiber.Ctx) error {
+			user, returnTo, err := callback.Callback(c)
+			if err != nil {
+				return fiber.NewError(fiber.StatusUnauthorized, fmt.Sprintf("sign in: %v", err))
+			}
+			s.startSession(c, user)
+			return c.Redirect(returnTo, fiber.StatusFound)
+		})
+	}
+	if exchange, ok := s.auth.(exchangeAuthenticator); ok {
+		router.Get(s.basePath+tokenSignInPath, func(c *fiber.Ctx) error {
+			user, err := exchange.Exchange(c)
+			if err != nil {
+				return fiber.NewError(fiber.StatusUnauthorized, fmt.Sprintf("sign in: %v", err))
+			}
+			s.startSession(c, user)
+			// Redirect to a URL without the token.
+			return c.Redirect(s.basePath+"/", fiber.StatusFound)
+		})
+	}
+
+	router.Use(func(c *fiber.Ctx) error {
+		if s.isProbe(c.Path()) {
+			return c.Next()
+		}
+		return s.authenticate(c)
+	})
+
+	// Browsers resend the session cookie and cached basic credentials on
+	// cross-site requests, so mutating requests must echo the token issued
+	// with the page. Bearer tokens are never sent implicitly.
+	router.Use(csrf.New(csrf.Config{
+		Next: func(c *fiber.Ctx) bool {
+			return bearerToken(c) != ""
+		},
+		KeyLookup:      "header:" + csrfHeaderName,
+		CookieName:     "casemd_csrf",
+		CookiePath:     s.cookiePath(),
+		CookieSameSite: fiber.CookieSameSiteLaxMode,
+		CookieHTTPOnly: true,
+		Expiration:     sessionLifetime,
+		ContextKey:     csrfLocalsKey,
+	}))
+}
+
+// bearerToken returns the token of an "Authorization: Bearer" header.
+func bearerToken(c *fiber.Ctx) string {
+	scheme, token, found := strings.Cut(c.Get(fiber.HeaderAuthorization), " ")
+	if !found || !strings.EqualFold(scheme, "Bearer") {
+		return ""
+	}
+	return strings.TrimSpace(token)
+}
+
+// readCredentials parses "name:secret" lines, skipping blanks and # comments.
+func readCredentials(r io.Reader) (map[string]string, error) {
+	credentials := make(map[string]string)
+	scanner := bufio.NewScanner(r)
+	lineNumber := 0
+	for scanner.Scan() {
+		lineNumber++
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		name, secret, found := strings.Cut(line, ":")
+		if !found || name == "" || secret == "" {
+			return nil, fmt.Errorf("line %d: expected name:secret", lineNumber)
+		}
+		credentials[name] = secret
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	if len(credentials) == 0 {
+		return nil, errors.New("no credentials defined")
+	}
+	return credentials, nil
+}
+
+// TokenAuth signs in API clients and scripts that present a static bearer
+// token. Browsers open /auth/token?access_token= once and continue on the
+// session cookie.
+type TokenAuth struct {
+	tokens map[string]string // user name -> token
+}
+
+// NewTokenAuth reads "user:token" lines.
+func NewTokenAuth(r io.Reader) (*TokenAuth, error) {
+	tokens, err := readCredentials(r)
+	if err != nil {
+		return nil, fmt.Errorf("read tokens: %w", err)
+	}
+	return &TokenAuth{tokens: tokens}, nil
+}
+
+// Authenticate implements Authenticator.
+func (a *TokenAuth) Authenticate(c *fiber.Ctx) (User, error) {
+	return a.lookup(bearerToken(c))
+}
+
+// Exchange implements exchangeAuthenticator.
+func (a *TokenAuth) Exchange(c *fiber.Ctx) (User, error) {
+	return a.lookup(c.Query("access_token"))
+}
+
+// lookup returns the user presented is the token of.
+func (a *TokenAuth) lookup(presented string) (User, error) {
+	if presented == "" {
+		return User{}, errNoCredentials
+	}
+	// Compare against every token so the response time does not reveal
+	// which user a guess was close to.
+	var match string
+	for name, token := range a.tokens {
+		if subtle.ConstantTimeCompare([]byte(presented), []byte(token)) == 1 {
+			match = name
+		}
+	}
+	if match == "" {
+		return User{}, errors.New("invalid token")
+	}
+	return User{Name: match}, nil
+}
+
+// Challenge implements Authenticator.
+func (a *TokenAuth) Challenge(c *fiber.Ctx) error {
+	c.Set(fiber.HeaderWWWAuthenticate, `Bearer realm="casemd"`)
+	return fiber.NewError(fiber.StatusUnauthorized, "a valid access token is required")
+}
+
+// BasicAuth signs in users listed in an htpasswd file with bcrypt hashes, as
+// written by `htpasswd -B`.
+type BasicAuth struct {
+	hashes map[string][]byte
+	// dummy is checked for unknown users so they take as long to reject as
+	// wrong passwords.
+	dummy []byte
+}
+
+// NewBasicAuth reads an htpasswd file. Only bcrypt ($2a$, $2b$, $2y$) entries are accepted.
+func NewBasicAuth(r io.Reader) (*BasicAuth, error) {
+	entries, err := readCredentials(r)
+	if err != nil {
+		return nil, fmt.Errorf("read htpasswd: %w", err)
+	}
+	hashes := make(map[string][]byte, len(entries))
+	cost := bcrypt.MinCost
+	for name, hash := range entries {
+		entryCost, err := bcrypt.Cost([]byte(hash))
+		if err != nil {
+			return nil, fmt.Errorf("read htpasswd: %s: only bcrypt hashes are supported", name)
+		}
+		cost = max(cost, entryCost)
+		hashes[name] = []byte(hash)
+	}
+	dummy, err := bcrypt.GenerateFromPassword([]byte("casemd"), cost)
+	if err != nil {
+		return nil, fmt.Errorf("read htpasswd: %w", err)
+	}
+	return &BasicAuth{hashes: hashes, dummy: dummy}, nil
+}
+
+// Authenticate implements Authenticator.
+func (a *BasicAuth) Authenticate(c *fiber.Ctx) (User, error) {
+	scheme, encoded, found := strings.Cut(c.Get(fiber.HeaderAuthorization), " ")
+	if !found || !strings.EqualFold(scheme, "Basic") {
+		return User{}, errNoCredentials
+	}
+	decoded, err := base64.StdEncoding.DecodeString(strings.TrimSpace(encoded))
+	if err != nil {
+		return User{}, errors.New("malformed basic credentials")
+	}
+	name, password, _ := strings.Cut(string(decoded), ":")
+	hash, known := a.hashes[name]
+	if !known {
+		hash = a.dummy
+	}
+	if err := bcrypt.CompareHashAndPassword(hash, []byte(password)); err != nil || !known {
+		return User{}, errors.New("invalid user name or password")
+	}
+	return User{Name: name}, nil
+}
+
+// Challenge implements Authenticator.
+func (a *BasicAuth) Challenge(c *fiber.Ctx) error {
+	c.Set(fiber.HeaderWWWAuthenticate, `Basic realm="casemd", charset="UTF-8"`)
+	return fiber.NewError(fiber.StatusUnauthorized, "sign in required")
+}
@@ -0,0 +1,200 @@
+package web_test
+
+import (
+	"encoding/base64"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"golang.org/x/crypto/bcrypt"
+
+	"github.com/9renpoto/casemd/internal/interfaces/web"
+)
+
+func newTokenServer(t *testing.T, options web.Options) *web.Server {
+	t.Helper()
+
+	auth, err := web.NewTokenAuth(strings.NewReader("# testers\nalice:alice-token\nbob:bob-token\n"))
+	if err != nil {
+		t.Fatalf("NewTokenAuth() returned an unexpected error: %v", err)
+	}
+	options.Auth = auth
+	server := web.NewServer(&stubConverter{}, options)
+	t.Cleanup(func() { server.Shutdown() })
+	return server
+}
+
+func send(t *testing.T, server *web.Server, req *http.Request) *http.Response {
+	t.Helper()
+
+	resp, err := server.App().Test(req, -1)
+	if err != nil {
+		t.Fatalf("%s %s: unexpected error: %v", req.Method, req.URL, err)
+	}
+	return resp
+}
+
+func cookie(resp *http.Response, name string) *http.Cookie {
+	for _, c := range resp.Cookies() {
+		if c.Name == name {
+			return c
+		}
+	}
+	return nil
+}
+
+func TestTokenAuthGuardsEveryRouteButHealth(t *testing.T) {
+	server := newTokenServer(t, web.Options{Previewer: &stubPreviewer{}})
+
+	if resp := send(t, server, httptest.NewRequest(fiber.MethodGet, "/healthz", nil)); resp.StatusCode != fiber.StatusOK {
+		t.Fatalf("expected /healthz to stay open, got %d", resp.StatusCode)
+	}
+
+	resp := send(t, server, httptest.NewRequest(fiber.MethodGet, "/", nil))
+	if resp.StatusCode != fiber.StatusUnauthorized || !strings.HasPrefix(resp.Header.Get("WWW-Authenticate"), "Bearer") {
+		t.Fatalf("expected a bearer challenge, got %d %q", resp.StatusCode, resp.Header.Get("WWW-Authenticate"))
+	}
+
+	req := httptest.NewRequest(fiber.MethodGet, "/", nil)
+	req.Header.Set("Authorization", "Bearer wrong")
+	if resp := send(t, server, req); resp.StatusCode != fiber.StatusUnauthorized {
+		t.Fatalf("expected an invalid token to be rejected, got %d", resp.StatusCode)
+	}
+
+	// Tokens in the URL are only accepted by the sign-in route, which
+	// redirects to a URL without them.
+	if resp := send(t, server, httptest.NewRequest(fiber.MethodGet, "/?access_token=bob-token", nil)); resp.StatusCode != fiber.StatusUnauthorized {
+		t.Fatalf("expected a token in the query of the page to be ignored, got %d", resp.StatusCode)
+	}
+	if resp := send(t, server, httptest.NewRequest(fiber.MethodGet, "/auth/token?access_token=wrong", nil)); resp.StatusCode != fiber.StatusUnauthorized || cookie(resp, "casemd_session") != nil {
+		t.Fatalf("expected an invalid token to be rejected on sign-in, got %d", resp.StatusCode)
+	}
+	session := signIn(t, server, "/auth/token", "bob-token")
+	if !session.HttpOnly {
+		t.Fatalf("expected an HTTP-only session cookie, got %+v", session)
+	}
+
+	req = httptest.NewRequest(fiber.MethodGet, "/", nil)
+	req.AddCookie(session)
+	if resp := send(t, server, req); resp.StatusCode != fiber.StatusOK {
+		t.Fatalf("expected the session to be accepted, got %d", resp.StatusCode)
+	}
+
+	req = httptest.NewRequest(fiber.MethodGet, "/", nil)
+	req.AddCookie(&http.Cookie{Name: "casemd_session", Value: strings.Replace(session.Value, ".", "x.", 1)})
+	if resp := send(t, server, req); resp.StatusCode != fiber.StatusUnauthorized {
+		t.Fatalf("expected a forged session to be rejected, got %d", resp.StatusCode)
+	}
+}
+
+// signIn trades token for a session cookie on the sign-in route at path.
+func signIn(t *testing.T, server *web.Server, path, token string) *http.Cookie {
+	t.Helper()
+
+	resp := send(t, server, httptest.NewRequest(fiber.MethodGet, path+"?access_token="+token, nil))
+	session := cookie(resp, "casemd_session")
+	if resp.StatusCode != fiber.StatusFound || session == nil {
+		t.Fatalf("expected a redirect with a session cookie, got %d %+v", resp.StatusCode, session)
+	}
+	if location := resp.Header.Get(fiber.HeaderLocation); strings.Contains(location, token) {
+		t.Fatalf("expected the redirect to drop the token, got %q", location)
+	}
+	return session
+}
+
+func TestBasicAuthChecksBcryptHtpasswd(t *testing.T) {
+	hash, err := bcrypt.GenerateFromPassword([]byte("s3cret"), bcrypt.MinCost)
+	if err != nil {
+		t.Fatalf("hash password: %v", err)
+	}
+	auth, err := web.NewBasicAuth(strings.NewReader("alice:" + string(hash) + "\n"))
+	if err != nil {
+		t.Fatalf("NewBasicAuth() returned an unexpected error: %v", err)
+	}
+	server := web.NewServer(&stubConverter{}, web.Options{Auth: auth})
+	defer server.Shutdown()
+
+	for credentials, expected := range map[string]int{
+		"alice:s3cret": fiber.StatusOK,
+		"alice:wrong":  fiber.StatusUnauthorized,
+		"bob:s3cret":   fiber.StatusUnauthorized,
+	} {
+		req := httptest.NewRequest(fiber.MethodGet, "/", nil)
+		req.Header.Set("Authorization", "Basic "+base64.StdEncoding.EncodeToString([]byte(credentials)))
+		resp := send(t, server, req)
+		if resp.StatusCode != expected {
+			t.Fatalf("%s: expected status %d, got %d", credentials, expected, resp.StatusCode)
+		}
+		if expected == fiber.StatusUnauthorized && !strings.HasPrefix(resp.Header.Get("WWW-Authenticate"), "Basic") {
+			t.Fatalf("%s: expected a basic challenge, got %q", credentials, resp.Header.Get("WWW-Authenticate"))
+		}
+	}
+
+	if _, err := web.NewBasicAuth(strings.NewReader("alice:{SHA}W6ph5Mm5Pz8GgiULbPgzG37mj9g=\n")); err == nil {
+		t.Fatal("expected non-bcrypt entries to be rejected")
+	}
+}
+
+func TestMutatingRequestsRequireCSRFToken(t *testing.T) {
+	_, workspace := newTestWorkspace(t, map[string]string{"login.md": "## Login\n#### Password"})
+	executor := &stubExecutor{}
+	server := newTokenServer(t, web.Options{Previewer: &stubPreviewer{}, Executor: executor, Workspace: workspace})
+
+	session := signIn(t, server, "/auth/token", "alice-token")
+	req := httptest.NewRequest(fiber.MethodGet, "/", nil)
+	req.AddCookie(session)
+	resp := send(t, server, req)
+	csrfCookie := cookie(resp, "casemd_csrf")
+	page, _ := io.ReadAll(resp.Body)
+	match := regexp.MustCompile(`<meta name="csrf-token" content="([^"]+)">`).FindSubmatch(page)
+	if session == nil || csrfCookie == nil || match == nil {
+		t.Fatalf("expected session, csrf cookie and token in the page, got %v %v", resp.Cookies(), match)
+	}
+	if !strings.Contains(string(page), `value="alice" readonly`) {
+		t.Fatal("expected the tester field to show the signed-in user")
+	}
+
+	body := `{"cases":[{"majorItem":"Login","minorItem":"Password","result":"pass","tester":"mallory"}]}`
+	record := func(token string) *http.Response {
+		req := httptest.NewRequest(fiber.MethodPut, "/api/workspace/run?path=login.md", strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		req.AddCookie(session)
+		req.AddCookie(csrfCookie)
+		if token != "" {
+			req.Header.Set("X-Csrf-Token", token)
+		}
+		return send(t, server, req)
+	}
+
+	if resp := record(""); resp.StatusCode != fiber.StatusForbidden {
+		t.Fatalf("expected a request without token to be rejected, got %d", resp.StatusCode)
+	}
+	if resp := record("forged"); resp.StatusCode != fiber.StatusForbidden {
+		t.Fatalf("expected a forged token to be rejected, got %d", resp.StatusCode)
+	}
+	if executor.submitted != nil {
+		t.Fatalf("expected rejected requests not to reach the executor, got %+v", executor.submitted)
+	}
+
+	if resp := record(string(match[1])); resp.StatusCode != fiber.StatusOK {
+		t.Fatalf("expected status 200, got %d", resp.StatusCode)
+	}
+	if executor.tester != "alice" {
+		t.Fatalf("expected results to be attributed to alice, got %q", executor.tester)
+	}
+
+	// API clients authenticating with a bearer token are not exposed to CSRF.
+	req = httptest.NewRequest(fiber.MethodPut, "/api/workspace/run?path=login.md", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer bob-token")
+	if resp := send(t, server, req); resp.StatusCode != fiber.StatusOK {
+		t.Fatalf("expected a bearer request to skip csrf, got %d", resp.StatusCode)
+	}
+	if executor.tester != "bob" {
+		t.Fatalf("expected results to be attributed to bob, got %q", executor.tester)
+	}
+}
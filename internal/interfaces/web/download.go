@@ -0,0 +1,148 @@
+package web
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+
+	"github.com/9renpoto/casemd/internal/app"
+)
+
+// download describes one format served by /api/convert/:format.
+type download struct {
+	converter   Converter
+	contentType string
+	extension   string
+}
+
+func newDownloads(csvConverter CSVConverter, options Options) map[string]download {
+	return map[string]download{
+		"csv":  {converter: csvConverter, contentType: "text/csv; charset=utf-8", extension: ".csv"},
+		"xlsx": {converter: options.Spreadsheet, contentType: "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet", extension: ".xlsx"},
+		"json": {converter: options.JSON, contentType: fiber.MIMEApplicationJSONCharsetUTF8, extension: ".json"},
+	}
+}
+
+// convertRequest is the JSON body of /api/convert/:format. Either a single
+// name/markdown pair or a list of sources may be sent.
+type convertRequest struct {
+	Name     string           `json:"name"`
+	Markdown string           `json:"markdown"`
+	Sources  []previewRequest `json:"sources"`
+}
+
+func (s *Server) registerDownloadRoutes(router fiber.Router) {
+	router.Post("/api/convert/:format", func(c *fiber.Ctx) error {
+		format := strings.ToLower(c.Params("format"))
+		target, ok := s.downloads[format]
+		if !ok {
+			return fiber.NewError(fiber.StatusNotFound, fmt.Sprintf("unsupported format %q", format))
+		}
+		if target.converter == nil {
+			return fiber.NewError(fiber.StatusServiceUnavailable, fmt.Sprintf("%s converter is not configured", format))
+		}
+
+		sources, err := readConvertSources(c)
+		if err != nil {
+			return err
+		}
+
+		var columns []app.Column
+		if spec := c.Query("columns"); spec != "" {
+			if columns, err = app.ParseColumns(spec); err != nil {
+				return fiber.NewError(fiber.StatusBadRequest, fmt.Sprintf("parse columns: %v", err))
+			}
+		}
+		layout, err := app.ParseLayout(c.Query("layout"))
+		if err != nil {
+			return fiber.NewError(fiber.StatusBadRequest, fmt.Sprintf("parse layout: %v", err))
+		}
+		selected, err := queryFilter(c)
+		if err != nil {
+			return err
+		}
+
+		ctx, cancel := s.conversionContext(c)
+		defer cancel()
+		language := s.language(c)
+		for i := range sources {
+			sources[i].Context = ctx
+			sources[i].Columns = columns
+			sources[i].Layout = layout
+			sources[i].Language = language
+			sources[i].Filter = selected
+		}
+
+		var buffer bytes.Buffer
+		if err := target.converter.Convert(sources, &buffer); err != nil {
+			return s.conversionError(err, fiber.StatusUnprocessableEntity, "convert markdown")
+		}
+
+		c.Attachment(downloadName(sources) + target.extension)
+		c.Set(fiber.HeaderContentType, target.contentType)
+		return c.SendStream(&buffer, buffer.Len())
+	})
+}
+
+// readConvertSources collects Markdown from either a multipart upload (one or
+// more "files" parts) or a JSON body.
+func readConvertSources(c *fiber.Ctx) ([]app.Source, error) {
+	var sources []app.Source
+
+	if strings.HasPrefix(string(c.Request().Header.ContentType()), fiber.MIMEMultipartForm) {
+		form, err := c.MultipartForm()
+		if err != nil {
+			return nil, fiber.NewError(fiber.StatusBadRequest, fmt.Sprintf("parse multipart form: %v", err))
+		}
+		for _, header := range form.File["files"] {
+			file, err := header.Open()
+			if err != nil {
+				return nil, fiber.NewError(fiber.StatusBadRequest, fmt.Sprintf("open %s: %v", header.Filename, err))
+			}
+			data, err := io.ReadAll(file)
+			file.Close()
+			if err != nil {
+				return nil, fiber.NewError(fiber.StatusBadRequest, fmt.Sprintf("read %s: %v", header.Filename, err))
+			}
+			sources = append(sources, app.Source{Name: header.Filename, Reader: bytes.NewReader(data)})
+		}
+	} else {
+		var payload convertRequest
+		if err := c.BodyParser(&payload); err != nil {
+			return nil, fiber.NewError(fiber.StatusBadRequest, fmt.Sprintf("parse request body: %v", err))
+		}
+		if strings.TrimSpace(payload.Markdown) != "" {
+			sources = append(sources, app.Source{Name: payload.Name, Reader: strings.NewReader(payload.Markdown)})
+		}
+		for _, source := range payload.Sources {
+			if strings.TrimSpace(source.Markdown) == "" {
+				continue
+			}
+			sources = append(sources, app.Source{Name: source.Name, Reader: strings.NewReader(source.Markdown)})
+		}
+	}
+
+	if len(sources) == 0 {
+		return nil, fiber.NewError(fiber.StatusBadRequest, "markdown content cannot be empty")
+	}
+	return sources, nil
+}
+
+// downloadName derives the attachment name from a single source; several
+// sources are bundled under a generic name.
+func downloadName(sources []app.Source) string {
+	const fallback = "casemd"
+	if len(sources) != 1 {
+		return fallback
+	}
+	name := filepath.Base(filepath.ToSlash(sources[0].Name))
+	name = strings.TrimSuffix(name, filepath.Ext(name))
+	if name == "" || name == "." || name == "/" {
+		return fallback
+	}
+	return name
+}
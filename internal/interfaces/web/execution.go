@@ -0,0 +1,189 @@
+package web
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"path"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+
+	"github.com/9renpoto/casemd/internal/app"
+	"github.com/9renpoto/casemd/internal/core/domain"
+	"github.com/9renpoto/casemd/internal/core/results"
+)
+
+// Executor backs the in-browser execution mode.
+type Executor interface {
+	Checklist(source app.Source) ([]domain.Case, error)
+	// Record attributes changed results to tester, the signed-in user, when
+	// it is not empty.
+	Record(source app.Source, submitted []domain.CaseResult, tester string, now time.Time) (domain.Run, error)
+}
+
+// executionCase is a case as shown to a tester executing a checklist.
+type executionCase struct {
+	MajorItem       string   `json:"majorItem"`
+	MediumItem      string   `json:"mediumItem"`
+	MinorItem       string   `json:"minorItem"`
+	ValidationSteps []string `json:"validationSteps"`
+	Checkpoints     []string `json:"checkpoints"`
+	Result          string   `json:"result"`
+	TestDate        string   `json:"testDate"`
+	Tester          string   `json:"tester"`
+	Notes           string   `json:"notes"`
+	Line            int      `json:"line"`
+}
+
+type recordRequest struct {
+	Cases []domain.CaseResult `json:"cases"`
+}
+
+// readResults loads the results file next to the named checklist; it returns
+// nil when none has been recorded yet.
+func (w *Workspace) readResults(name string) (*domain.Run, error) {
+	file, err := w.root.Open(results.SidecarPath(name))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("open results for %s: %w", name, err)
+	}
+	defer file.Close()
+
+	run, err := results.Read(file)
+	if err != nil {
+		return nil, fmt.Errorf("read results for %s: %w", name, err)
+	}
+	return &run, nil
+}
+
+// lockResults serializes updates to the results of the named checklist and
+// returns the function releasing the lock.
+func (w *Workspace) lockResults(name string) func() {
+	lock, _ := w.results.LoadOrStore(name, &sync.Mutex{})
+	mutex := lock.(*sync.Mutex)
+	mutex.Lock()
+	return mutex.Unlock
+}
+
+// writeResults replaces the results file of the named checklist. It writes
+// next to the file and renames, so readers never see half a run.
+func (w *Workspace) writeResults(name string, run domain.Run) error {
+	var buffer bytes.Buffer
+	if err := results.Write(&buffer, run); err != nil {
+		return err
+	}
+
+	target := results.SidecarPath(name)
+	temporary := path.Join(path.Dir(target), "."+path.Base(target)+"-"+strconv.FormatInt(time.Now().UnixNano(), 36)+".tmp")
+	file, err := w.root.OpenFile(temporary, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o644)
+	if err != nil {
+		return fmt.Errorf("write results for %s: %w", name, err)
+	}
+	_, writeErr := file.Write(buffer.Bytes())
+	closeErr := file.Close()
+	if err := errors.Join(writeErr, closeErr); err != nil {
+		w.root.Remove(temporary)
+		return fmt.Errorf("write results for %s: %w", name, err)
+	}
+	if err := w.root.Rename(temporary, target); err != nil {
+		w.root.Remove(temporary)
+		return fmt.Errorf("write results for %s: %w", name, err)
+	}
+	return nil
+}
+
+// checklistSource pairs the content of a workspace checklist with its recorded results.
+func (s *Server) checklistSource(name string, data []byte) (app.Source, error) {
+	run, err := s.workspace.readResults(name)
+	if err != nil {
+		return app.Source{}, fiber.NewError(fiber.StatusUnprocessableEntity, err.Error())
+	}
+	return app.Source{Name: name, Reader: bytes.NewReader(data), Results: run, Includes: s.workspace.includes(name)}, nil
+}
+
+func (s *Server) registerExecutionRoutes(router fiber.Router) {
+	router.Get("/run", func(c *fiber.Ctx) error {
+		if s.executor == nil {
+			return fiber.NewError(fiber.StatusServiceUnavailable, "execution mode is not configured")
+		}
+
+		name := c.Query("path")
+		data, err := s.workspace.read(name)
+		if err != nil {
+			return err
+		}
+		source, err := s.checklistSource(name, data)
+		if err != nil {
+			return err
+		}
+		ctx, cancel := s.conversionContext(c)
+		defer cancel()
+		source.Context = ctx
+
+		cases, err := s.executor.Checklist(source)
+		if err != nil {
+			return s.conversionError(err, fiber.StatusUnprocessableEntity, "open checklist")
+		}
+
+		view := make([]executionCase, 0, len(cases))
+		for _, aCase := range cases {
+			view = append(view, executionCase{
+				MajorItem:       aCase.MajorItem,
+				MediumItem:      aCase.MediumItem,
+				MinorItem:       aCase.MinorItem,
+				ValidationSteps: append([]string{}, aCase.ValidationSteps...),
+				Checkpoints:     append([]string{}, aCase.Checkpoints...),
+				Result:          aCase.Result,
+				TestDate:        aCase.TestDate,
+				Tester:          aCase.Tester,
+				Notes:           aCase.Notes,
+				Line:            aCase.Line,
+			})
+		}
+		return c.JSON(fiber.Map{"path": name, "resultsPath": results.SidecarPath(name), "cases": view})
+	})
+
+	router.Put("/run", func(c *fiber.Ctx) error {
+		if s.executor == nil {
+			return fiber.NewError(fiber.StatusServiceUnavailable, "execution mode is not configured")
+		}
+
+		var payload recordRequest
+		if err := c.BodyParser(&payload); err != nil {
+			return fiber.NewError(fiber.StatusBadRequest, fmt.Sprintf("parse request body: %v", err))
+		}
+
+		name := c.Query("path")
+		data, err := s.workspace.read(name)
+		if err != nil {
+			return err
+		}
+		// Hold the lock from reading the recorded results to writing the
+		// merged ones, so concurrent testers do not drop each other's work.
+		unlock := s.workspace.lockResults(name)
+		defer unlock()
+		source, err := s.checklistSource(name, data)
+		if err != nil {
+			return err
+		}
+		ctx, cancel := s.conversionContext(c)
+		defer cancel()
+		source.Context = ctx
+
+		user, _ := currentUser(c)
+		run, err := s.executor.Record(source, payload.Cases, user.Name, time.Now())
+		if err != nil {
+			return s.conversionError(err, fiber.StatusUnprocessableEntity, "record results")
+		}
+		if err := s.workspace.writeResults(name, run); err != nil {
+			return fiber.NewError(fiber.StatusInternalServerError, err.Error())
+		}
+		return c.JSON(fiber.Map{"resultsPath": results.SidecarPath(name), "run": run})
+	})
+}
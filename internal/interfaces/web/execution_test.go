@@ -0,0 +1,195 @@
+package web_test
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+
+	"github.com/9renpoto/casemd/internal/app"
+	"github.com/9renpoto/casemd/internal/core/domain"
+	"github.com/9renpoto/casemd/internal/core/parser"
+	"github.com/9renpoto/casemd/internal/interfaces/web"
+)
+
+type stubExecutor struct {
+	cases     []domain.Case
+	err       error
+	source    app.Source
+	submitted []domain.CaseResult
+	tester    string
+}
+
+func (s *stubExecutor) Checklist(source app.Source) ([]domain.Case, error) {
+	s.source = source
+	return s.cases, s.err
+}
+
+func (s *stubExecutor) Record(source app.Source, submitted []domain.CaseResult, tester string, now time.Time) (domain.Run, error) {
+	s.source = source
+	s.submitted = submitted
+	s.tester = tester
+	return domain.Run{Source: source.Name, Cases: submitted}, s.err
+}
+
+func TestExecutionRecordsResultsNextToChecklist(t *testing.T) {
+	dir, workspace := newTestWorkspace(t, map[string]string{"auth/login.md": "## Login\n#### Password"})
+	executor := &stubExecutor{cases: []domain.Case{
+		{MajorItem: "Login", MinorItem: "Password", Checkpoints: []string{"* [ ] Signed in"}},
+	}}
+	server := web.NewServer(&stubConverter{}, web.Options{Previewer: &stubPreviewer{}, Executor: executor, Workspace: workspace})
+	defer server.Shutdown()
+
+	var checklist struct {
+		ResultsPath string `json:"resultsPath"`
+		Cases       []struct {
+			MinorItem       string   `json:"minorItem"`
+			ValidationSteps []string `json:"validationSteps"`
+		} `json:"cases"`
+	}
+	if status := getJSON(t, server, "/api/workspace/run?path=auth/login.md", &checklist); status != fiber.StatusOK {
+		t.Fatalf("expected status 200, got %d", status)
+	}
+	if checklist.ResultsPath != "auth/login.results.json" || len(checklist.Cases) != 1 || checklist.Cases[0].ValidationSteps == nil {
+		t.Fatalf("unexpected checklist: %+v", checklist)
+	}
+	if executor.source.Results != nil {
+		t.Fatalf("expected no recorded results yet, got %+v", executor.source.Results)
+	}
+
+	body := `{"cases":[{"majorItem":"Login","minorItem":"Password","checkpoints":["* [x] Signed in"],"result":"pass","tester":"alice"}]}`
+	req := httptest.NewRequest(fiber.MethodPut, "/api/workspace/run?path=auth/login.md", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := server.App().Test(req, -1)
+	if err != nil {
+		t.Fatalf("record results: %v", err)
+	}
+	if resp.StatusCode != fiber.StatusOK {
+		t.Fatalf("expected status 200, got %d", resp.StatusCode)
+	}
+
+	data, err := os.ReadFile(filepath.Join(dir, "auth", "login.results.json"))
+	if err != nil {
+		t.Fatalf("read results file: %v", err)
+	}
+	var saved domain.Run
+	if err := json.Unmarshal(data, &saved); err != nil {
+		t.Fatalf("decode results file: %v", err)
+	}
+	if saved.Source != "auth/login.md" || len(saved.Cases) != 1 || saved.Cases[0].Tester != "alice" {
+		t.Fatalf("unexpected results file: %s", data)
+	}
+
+	// Reopening the checklist hands the recorded run to the executor.
+	if status := getJSON(t, server, "/api/workspace/run?path=auth/login.md", nil); status != fiber.StatusOK {
+		t.Fatalf("expected status 200, got %d", status)
+	}
+	if executor.source.Results == nil || executor.source.Results.Cases[0].Result != "pass" {
+		t.Fatalf("expected recorded results to be loaded, got %+v", executor.source.Results)
+	}
+}
+
+type markdownCaseParser struct{}
+
+func (markdownCaseParser) Parse(r io.Reader) ([]domain.Case, error) {
+	return parser.Parse(r)
+}
+
+func TestExecutionKeepsConcurrentResults(t *testing.T) {
+	const testers = 8
+	var checklist strings.Builder
+	checklist.WriteString("## Login\n")
+	for i := 0; i < testers; i++ {
+		fmt.Fprintf(&checklist, "#### Case %d\n", i)
+	}
+	dir, workspace := newTestWorkspace(t, map[string]string{"login.md": checklist.String()})
+	executor := app.NewMarkdownExecution(markdownCaseParser{})
+	server := web.NewServer(&stubConverter{}, web.Options{Executor: executor, Workspace: workspace})
+	defer server.Shutdown()
+
+	// Each tester saves only their own case at the same time; none of the
+	// results may be lost.
+	var wg sync.WaitGroup
+	statuses := make([]int, testers)
+	for i := 0; i < testers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			body := fmt.Sprintf(`{"cases":[{"majorItem":"Login","minorItem":"Case %d","result":"pass"}]}`, i)
+			req := httptest.NewRequest(fiber.MethodPut, "/api/workspace/run?path=login.md", strings.NewReader(body))
+			req.Header.Set("Content-Type", "application/json")
+			resp, err := server.App().Test(req, -1)
+			if err != nil {
+				t.Errorf("record results: %v", err)
+				return
+			}
+			statuses[i] = resp.StatusCode
+		}()
+	}
+	wg.Wait()
+
+	for i, status := range statuses {
+		if status != fiber.StatusOK {
+			t.Fatalf("tester %d: expected status 200, got %d", i, status)
+		}
+	}
+	data, err := os.ReadFile(filepath.Join(dir, "login.results.json"))
+	if err != nil {
+		t.Fatalf("read results file: %v", err)
+	}
+	var saved domain.Run
+	if err := json.Unmarshal(data, &saved); err != nil {
+		t.Fatalf("decode results file: %v", err)
+	}
+	if len(saved.Cases) != testers {
+		t.Fatalf("expected %d results, got %d: %s", testers, len(saved.Cases), data)
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatalf("list workspace: %v", err)
+	}
+	if len(entries) != 2 {
+		t.Fatalf("expected only the checklist and its results, got %v", entries)
+	}
+}
+
+func TestExecutionRejectsInvalidRequests(t *testing.T) {
+	_, workspace := newTestWorkspace(t, map[string]string{"login.md": "## Login"})
+	executor := &stubExecutor{err: errors.New("results do not match")}
+	server := web.NewServer(&stubConverter{}, web.Options{Executor: executor, Workspace: workspace})
+	defer server.Shutdown()
+	unconfigured := web.NewServer(&stubConverter{}, web.Options{Workspace: workspace})
+	defer unconfigured.Shutdown()
+
+	for name, tc := range map[string]struct {
+		server *web.Server
+		path   string
+		body   string
+		status int
+	}{
+		"unknown case":       {server, "/api/workspace/run?path=login.md", `{"cases":[{"minorItem":"Nope"}]}`, fiber.StatusUnprocessableEntity},
+		"outside workspace":  {server, "/api/workspace/run?path=../login.md", `{"cases":[]}`, fiber.StatusBadRequest},
+		"missing checklist":  {server, "/api/workspace/run?path=missing.md", `{"cases":[]}`, fiber.StatusNotFound},
+		"malformed body":     {server, "/api/workspace/run?path=login.md", `{"cases":`, fiber.StatusBadRequest},
+		"executor not wired": {unconfigured, "/api/workspace/run?path=login.md", `{"cases":[]}`, fiber.StatusServiceUnavailable},
+	} {
+		req := httptest.NewRequest(fiber.MethodPut, tc.path, strings.NewReader(tc.body))
+		req.Header.Set("Content-Type", "application/json")
+		resp, err := tc.server.App().Test(req, -1)
+		if err != nil {
+			t.Fatalf("%s: unexpected error: %v", name, err)
+		}
+		if resp.StatusCode != tc.status {
+			t.Fatalf("%s: expected status %d, got %d", name, tc.status, resp.StatusCode)
+		}
+	}
+}
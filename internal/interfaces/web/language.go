@@ -0,0 +1,45 @@
+package web
+
+import (
+	"github.com/gofiber/fiber/v2"
+
+	"github.com/9renpoto/casemd/internal/i18n"
+)
+
+// languageCookieName remembers the language picked with ?lang= so the
+// requests made by the page's script use it too.
+const languageCookieName = "casemd_lang"
+
+// language picks the language of a request: the "lang" query parameter, then
+// the language cookie, then Options.Language, then the Accept-Language
+// header, and English otherwise.
+func (s *Server) language(c *fiber.Ctx) i18n.Language {
+	if language, ok := i18n.Parse(c.Query("lang")); ok {
+		return language
+	}
+	if language, ok := i18n.Parse(c.Cookies(languageCookieName)); ok {
+		return language
+	}
+	if s.defaultLanguage != "" {
+		return s.defaultLanguage
+	}
+	if language, ok := i18n.FromAcceptLanguage(c.Get(fiber.HeaderAcceptLanguage)); ok {
+		return language
+	}
+	return i18n.English
+}
+
+// rememberLanguage stores a language picked with ?lang= in a cookie.
+func (s *Server) rememberLanguage(c *fiber.Ctx, language i18n.Language) {
+	if _, ok := i18n.Parse(c.Query("lang")); !ok {
+		return
+	}
+	c.Cookie(&fiber.Cookie{
+		Name:     languageCookieName,
+		Value:    string(language),
+		Path:     s.cookiePath(),
+		HTTPOnly: true,
+		Secure:   c.Protocol() == "https",
+		SameSite: fiber.CookieSameSiteLaxMode,
+	})
+}
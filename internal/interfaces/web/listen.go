@@ -0,0 +1,115 @@
+package web
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"io/fs"
+	"net"
+	"os"
+	"strings"
+	"time"
+)
+
+const defaultDrainTimeout = 10 * time.Second
+
+// unixAddrPrefix marks a listen address as the path of a Unix socket.
+const unixAddrPrefix = "unix:"
+
+// ListenOptions describes where and how the server accepts connections.
+type ListenOptions struct {
+	// Addr is a TCP address such as ":3000", or "unix:" followed by the path
+	// of a Unix socket.
+	Addr string
+	// CertFile and KeyFile serve HTTPS when both are set.
+	CertFile string
+	KeyFile  string
+}
+
+// OpenListener opens the socket described by options. A stale Unix socket
+// left behind by a previous run is replaced.
+func OpenListener(options ListenOptions) (net.Listener, error) {
+	if (options.CertFile == "") != (options.KeyFile == "") {
+		return nil, errors.New("tls needs both a certificate and a key file")
+	}
+
+	var listener net.Listener
+	var err error
+	if socket, ok := strings.CutPrefix(options.Addr, unixAddrPrefix); ok {
+		if socket == "" {
+			return nil, errors.New("unix socket path is empty")
+		}
+		if info, statErr := os.Stat(socket); statErr == nil && info.Mode().Type() == fs.ModeSocket {
+			if err := os.Remove(socket); err != nil {
+				return nil, fmt.Errorf("remove stale socket: %w", err)
+			}
+		}
+		listener, err = net.Listen("unix", socket)
+	} else {
+		listener, err = net.Listen("tcp", options.Addr)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("listen on %s: %w", options.Addr, err)
+	}
+
+	if options.CertFile != "" {
+		certificate, err := tls.LoadX509KeyPair(options.CertFile, options.KeyFile)
+		if err != nil {
+			listener.Close()
+			return nil, fmt.Errorf("load tls certificate: %w", err)
+		}
+		listener = tls.NewListener(listener, &tls.Config{
+			Certificates: []tls.Certificate{certificate},
+			MinVersion:   tls.VersionTLS12,
+		})
+	}
+	return listener, nil
+}
+
+// DrainOptions controls how Serve shuts down.
+type DrainOptions struct {
+	// ReadyGrace keeps serving for this long after shutdown begins while
+	// /readyz reports 503, so load balancers stop routing new requests
+	// before the listener closes. Zero closes it at once.
+	ReadyGrace time.Duration
+	// Timeout bounds the wait for requests in flight once the listener is
+	// closed (ten seconds when zero).
+	Timeout time.Duration
+}
+
+// Serve handles connections from listener until ctx is done, e.g. on
+// SIGTERM. It then reports not ready on /readyz, keeps serving for the
+// ready grace period, stops accepting connections and waits for requests in
+// flight to finish.
+func (s *Server) Serve(ctx context.Context, listener net.Listener, options DrainOptions) error {
+	served := make(chan error, 1)
+	go func() { served <- s.app.Listener(listener) }()
+
+	select {
+	case err := <-served:
+		return err
+	case <-ctx.Done():
+	}
+
+	s.draining.Store(true)
+	if options.ReadyGrace > 0 {
+		grace := time.NewTimer(options.ReadyGrace)
+		select {
+		case err := <-served:
+			grace.Stop()
+			return err
+		case <-grace.C:
+		}
+	}
+
+	timeout := options.Timeout
+	if timeout <= 0 {
+		timeout = defaultDrainTimeout
+	}
+	err := s.shutdown(func() error { return s.app.ShutdownWithTimeout(timeout) })
+	if err != nil {
+		return fmt.Errorf("drain connections: %w", err)
+	}
+	return <-served
+}
@@ -0,0 +1,247 @@
+package web_test
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"io"
+	"math/big"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+
+	"github.com/9renpoto/casemd/internal/interfaces/web"
+)
+
+func TestServeDrainsOnUnixSocket(t *testing.T) {
+	// Unix socket paths are limited to about 100 bytes, which t.TempDir()
+	// can exceed.
+	dir, err := os.MkdirTemp("", "casemd")
+	if err != nil {
+		t.Fatalf("create temp dir: %v", err)
+	}
+	defer os.RemoveAll(dir)
+	socket := filepath.Join(dir, "casemd.sock")
+
+	listener, err := web.OpenListener(web.ListenOptions{Addr: "unix:" + socket})
+	if err != nil {
+		t.Fatalf("OpenListener() returned an unexpected error: %v", err)
+	}
+	server := web.NewServer(&stubConverter{}, web.Options{})
+	ctx, cancel := context.WithCancel(context.Background())
+	served := make(chan error, 1)
+	go func() { served <- server.Serve(ctx, listener, web.DrainOptions{Timeout: time.Second}) }()
+
+	client := &http.Client{
+		Timeout: 5 * time.Second,
+		Transport: &http.Transport{DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+			return (&net.Dialer{}).DialContext(ctx, "unix", socket)
+		}},
+	}
+	resp, err := client.Get("http://casemd/readyz")
+	if err != nil {
+		t.Fatalf("GET /readyz: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != fiber.StatusOK {
+		t.Fatalf("expected status 200, got %d", resp.StatusCode)
+	}
+
+	cancel()
+	select {
+	case err := <-served:
+		if err != nil {
+			t.Fatalf("Serve() returned an unexpected error: %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Serve() did not return after the context was cancelled")
+	}
+	if _, err := os.Stat(socket); !os.IsNotExist(err) {
+		t.Fatalf("expected the socket to be removed, got %v", err)
+	}
+	if resp := send(t, server, httptest.NewRequest(fiber.MethodGet, "/readyz", nil)); resp.StatusCode != fiber.StatusServiceUnavailable {
+		t.Fatalf("expected /readyz to report 503 once draining, got %d", resp.StatusCode)
+	}
+}
+
+func TestServeKeepsServingDuringReadyGrace(t *testing.T) {
+	listener, err := web.OpenListener(web.ListenOptions{Addr: "127.0.0.1:0"})
+	if err != nil {
+		t.Fatalf("OpenListener() returned an unexpected error: %v", err)
+	}
+	server := web.NewServer(&stubConverter{}, web.Options{})
+	ctx, cancel := context.WithCancel(context.Background())
+	served := make(chan error, 1)
+	go func() {
+		served <- server.Serve(ctx, listener, web.DrainOptions{ReadyGrace: time.Second, Timeout: time.Second})
+	}()
+
+	// A fresh connection per request shows the listener is still open.
+	client := &http.Client{Timeout: 5 * time.Second, Transport: &http.Transport{DisableKeepAlives: true}}
+	status := func(path string) int {
+		t.Helper()
+		resp, err := client.Get("http://" + listener.Addr().String() + path)
+		if err != nil {
+			t.Fatalf("GET %s: %v", path, err)
+		}
+		resp.Body.Close()
+		return resp.StatusCode
+	}
+	if code := status("/readyz"); code != fiber.StatusOK {
+		t.Fatalf("expected /readyz to report 200, got %d", code)
+	}
+
+	cancel()
+	// Serve notices the cancellation asynchronously.
+	deadline := time.Now().Add(500 * time.Millisecond)
+	for status("/readyz") != fiber.StatusServiceUnavailable {
+		if time.Now().After(deadline) {
+			t.Fatal("expected /readyz to report 503 during the grace period")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	if code := status("/healthz"); code != fiber.StatusOK {
+		t.Fatalf("expected /healthz to keep answering during the grace period, got %d", code)
+	}
+	select {
+	case err := <-served:
+		t.Fatalf("Serve() returned before the grace period ended: %v", err)
+	default:
+	}
+
+	select {
+	case err := <-served:
+		if err != nil {
+			t.Fatalf("Serve() returned an unexpected error: %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Serve() did not return after the grace period")
+	}
+	if _, err := client.Get("http://" + listener.Addr().String() + "/healthz"); err == nil {
+		t.Fatal("expected the listener to be closed after the grace period")
+	}
+}
+
+func TestServeWithTLS(t *testing.T) {
+	certFile, keyFile := writeSelfSignedCertificate(t)
+	if _, err := web.OpenListener(web.ListenOptions{Addr: "127.0.0.1:0", CertFile: certFile}); err == nil {
+		t.Fatal("expected a certificate without key to be rejected")
+	}
+
+	listener, err := web.OpenListener(web.ListenOptions{Addr: "127.0.0.1:0", CertFile: certFile, KeyFile: keyFile})
+	if err != nil {
+		t.Fatalf("OpenListener() returned an unexpected error: %v", err)
+	}
+	server := web.NewServer(&stubConverter{}, web.Options{})
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go func() { _ = server.Serve(ctx, listener, web.DrainOptions{Timeout: time.Second}) }()
+
+	client := &http.Client{
+		Timeout:   5 * time.Second,
+		Transport: &http.Transport{TLSClientConfig: &tls.Config{InsecureSkipVerify: true}},
+	}
+	resp, err := client.Get("https://" + listener.Addr().String() + "/healthz")
+	if err != nil {
+		t.Fatalf("GET /healthz over https: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != fiber.StatusOK || resp.TLS == nil {
+		t.Fatalf("expected a TLS response with status 200, got %d", resp.StatusCode)
+	}
+}
+
+func TestBasePathPrefixesEveryRoute(t *testing.T) {
+	auth, err := web.NewTokenAuth(strings.NewReader("alice:alice-token\n"))
+	if err != nil {
+		t.Fatalf("NewTokenAuth() returned an unexpected error: %v", err)
+	}
+	server := web.NewServer(&stubConverter{}, web.Options{BasePath: "casemd/", Auth: auth})
+	defer server.Shutdown()
+
+	for path, expected := range map[string]int{
+		"/casemd/healthz":          fiber.StatusOK,
+		"/casemd/readyz":           fiber.StatusOK,
+		"/casemd/":                 fiber.StatusOK,
+		"/casemd/static/index.css": fiber.StatusOK,
+		"/healthz":                 fiber.StatusNotFound,
+		"/api/workspace":           fiber.StatusNotFound,
+	} {
+		req := httptest.NewRequest(fiber.MethodGet, path, nil)
+		req.Header.Set("Authorization", "Bearer alice-token")
+		if resp := send(t, server, req); resp.StatusCode != expected {
+			t.Fatalf("GET %s: expected status %d, got %d", path, expected, resp.StatusCode)
+		}
+	}
+	if resp := send(t, server, httptest.NewRequest(fiber.MethodGet, "/casemd/static/index.css", nil)); resp.StatusCode != fiber.StatusUnauthorized {
+		t.Fatalf("expected static files to require sign-in, got %d", resp.StatusCode)
+	}
+
+	session := signIn(t, server, "/casemd/auth/token", "alice-token")
+	req := httptest.NewRequest(fiber.MethodGet, "/casemd/?lang=ja", nil)
+	req.AddCookie(session)
+	resp := send(t, server, req)
+	// Cookies stay with the base path instead of every app on the host.
+	for _, issued := range append(resp.Cookies(), session) {
+		if issued.Path != "/casemd" {
+			t.Fatalf("expected cookie %s to be scoped to /casemd, got %q", issued.Name, issued.Path)
+		}
+	}
+	if len(resp.Cookies()) != 2 {
+		t.Fatalf("expected csrf and language cookies, got %v", resp.Cookies())
+	}
+	page, _ := io.ReadAll(resp.Body)
+	for _, expected := range []string{`<meta name="base-path" content="/casemd">`, `src="/casemd/static/index.js"`} {
+		if !strings.Contains(string(page), expected) {
+			t.Fatalf("expected the page to contain %s", expected)
+		}
+	}
+}
+
+func writeSelfSignedCertificate(t *testing.T) (string, string) {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("generate key: %v", err)
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "casemd test"},
+		IPAddresses:  []net.IP{net.ParseIP("127.0.0.1")},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	}
+	certificate, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatalf("create certificate: %v", err)
+	}
+	privateKey, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatalf("marshal key: %v", err)
+	}
+
+	dir := t.TempDir()
+	certFile, keyFile := filepath.Join(dir, "cert.pem"), filepath.Join(dir, "key.pem")
+	if err := os.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: certificate}), 0o600); err != nil {
+		t.Fatalf("write certificate: %v", err)
+	}
+	if err := os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: privateKey}), 0o600); err != nil {
+		t.Fatalf("write key: %v", err)
+	}
+	return certFile, keyFile
+}
@@ -0,0 +1,269 @@
+package web
+
+import (
+	"bufio"
+	"crypto/subtle"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+
+	"github.com/9renpoto/casemd/internal/app"
+)
+
+// Previewer renders live previews with diagnostics for the web UI.
+type Previewer interface {
+	Preview(source app.Source) (app.Preview, error)
+	Workbook(sources []app.Source) ([]app.SheetPreview, error)
+}
+
+const (
+	liveKeepAliveInterval = 15 * time.Second
+	// liveMaxSessions caps the live sessions kept at once; creating another
+	// forgets the one used least recently.
+	liveMaxSessions = 1024
+	// liveKeyHeader carries the publish key issued with a live session.
+	liveKeyHeader = "X-Live-Key"
+)
+
+var sessionIDRegex = regexp.MustCompile(`^[A-Za-z0-9_-]{1,64}$`)
+
+// liveSession is a live preview created by one browser. Only requests that
+// echo its key, from the user who created it when authentication is enabled,
+// may publish to it, and only that user may subscribe to it.
+type liveSession struct {
+	owner string
+	key   string
+	used  time.Time
+}
+
+// liveHub fans preview events out to the SSE streams subscribed to a session.
+// Each subscriber only keeps the latest event: a slow browser skips stale
+// previews instead of queueing them.
+type liveHub struct {
+	mu          sync.Mutex
+	subscribers map[string]map[chan []byte]struct{}
+	sessions    map[string]*liveSession
+}
+
+func newLiveHub() *liveHub {
+	return &liveHub{
+		subscribers: make(map[string]map[chan []byte]struct{}),
+		sessions:    make(map[string]*liveSession),
+	}
+}
+
+// create issues a live session for owner and returns its ID and publish key.
+func (h *liveHub) create(owner string, now time.Time) (string, string) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	for id, session := range h.sessions {
+		if now.Sub(session.used) > sessionLifetime {
+			delete(h.sessions, id)
+		}
+	}
+	if len(h.sessions) >= liveMaxSessions {
+		h.dropLeastUsedSession()
+	}
+	id, key := randomToken(), randomToken()
+	h.sessions[id] = &liveSession{owner: owner, key: key, used: now}
+	return id, key
+}
+
+// dropLeastUsedSession forgets the session used least recently. h.mu must be
+// held.
+func (h *liveHub) dropLeastUsedSession() {
+	var oldest string
+	var used time.Time
+	for id, session := range h.sessions {
+		if oldest == "" || session.used.Before(used) {
+			oldest, used = id, session.used
+		}
+	}
+	delete(h.sessions, oldest)
+}
+
+// watch checks that owner may subscribe to the session's events. Browsers
+// cannot send the publish key with an EventSource, so only the owner is
+// checked; it answers like authorize otherwise.
+func (h *liveHub) watch(id, owner string) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	session, ok := h.sessions[id]
+	if !ok {
+		return fiber.NewError(fiber.StatusNotFound, "unknown live session")
+	}
+	if session.owner != owner {
+		return fiber.NewError(fiber.StatusForbidden, "live session belongs to another client")
+	}
+	return nil
+}
+
+// authorize checks that owner holding key may publish to the session. It
+// answers 404 for unknown sessions and 403 for anyone but their creator.
+func (h *liveHub) authorize(id, owner, key string, now time.Time) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	session, ok := h.sessions[id]
+	if !ok {
+		return fiber.NewError(fiber.StatusNotFound, "unknown live session")
+	}
+	if session.owner != owner || subtle.ConstantTimeCompare([]byte(session.key), []byte(key)) != 1 {
+		return fiber.NewError(fiber.StatusForbidden, "live session belongs to another client")
+	}
+	session.used = now
+	return nil
+}
+
+func (h *liveHub) subscribe(session string) (<-chan []byte, func()) {
+	events := make(chan []byte, 1)
+
+	h.mu.Lock()
+	if h.subscribers[session] == nil {
+		h.subscribers[session] = make(map[chan []byte]struct{})
+	}
+	h.subscribers[session][events] = struct{}{}
+	h.mu.Unlock()
+
+	unsubscribe := func() {
+		h.mu.Lock()
+		defer h.mu.Unlock()
+		delete(h.subscribers[session], events)
+		if len(h.subscribers[session]) == 0 {
+			delete(h.subscribers, session)
+		}
+	}
+	return events, unsubscribe
+}
+
+// publish delivers payload to every subscriber of session and reports how many received it.
+func (h *liveHub) publish(session string, payload []byte) int {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	for events := range h.subscribers[session] {
+		select {
+		case <-events:
+		default:
+		}
+		events <- payload
+	}
+	return len(h.subscribers[session])
+}
+
+// liveOwner returns the user a live session is bound to: the signed-in user,
+// or nobody when authentication is disabled and the key alone binds it.
+func liveOwner(c *fiber.Ctx) string {
+	user, _ := currentUser(c)
+	return user.Name
+}
+
+func (s *Server) registerLiveRoutes(router fiber.Router) {
+	// The server issues session IDs, so a client cannot publish to a
+	// session it did not create by guessing or reusing its ID.
+	router.Post("/api/live", func(c *fiber.Ctx) error {
+		if s.previewer == nil {
+			return fiber.NewError(fiber.StatusServiceUnavailable, "live preview is not configured")
+		}
+		session, key := s.live.create(liveOwner(c), time.Now())
+		return c.Status(fiber.StatusCreated).JSON(fiber.Map{"session": session, "key": key})
+	})
+
+	router.Get("/api/live/:session/events", func(c *fiber.Ctx) error {
+		session := c.Params("session")
+		if !sessionIDRegex.MatchString(session) {
+			return fiber.NewError(fiber.StatusBadRequest, "invalid session id")
+		}
+		if err := s.live.watch(session, liveOwner(c)); err != nil {
+			return err
+		}
+
+		return streamEvents(c, s.live, session, "preview")
+	})
+
+	router.Post("/api/live/:session", func(c *fiber.Ctx) error {
+		session := c.Params("session")
+		if !sessionIDRegex.MatchString(session) {
+			return fiber.NewError(fiber.StatusBadRequest, "invalid session id")
+		}
+		if s.previewer == nil {
+			return fiber.NewError(fiber.StatusServiceUnavailable, "live preview is not configured")
+		}
+		if err := s.live.authorize(session, liveOwner(c), c.Get(liveKeyHeader), time.Now()); err != nil {
+			return err
+		}
+
+		var payload previewRequest
+		if err := c.BodyParser(&payload); err != nil {
+			return fiber.NewError(fiber.StatusBadRequest, fmt.Sprintf("parse request body: %v", err))
+		}
+
+		selected, err := queryFilter(c)
+		if err != nil {
+			return err
+		}
+
+		ctx, cancel := s.conversionContext(c)
+		defer cancel()
+
+		preview, err := s.previewer.Preview(app.Source{Name: payload.Name, Reader: strings.NewReader(payload.Markdown), Context: ctx, Language: s.language(c), Filter: selected})
+		if err != nil {
+			return s.conversionError(err, fiber.StatusUnprocessableEntity, "preview markdown")
+		}
+
+		event, err := json.Marshal(preview)
+		if err != nil {
+			return fiber.NewError(fiber.StatusInternalServerError, fmt.Sprintf("encode preview: %v", err))
+		}
+		delivered := s.live.publish(session, event)
+
+		return c.Status(fiber.StatusAccepted).JSON(fiber.Map{"subscribers": delivered})
+	})
+}
+
+// streamEvents subscribes the request to topic on hub and writes every
+// published payload as a Server-Sent Event named event until the client goes
+// away or the server shuts down.
+func streamEvents(c *fiber.Ctx, hub *liveHub, topic, event string) error {
+	c.Set(fiber.HeaderContentType, "text/event-stream")
+	c.Set(fiber.HeaderCacheControl, "no-cache")
+	c.Set(fiber.HeaderConnection, "keep-alive")
+
+	events, unsubscribe := hub.subscribe(topic)
+	// Done is closed when the server shuts down, which would otherwise wait
+	// for the next keep-alive to notice the open stream.
+	shutdown := c.Context().Done()
+	c.Context().SetBodyStreamWriter(func(w *bufio.Writer) {
+		defer unsubscribe()
+
+		fmt.Fprint(w, ": connected\n\n")
+		if err := w.Flush(); err != nil {
+			return
+		}
+
+		ticker := time.NewTicker(liveKeepAliveInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case payload := <-events:
+				fmt.Fprintf(w, "event: %s\ndata: %s\n\n", event, payload)
+			case <-ticker.C:
+				fmt.Fprint(w, ": keep-alive\n\n")
+			case <-shutdown:
+				return
+			}
+			// A flush error means the browser went away.
+			if err := w.Flush(); err != nil {
+				return
+			}
+		}
+	})
+	return nil
+}
@@ -0,0 +1,192 @@
+package web
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+
+	"github.com/9renpoto/casemd/internal/app"
+)
+
+// otherRoute labels requests answered by global middleware, such as
+// unauthenticated or rate-limited requests and unknown paths, so request
+// paths never become label values.
+const otherRoute = "other"
+
+// httpMetrics counts requests per route in the Prometheus text format.
+type httpMetrics struct {
+	mu        sync.Mutex
+	requests  map[requestKey]uint64
+	durations map[routeKey]*app.Histogram
+}
+
+type routeKey struct {
+	method string
+	route  string
+}
+
+type requestKey struct {
+	routeKey
+	status int
+}
+
+func newHTTPMetrics() *httpMetrics {
+	return &httpMetrics{
+		requests:  make(map[requestKey]uint64),
+		durations: make(map[routeKey]*app.Histogram),
+	}
+}
+
+// middleware records every request under the route pattern that answered it.
+func (m *httpMetrics) middleware(c *fiber.Ctx) error {
+	start := time.Now()
+	err := c.Next()
+
+	// Fiber reuses the memory behind request strings, so copy them before
+	// they become map keys.
+	route := c.Route()
+	key := routeKey{method: strings.Clone(c.Method()), route: strings.Clone(route.Path)}
+	// Requests no route matched report the last global middleware, which
+	// Fiber registers under "/" for every method.
+	if route.Path == "/" && c.Path() != "/" {
+		key.route = otherRoute
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.requests[requestKey{routeKey: key, status: responseStatus(c, err)}]++
+	histogram, ok := m.durations[key]
+	if !ok {
+		created := app.NewHistogram(app.DurationBuckets)
+		histogram = &created
+		m.durations[key] = histogram
+	}
+	histogram.Observe(time.Since(start).Seconds())
+	return err
+}
+
+func (s *Server) registerMetricsRoutes(router fiber.Router) {
+	router.Get("/metrics", func(c *fiber.Ctx) error {
+		c.Set(fiber.HeaderContentType, "text/plain; version=0.0.4; charset=utf-8")
+		var buffer strings.Builder
+		s.metrics.write(&buffer)
+		if s.stats != nil {
+			writeStats(&buffer, s.stats.Snapshot())
+		}
+		return c.SendString(buffer.String())
+	})
+}
+
+func (m *httpMetrics) write(w io.Writer) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	requests := make([]requestKey, 0, len(m.requests))
+	for key := range m.requests {
+		requests = append(requests, key)
+	}
+	sort.Slice(requests, func(i, j int) bool {
+		if requests[i].routeKey != requests[j].routeKey {
+			return requests[i].routeKey.less(requests[j].routeKey)
+		}
+		return requests[i].status < requests[j].status
+	})
+	writeHeader(w, "casemd_http_requests_total", "counter", "HTTP requests by route and status.")
+	for _, key := range requests {
+		writeSample(w, "casemd_http_requests_total", labels("method", key.method, "route", key.route, "status", strconv.Itoa(key.status)), float64(m.requests[key]))
+	}
+
+	routes := make([]routeKey, 0, len(m.durations))
+	for key := range m.durations {
+		routes = append(routes, key)
+	}
+	sort.Slice(routes, func(i, j int) bool { return routes[i].less(routes[j]) })
+	writeHeader(w, "casemd_http_request_duration_seconds", "histogram", "HTTP request latency by route.")
+	for _, key := range routes {
+		writeHistogram(w, "casemd_http_request_duration_seconds", labels("method", key.method, "route", key.route), *m.durations[key])
+	}
+}
+
+func (k routeKey) less(other routeKey) bool {
+	if k.route != other.route {
+		return k.route < other.route
+	}
+	return k.method < other.method
+}
+
+// writeStats renders the parser and converter statistics collected in internal/app.
+func writeStats(w io.Writer, snapshot app.StatsSnapshot) {
+	for _, counter := range []struct {
+		name, help string
+		value      float64
+	}{
+		{"casemd_parsed_sources_total", "Markdown sources parsed.", float64(snapshot.Parse.Sources)},
+		{"casemd_parse_errors_total", "Markdown sources that failed to parse.", float64(snapshot.Parse.Errors)},
+		{"casemd_parsed_cases_total", "Cases found in parsed sources.", float64(snapshot.Parse.Cases)},
+		{"casemd_parsed_bytes_total", "Bytes of Markdown parsed.", float64(snapshot.Parse.Bytes)},
+	} {
+		writeHeader(w, counter.name, "counter", counter.help)
+		writeSample(w, counter.name, "", counter.value)
+	}
+
+	writeHeader(w, "casemd_conversions_total", "counter", "Conversions by output format.")
+	for _, conversion := range snapshot.Conversions {
+		writeSample(w, "casemd_conversions_total", labels("format", conversion.Format), float64(conversion.Count))
+	}
+	writeHeader(w, "casemd_conversion_errors_total", "counter", "Failed conversions by output format.")
+	for _, conversion := range snapshot.Conversions {
+		writeSample(w, "casemd_conversion_errors_total", labels("format", conversion.Format), float64(conversion.Errors))
+	}
+	writeHeader(w, "casemd_conversion_duration_seconds", "histogram", "Conversion time by output format.")
+	for _, conversion := range snapshot.Conversions {
+		writeHistogram(w, "casemd_conversion_duration_seconds", labels("format", conversion.Format), conversion.Duration)
+	}
+	writeHeader(w, "casemd_conversion_output_bytes", "histogram", "Size of successful conversions by output format.")
+	for _, conversion := range snapshot.Conversions {
+		writeHistogram(w, "casemd_conversion_output_bytes", labels("format", conversion.Format), conversion.Size)
+	}
+}
+
+func writeHeader(w io.Writer, name, kind, help string) {
+	fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s %s\n", name, help, name, kind)
+}
+
+func writeSample(w io.Writer, name, labels string, value float64) {
+	if labels != "" {
+		labels = "{" + labels + "}"
+	}
+	fmt.Fprintf(w, "%s%s %s\n", name, labels, strconv.FormatFloat(value, 'g', -1, 64))
+}
+
+// writeHistogram writes cumulative buckets as Prometheus expects.
+func writeHistogram(w io.Writer, name, labelSet string, histogram app.Histogram) {
+	prefix := labelSet
+	if prefix != "" {
+		prefix += ","
+	}
+	var cumulative uint64
+	for i, bound := range histogram.Bounds {
+		cumulative += histogram.Counts[i]
+		writeSample(w, name+"_bucket", prefix+labels("le", strconv.FormatFloat(bound, 'g', -1, 64)), float64(cumulative))
+	}
+	writeSample(w, name+"_bucket", prefix+labels("le", "+Inf"), float64(histogram.Count))
+	writeSample(w, name+"_sum", labelSet, histogram.Sum)
+	writeSample(w, name+"_count", labelSet, float64(histogram.Count))
+}
+
+var labelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+// labels formats name/value pairs as a Prometheus label list without braces.
+func labels(pairs ...string) string {
+	parts := make([]string, 0, len(pairs)/2)
+	for i := 0; i+1 < len(pairs); i += 2 {
+		parts = append(parts, fmt.Sprintf(`%s="%s"`, pairs[i], labelEscaper.Replace(pairs[i+1])))
+	}
+	return strings.Join(parts, ",")
+}
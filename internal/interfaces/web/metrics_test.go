@@ -0,0 +1,60 @@
+package web_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+
+	"github.com/9renpoto/casemd/internal/app"
+	"github.com/9renpoto/casemd/internal/interfaces/web"
+)
+
+func TestMetricsEndpointReportsRequestsAndConversions(t *testing.T) {
+	server := web.NewServer(&stubConverter{output: "Major Item\n"}, web.Options{Stats: app.NewStats()})
+
+	if status := postPreview(t, server, "## Setup"); status != fiber.StatusOK {
+		t.Fatalf("preview returned %d", status)
+	}
+	body, _ := json.Marshal(map[string]string{"name": "notes.md", "markdown": "## Setup"})
+	req := httptest.NewRequest(fiber.MethodPost, "/api/convert/csv", bytes.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	if resp := send(t, server, req); resp.StatusCode != fiber.StatusOK {
+		t.Fatalf("convert returned %d", resp.StatusCode)
+	}
+	send(t, server, httptest.NewRequest(fiber.MethodGet, "/no-such-page", nil))
+
+	resp := send(t, server, httptest.NewRequest(fiber.MethodGet, "/metrics", nil))
+	if resp.StatusCode != fiber.StatusOK {
+		t.Fatalf("metrics returned %d", resp.StatusCode)
+	}
+	if contentType := resp.Header.Get("Content-Type"); !strings.HasPrefix(contentType, "text/plain; version=0.0.4") {
+		t.Fatalf("unexpected content type %q", contentType)
+	}
+	content, _ := io.ReadAll(resp.Body)
+	metrics := string(content)
+
+	// The preview renders its table through the CSV converter too.
+	for _, expected := range []string{
+		`casemd_http_requests_total{method="POST",route="/api/preview",status="200"} 1`,
+		`casemd_http_requests_total{method="POST",route="/api/convert/:format",status="200"} 1`,
+		`casemd_http_requests_total{method="GET",route="other",status="404"} 1`,
+		`casemd_http_request_duration_seconds_count{method="POST",route="/api/preview"} 1`,
+		`casemd_conversions_total{format="csv"} 2`,
+		`casemd_conversion_errors_total{format="csv"} 0`,
+		`casemd_conversion_output_bytes_bucket{format="csv",le="1024"} 2`,
+		`casemd_conversion_duration_seconds_bucket{format="csv",le="+Inf"} 2`,
+		"# TYPE casemd_parsed_cases_total counter",
+	} {
+		if !strings.Contains(metrics, expected) {
+			t.Errorf("metrics missing %q:\n%s", expected, metrics)
+		}
+	}
+	if strings.Contains(metrics, "/no-such-page") {
+		t.Fatalf("unknown paths must not become labels:\n%s", metrics)
+	}
+}
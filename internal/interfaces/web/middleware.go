@@ -0,0 +1,104 @@
+package web
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/middleware/helmet"
+	"github.com/gofiber/fiber/v2/middleware/limiter"
+)
+
+// contentSecurityPolicy only allows the UI's own scripts, styles and API
+// calls; the page has no inline scripts besides JSON data blocks.
+const contentSecurityPolicy = "default-src 'self'; img-src 'self' data:; object-src 'none'; base-uri 'self'; form-action 'self'; frame-ancestors 'none'"
+
+// accessLog writes one structured record per request. Query strings are left
+// out because they may carry access tokens.
+func accessLog(logger *slog.Logger) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		start := time.Now()
+		err := c.Next()
+		status := responseStatus(c, err)
+
+		attrs := []slog.Attr{
+			slog.String("method", c.Method()),
+			slog.String("path", c.Path()),
+			slog.Int("status", status),
+			slog.Duration("duration", time.Since(start)),
+			slog.String("ip", c.IP()),
+		}
+		if user, ok := currentUser(c); ok {
+			attrs = append(attrs, slog.String("user", user.Name))
+		}
+		level := slog.LevelInfo
+		if status >= fiber.StatusInternalServerError {
+			level = slog.LevelError
+		}
+		logger.LogAttrs(c.UserContext(), level, "request", attrs...)
+		return err
+	}
+}
+
+// responseStatus is the status the error handler will send for err, or the
+// status already set on the response.
+func responseStatus(c *fiber.Ctx, err error) int {
+	var fiberErr *fiber.Error
+	switch {
+	case errors.As(err, &fiberErr):
+		return fiberErr.Code
+	case err != nil:
+		return fiber.StatusInternalServerError
+	default:
+		return c.Response().StatusCode()
+	}
+}
+
+// securityHeaders sets the usual hardening headers on every response.
+func securityHeaders() fiber.Handler {
+	return helmet.New(helmet.Config{
+		ContentSecurityPolicy: contentSecurityPolicy,
+		XFrameOptions:         "DENY",
+		// The CSRF check compares the Referer of HTTPS requests with the
+		// host, so same-origin requests must keep sending it.
+		ReferrerPolicy: "same-origin",
+	})
+}
+
+// rateLimit allows each client IP perMinute requests per minute. Health
+// checks and static assets are not counted.
+func (s *Server) rateLimit(perMinute int) fiber.Handler {
+	return limiter.New(limiter.Config{
+		Next: func(c *fiber.Ctx) bool {
+			return s.isProbe(c.Path()) || strings.HasPrefix(c.Path(), s.basePath+"/static/")
+		},
+		Max:        perMinute,
+		Expiration: time.Minute,
+		LimitReached: func(c *fiber.Ctx) error {
+			return fiber.NewError(fiber.StatusTooManyRequests, "too many requests, retry later")
+		},
+	})
+}
+
+// conversionContext bounds the parsing and conversion done for a request by
+// the configured timeout. Sources built for the request carry the context so
+// parsing and writing CSV and workbook rows stop once it is done.
+func (s *Server) conversionContext(c *fiber.Ctx) (context.Context, context.CancelFunc) {
+	if s.convertTimeout <= 0 {
+		return context.WithCancel(c.UserContext())
+	}
+	return context.WithTimeout(c.UserContext(), s.convertTimeout)
+}
+
+// conversionError reports err with status, unless the conversion ran out of
+// time, which is the server's limit rather than a problem with the input.
+func (s *Server) conversionError(err error, status int, action string) error {
+	if errors.Is(err, context.DeadlineExceeded) {
+		return fiber.NewError(fiber.StatusServiceUnavailable, fmt.Sprintf("%s: timed out after %s", action, s.convertTimeout))
+	}
+	return fiber.NewError(status, fmt.Sprintf("%s: %v", action, err))
+}
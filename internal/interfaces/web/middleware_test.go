@@ -0,0 +1,141 @@
+package web_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+
+	"github.com/9renpoto/casemd/internal/app"
+	"github.com/9renpoto/casemd/internal/interfaces/web"
+)
+
+// blockingConverter converts nothing until the request context of its
+// sources is done, like a parser stuck on a huge document.
+type blockingConverter struct{}
+
+func (blockingConverter) Convert(sources []app.Source, writer io.Writer) error {
+	<-sources[0].Context.Done()
+	return sources[0].Context.Err()
+}
+
+func postPreview(t *testing.T, server *web.Server, markdown string) int {
+	t.Helper()
+
+	body, _ := json.Marshal(map[string]string{"name": "notes.md", "markdown": markdown})
+	req := httptest.NewRequest(fiber.MethodPost, "/api/preview", bytes.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	return send(t, server, req).StatusCode
+}
+
+func TestBodyLimitRejectsLargeRequests(t *testing.T) {
+	server := web.NewServer(&stubConverter{output: "Major Item\n"}, web.Options{BodyLimit: 1024})
+
+	// The limit is enforced while reading the request, before Fiber's test
+	// helper can see a response, so this test needs a real listener.
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	go func() { _ = server.App().Listener(listener) }()
+	defer server.Shutdown()
+
+	for markdown, expected := range map[string]int{
+		"## Setup":                         fiber.StatusOK,
+		strings.Repeat("#### Case\n", 200): fiber.StatusRequestEntityTooLarge,
+	} {
+		body, _ := json.Marshal(map[string]string{"name": "notes.md", "markdown": markdown})
+		resp, err := http.Post("http://"+listener.Addr().String()+"/api/preview", "application/json", bytes.NewReader(body))
+		if err != nil {
+			t.Fatalf("post %d bytes: %v", len(body), err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != expected {
+			t.Fatalf("%d bytes: expected status %d, got %d", len(body), expected, resp.StatusCode)
+		}
+	}
+}
+
+func TestConvertTimeoutCancelsConversion(t *testing.T) {
+	server := web.NewServer(blockingConverter{}, web.Options{ConvertTimeout: 20 * time.Millisecond})
+
+	done := make(chan int, 1)
+	go func() { done <- postPreview(t, server, "## Setup") }()
+	select {
+	case status := <-done:
+		if status != fiber.StatusServiceUnavailable {
+			t.Fatalf("expected status 503, got %d", status)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("conversion was not cancelled")
+	}
+}
+
+func TestRateLimitRejectsBurstsPerClient(t *testing.T) {
+	server := web.NewServer(&stubConverter{output: "Major Item\n"}, web.Options{RateLimit: 2})
+
+	for i, expected := range []int{fiber.StatusOK, fiber.StatusOK, fiber.StatusTooManyRequests} {
+		if status := postPreview(t, server, "## Setup"); status != expected {
+			t.Fatalf("request %d: expected status %d, got %d", i+1, expected, status)
+		}
+	}
+	if resp := send(t, server, httptest.NewRequest(fiber.MethodGet, "/healthz", nil)); resp.StatusCode != fiber.StatusOK {
+		t.Fatalf("expected health checks not to be limited, got %d", resp.StatusCode)
+	}
+}
+
+func TestAccessLogAndSecurityHeaders(t *testing.T) {
+	var logs bytes.Buffer
+	auth, err := web.NewTokenAuth(strings.NewReader("alice:alice-token\n"))
+	if err != nil {
+		t.Fatalf("NewTokenAuth() returned an unexpected error: %v", err)
+	}
+	server := web.NewServer(&stubConverter{}, web.Options{Auth: auth, AccessLog: slog.New(slog.NewJSONHandler(&logs, nil))})
+
+	req := httptest.NewRequest(fiber.MethodGet, "/", nil)
+	req.Header.Set("Authorization", "Bearer alice-token")
+	resp := send(t, server, req)
+	if resp.StatusCode != fiber.StatusOK {
+		t.Fatalf("expected status 200, got %d", resp.StatusCode)
+	}
+	for header, expected := range map[string]string{
+		"Content-Security-Policy": "default-src 'self'",
+		"X-Content-Type-Options":  "nosniff",
+		"X-Frame-Options":         "DENY",
+		"Referrer-Policy":         "same-origin",
+	} {
+		if !strings.Contains(resp.Header.Get(header), expected) {
+			t.Fatalf("expected %s to contain %q, got %q", header, expected, resp.Header.Get(header))
+		}
+	}
+	send(t, server, httptest.NewRequest(fiber.MethodGet, "/api/workspace", nil))
+
+	var records []map[string]any
+	for _, line := range strings.Split(strings.TrimSpace(logs.String()), "\n") {
+		var record map[string]any
+		if err := json.Unmarshal([]byte(line), &record); err != nil {
+			t.Fatalf("decode log line %q: %v", line, err)
+		}
+		records = append(records, record)
+	}
+	if len(records) != 2 {
+		t.Fatalf("expected one record per request, got %d", len(records))
+	}
+	if records[0]["path"] != "/" || records[0]["status"] != float64(200) || records[0]["user"] != "alice" {
+		t.Fatalf("unexpected record: %v", records[0])
+	}
+	if strings.Contains(logs.String(), "alice-token") {
+		t.Fatal("access log must not contain access tokens")
+	}
+	if records[1]["status"] != float64(401) {
+		t.Fatalf("expected the rejected request to be logged with 401, got %v", records[1])
+	}
+}
@@ -0,0 +1,400 @@
+package web
+
+import (
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+const (
+	oidcStateLifetime = 10 * time.Minute
+	// oidcMaxPending is the default number of sign-ins waiting for the
+	// provider; each unauthenticated browser request starts one.
+	oidcMaxPending = 1024
+	// oidcKeyRefreshInterval is the least time between two fetches of the
+	// provider's key set, so tokens with unknown key ids cannot make the
+	// server hammer the provider.
+	oidcKeyRefreshInterval = time.Minute
+)
+
+// OIDCConfig describes the OpenID Connect provider and the client registered for casemd.
+type OIDCConfig struct {
+	// Issuer is the provider URL; its /.well-known/openid-configuration is
+	// read at startup.
+	Issuer       string
+	ClientID     string
+	ClientSecret string
+	// RedirectURL is the callback registered with the provider, e.g.
+	// https://casemd.example.com/auth/callback. Its path is served by the
+	// web server.
+	RedirectURL string
+	// HTTPClient talks to the provider; http.DefaultClient when nil.
+	HTTPClient *http.Client
+	// MaxPendingSignIns caps the sign-ins waiting for the provider to
+	// redirect back; the oldest is dropped beyond it. Zero means 1024.
+	MaxPendingSignIns int
+}
+
+// OIDCAuth signs browsers in through an OpenID Connect provider with the
+// authorization code flow and accepts the provider's ID tokens as bearer
+// tokens from API clients.
+type OIDCAuth struct {
+	config   OIDCConfig
+	client   *http.Client
+	provider oidcProvider
+	callback string
+
+	mu      sync.Mutex
+	keys    map[string]*rsa.PublicKey
+	pending map[string]oidcLogin
+	// refreshed is when the key set was last fetched.
+	refreshed time.Time
+}
+
+type oidcProvider struct {
+	Issuer                string `json:"issuer"`
+	AuthorizationEndpoint string `json:"authorization_endpoint"`
+	TokenEndpoint         string `json:"token_endpoint"`
+	JWKSURI               string `json:"jwks_uri"`
+}
+
+// oidcLogin is a sign-in waiting for the provider to redirect back.
+type oidcLogin struct {
+	nonce    string
+	returnTo string
+	expires  time.Time
+}
+
+// NewOIDCAuth discovers the provider configuration of config.Issuer.
+func NewOIDCAuth(ctx context.Context, config OIDCConfig) (*OIDCAuth, error) {
+	if config.Issuer == "" || config.ClientID == "" || config.RedirectURL == "" {
+		return nil, errors.New("oidc: issuer, client id and redirect url are required")
+	}
+	redirect, err := url.Parse(config.RedirectURL)
+	if err != nil || redirect.Path == "" {
+		return nil, fmt.Errorf("oidc: invalid redirect url %q", config.RedirectURL)
+	}
+
+	client := config.HTTPClient
+	if client == nil {
+		client = http.DefaultClient
+	}
+	if config.MaxPendingSignIns <= 0 {
+		config.MaxPendingSignIns = oidcMaxPending
+	}
+	auth := &OIDCAuth{
+		config:   config,
+		client:   client,
+		callback: redirect.Path,
+		keys:     make(map[string]*rsa.PublicKey),
+		pending:  make(map[string]oidcLogin),
+	}
+
+	discovery := strings.TrimSuffix(config.Issuer, "/") + "/.well-known/openid-configuration"
+	if err := auth.getJSON(ctx, discovery, &auth.provider); err != nil {
+		return nil, fmt.Errorf("oidc: discover provider: %w", err)
+	}
+	if auth.provider.Issuer != config.Issuer {
+		return nil, fmt.Errorf("oidc: provider reports issuer %q, expected %q", auth.provider.Issuer, config.Issuer)
+	}
+	if auth.provider.AuthorizationEndpoint == "" || auth.provider.TokenEndpoint == "" || auth.provider.JWKSURI == "" {
+		return nil, errors.New("oidc: provider configuration is incomplete")
+	}
+	return auth, nil
+}
+
+func (a *OIDCAuth) getJSON(ctx context.Context, target string, value any) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, target, nil)
+	if err != nil {
+		return err
+	}
+	resp, err := a.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("GET %s: %s", target, resp.Status)
+	}
+	return json.NewDecoder(resp.Body).Decode(value)
+}
+
+// Authenticate implements Authenticator for API clients presenting an ID token.
+func (a *OIDCAuth) Authenticate(c *fiber.Ctx) (User, error) {
+	token := bearerToken(c)
+	if token == "" {
+		return User{}, errNoCredentials
+	}
+	return a.verify(c.UserContext(), token, "")
+}
+
+// Challenge implements Authenticator: browsers are sent to the provider, API
+// clients get a 401.
+func (a *OIDCAuth) Challenge(c *fiber.Ctx) error {
+	if c.Method() != fiber.MethodGet || !strings.Contains(c.Get(fiber.HeaderAccept), fiber.MIMETextHTML) {
+		c.Set(fiber.HeaderWWWAuthenticate, `Bearer realm="casemd"`)
+		return fiber.NewError(fiber.StatusUnauthorized, "sign in required")
+	}
+
+	state, nonce := randomToken(), randomToken()
+	now := time.Now()
+	a.mu.Lock()
+	for key, login := range a.pending {
+		if now.After(login.expires) {
+			delete(a.pending, key)
+		}
+	}
+	for len(a.pending) >= a.config.MaxPendingSignIns {
+		a.dropOldestLogin()
+	}
+	a.pending[state] = oidcLogin{nonce: nonce, returnTo: c.OriginalURL(), expires: now.Add(oidcStateLifetime)}
+	a.mu.Unlock()
+
+	query := url.Values{
+		"response_type": {"code"},
+		"client_id":     {a.config.ClientID},
+		"redirect_uri":  {a.config.RedirectURL},
+		"scope":         {"openid profile email"},
+		"state":         {state},
+		"nonce":         {nonce},
+	}
+	separator := "?"
+	if strings.Contains(a.provider.AuthorizationEndpoint, "?") {
+		separator = "&"
+	}
+	return c.Redirect(a.provider.AuthorizationEndpoint+separator+query.Encode(), fiber.StatusFound)
+}
+
+// dropOldestLogin forgets the sign-in that expires first. a.mu must be held.
+func (a *OIDCAuth) dropOldestLogin() {
+	var oldest string
+	var expires time.Time
+	for key, login := range a.pending {
+		if oldest == "" || login.expires.Before(expires) {
+			oldest, expires = key, login.expires
+		}
+	}
+	delete(a.pending, oldest)
+}
+
+// CallbackPath implements callbackAuthenticator.
+func (a *OIDCAuth) CallbackPath() string {
+	return a.callback
+}
+
+// Callback implements callbackAuthenticator by redeeming the authorization code.
+func (a *OIDCAuth) Callback(c *fiber.Ctx) (User, string, error) {
+	if message := c.Query("error"); message != "" {
+		return User{}, "", fmt.Errorf("provider returned %s", message)
+	}
+
+	a.mu.Lock()
+	login, ok := a.pending[c.Query("state")]
+	delete(a.pending, c.Query("state"))
+	a.mu.Unlock()
+	if !ok || time.Now().After(login.expires) {
+		return User{}, "", errors.New("unknown or expired sign-in state")
+	}
+
+	form := url.Values{
+		"grant_type":    {"authorization_code"},
+		"code":          {c.Query("code")},
+		"redirect_uri":  {a.config.RedirectURL},
+		"client_id":     {a.config.ClientID},
+		"client_secret": {a.config.ClientSecret},
+	}
+	req, err := http.NewRequestWithContext(c.UserContext(), http.MethodPost, a.provider.TokenEndpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return User{}, "", err
+	}
+	req.Header.Set(fiber.HeaderContentType, fiber.MIMEApplicationForm)
+	resp, err := a.client.Do(req)
+	if err != nil {
+		return User{}, "", fmt.Errorf("redeem code: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return User{}, "", fmt.Errorf("redeem code: %s", resp.Status)
+	}
+	var tokens struct {
+		IDToken string `json:"id_token"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&tokens); err != nil {
+		return User{}, "", fmt.Errorf("decode token response: %w", err)
+	}
+
+	user, err := a.verify(c.UserContext(), tokens.IDToken, login.nonce)
+	if err != nil {
+		return User{}, "", err
+	}
+	returnTo := login.returnTo
+	if !strings.HasPrefix(returnTo, "/") || strings.HasPrefix(returnTo, "//") {
+		returnTo = "/"
+	}
+	return user, returnTo, nil
+}
+
+type idTokenClaims struct {
+	Issuer            string          `json:"iss"`
+	Subject           string          `json:"sub"`
+	Audience          json.RawMessage `json:"aud"`
+	Expires           int64           `json:"exp"`
+	Nonce             string          `json:"nonce"`
+	PreferredUsername string          `json:"preferred_username"`
+	Name              string          `json:"name"`
+	Email             string          `json:"email"`
+}
+
+func (c idTokenClaims) hasAudience(clientID string) bool {
+	var single string
+	if json.Unmarshal(c.Audience, &single) == nil {
+		return single == clientID
+	}
+	var many []string
+	if json.Unmarshal(c.Audience, &many) == nil {
+		for _, audience := range many {
+			if audience == clientID {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// verify checks an RS256-signed ID token and returns the user it names.
+// An empty nonce skips the nonce check, as bearer tokens were issued to
+// another client session.
+func (a *OIDCAuth) verify(ctx context.Context, token, nonce string) (User, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return User{}, errors.New("malformed id token")
+	}
+
+	var header struct {
+		Algorithm string `json:"alg"`
+		KeyID     string `json:"kid"`
+	}
+	if err := decodeSegment(parts[0], &header); err != nil {
+		return User{}, fmt.Errorf("decode id token header: %w", err)
+	}
+	if header.Algorithm != "RS256" {
+		return User{}, fmt.Errorf("unsupported id token algorithm %q", header.Algorithm)
+	}
+	key, err := a.key(ctx, header.KeyID)
+	if err != nil {
+		return User{}, err
+	}
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return User{}, errors.New("malformed id token signature")
+	}
+	digest := sha256.Sum256([]byte(parts[0] + "." + parts[1]))
+	if err := rsa.VerifyPKCS1v15(key, crypto.SHA256, digest[:], signature); err != nil {
+		return User{}, errors.New("invalid id token signature")
+	}
+
+	var claims idTokenClaims
+	if err := decodeSegment(parts[1], &claims); err != nil {
+		return User{}, fmt.Errorf("decode id token claims: %w", err)
+	}
+	switch {
+	case claims.Issuer != a.provider.Issuer:
+		return User{}, errors.New("id token issued by another provider")
+	case !claims.hasAudience(a.config.ClientID):
+		return User{}, errors.New("id token issued for another client")
+	case time.Now().Unix() >= claims.Expires:
+		return User{}, errors.New("id token expired")
+	case nonce != "" && claims.Nonce != nonce:
+		return User{}, errors.New("id token nonce mismatch")
+	}
+
+	for _, name := range []string{claims.PreferredUsername, claims.Email, claims.Name, claims.Subject} {
+		if name != "" {
+			return User{Name: name}, nil
+		}
+	}
+	return User{}, errors.New("id token does not name a user")
+}
+
+// key returns the signing key with the given id, refreshing the provider's
+// key set when the id is unknown so key rotation is picked up. The key set is
+// fetched at most once per oidcKeyRefreshInterval.
+func (a *OIDCAuth) key(ctx context.Context, id string) (*rsa.PublicKey, error) {
+	a.mu.Lock()
+	key, ok := a.keys[id]
+	recent := !a.refreshed.IsZero() && time.Since(a.refreshed) < oidcKeyRefreshInterval
+	if !ok && !recent {
+		a.refreshed = time.Now()
+	}
+	a.mu.Unlock()
+	if ok {
+		return key, nil
+	}
+	if recent {
+		return nil, fmt.Errorf("unknown signing key %q", id)
+	}
+
+	var set struct {
+		Keys []struct {
+			KeyType string `json:"kty"`
+			KeyID   string `json:"kid"`
+			N       string `json:"n"`
+			E       string `json:"e"`
+		} `json:"keys"`
+	}
+	if err := a.getJSON(ctx, a.provider.JWKSURI, &set); err != nil {
+		return nil, fmt.Errorf("fetch signing keys: %w", err)
+	}
+
+	keys := make(map[string]*rsa.PublicKey, len(set.Keys))
+	for _, jwk := range set.Keys {
+		if jwk.KeyType != "RSA" {
+			continue
+		}
+		modulus, errN := base64.RawURLEncoding.DecodeString(jwk.N)
+		exponent, errE := base64.RawURLEncoding.DecodeString(jwk.E)
+		if errN != nil || errE != nil {
+			continue
+		}
+		keys[jwk.KeyID] = &rsa.PublicKey{N: new(big.Int).SetBytes(modulus), E: int(new(big.Int).SetBytes(exponent).Int64())}
+	}
+
+	a.mu.Lock()
+	a.keys = keys
+	a.mu.Unlock()
+
+	if key, ok := keys[id]; ok {
+		return key, nil
+	}
+	return nil, fmt.Errorf("unknown signing key %q", id)
+}
+
+func decodeSegment(segment string, value any) error {
+	data, err := base64.RawURLEncoding.DecodeString(segment)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, value)
+}
+
+func randomToken() string {
+	data := make([]byte, 24)
+	if _, err := rand.Read(data); err != nil {
+		panic(fmt.Sprintf("generate random token: %v", err))
+	}
+	return base64.RawURLEncoding.EncodeToString(data)
+}
@@ -0,0 +1,242 @@
+package web_test
+
+import (
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+
+	"github.com/9renpoto/casemd/internal/interfaces/web"
+)
+
+// mockIssuer is a minimal OpenID Connect provider. Authorization codes are the
+// nonce of the sign-in, so the token endpoint can echo it without state.
+type mockIssuer struct {
+	*httptest.Server
+	key *rsa.PrivateKey
+	// keyFetches counts the requests for the key set.
+	keyFetches atomic.Int32
+}
+
+func newMockIssuer(t *testing.T) *mockIssuer {
+	t.Helper()
+
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("generate key: %v", err)
+	}
+	issuer := &mockIssuer{key: key}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/openid-configuration", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]string{
+			"issuer":                 issuer.URL,
+			"authorization_endpoint": issuer.URL + "/authorize",
+			"token_endpoint":         issuer.URL + "/token",
+			"jwks_uri":               issuer.URL + "/jwks",
+		})
+	})
+	mux.HandleFunc("/jwks", func(w http.ResponseWriter, r *http.Request) {
+		issuer.keyFetches.Add(1)
+		json.NewEncoder(w).Encode(map[string]any{"keys": []map[string]string{{
+			"kty": "RSA",
+			"kid": "test",
+			"n":   base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+			"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+		}}})
+	})
+	mux.HandleFunc("/token", func(w http.ResponseWriter, r *http.Request) {
+		if r.PostFormValue("client_secret") != "secret" || r.PostFormValue("grant_type") != "authorization_code" {
+			http.Error(w, "invalid_client", http.StatusUnauthorized)
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]string{
+			"id_token": issuer.sign(t, map[string]any{"nonce": r.PostFormValue("code")}),
+		})
+	})
+	issuer.Server = httptest.NewServer(mux)
+	t.Cleanup(issuer.Close)
+	return issuer
+}
+
+// sign issues an ID token for alice; claims override the defaults.
+func (i *mockIssuer) sign(t *testing.T, claims map[string]any) string {
+	t.Helper()
+	return i.signWithKey(t, "test", claims)
+}
+
+// signWithKey is sign with the key id kid in the token header.
+func (i *mockIssuer) signWithKey(t *testing.T, kid string, claims map[string]any) string {
+	t.Helper()
+
+	payload := map[string]any{
+		"iss":                i.URL,
+		"sub":                "user-1",
+		"aud":                "casemd",
+		"exp":                time.Now().Add(time.Hour).Unix(),
+		"preferred_username": "alice",
+	}
+	for name, value := range claims {
+		payload[name] = value
+	}
+	header, _ := json.Marshal(map[string]string{"alg": "RS256", "kid": kid})
+	body, _ := json.Marshal(payload)
+	signed := base64.RawURLEncoding.EncodeToString(header) + "." + base64.RawURLEncoding.EncodeToString(body)
+	digest := sha256.Sum256([]byte(signed))
+	signature, err := rsa.SignPKCS1v15(rand.Reader, i.key, crypto.SHA256, digest[:])
+	if err != nil {
+		t.Fatalf("sign id token: %v", err)
+	}
+	return signed + "." + base64.RawURLEncoding.EncodeToString(signature)
+}
+
+func newOIDCServer(t *testing.T, issuer *mockIssuer) *web.Server {
+	t.Helper()
+	return newOIDCServerWithConfig(t, web.OIDCConfig{Issuer: issuer.URL})
+}
+
+// newOIDCServerWithConfig completes config with the client registered at
+// the mock issuer.
+func newOIDCServerWithConfig(t *testing.T, config web.OIDCConfig) *web.Server {
+	t.Helper()
+
+	config.ClientID, config.ClientSecret, config.RedirectURL = "casemd", "secret", "http://casemd.test/auth/callback"
+	auth, err := web.NewOIDCAuth(context.Background(), config)
+	if err != nil {
+		t.Fatalf("NewOIDCAuth() returned an unexpected error: %v", err)
+	}
+	server := web.NewServer(&stubConverter{}, web.Options{Auth: auth})
+	t.Cleanup(func() { server.Shutdown() })
+	return server
+}
+
+func TestOIDCSignsBrowsersInWithAuthorizationCode(t *testing.T) {
+	issuer := newMockIssuer(t)
+	server := newOIDCServer(t, issuer)
+
+	req := httptest.NewRequest(fiber.MethodGet, "/?tab=preview", nil)
+	req.Header.Set("Accept", "text/html,application/xhtml+xml")
+	resp := send(t, server, req)
+	if resp.StatusCode != fiber.StatusFound {
+		t.Fatalf("expected a redirect to the provider, got %d", resp.StatusCode)
+	}
+	authorize, err := url.Parse(resp.Header.Get("Location"))
+	if err != nil || !strings.HasPrefix(authorize.String(), issuer.URL+"/authorize?") {
+		t.Fatalf("unexpected redirect %q", resp.Header.Get("Location"))
+	}
+	query := authorize.Query()
+	if query.Get("client_id") != "casemd" || query.Get("redirect_uri") != "http://casemd.test/auth/callback" {
+		t.Fatalf("unexpected authorization request: %v", query)
+	}
+
+	callback := "/auth/callback?" + url.Values{"code": {query.Get("nonce")}, "state": {query.Get("state")}}.Encode()
+	resp = send(t, server, httptest.NewRequest(fiber.MethodGet, callback, nil))
+	session := cookie(resp, "casemd_session")
+	if resp.StatusCode != fiber.StatusFound || resp.Header.Get("Location") != "/?tab=preview" || session == nil {
+		t.Fatalf("expected a session and a redirect back, got %d %q", resp.StatusCode, resp.Header.Get("Location"))
+	}
+
+	// The state is single use.
+	if resp := send(t, server, httptest.NewRequest(fiber.MethodGet, callback, nil)); resp.StatusCode != fiber.StatusUnauthorized {
+		t.Fatalf("expected a replayed callback to be rejected, got %d", resp.StatusCode)
+	}
+
+	req = httptest.NewRequest(fiber.MethodGet, "/", nil)
+	req.AddCookie(session)
+	if resp := send(t, server, req); resp.StatusCode != fiber.StatusOK {
+		t.Fatalf("expected the session to be accepted, got %d", resp.StatusCode)
+	}
+}
+
+func TestOIDCAcceptsIDTokensFromAPIClients(t *testing.T) {
+	issuer := newMockIssuer(t)
+	server := newOIDCServer(t, issuer)
+
+	for name, tc := range map[string]struct {
+		token    string
+		expected int
+	}{
+		"valid":          {issuer.sign(t, nil), fiber.StatusOK},
+		"other audience": {issuer.sign(t, map[string]any{"aud": []string{"another-app"}}), fiber.StatusUnauthorized},
+		"expired":        {issuer.sign(t, map[string]any{"exp": time.Now().Add(-time.Minute).Unix()}), fiber.StatusUnauthorized},
+		"other issuer":   {issuer.sign(t, map[string]any{"iss": "https://evil.test"}), fiber.StatusUnauthorized},
+		"tampered":       {issuer.sign(t, nil) + "x", fiber.StatusUnauthorized},
+	} {
+		req := httptest.NewRequest(fiber.MethodGet, "/", nil)
+		req.Header.Set("Authorization", "Bearer "+tc.token)
+		if resp := send(t, server, req); resp.StatusCode != tc.expected {
+			t.Fatalf("%s: expected status %d, got %d", name, tc.expected, resp.StatusCode)
+		}
+	}
+}
+
+func TestOIDCCapsPendingSignIns(t *testing.T) {
+	issuer := newMockIssuer(t)
+	server := newOIDCServerWithConfig(t, web.OIDCConfig{Issuer: issuer.URL, MaxPendingSignIns: 2})
+
+	challenge := func() url.Values {
+		req := httptest.NewRequest(fiber.MethodGet, "/", nil)
+		req.Header.Set("Accept", "text/html")
+		resp := send(t, server, req)
+		authorize, err := url.Parse(resp.Header.Get("Location"))
+		if resp.StatusCode != fiber.StatusFound || err != nil {
+			t.Fatalf("expected a redirect to the provider, got %d", resp.StatusCode)
+		}
+		return authorize.Query()
+	}
+	callback := func(query url.Values) int {
+		target := "/auth/callback?" + url.Values{"code": {query.Get("nonce")}, "state": {query.Get("state")}}.Encode()
+		return send(t, server, httptest.NewRequest(fiber.MethodGet, target, nil)).StatusCode
+	}
+
+	first := challenge()
+	time.Sleep(time.Millisecond)
+	second, third := challenge(), challenge()
+	if status := callback(first); status != fiber.StatusUnauthorized {
+		t.Fatalf("expected the oldest sign-in to be dropped, got %d", status)
+	}
+	for _, query := range []url.Values{second, third} {
+		if status := callback(query); status != fiber.StatusFound {
+			t.Fatalf("expected the recent sign-ins to complete, got %d", status)
+		}
+	}
+}
+
+func TestOIDCLimitsKeySetRefreshes(t *testing.T) {
+	issuer := newMockIssuer(t)
+	server := newOIDCServer(t, issuer)
+
+	for i, token := range []string{
+		issuer.sign(t, nil),
+		issuer.signWithKey(t, "rotated-1", nil),
+		issuer.signWithKey(t, "rotated-2", nil),
+		issuer.signWithKey(t, "rotated-3", nil),
+		issuer.sign(t, nil),
+	} {
+		expected := fiber.StatusUnauthorized
+		if i == 0 || i == 4 {
+			expected = fiber.StatusOK
+		}
+		req := httptest.NewRequest(fiber.MethodGet, "/", nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		if resp := send(t, server, req); resp.StatusCode != expected {
+			t.Fatalf("token %d: expected status %d, got %d", i, expected, resp.StatusCode)
+		}
+	}
+	if fetches := issuer.keyFetches.Load(); fetches != 1 {
+		t.Fatalf("expected the key set to be fetched once, got %d", fetches)
+	}
+}
@@ -2,51 +2,188 @@ package web
 
 import (
 	"bytes"
+	"embed"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"html/template"
 	"io"
+	"io/fs"
+	"log/slog"
+	"net/http"
 	"os"
+	"path/filepath"
 	"strings"
+	"sync/atomic"
+	"time"
 
 	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/middleware/filesystem"
 	templatehtml "github.com/gofiber/template/html/v2"
 
 	"github.com/9renpoto/casemd/internal/app"
+	"github.com/9renpoto/casemd/internal/core/filter"
+	"github.com/9renpoto/casemd/internal/i18n"
 )
 
-// CSVConverter drives Markdown transformations for the web UI.
-type CSVConverter interface {
+//go:embed templates static
+var embeddedAssets embed.FS
+
+// Stylesheet returns the CSS shared by the web UI and the static HTML reports.
+func Stylesheet() string {
+	data, err := embeddedAssets.ReadFile("static/index.css")
+	if err != nil {
+		return ""
+	}
+	return string(data)
+}
+
+// Converter drives Markdown transformations for the web UI.
+type Converter interface {
 	Convert(sources []app.Source, output io.Writer) error
 }
 
+// CSVConverter renders the CSV used by the preview table and CSV downloads.
+type CSVConverter = Converter
+
+// Options configures optional behavior of the web server.
+type Options struct {
+	// AssetsDir serves templates and static files from disk instead of the
+	// copies embedded in the binary. The directory must contain "templates"
+	// and "static" subdirectories; templates are re-parsed on every request
+	// so edits show up without restarting.
+	AssetsDir string
+	// Previewer enables the live preview endpoints; they answer 503 when nil.
+	Previewer Previewer
+	// Parser backs POST /api/v1/parse, which answers 503 when it is nil.
+	Parser Parser
+	// Spreadsheet and JSON back the XLSX and JSON downloads of
+	// /api/convert/:format; a format whose converter is nil answers 503.
+	Spreadsheet Converter
+	JSON        Converter
+	// Executor enables execution mode in a workspace: testers record results
+	// that are saved next to each checklist.
+	Executor Executor
+	// Workspace switches the UI to browsing a directory of checklists.
+	// WatchInterval sets how often it is polled for changes (one second when
+	// zero).
+	Workspace     *Workspace
+	WatchInterval time.Duration
+	// Auth requires every request except /healthz to be signed in and turns
+	// on CSRF protection for mutating requests; the UI is open when nil.
+	// SessionKey signs the session cookie issued after sign-in; a random key
+	// is used when empty, so sessions end when the server restarts.
+	Auth       Authenticator
+	SessionKey []byte
+	// BodyLimit caps request bodies in bytes; larger requests answer 413.
+	// Fiber's 4 MiB default applies when zero.
+	BodyLimit int
+	// ConvertTimeout bounds how long a request may spend parsing and
+	// converting Markdown before it answers 503; zero means no limit.
+	ConvertTimeout time.Duration
+	// RateLimit is the number of requests a client IP may make per minute
+	// before getting 429 responses; zero disables rate limiting.
+	RateLimit int
+	// AccessLog receives one record per request when set.
+	AccessLog *slog.Logger
+	// Stats times the CSV and download conversions and is exported on
+	// /metrics next to the HTTP request metrics; pass the Stats that also
+	// wraps the parser to include parse counts.
+	Stats *app.Stats
+	// BasePath mounts every route below a prefix such as "/casemd", for
+	// reverse proxies that forward a sub-path without stripping it.
+	BasePath string
+	// Language sets the language of the UI and of the sheet headers. When
+	// empty, each browser gets the language of its Accept-Language header;
+	// visiting /?lang=ja overrides both.
+	Language i18n.Language
+}
+
 // Server exposes a Fiber application that wraps the Markdown converters for ad-hoc debugging.
 type Server struct {
-	app          *fiber.App
-	csvConverter CSVConverter
-	defaults     defaultState
+	app            *fiber.App
+	csvConverter   CSVConverter
+	previewer      Previewer
+	parser         Parser
+	executor       Executor
+	downloads      map[string]download
+	workspace      *Workspace
+	auth           Authenticator
+	sessions       sessionCodec
+	convertTimeout time.Duration
+	basePath       string
+	draining       atomic.Bool
+	metrics        *httpMetrics
+	stats          *app.Stats
+	live           *liveHub
+	stop           chan struct{}
+	defaults       map[i18n.Language]defaultState
+	// defaultLanguage is Options.Language.
+	defaultLanguage i18n.Language
 }
 
 // NewServer wires the Fiber instance with the provided converter and registers the base routes.
-func NewServer(csvConverter CSVConverter) *Server {
-	engine := templatehtml.New("./internal/interfaces/web/templates", ".html")
+func NewServer(csvConverter CSVConverter, options Options) *Server {
+	var engine *templatehtml.Engine
+	if options.AssetsDir != "" {
+		engine = templatehtml.New(filepath.Join(options.AssetsDir, "templates"), ".html")
+		engine.Reload(true)
+	} else {
+		engine = templatehtml.NewFileSystem(http.FS(mustSub(embeddedAssets, "templates")), ".html")
+	}
 	engine.AddFunc("json", toJSON)
 
+	if options.Stats != nil {
+		csvConverter = options.Stats.Converter("csv", csvConverter)
+		options.Spreadsheet = options.Stats.Converter("xlsx", options.Spreadsheet)
+		options.JSON = options.Stats.Converter("json", options.JSON)
+	}
+
 	fiberApp := fiber.New(fiber.Config{
 		DisableStartupMessage: true,
 		Views:                 engine,
+		BodyLimit:             options.BodyLimit,
 	})
 
 	server := &Server{
-		app:          fiberApp,
-		csvConverter: csvConverter,
+		app:             fiberApp,
+		csvConverter:    csvConverter,
+		previewer:       options.Previewer,
+		parser:          options.Parser,
+		executor:        options.Executor,
+		downloads:       newDownloads(csvConverter, options),
+		workspace:       options.Workspace,
+		auth:            options.Auth,
+		sessions:        newSessionCodec(options.SessionKey),
+		convertTimeout:  options.ConvertTimeout,
+		basePath:        normalizeBasePath(options.BasePath),
+		metrics:         newHTTPMetrics(),
+		stats:           options.Stats,
+		live:            newLiveHub(),
+		stop:            make(chan struct{}),
+		defaultLanguage: options.Language,
 	}
 	server.loadDefaultPreview()
-	server.registerRoutes()
+	server.registerRoutes(options)
+
+	if server.workspace != nil {
+		interval := options.WatchInterval
+		if interval <= 0 {
+			interval = defaultWatchInterval
+		}
+		go server.watchWorkspace(interval)
+	}
 	return server
 }
 
+func mustSub(fsys fs.FS, dir string) fs.FS {
+	sub, err := fs.Sub(fsys, dir)
+	if err != nil {
+		panic(fmt.Sprintf("embedded assets are missing %s: %v", dir, err))
+	}
+	return sub
+}
+
 // Listen starts serving the Fiber application on the provided address.
 func (s *Server) Listen(addr string) error {
 	if s.app == nil {
@@ -55,25 +192,89 @@ func (s *Server) Listen(addr string) error {
 	return s.app.Listen(addr)
 }
 
+// Shutdown stops background workers and the Fiber application.
+func (s *Server) Shutdown() error {
+	return s.shutdown(func() error { return s.app.Shutdown() })
+}
+
+// shutdown marks the server as not ready, stops background workers and then
+// stops the Fiber application with stopApp.
+func (s *Server) shutdown(stopApp func() error) error {
+	s.draining.Store(true)
+	select {
+	case <-s.stop:
+	default:
+		close(s.stop)
+	}
+	if s.app == nil {
+		return nil
+	}
+	return stopApp()
+}
+
 // App returns the underlying Fiber application. Useful for tests.
 func (s *Server) App() *fiber.App {
 	return s.app
 }
 
-func (s *Server) registerRoutes() {
-	s.app.Static("/static", "./internal/interfaces/web/static")
+func (s *Server) registerRoutes(options Options) {
+	s.app.Use(s.metrics.middleware)
+	if options.AccessLog != nil {
+		s.app.Use(accessLog(options.AccessLog))
+	}
+	s.app.Use(securityHeaders())
+	// Limit before authenticating so password guesses are throttled too.
+	if options.RateLimit > 0 {
+		s.app.Use(s.rateLimit(options.RateLimit))
+	}
+	if s.auth != nil {
+		s.registerAuthRoutes(s.app)
+	}
+
+	var router fiber.Router = s.app
+	if s.basePath != "" {
+		router = s.app.Group(s.basePath)
+	}
+
+	if options.AssetsDir != "" {
+		router.Static("/static", filepath.Join(options.AssetsDir, "static"))
+	} else {
+		router.Use("/static", filesystem.New(filesystem.Config{
+			Root: http.FS(mustSub(embeddedAssets, "static")),
+		}))
+	}
 
-	s.app.Get("/healthz", func(c *fiber.Ctx) error {
+	// /healthz reports that the process is up; /readyz that it can serve
+	// requests, so load balancers stop routing to it while it drains.
+	router.Get("/healthz", func(c *fiber.Ctx) error {
 		return c.JSON(fiber.Map{"status": "ok"})
 	})
+	router.Get("/readyz", func(c *fiber.Ctx) error {
+		if s.draining.Load() {
+			return fiber.NewError(fiber.StatusServiceUnavailable, "shutting down")
+		}
+		if s.workspace != nil {
+			if _, err := s.workspace.files(); err != nil {
+				return fiber.NewError(fiber.StatusServiceUnavailable, fmt.Sprintf("workspace unavailable: %v", err))
+			}
+		}
+		return c.JSON(fiber.Map{"status": "ready"})
+	})
 
-	s.app.Get("/", func(c *fiber.Ctx) error {
-		return c.Render("index", indexViewModel{
-			DefaultState: s.defaults,
-		})
+	router.Get("/", func(c *fiber.Ctx) error {
+		language := s.language(c)
+		s.rememberLanguage(c, language)
+		model := indexViewModel{DefaultState: s.defaults[language], CSRFToken: csrfToken(c), BasePath: s.basePath, Language: language}
+		if user, ok := currentUser(c); ok {
+			model.User = user.Name
+		}
+		if s.workspace != nil {
+			model.Workspace = s.workspace.name
+		}
+		return c.Render("index", model)
 	})
 
-	s.app.Post("/api/preview", func(c *fiber.Ctx) error {
+	router.Post("/api/preview", func(c *fiber.Ctx) error {
 		var payload previewRequest
 		if err := c.BodyParser(&payload); err != nil {
 			return fiber.NewError(fiber.StatusBadRequest, fmt.Sprintf("parse request body: %v", err))
@@ -86,47 +287,109 @@ func (s *Server) registerRoutes() {
 		if s.csvConverter == nil {
 			return fiber.NewError(fiber.StatusServiceUnavailable, "csv converter is not configured")
 		}
+		selected, err := queryFilter(c)
+		if err != nil {
+			return err
+		}
+
+		ctx, cancel := s.conversionContext(c)
+		defer cancel()
 
 		var buffer bytes.Buffer
-		err := s.csvConverter.Convert([]app.Source{
-			{Name: payload.Name, Reader: strings.NewReader(payload.Markdown)},
+		err = s.csvConverter.Convert([]app.Source{
+			{Name: payload.Name, Reader: strings.NewReader(payload.Markdown), Context: ctx, Language: s.language(c), Filter: selected},
 		}, &buffer)
 		if err != nil {
-			return fiber.NewError(fiber.StatusInternalServerError, fmt.Sprintf("convert markdown: %v", err))
+			return s.conversionError(err, fiber.StatusInternalServerError, "convert markdown")
 		}
 
 		return c.JSON(fiber.Map{"csv": buffer.String()})
 	})
+
+	s.registerDownloadRoutes(router)
+	s.registerAPIRoutes(router)
+	s.registerWorkspaceRoutes(router)
+	s.registerLiveRoutes(router)
+	s.registerMetricsRoutes(router)
 }
 
-type previewRequest struct {
-	Name     string `json:"name"`
-	Markdown string `json:"markdown"`
+// normalizeBasePath turns "casemd/" into "/casemd"; the root path becomes "".
+func normalizeBasePath(basePath string) string {
+	basePath = strings.Trim(basePath, "/")
+	if basePath == "" {
+		return ""
+	}
+	return "/" + basePath
 }
 
-func (s *Server) loadDefaultPreview() {
-	const defaultMarkdownPath = "notes.md"
+// cookiePath scopes the server's cookies to the base path, so they are not
+// sent to other applications on the same host.
+func (s *Server) cookiePath() string {
+	if s.basePath == "" {
+		return "/"
+	}
+	return s.basePath
+}
+
+// isProbe reports whether path is a health or readiness check, which stay
+// open to monitoring without credentials or rate limits.
+func (s *Server) isProbe(path string) bool {
+	return path == s.basePath+"/healthz" || path == s.basePath+"/readyz"
+}
 
-	data, err := os.ReadFile(defaultMarkdownPath)
+// queryFilter reads the cases selected by the "filter" expression and the
+// "select" path query parameters; without them every case is kept.
+func queryFilter(c *fiber.Ctx) (filter.Filter, error) {
+	byExpression, err := filter.Parse(c.Query("filter"))
 	if err != nil {
-		return
+		return filter.Filter{}, fiber.NewError(fiber.StatusBadRequest, fmt.Sprintf("parse filter: %v", err))
+	}
+	byPath, err := filter.Select(c.Query("select"))
+	if err != nil {
+		return filter.Filter{}, fiber.NewError(fiber.StatusBadRequest, fmt.Sprintf("parse select: %v", err))
 	}
+	return byExpression.And(byPath), nil
+}
 
-	s.defaults.Name = defaultMarkdownPath
-	s.defaults.Markdown = string(data)
+type previewRequest struct {
+	Name     string `json:"name"`
+	Markdown string `json:"markdown"`
+}
 
-	if s.csvConverter == nil {
-		return
+// loadDefaultPreview fills the editor with the first workspace file, or with
+// notes.md from the working directory outside workspace mode. The CSV is
+// rendered once per language.
+func (s *Server) loadDefaultPreview() {
+	s.defaults = make(map[i18n.Language]defaultState)
+	defaultMarkdownPath := "notes.md"
+	readFile := os.ReadFile
+	if s.workspace != nil {
+		files, err := s.workspace.files()
+		if err != nil || len(files) == 0 {
+			return
+		}
+		defaultMarkdownPath = files[0].Path
+		readFile = s.workspace.read
 	}
 
-	var buffer bytes.Buffer
-	err = s.csvConverter.Convert([]app.Source{
-		{Name: defaultMarkdownPath, Reader: bytes.NewReader(data)},
-	}, &buffer)
+	data, err := readFile(defaultMarkdownPath)
 	if err != nil {
 		return
 	}
-	s.defaults.CSV = buffer.String()
+
+	for _, language := range i18n.Languages() {
+		state := defaultState{Name: defaultMarkdownPath, Markdown: string(data)}
+		if s.csvConverter != nil {
+			var buffer bytes.Buffer
+			err = s.csvConverter.Convert([]app.Source{
+				{Name: defaultMarkdownPath, Reader: bytes.NewReader(data), Language: language},
+			}, &buffer)
+			if err == nil {
+				state.CSV = buffer.String()
+			}
+		}
+		s.defaults[language] = state
+	}
 }
 
 type defaultState struct {
@@ -137,6 +400,35 @@ type defaultState struct {
 
 type indexViewModel struct {
 	DefaultState defaultState
+	// Workspace names the browsed directory; empty outside workspace mode.
+	Workspace string
+	// User and CSRFToken are set when authentication is enabled.
+	User      string
+	CSRFToken string
+	// BasePath prefixes the asset and API URLs used by the page.
+	BasePath string
+	// Language selects the messages of the page and of its script.
+	Language i18n.Language
+}
+
+// T returns the UI message with key, formatted with args when given.
+func (m indexViewModel) T(key string, args ...any) string {
+	if len(args) == 0 {
+		return m.Language.Text(key)
+	}
+	return m.Language.Format(key, args...)
+}
+
+// ExecutionNotice returns the execution help text with the flag it mentions
+// set in code.
+func (m indexViewModel) ExecutionNotice() template.HTML {
+	notice := template.HTMLEscapeString(m.Language.Text("ui.executionNotice"))
+	return template.HTML(fmt.Sprintf(notice, "<code>--with-results</code>"))
+}
+
+// Messages returns the UI messages handed to the page's script.
+func (m indexViewModel) Messages() map[string]string {
+	return m.Language.Messages("ui.")
 }
 
 func toJSON(value any) template.JS {
@@ -1,19 +1,33 @@
 package web_test
 
 import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
 	"io"
+	"mime/multipart"
+	"net"
+	"net/http"
 	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"reflect"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/gofiber/fiber/v2"
 
 	"github.com/9renpoto/casemd/internal/app"
+	"github.com/9renpoto/casemd/internal/core/domain"
+	"github.com/9renpoto/casemd/internal/i18n"
 	"github.com/9renpoto/casemd/internal/interfaces/web"
 )
 
 func TestHealthEndpoint(t *testing.T) {
-	server := web.NewServer(&stubConverter{})
+	server := web.NewServer(&stubConverter{}, web.Options{})
 
 	req := httptest.NewRequest(fiber.MethodGet, "/healthz", nil)
 	resp, err := server.App().Test(req, -1)
@@ -26,9 +40,71 @@ func TestHealthEndpoint(t *testing.T) {
 	}
 }
 
+func TestIndexAndStaticAssetsAreEmbedded(t *testing.T) {
+	server := web.NewServer(&stubConverter{}, web.Options{})
+
+	for path, expected := range map[string]string{
+		"/":                 "casemd Markdown Preview",
+		"/static/index.css": ".table-wrapper",
+		"/static/index.js":  "function renderTable",
+	} {
+		req := httptest.NewRequest(fiber.MethodGet, path, nil)
+		resp, err := server.App().Test(req, -1)
+		if err != nil {
+			t.Fatalf("GET %s: unexpected error: %v", path, err)
+		}
+		if resp.StatusCode != fiber.StatusOK {
+			t.Fatalf("GET %s: expected status 200, got %d", path, resp.StatusCode)
+		}
+		body, err := io.ReadAll(resp.Body)
+		if err != nil {
+			t.Fatalf("GET %s: read body: %v", path, err)
+		}
+		if !strings.Contains(string(body), expected) {
+			t.Fatalf("GET %s: body missing %q", path, expected)
+		}
+	}
+}
+
+func TestAssetsDirOverridesEmbeddedAssets(t *testing.T) {
+	dir := t.TempDir()
+	for path, content := range map[string]string{
+		"templates/index.html": `{{ define "index" }}<h1>hot reloaded</h1>{{ end }}`,
+		"static/index.css":     "body { color: hotpink; }",
+	} {
+		full := filepath.Join(dir, path)
+		if err := os.MkdirAll(filepath.Dir(full), 0o755); err != nil {
+			t.Fatalf("create %s: %v", filepath.Dir(full), err)
+		}
+		if err := os.WriteFile(full, []byte(content), 0o644); err != nil {
+			t.Fatalf("write %s: %v", full, err)
+		}
+	}
+
+	server := web.NewServer(&stubConverter{}, web.Options{AssetsDir: dir})
+
+	for path, expected := range map[string]string{
+		"/":                 "hot reloaded",
+		"/static/index.css": "hotpink",
+	} {
+		req := httptest.NewRequest(fiber.MethodGet, path, nil)
+		resp, err := server.App().Test(req, -1)
+		if err != nil {
+			t.Fatalf("GET %s: unexpected error: %v", path, err)
+		}
+		body, err := io.ReadAll(resp.Body)
+		if err != nil {
+			t.Fatalf("GET %s: read body: %v", path, err)
+		}
+		if !strings.Contains(string(body), expected) {
+			t.Fatalf("GET %s: body missing %q: %s", path, expected, body)
+		}
+	}
+}
+
 func TestPreviewEndpoint(t *testing.T) {
 	converter := &stubConverter{output: "header1,header2\nvalue1,value2\n"}
-	server := web.NewServer(converter)
+	server := web.NewServer(converter, web.Options{})
 
 	body := strings.NewReader(`{"name":"sample.md","markdown":"# heading"}`)
 	req := httptest.NewRequest(fiber.MethodPost, "/api/preview", body)
@@ -51,8 +127,68 @@ func TestPreviewEndpoint(t *testing.T) {
 	}
 }
 
+func TestIndexAndPreviewFollowTheLanguage(t *testing.T) {
+	converter := &stubConverter{output: "header1\nvalue1\n"}
+	server := web.NewServer(converter, web.Options{})
+
+	req := httptest.NewRequest(fiber.MethodGet, "/", nil)
+	req.Header.Set("Accept-Language", "ja-JP,ja;q=0.9,en;q=0.8")
+	resp, err := server.App().Test(req, -1)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	body, _ := io.ReadAll(resp.Body)
+	for _, expected := range []string{`<html lang="ja">`, "casemd Markdown プレビュー", `"ui.converting":"変換しています..."`, `"ui.noData":"データがありません。"`} {
+		if !strings.Contains(string(body), expected) {
+			t.Fatalf("page missing %q:\n%s", expected, body)
+		}
+	}
+
+	req = httptest.NewRequest(fiber.MethodGet, "/?lang=en", nil)
+	req.Header.Set("Accept-Language", "ja")
+	resp, err = server.App().Test(req, -1)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	body, _ = io.ReadAll(resp.Body)
+	if !strings.Contains(string(body), "casemd Markdown Preview") {
+		t.Fatalf("expected ?lang=en to override Accept-Language:\n%s", body)
+	}
+	var cookie *http.Cookie
+	for _, c := range resp.Cookies() {
+		if c.Name == "casemd_lang" {
+			cookie = c
+		}
+	}
+	if cookie == nil || cookie.Value != "en" {
+		t.Fatalf("expected a language cookie, got %v", resp.Cookies())
+	}
+
+	req = httptest.NewRequest(fiber.MethodPost, "/api/preview", strings.NewReader(`{"name":"sample.md","markdown":"# heading"}`))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept-Language", "ja")
+	req.AddCookie(cookie)
+	if _, err := server.App().Test(req, -1); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if converter.sources[0].Language != i18n.English {
+		t.Fatalf("expected the cookie to pick English, got %q", converter.sources[0].Language)
+	}
+
+	server = web.NewServer(converter, web.Options{Language: i18n.Japanese})
+	req = httptest.NewRequest(fiber.MethodPost, "/api/preview", strings.NewReader(`{"name":"sample.md","markdown":"# heading"}`))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept-Language", "en")
+	if _, err := server.App().Test(req, -1); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if converter.sources[0].Language != i18n.Japanese {
+		t.Fatalf("expected Options.Language to win over Accept-Language, got %q", converter.sources[0].Language)
+	}
+}
+
 func TestPreviewEndpointMissingMarkdown(t *testing.T) {
-	server := web.NewServer(&stubConverter{})
+	server := web.NewServer(&stubConverter{}, web.Options{})
 
 	body := strings.NewReader(`{"name":"sample.md","markdown":"   "}`)
 	req := httptest.NewRequest(fiber.MethodPost, "/api/preview", body)
@@ -68,6 +204,355 @@ func TestPreviewEndpointMissingMarkdown(t *testing.T) {
 	}
 }
 
+func TestConvertEndpointDownloadsEachFormat(t *testing.T) {
+	csvConverter := &stubConverter{output: "Major Item\nSetup\n"}
+	spreadsheetConverter := &stubConverter{output: "PK-xlsx"}
+	jsonConverter := &stubConverter{output: `{"sources":[]}`}
+	server := web.NewServer(csvConverter, web.Options{Spreadsheet: spreadsheetConverter, JSON: jsonConverter})
+
+	for _, tc := range []struct {
+		format      string
+		converter   *stubConverter
+		contentType string
+		filename    string
+	}{
+		{"csv", csvConverter, "text/csv; charset=utf-8", "notes.csv"},
+		{"xlsx", spreadsheetConverter, "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet", "notes.xlsx"},
+		{"json", jsonConverter, "application/json; charset=utf-8", "notes.json"},
+	} {
+		body := strings.NewReader(`{"name":"docs/notes.md","markdown":"# heading"}`)
+		req := httptest.NewRequest(fiber.MethodPost, "/api/convert/"+tc.format, body)
+		req.Header.Set("Content-Type", "application/json")
+
+		resp, err := server.App().Test(req, -1)
+		if err != nil {
+			t.Fatalf("%s: unexpected error: %v", tc.format, err)
+		}
+		if resp.StatusCode != fiber.StatusOK {
+			t.Fatalf("%s: expected status 200, got %d", tc.format, resp.StatusCode)
+		}
+		if got := resp.Header.Get("Content-Type"); got != tc.contentType {
+			t.Fatalf("%s: expected content type %q, got %q", tc.format, tc.contentType, got)
+		}
+		if got := resp.Header.Get("Content-Disposition"); got != `attachment; filename="`+tc.filename+`"` {
+			t.Fatalf("%s: unexpected content disposition %q", tc.format, got)
+		}
+		data, err := io.ReadAll(resp.Body)
+		if err != nil {
+			t.Fatalf("%s: read body: %v", tc.format, err)
+		}
+		if string(data) != tc.converter.output {
+			t.Fatalf("%s: expected body %q, got %q", tc.format, tc.converter.output, data)
+		}
+		if len(tc.converter.sources) != 1 || tc.converter.sources[0].Name != "docs/notes.md" {
+			t.Fatalf("%s: unexpected sources %+v", tc.format, tc.converter.sources)
+		}
+	}
+}
+
+func TestConvertEndpointAcceptsMultipartUploads(t *testing.T) {
+	converter := &stubConverter{output: "PK"}
+	server := web.NewServer(&stubConverter{}, web.Options{Spreadsheet: converter})
+
+	var body bytes.Buffer
+	writer := multipart.NewWriter(&body)
+	for name, content := range map[string]string{"a.md": "## A", "b.md": "## B"} {
+		part, err := writer.CreateFormFile("files", name)
+		if err != nil {
+			t.Fatalf("create form file: %v", err)
+		}
+		io.WriteString(part, content)
+	}
+	writer.Close()
+
+	req := httptest.NewRequest(fiber.MethodPost, "/api/convert/xlsx", &body)
+	req.Header.Set("Content-Type", writer.FormDataContentType())
+
+	resp, err := server.App().Test(req, -1)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if resp.StatusCode != fiber.StatusOK {
+		t.Fatalf("expected status 200, got %d", resp.StatusCode)
+	}
+	if got := resp.Header.Get("Content-Disposition"); got != `attachment; filename="casemd.xlsx"` {
+		t.Fatalf("unexpected content disposition %q", got)
+	}
+
+	contents := map[string]string{}
+	for _, source := range converter.sources {
+		data, err := io.ReadAll(source.Reader)
+		if err != nil {
+			t.Fatalf("read %s: %v", source.Name, err)
+		}
+		contents[source.Name] = string(data)
+	}
+	if !reflect.DeepEqual(contents, map[string]string{"a.md": "## A", "b.md": "## B"}) {
+		t.Fatalf("unexpected uploaded sources: %v", contents)
+	}
+}
+
+func TestConvertEndpointSelectsColumns(t *testing.T) {
+	converter := &stubConverter{output: "Minor Item,Priority\n"}
+	server := web.NewServer(converter, web.Options{})
+
+	req := httptest.NewRequest(fiber.MethodPost, "/api/convert/csv?columns=minor,priority&layout=step", strings.NewReader(`{"markdown":"# a"}`))
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := server.App().Test(req, -1)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if resp.StatusCode != fiber.StatusOK {
+		t.Fatalf("expected status 200, got %d", resp.StatusCode)
+	}
+	columns := converter.sources[0].Columns
+	if len(columns) != 2 || columns[0].Key != "minor" || columns[1].Header != "Priority" {
+		t.Fatalf("unexpected columns: %+v", columns)
+	}
+	if layout := converter.sources[0].Layout; layout != app.StepLayout {
+		t.Fatalf("unexpected layout: %q", layout)
+	}
+}
+
+func TestConvertEndpointFiltersCases(t *testing.T) {
+	converter := &stubConverter{}
+	server := web.NewServer(converter, web.Options{})
+
+	query := url.Values{"filter": {"tag:smoke && priority<=P2"}, "select": {"Setup/*"}}
+	req := httptest.NewRequest(fiber.MethodPost, "/api/convert/csv?"+query.Encode(), strings.NewReader(`{"markdown":"# a"}`))
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := server.App().Test(req, -1)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if resp.StatusCode != fiber.StatusOK {
+		t.Fatalf("expected status 200, got %d", resp.StatusCode)
+	}
+
+	selected := converter.sources[0].Filter
+	for aCase, expected := range map[*domain.Case]bool{
+		{MajorItem: "Setup", MinorItem: "Install", Priority: "P1", Tags: []string{"smoke"}}:   true,
+		{MajorItem: "Setup", MinorItem: "Configure", Priority: "P3", Tags: []string{"smoke"}}: false,
+		{MajorItem: "Execution", MinorItem: "Run", Priority: "P1", Tags: []string{"smoke"}}:   false,
+	} {
+		if selected.Match(*aCase) != expected {
+			t.Fatalf("Match(%s) = %v, want %v", aCase.MinorItem, !expected, expected)
+		}
+	}
+}
+
+func TestConvertEndpointRejectsInvalidRequests(t *testing.T) {
+	server := web.NewServer(&stubConverter{err: errors.New("boom")}, web.Options{})
+
+	for name, tc := range map[string]struct {
+		path   string
+		body   string
+		status int
+	}{
+		"unknown format":     {"/api/convert/pdf", `{"markdown":"# a"}`, fiber.StatusNotFound},
+		"missing converter":  {"/api/convert/xlsx", `{"markdown":"# a"}`, fiber.StatusServiceUnavailable},
+		"empty markdown":     {"/api/convert/csv", `{"markdown":"  "}`, fiber.StatusBadRequest},
+		"unknown column":     {"/api/convert/csv?columns=minor,owner", `{"markdown":"# a"}`, fiber.StatusBadRequest},
+		"unknown layout":     {"/api/convert/csv?layout=sheet", `{"markdown":"# a"}`, fiber.StatusBadRequest},
+		"invalid filter":     {"/api/convert/csv?filter=owner:me", `{"markdown":"# a"}`, fiber.StatusBadRequest},
+		"invalid select":     {"/api/convert/csv?select=a/b/c/d", `{"markdown":"# a"}`, fiber.StatusBadRequest},
+		"conversion failure": {"/api/convert/csv", `{"markdown":"# a"}`, fiber.StatusUnprocessableEntity},
+	} {
+		req := httptest.NewRequest(fiber.MethodPost, tc.path, strings.NewReader(tc.body))
+		req.Header.Set("Content-Type", "application/json")
+
+		resp, err := server.App().Test(req, -1)
+		if err != nil {
+			t.Fatalf("%s: unexpected error: %v", name, err)
+		}
+		if resp.StatusCode != tc.status {
+			t.Fatalf("%s: expected status %d, got %d", name, tc.status, resp.StatusCode)
+		}
+	}
+}
+
+func TestLivePreviewStreamsEvents(t *testing.T) {
+	previewer := &stubPreviewer{preview: app.Preview{
+		CSV:         "Major Item\nSetup\n",
+		Rows:        []app.PreviewRow{{Line: 3, EndLine: 6}},
+		Diagnostics: []domain.Diagnostic{{Line: 1, Severity: domain.SeverityWarning, Message: "ignored"}},
+	}}
+	server := web.NewServer(&stubConverter{}, web.Options{Previewer: previewer})
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	go func() { _ = server.App().Listener(listener) }()
+	defer func() { _ = server.App().Shutdown() }()
+
+	session := createLiveSession(t, server, "")
+	client := &http.Client{Timeout: 5 * time.Second}
+	resp, err := client.Get("http://" + listener.Addr().String() + "/api/live/" + session.ID + "/events")
+	if err != nil {
+		t.Fatalf("open event stream: %v", err)
+	}
+	defer resp.Body.Close()
+	if got := resp.Header.Get("Content-Type"); got != "text/event-stream" {
+		t.Fatalf("unexpected content type: %s", got)
+	}
+
+	stream := bufio.NewReader(resp.Body)
+	if line, err := stream.ReadString('\n'); err != nil || line != ": connected\n" {
+		t.Fatalf("unexpected first line %q (err %v)", line, err)
+	}
+
+	body := strings.NewReader(`{"name":"sample.md","markdown":"## Setup"}`)
+	req := httptest.NewRequest(fiber.MethodPost, "/api/live/"+session.ID, body)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Live-Key", session.Key)
+	update, err := server.App().Test(req, -1)
+	if err != nil {
+		t.Fatalf("post update: %v", err)
+	}
+	if update.StatusCode != fiber.StatusAccepted {
+		t.Fatalf("expected status %d, got %d", fiber.StatusAccepted, update.StatusCode)
+	}
+	if previewer.source.Name != "sample.md" {
+		t.Fatalf("unexpected source name: %q", previewer.source.Name)
+	}
+
+	var data string
+	for data == "" {
+		line, err := stream.ReadString('\n')
+		if err != nil {
+			t.Fatalf("read event: %v", err)
+		}
+		if strings.HasPrefix(line, "data: ") {
+			data = strings.TrimPrefix(strings.TrimSpace(line), "data: ")
+		}
+	}
+
+	var event app.Preview
+	if err := json.Unmarshal([]byte(data), &event); err != nil {
+		t.Fatalf("decode event: %v", err)
+	}
+	if !reflect.DeepEqual(event, previewer.preview) {
+		t.Fatalf("unexpected event: %#v", event)
+	}
+}
+
+func TestLivePreviewRejectsInvalidRequests(t *testing.T) {
+	tests := []struct {
+		name      string
+		previewer web.Previewer
+		path      string
+		status    int
+	}{
+		{name: "invalid session", previewer: &stubPreviewer{}, path: "/api/live/bad%20id", status: fiber.StatusBadRequest},
+		{name: "previewer missing", path: "/api/live/session-1", status: fiber.StatusServiceUnavailable},
+		{name: "session not issued", previewer: &stubPreviewer{}, path: "/api/live/session-1", status: fiber.StatusNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := web.NewServer(&stubConverter{}, web.Options{Previewer: tt.previewer})
+
+			body := strings.NewReader(`{"name":"sample.md","markdown":"## Setup"}`)
+			req := httptest.NewRequest(fiber.MethodPost, tt.path, body)
+			req.Header.Set("Content-Type", "application/json")
+			resp, err := server.App().Test(req, -1)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if resp.StatusCode != tt.status {
+				t.Fatalf("expected status %d, got %d", tt.status, resp.StatusCode)
+			}
+		})
+	}
+}
+
+type liveSession struct {
+	ID  string `json:"session"`
+	Key string `json:"key"`
+}
+
+// createLiveSession creates a live session, signed in with token when it is
+// not empty.
+func createLiveSession(t *testing.T, server *web.Server, token string) liveSession {
+	t.Helper()
+
+	req := httptest.NewRequest(fiber.MethodPost, "/api/live", nil)
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	resp := send(t, server, req)
+	if resp.StatusCode != fiber.StatusCreated {
+		t.Fatalf("POST /api/live: expected status %d, got %d", fiber.StatusCreated, resp.StatusCode)
+	}
+	var session liveSession
+	if err := json.NewDecoder(resp.Body).Decode(&session); err != nil {
+		t.Fatalf("decode live session: %v", err)
+	}
+	if session.ID == "" || session.Key == "" {
+		t.Fatalf("expected a session ID and key, got %+v", session)
+	}
+	return session
+}
+
+func TestLivePreviewOnlyAcceptsTheCreator(t *testing.T) {
+	server := newTokenServer(t, web.Options{Previewer: &stubPreviewer{}})
+	session := createLiveSession(t, server, "alice-token")
+	if other := createLiveSession(t, server, "alice-token"); other.ID == session.ID || other.Key == session.Key {
+		t.Fatalf("expected every session to get its own ID and key, got %+v twice", session)
+	}
+
+	for name, tc := range map[string]struct {
+		token, key string
+		status     int
+	}{
+		"creator":      {token: "alice-token", key: session.Key, status: fiber.StatusAccepted},
+		"missing key":  {token: "alice-token", status: fiber.StatusForbidden},
+		"wrong key":    {token: "alice-token", key: "guess", status: fiber.StatusForbidden},
+		"another user": {token: "bob-token", key: session.Key, status: fiber.StatusForbidden},
+	} {
+		req := httptest.NewRequest(fiber.MethodPost, "/api/live/"+session.ID, strings.NewReader(`{"name":"sample.md","markdown":"## Setup"}`))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Authorization", "Bearer "+tc.token)
+		if tc.key != "" {
+			req.Header.Set("X-Live-Key", tc.key)
+		}
+		if resp := send(t, server, req); resp.StatusCode != tc.status {
+			t.Fatalf("%s: expected status %d, got %d", name, tc.status, resp.StatusCode)
+		}
+	}
+
+	req := httptest.NewRequest(fiber.MethodGet, "/api/live/"+session.ID+"/events", nil)
+	req.Header.Set("Authorization", "Bearer bob-token")
+	if resp := send(t, server, req); resp.StatusCode != fiber.StatusForbidden {
+		t.Fatalf("expected events of another user's session to answer 403, got %d", resp.StatusCode)
+	}
+
+	req = httptest.NewRequest(fiber.MethodGet, "/api/live/unknown/events", nil)
+	req.Header.Set("Authorization", "Bearer alice-token")
+	if resp := send(t, server, req); resp.StatusCode != fiber.StatusNotFound {
+		t.Fatalf("expected events of an unknown session to answer 404, got %d", resp.StatusCode)
+	}
+}
+
+type stubPreviewer struct {
+	preview app.Preview
+	sheets  []app.SheetPreview
+	err     error
+	source  app.Source
+	sources []app.Source
+}
+
+func (s *stubPreviewer) Preview(source app.Source) (app.Preview, error) {
+	s.source = source
+	return s.preview, s.err
+}
+
+func (s *stubPreviewer) Workbook(sources []app.Source) ([]app.SheetPreview, error) {
+	s.sources = sources
+	return s.sheets, s.err
+}
+
 type stubConverter struct {
 	output  string
 	err     error
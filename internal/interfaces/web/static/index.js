@@ -10,6 +10,68 @@
  * @property {string} [csv]
  */
 
+/**
+ * @typedef {Object} PreviewRow
+ * @property {number} line
+ * @property {number} endLine
+ */
+
+/**
+ * @typedef {Object} Diagnostic
+ * @property {number} line
+ * @property {string} severity
+ * @property {string} message
+ */
+
+/**
+ * @typedef {Object} LivePreviewEvent
+ * @property {string} csv
+ * @property {PreviewRow[]} rows
+ * @property {Diagnostic[]} diagnostics
+ */
+
+/**
+ * @typedef {Object} WorkspaceFile
+ * @property {string} path
+ */
+
+/**
+ * @typedef {Object} SheetPreview
+ * @property {string} name
+ * @property {string} source
+ * @property {string} csv
+ */
+
+/**
+ * @typedef {Object} WorkspaceElements
+ * @property {HTMLElement} tree
+ * @property {HTMLElement} tabs
+ * @property {HTMLInputElement} nameField
+ * @property {HTMLTextAreaElement} markdownField
+ * @property {HTMLElement} statusElement
+ * @property {HTMLElement} errorElement
+ * @property {HTMLElement} diagnosticsElement
+ * @property {HTMLTableSectionElement} headElement
+ * @property {HTMLTableSectionElement} bodyElement
+ */
+
+/**
+ * @typedef {Object} ExecutionCase
+ * @property {string} majorItem
+ * @property {string} mediumItem
+ * @property {string} minorItem
+ * @property {string[]} validationSteps
+ * @property {string[]} checkpoints
+ * @property {string} result
+ * @property {string} testDate
+ * @property {string} tester
+ * @property {string} notes
+ */
+
+const LIVE_PREVIEW_DELAY_MS = 300;
+const CHECKPOINT_PATTERN = /^\*\s+\[([ xX])\]\s+(.*)$/;
+const RESULT_OPTIONS = ["", "pass", "fail", "skip"];
+
 /**
  * Extracts the default state from the DOM.
  *
@@ -36,6 +98,58 @@ function loadDefaultState() {
   }
 }
 
+/** @type {Record<string, string> | undefined} */
+let messages;
+
+/**
+ * Returns the UI message with key in the page's language, replacing each %s
+ * with the next argument. The server embeds the messages in the page.
+ *
+ * @param {string} key
+ * @param {...(string | number)} args
+ * @returns {string}
+ */
+function t(key, ...args) {
+  if (!messages) {
+    const script = document.getElementById("messages");
+    try {
+      messages = JSON.parse(script?.textContent ?? "{}");
+    } catch (error) {
+      console.warn("Failed to parse messages", error);
+      messages = {};
+    }
+  }
+  let index = 0;
+  return (messages?.[`ui.${key}`] ?? key).replace(/%s/g, () => String(args[index++] ?? ""));
+}
+
+/**
+ * Prefixes an absolute path with the base path the server is mounted under,
+ * e.g. behind a reverse proxy.
+ *
+ * @param {string} path
+ * @returns {string}
+ */
+function appURL(path) {
+  const meta = document.querySelector('meta[name="base-path"]');
+  return (meta instanceof HTMLMetaElement ? meta.content : "") + path;
+}
+
+/**
+ * Adds the CSRF token the server issued with the page to request headers.
+ * The page carries no token when authentication is disabled.
+ *
+ * @param {Record<string, string>} [headers]
+ * @returns {Record<string, string>}
+ */
+function csrfHeaders(headers = {}) {
+  const meta = document.querySelector('meta[name="csrf-token"]');
+  if (meta instanceof HTMLMetaElement && meta.content) {
+    return { ...headers, "X-Csrf-Token": meta.content };
+  }
+  return headers;
+}
+
 /**
  * Registers event handlers for the preview form.
  *
@@ -68,13 +182,13 @@ function initializePreview(
     const markdown = markdownField instanceof HTMLTextAreaElement ? markdownField.value : "";
 
     submitButton.disabled = true;
-    statusElement.textContent = "Converting...";
+    statusElement.textContent = t("converting");
     errorElement.textContent = "";
 
     try {
-      const response = await fetch("/api/preview", {
+      const response = await fetch(appURL("/api/preview"), {
         method: "POST",
-        headers: { "Content-Type": "application/json" },
+        headers: csrfHeaders({ "Content-Type": "application/json" }),
         body: JSON.stringify({ name, markdown }),
       });
 
@@ -85,7 +199,7 @@ function initializePreview(
       /** @type {PreviewResponse} */
       const result = await response.json();
       renderTable(result.csv ?? "", headElement, bodyElement);
-      statusElement.textContent = "Done";
+      statusElement.textContent = t("done");
     } catch (error) {
       errorElement.textContent = error instanceof Error ? error.message : String(error);
       statusElement.textContent = "";
@@ -96,26 +210,43 @@ function initializePreview(
   });
 }
 
+/**
+ * Replaces the rows of a table body with a single placeholder cell.
+ *
+ * @param {HTMLTableSectionElement} bodyElement
+ * @param {string} text
+ * @returns {void}
+ */
+function renderPlaceholder(bodyElement, text) {
+  const row = document.createElement("tr");
+  const cell = document.createElement("td");
+  cell.className = "placeholder";
+  cell.textContent = text;
+  row.appendChild(cell);
+  bodyElement.replaceChildren(row);
+}
+
 /**
  * Renders CSV content inside the provided table sections.
  *
  * @param {string} csvText
  * @param {HTMLTableSectionElement} headElement
  * @param {HTMLTableSectionElement} bodyElement
+ * @param {PreviewRow[]} [rowLines] Source lines for each data row, when known.
  * @returns {void}
  */
-function renderTable(csvText, headElement, bodyElement) {
+function renderTable(csvText, headElement, bodyElement, rowLines = []) {
   headElement.innerHTML = "";
   bodyElement.innerHTML = "";
 
   if (!csvText.trim()) {
-    bodyElement.innerHTML = '<tr><td class="placeholder">No data available.</td></tr>';
+    renderPlaceholder(bodyElement, t("noData"));
     return;
   }
 
   const rows = parseCSV(csvText);
   if (!rows.length) {
-    bodyElement.innerHTML = '<tr><td class="placeholder">No data available.</td></tr>';
+    renderPlaceholder(bodyElement, t("noData"));
     return;
   }
 
@@ -130,17 +261,18 @@ function renderTable(csvText, headElement, bodyElement) {
   headElement.appendChild(headerRow);
 
   if (!dataRows.length) {
-    const row = document.createElement("tr");
-    const cell = document.createElement("td");
-    cell.className = "placeholder";
-    cell.textContent = "No rows parsed.";
-    row.appendChild(cell);
-    bodyElement.appendChild(row);
+    renderPlaceholder(bodyElement, t("noRows"));
     return;
   }
 
-  dataRows.forEach((values) => {
+  dataRows.forEach((values, rowIndex) => {
     const row = document.createElement("tr");
+    const lines = rowLines[rowIndex];
+    if (lines && lines.line > 0) {
+      row.dataset.line = String(lines.line);
+      row.dataset.endLine = String(lines.endLine || lines.line);
+      row.title = t("lines", lines.line, lines.endLine || lines.line);
+    }
     header.forEach((_, index) => {
       const cell = document.createElement("td");
       cell.textContent = values[index] ?? "";
@@ -150,6 +282,668 @@ function renderTable(csvText, headElement, bodyElement) {
   });
 }
 
+/**
+ * Renders parser and lint diagnostics as a clickable list.
+ *
+ * @param {Diagnostic[]} diagnostics
+ * @param {HTMLElement} listElement
+ * @returns {void}
+ */
+function renderDiagnostics(diagnostics, listElement) {
+  listElement.innerHTML = "";
+
+  if (!diagnostics.length) {
+    const item = document.createElement("li");
+    item.className = "placeholder";
+    item.textContent = t("noProblems");
+    listElement.appendChild(item);
+    return;
+  }
+
+  diagnostics.forEach((diagnostic) => {
+    const item = document.createElement("li");
+    item.className = `severity-${diagnostic.severity}`;
+    item.dataset.line = String(diagnostic.line);
+    item.dataset.endLine = String(diagnostic.line);
+    item.textContent = t("diagnosticLine", diagnostic.line, diagnostic.message);
+    listElement.appendChild(item);
+  });
+}
+
+/**
+ * Selects the given 1-based line range inside the Markdown editor.
+ *
+ * @param {HTMLTextAreaElement} textarea
+ * @param {number} line
+ * @param {number} endLine
+ * @returns {void}
+ */
+function highlightLines(textarea, line, endLine) {
+  const lines = textarea.value.split("\n");
+  let start = 0;
+  for (let index = 0; index < line - 1 && index < lines.length; index += 1) {
+    start += lines[index].length + 1;
+  }
+  let end = start;
+  for (let index = line - 1; index < endLine && index < lines.length; index += 1) {
+    end += lines[index].length + 1;
+  }
+
+  textarea.focus();
+  textarea.setSelectionRange(start, Math.max(start, end - 1));
+
+  const lineHeight = parseFloat(getComputedStyle(textarea).lineHeight) || 20;
+  textarea.scrollTop = Math.max(0, (line - 3) * lineHeight);
+}
+
+/**
+ * Highlights the Markdown behind a clicked table row or diagnostic.
+ *
+ * @param {HTMLElement} container
+ * @param {HTMLTextAreaElement} textarea
+ * @returns {void}
+ */
+function bindLineHighlighting(container, textarea) {
+  container.addEventListener("click", (event) => {
+    if (!(event.target instanceof Element)) {
+      return;
+    }
+    const source = event.target.closest("[data-line]");
+    if (!(source instanceof HTMLElement)) {
+      return;
+    }
+
+    container.querySelectorAll(".highlighted").forEach((element) => element.classList.remove("highlighted"));
+    source.classList.add("highlighted");
+    highlightLines(textarea, Number(source.dataset.line), Number(source.dataset.endLine));
+  });
+}
+
+/**
+ * Streams debounced edits to the server and renders previews pushed back over SSE.
+ *
+ * @param {HTMLInputElement} toggle
+ * @param {HTMLInputElement} nameField
+ * @param {HTMLTextAreaElement} markdownField
+ * @param {HTMLElement} statusElement
+ * @param {HTMLElement} errorElement
+ * @param {HTMLElement} diagnosticsElement
+ * @param {HTMLTableSectionElement} headElement
+ * @param {HTMLTableSectionElement} bodyElement
+ * @returns {void}
+ */
+function initializeLivePreview(
+  toggle,
+  nameField,
+  markdownField,
+  statusElement,
+  errorElement,
+  diagnosticsElement,
+  headElement,
+  bodyElement,
+) {
+  /** @type {{ session: string, key: string } | null} */
+  let live = null;
+  /** @type {EventSource | null} */
+  let events = null;
+  /** @type {number | undefined} */
+  let timer;
+
+  const sendUpdate = async () => {
+    if (!live) {
+      return;
+    }
+    statusElement.textContent = t("updating");
+    try {
+      const response = await fetch(appURL(`/api/live/${live.session}`), {
+        method: "POST",
+        headers: csrfHeaders({ "Content-Type": "application/json", "X-Live-Key": live.key }),
+        body: JSON.stringify({ name: nameField.value, markdown: markdownField.value }),
+      });
+      if (response.status === 404) {
+        // The server restarted or forgot the session; ticking the box
+        // again creates a new one.
+        live = null;
+        events?.close();
+        events = null;
+        toggle.checked = false;
+      }
+      if (!response.ok) {
+        throw new Error((await response.text()) || "live preview failed");
+      }
+      errorElement.textContent = "";
+    } catch (error) {
+      errorElement.textContent = error instanceof Error ? error.message : String(error);
+      statusElement.textContent = "";
+    }
+  };
+
+  const scheduleUpdate = () => {
+    if (!events) {
+      return;
+    }
+    window.clearTimeout(timer);
+    timer = window.setTimeout(sendUpdate, LIVE_PREVIEW_DELAY_MS);
+  };
+
+  toggle.addEventListener("change", async () => {
+    if (!toggle.checked) {
+      events?.close();
+      events = null;
+      statusElement.textContent = "";
+      return;
+    }
+
+    // The server issues the session and the key that lets only this page
+    // publish to it.
+    if (!live) {
+      try {
+        const response = await fetch(appURL("/api/live"), { method: "POST", headers: csrfHeaders() });
+        if (!response.ok) {
+          throw new Error((await response.text()) || "live preview failed");
+        }
+        live = await response.json();
+      } catch (error) {
+        errorElement.textContent = error instanceof Error ? error.message : String(error);
+        toggle.checked = false;
+        return;
+      }
+    }
+    if (!toggle.checked || events || !live) {
+      return;
+    }
+
+    events = new EventSource(appURL(`/api/live/${live.session}/events`));
+    events.addEventListener("open", () => sendUpdate());
+    events.addEventListener("preview", (event) => {
+      /** @type {LivePreviewEvent} */
+      const preview = JSON.parse(event.data);
+      renderTable(preview.csv ?? "", headElement, bodyElement, preview.rows ?? []);
+      renderDiagnostics(preview.diagnostics ?? [], diagnosticsElement);
+      statusElement.textContent = t("live");
+    });
+    events.addEventListener("error", () => {
+      statusElement.textContent = t("reconnecting");
+    });
+  });
+
+  markdownField.addEventListener("input", scheduleUpdate);
+  nameField.addEventListener("input", scheduleUpdate);
+}
+
+/**
+ * Wires the download buttons to /api/convert/:format. Selected files are
+ * uploaded as multipart form data; otherwise the editor content is sent.
+ *
+ * @param {HTMLElement} container
+ * @param {HTMLInputElement} nameField
+ * @param {HTMLTextAreaElement} markdownField
+ * @param {HTMLInputElement} filesField
+ * @param {HTMLElement} statusElement
+ * @param {HTMLElement} errorElement
+ * @returns {void}
+ */
+function initializeDownloads(container, nameField, markdownField, filesField, statusElement, errorElement) {
+  container.addEventListener("click", async (event) => {
+    if (!(event.target instanceof HTMLButtonElement) || !event.target.dataset.format) {
+      return;
+    }
+    const button = event.target;
+    const format = button.dataset.format;
+
+    /** @type {RequestInit} */
+    const request = { method: "POST", headers: csrfHeaders() };
+    if (filesField.files && filesField.files.length > 0) {
+      const form = new FormData();
+      Array.from(filesField.files).forEach((file) => form.append("files", file, file.name));
+      request.body = form;
+    } else {
+      request.headers = csrfHeaders({ "Content-Type": "application/json" });
+      request.body = JSON.stringify({ name: nameField.value, markdown: markdownField.value });
+    }
+
+    button.disabled = true;
+    statusElement.textContent = t("preparingDownload");
+    errorElement.textContent = "";
+
+    try {
+      const response = await fetch(appURL(`/api/convert/${format}`), request);
+      if (!response.ok) {
+        throw new Error((await response.text()) || "download failed");
+      }
+
+      const blob = await response.blob();
+      const link = document.createElement("a");
+      link.href = URL.createObjectURL(blob);
+      link.download = downloadFilename(response.headers.get("Content-Disposition"), `casemd.${format}`);
+      document.body.appendChild(link);
+      link.click();
+      link.remove();
+      URL.revokeObjectURL(link.href);
+      statusElement.textContent = t("downloaded");
+    } catch (error) {
+      errorElement.textContent = error instanceof Error ? error.message : String(error);
+      statusElement.textContent = "";
+    } finally {
+      button.disabled = false;
+    }
+  });
+}
+
+/**
+ * Extracts the filename from a Content-Disposition header.
+ *
+ * @param {string | null} header
+ * @param {string} fallback
+ * @returns {string}
+ */
+function downloadFilename(header, fallback) {
+  const match = header ? /filename="([^"]+)"/.exec(header) : null;
+  return match ? match[1] : fallback;
+}
+
+/**
+ * Returns the directory part of a workspace path ("." for top-level files).
+ *
+ * @param {string} path
+ * @returns {string}
+ */
+function dirname(path) {
+  const index = path.lastIndexOf("/");
+  return index < 0 ? "." : path.slice(0, index);
+}
+
+/**
+ * Renders workspace files as a nested list with a workbook button per folder.
+ *
+ * @param {WorkspaceFile[]} files
+ * @param {HTMLElement} container
+ * @param {string} activePath
+ * @returns {void}
+ */
+function renderWorkspaceTree(files, container, activePath) {
+  container.innerHTML = "";
+  if (!files.length) {
+    const empty = document.createElement("p");
+    empty.className = "placeholder";
+    empty.textContent = t("noFiles");
+    container.appendChild(empty);
+    return;
+  }
+
+  /** @type {Map<string, HTMLUListElement>} */
+  const lists = new Map();
+
+  /**
+   * @param {string} dir
+   * @returns {HTMLUListElement}
+   */
+  const listFor = (dir) => {
+    const existing = lists.get(dir);
+    if (existing) {
+      return existing;
+    }
+
+    const list = document.createElement("ul");
+    list.className = "tree";
+    const summary = document.createElement("summary");
+    summary.textContent = dir === "." ? "/" : dir.slice(dir.lastIndexOf("/") + 1);
+    const workbook = document.createElement("button");
+    workbook.type = "button";
+    workbook.className = "link";
+    workbook.dataset.workbook = dir;
+    workbook.textContent = t("workbook");
+    summary.appendChild(workbook);
+    const details = document.createElement("details");
+    details.open = true;
+    details.append(summary, list);
+
+    if (dir === ".") {
+      container.appendChild(details);
+    } else {
+      const item = document.createElement("li");
+      item.appendChild(details);
+      listFor(dirname(dir)).appendChild(item);
+    }
+    lists.set(dir, list);
+    return list;
+  };
+
+  files.forEach((file) => {
+    const link = document.createElement("a");
+    link.href = "#";
+    link.dataset.path = file.path;
+    link.textContent = file.path.slice(file.path.lastIndexOf("/") + 1);
+    if (file.path === activePath) {
+      link.classList.add("active");
+    }
+    const item = document.createElement("li");
+    item.appendChild(link);
+    listFor(dirname(file.path)).appendChild(item);
+  });
+}
+
+/**
+ * Renders one tab per workbook sheet and shows the selected sheet in the table.
+ *
+ * @param {SheetPreview[]} sheets
+ * @param {WorkspaceElements} elements
+ * @returns {void}
+ */
+function renderSheetTabs(sheets, elements) {
+  elements.tabs.innerHTML = "";
+
+  /** @param {number} selected */
+  const select = (selected) => {
+    Array.from(elements.tabs.children).forEach((tab, index) => {
+      tab.setAttribute("aria-selected", String(index === selected));
+    });
+    renderTable(sheets[selected]?.csv ?? "", elements.headElement, elements.bodyElement);
+  };
+
+  sheets.forEach((sheet, index) => {
+    const tab = document.createElement("button");
+    tab.type = "button";
+    tab.setAttribute("role", "tab");
+    tab.title = sheet.source;
+    tab.textContent = sheet.name;
+    tab.addEventListener("click", () => select(index));
+    elements.tabs.appendChild(tab);
+  });
+  select(0);
+}
+
+/**
+ * Browses the checklist directory served by `casemd serve <dir>` and keeps the
+ * open file or workbook in sync with changes on disk.
+ *
+ * @param {WorkspaceElements} elements
+ * @param {string} initialPath
+ * @returns {void}
+ */
+function initializeWorkspace(elements, initialPath) {
+  /** @type {{ path: string } | { dir: string }} */
+  let current = { path: initialPath };
+  /** @type {WorkspaceFile[]} */
+  let files = [];
+
+  /**
+   * @param {string} url
+   * @returns {Promise<any>}
+   */
+  const load = async (url) => {
+    const response = await fetch(url);
+    if (!response.ok) {
+      throw new Error((await response.text()) || "request failed");
+    }
+    return response.json();
+  };
+
+  /**
+   * @param {() => Promise<void>} action
+   * @returns {Promise<void>}
+   */
+  const run = async (action) => {
+    elements.errorElement.textContent = "";
+    try {
+      await action();
+    } catch (error) {
+      elements.errorElement.textContent = error instanceof Error ? error.message : String(error);
+    }
+  };
+
+  const refreshTree = () =>
+    run(async () => {
+      files = (await load(appURL("/api/workspace"))).files;
+      renderWorkspaceTree(files, elements.tree, "path" in current ? current.path : "");
+    });
+
+  /** @param {string} path */
+  const openFile = (path) =>
+    run(async () => {
+      const file = await load(appURL(`/api/workspace/file?path=${encodeURIComponent(path)}`));
+      current = { path };
+      elements.nameField.value = file.path;
+      elements.markdownField.value = file.markdown;
+      elements.tabs.innerHTML = "";
+      renderTable(file.preview.csv, elements.headElement, elements.bodyElement, file.preview.rows ?? []);
+      renderDiagnostics(file.preview.diagnostics ?? [], elements.diagnosticsElement);
+      renderWorkspaceTree(files, elements.tree, path);
+      elements.statusElement.textContent = t("showing", path);
+    });
+
+  /** @param {string} dir */
+  const openWorkbook = (dir) =>
+    run(async () => {
+      const workbook = await load(appURL(`/api/workspace/workbook?dir=${encodeURIComponent(dir)}`));
+      current = { dir };
+      renderSheetTabs(workbook.sheets, elements);
+      renderWorkspaceTree(files, elements.tree, "");
+      elements.statusElement.textContent = t("workbookOf", dir === "." ? "/" : dir);
+    });
+
+  elements.tree.addEventListener("click", (event) => {
+    if (!(event.target instanceof HTMLElement)) {
+      return;
+    }
+    if (event.target.dataset.path) {
+      event.preventDefault();
+      openFile(event.target.dataset.path);
+    } else if (event.target.dataset.workbook) {
+      event.preventDefault();
+      openWorkbook(event.target.dataset.workbook);
+    }
+  });
+
+  const events = new EventSource(appURL("/api/workspace/events"));
+  events.addEventListener("change", async (event) => {
+    /** @type {{ changed: string[] }} */
+    const { changed } = JSON.parse(event.data);
+    await refreshTree();
+    if ("path" in current && changed.includes(current.path)) {
+      openFile(current.path);
+    } else if ("dir" in current) {
+      const dir = current.dir;
+      if (changed.some((path) => dirname(path) === dir)) {
+        openWorkbook(dir);
+      }
+    }
+  });
+
+  refreshTree().then(() => {
+    if (initialPath) {
+      openFile(initialPath);
+    }
+  });
+}
+
+/**
+ * Renders one editable card per case for execution mode.
+ *
+ * @param {ExecutionCase[]} cases
+ * @param {HTMLElement} container
+ * @returns {void}
+ */
+function renderExecutionCases(cases, container) {
+  container.innerHTML = "";
+
+  cases.forEach((testCase, caseIndex) => {
+    const card = document.createElement("fieldset");
+    card.className = "case";
+    card.dataset.index = String(caseIndex);
+
+    const legend = document.createElement("legend");
+    legend.textContent = [testCase.majorItem, testCase.mediumItem, testCase.minorItem].filter(Boolean).join(" / ");
+    card.appendChild(legend);
+
+    if (testCase.validationSteps.length) {
+      const steps = document.createElement("ol");
+      testCase.validationSteps.forEach((step) => {
+        const item = document.createElement("li");
+        item.textContent = step;
+        steps.appendChild(item);
+      });
+      card.appendChild(steps);
+    }
+
+    testCase.checkpoints.forEach((checkpoint, checkpointIndex) => {
+      const match = CHECKPOINT_PATTERN.exec(checkpoint);
+      const label = document.createElement("label");
+      label.className = "toggle";
+      const box = document.createElement("input");
+      box.type = "checkbox";
+      box.dataset.checkpoint = String(checkpointIndex);
+      box.dataset.text = match ? match[2] : checkpoint;
+      box.checked = match ? match[1].toLowerCase() === "x" : false;
+      label.append(box, ` ${box.dataset.text}`);
+      card.appendChild(label);
+    });
+
+    const result = document.createElement("select");
+    result.name = "result";
+    RESULT_OPTIONS.forEach((value) => {
+      const option = document.createElement("option");
+      option.value = value;
+      option.textContent = value || t("notRun");
+      result.appendChild(option);
+    });
+    if (testCase.result && !RESULT_OPTIONS.includes(testCase.result)) {
+      const option = document.createElement("option");
+      option.value = testCase.result;
+      option.textContent = testCase.result;
+      result.appendChild(option);
+    }
+    result.value = testCase.result;
+
+    const date = document.createElement("input");
+    date.type = "date";
+    date.name = "testDate";
+    date.value = testCase.testDate;
+
+    const tester = document.createElement("input");
+    tester.type = "text";
+    tester.name = "tester";
+    tester.placeholder = t("tester");
+    tester.value = testCase.tester;
+
+    const notes = document.createElement("textarea");
+    notes.name = "notes";
+    notes.placeholder = t("notes");
+    notes.value = testCase.notes;
+
+    const fields = document.createElement("div");
+    fields.className = "case-fields";
+    fields.append(result, date, tester);
+    card.append(fields, notes);
+    container.appendChild(card);
+  });
+}
+
+/**
+ * Lets a tester execute the checklist open in the workspace and saves the
+ * results next to it.
+ *
+ * @param {HTMLButtonElement} openButton
+ * @param {HTMLElement} section
+ * @param {HTMLInputElement} nameField
+ * @returns {void}
+ */
+function initializeExecution(openButton, section, nameField) {
+  const fileElement = section.querySelector("#execution-file");
+  const casesElement = section.querySelector("#execution-cases");
+  const testerField = section.querySelector("#tester");
+  const saveButton = section.querySelector("#save-results");
+  const statusElement = section.querySelector("#execution-status");
+  if (
+    !(fileElement instanceof HTMLElement) ||
+    !(casesElement instanceof HTMLElement) ||
+    !(testerField instanceof HTMLInputElement) ||
+    !(saveButton instanceof HTMLButtonElement) ||
+    !(statusElement instanceof HTMLElement)
+  ) {
+    console.warn("Execution UI is missing expected elements.");
+    return;
+  }
+
+  // Signed-in users are recorded under their account name instead.
+  if (!testerField.readOnly) {
+    testerField.value = localStorage.getItem("casemd.tester") ?? "";
+    testerField.addEventListener("change", () => localStorage.setItem("casemd.tester", testerField.value));
+  }
+
+  /** @type {ExecutionCase[]} */
+  let cases = [];
+  let path = "";
+
+  openButton.addEventListener("click", async () => {
+    path = nameField.value;
+    statusElement.textContent = t("loading");
+    try {
+      const response = await fetch(appURL(`/api/workspace/run?path=${encodeURIComponent(path)}`));
+      if (!response.ok) {
+        throw new Error((await response.text()) || "could not open checklist");
+      }
+      const checklist = await response.json();
+      cases = checklist.cases;
+      fileElement.textContent = path;
+      renderExecutionCases(cases, casesElement);
+      section.hidden = false;
+      section.scrollIntoView({ behavior: "smooth" });
+      statusElement.textContent = "";
+    } catch (error) {
+      statusElement.textContent = error instanceof Error ? error.message : String(error);
+    }
+  });
+
+  saveButton.addEventListener("click", async () => {
+    const submitted = Array.from(casesElement.querySelectorAll("fieldset.case")).map((card) => {
+      const testCase = cases[Number(card.getAttribute("data-index"))];
+      /** @param {string} name */
+      const field = (name) => {
+        const element = card.querySelector(`[name="${name}"]`);
+        return element instanceof HTMLInputElement ||
+            element instanceof HTMLSelectElement ||
+            element instanceof HTMLTextAreaElement
+          ? element.value
+          : "";
+      };
+      const checkpoints = Array.from(card.querySelectorAll("input[data-checkpoint]")).map((box) =>
+        box instanceof HTMLInputElement ? `* [${box.checked ? "x" : " "}] ${box.dataset.text}` : ""
+      );
+      const result = field("result");
+      return {
+        majorItem: testCase.majorItem,
+        mediumItem: testCase.mediumItem,
+        minorItem: testCase.minorItem,
+        checkpoints,
+        result,
+        testDate: field("testDate"),
+        tester: field("tester") || (result ? testerField.value : ""),
+        notes: field("notes"),
+      };
+    });
+
+    saveButton.disabled = true;
+    statusElement.textContent = t("saving");
+    try {
+      const response = await fetch(appURL(`/api/workspace/run?path=${encodeURIComponent(path)}`), {
+        method: "PUT",
+        headers: csrfHeaders({ "Content-Type": "application/json" }),
+        body: JSON.stringify({ cases: submitted }),
+      });
+      if (!response.ok) {
+        throw new Error((await response.text()) || "could not save results");
+      }
+      const saved = await response.json();
+      statusElement.textContent = t("savedTo", saved.resultsPath);
+    } catch (error) {
+      statusElement.textContent = error instanceof Error ? error.message : String(error);
+    } finally {
+      saveButton.disabled = false;
+    }
+  });
+}
+
 /**
  * Converts CSV text into a matrix of values.
  *
@@ -219,6 +1013,12 @@ function parseCSV(text) {
   const errorElement = document.getElementById("error");
   const headElement = document.getElementById("csv-head");
   const bodyElement = document.getElementById("csv-body");
+  const liveToggle = document.getElementById("live");
+  const nameField = document.getElementById("name");
+  const markdownField = document.getElementById("markdown");
+  const diagnosticsElement = document.getElementById("diagnostics");
+  const downloadsElement = document.getElementById("downloads");
+  const filesField = document.getElementById("files");
 
   if (
     !(form instanceof HTMLFormElement) ||
@@ -226,7 +1026,13 @@ function parseCSV(text) {
     !(statusElement instanceof HTMLElement) ||
     !(errorElement instanceof HTMLElement) ||
     !(headElement instanceof HTMLTableSectionElement) ||
-    !(bodyElement instanceof HTMLTableSectionElement)
+    !(bodyElement instanceof HTMLTableSectionElement) ||
+    !(liveToggle instanceof HTMLInputElement) ||
+    !(nameField instanceof HTMLInputElement) ||
+    !(markdownField instanceof HTMLTextAreaElement) ||
+    !(diagnosticsElement instanceof HTMLElement) ||
+    !(downloadsElement instanceof HTMLElement) ||
+    !(filesField instanceof HTMLInputElement)
   ) {
     console.warn("Preview UI is missing expected elements.");
     return;
@@ -241,4 +1047,40 @@ function parseCSV(text) {
     bodyElement,
     defaults,
   );
+  initializeLivePreview(
+    liveToggle,
+    nameField,
+    markdownField,
+    statusElement,
+    errorElement,
+    diagnosticsElement,
+    headElement,
+    bodyElement,
+  );
+  const workspaceTree = document.getElementById("workspace-tree");
+  const sheetTabs = document.getElementById("sheet-tabs");
+  if (workspaceTree instanceof HTMLElement && sheetTabs instanceof HTMLElement) {
+    initializeWorkspace(
+      {
+        tree: workspaceTree,
+        tabs: sheetTabs,
+        nameField,
+        markdownField,
+        statusElement,
+        errorElement,
+        diagnosticsElement,
+        headElement,
+        bodyElement,
+      },
+      defaults.name,
+    );
+  }
+  const executeButton = document.getElementById("execute");
+  const executionSection = document.getElementById("execution");
+  if (executeButton instanceof HTMLButtonElement && executionSection instanceof HTMLElement) {
+    initializeExecution(executeButton, executionSection, nameField);
+  }
+  initializeDownloads(downloadsElement, nameField, markdownField, filesField, statusElement, errorElement);
+  bindLineHighlighting(bodyElement, markdownField);
+  bindLineHighlighting(diagnosticsElement, markdownField);
 })();
@@ -0,0 +1,270 @@
+package web
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+
+	"github.com/9renpoto/casemd/internal/app"
+)
+
+const (
+	defaultWatchInterval = time.Second
+	workspaceTopic       = "workspace:changes"
+)
+
+// Workspace is a directory of Markdown checklists browsed by the web UI.
+// Files are resolved through an os.Root, so request paths cannot escape the
+// directory.
+type Workspace struct {
+	root *os.Root
+	name string
+	// results holds a *sync.Mutex per checklist, serializing the
+	// read, merge and write of its results file.
+	results sync.Map
+}
+
+// OpenWorkspace opens dir for browsing.
+func OpenWorkspace(dir string) (*Workspace, error) {
+	root, err := os.OpenRoot(dir)
+	if err != nil {
+		return nil, fmt.Errorf("open workspace: %w", err)
+	}
+	name := filepath.Base(dir)
+	if absolute, err := filepath.Abs(dir); err == nil {
+		name = filepath.Base(absolute)
+	}
+	return &Workspace{root: root, name: name}, nil
+}
+
+// Close releases the workspace directory.
+func (w *Workspace) Close() error {
+	return w.root.Close()
+}
+
+type workspaceFile struct {
+	Path    string    `json:"path"`
+	Size    int64     `json:"size"`
+	ModTime time.Time `json:"modTime"`
+}
+
+func isMarkdownFile(name string) bool {
+	switch strings.ToLower(path.Ext(name)) {
+	case ".md", ".markdown":
+		return true
+	}
+	return false
+}
+
+// files lists the Markdown files below the workspace root in lexical order,
+// skipping hidden files and directories.
+func (w *Workspace) files() ([]workspaceFile, error) {
+	var files []workspaceFile
+	err := fs.WalkDir(w.root.FS(), ".", func(name string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if name != "." && strings.HasPrefix(entry.Name(), ".") {
+			if entry.IsDir() {
+				return fs.SkipDir
+			}
+			return nil
+		}
+		if entry.IsDir() || !isMarkdownFile(name) {
+			return nil
+		}
+		info, err := entry.Info()
+		if err != nil {
+			return err
+		}
+		files = append(files, workspaceFile{Path: name, Size: info.Size(), ModTime: info.ModTime()})
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("list workspace: %w", err)
+	}
+	return files, nil
+}
+
+func (w *Workspace) read(name string) ([]byte, error) {
+	if !fs.ValidPath(name) || !isMarkdownFile(name) {
+		return nil, fiber.NewError(fiber.StatusBadRequest, fmt.Sprintf("invalid markdown path %q", name))
+	}
+	data, err := w.root.ReadFile(name)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, fiber.NewError(fiber.StatusNotFound, fmt.Sprintf("%s not found", name))
+		}
+		return nil, fiber.NewError(fiber.StatusInternalServerError, fmt.Sprintf("read %s: %v", name, err))
+	}
+	return data, nil
+}
+
+// includes resolves the include directives of the checklist called name
+// against the workspace; included documents cannot escape it either.
+func (w *Workspace) includes(name string) app.Includes {
+	return app.Includes{Name: name, Open: func(name string) (io.ReadCloser, error) {
+		return w.root.Open(name)
+	}}
+}
+
+// changedFiles compares two listings and returns the paths that were added,
+// removed, or modified.
+func changedFiles(before, after []workspaceFile) []string {
+	previous := make(map[string]workspaceFile, len(before))
+	for _, file := range before {
+		previous[file.Path] = file
+	}
+
+	var changed []string
+	for _, file := range after {
+		old, ok := previous[file.Path]
+		delete(previous, file.Path)
+		if !ok || old.Size != file.Size || !old.ModTime.Equal(file.ModTime) {
+			changed = append(changed, file.Path)
+		}
+	}
+	for removed := range previous {
+		changed = append(changed, removed)
+	}
+	sort.Strings(changed)
+	return changed
+}
+
+// watchWorkspace polls the workspace and publishes a change event whenever
+// files are added, removed, or modified. Polling keeps the server free of
+// platform-specific file notification APIs and works on network mounts.
+func (s *Server) watchWorkspace(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	previous, _ := s.workspace.files()
+	for {
+		select {
+		case <-s.stop:
+			return
+		case <-ticker.C:
+		}
+
+		current, err := s.workspace.files()
+		if err != nil {
+			continue
+		}
+		changed := changedFiles(previous, current)
+		previous = current
+		if len(changed) == 0 {
+			continue
+		}
+
+		payload, err := json.Marshal(fiber.Map{"changed": changed})
+		if err != nil {
+			continue
+		}
+		s.live.publish(workspaceTopic, payload)
+	}
+}
+
+func (s *Server) registerWorkspaceRoutes(router fiber.Router) {
+	group := router.Group("/api/workspace", func(c *fiber.Ctx) error {
+		if s.workspace == nil {
+			return fiber.NewError(fiber.StatusNotFound, "workspace mode is not enabled")
+		}
+		return c.Next()
+	})
+
+	group.Get("/", func(c *fiber.Ctx) error {
+		files, err := s.workspace.files()
+		if err != nil {
+			return fiber.NewError(fiber.StatusInternalServerError, err.Error())
+		}
+		if files == nil {
+			files = []workspaceFile{}
+		}
+		return c.JSON(fiber.Map{"name": s.workspace.name, "files": files})
+	})
+
+	group.Get("/file", func(c *fiber.Ctx) error {
+		if s.previewer == nil {
+			return fiber.NewError(fiber.StatusServiceUnavailable, "previewer is not configured")
+		}
+
+		name := c.Query("path")
+		data, err := s.workspace.read(name)
+		if err != nil {
+			return err
+		}
+		source, err := s.checklistSource(name, data)
+		if err != nil {
+			return err
+		}
+		ctx, cancel := s.conversionContext(c)
+		defer cancel()
+		source.Context = ctx
+		source.Language = s.language(c)
+
+		preview, err := s.previewer.Preview(source)
+		if err != nil {
+			return s.conversionError(err, fiber.StatusUnprocessableEntity, "preview "+name)
+		}
+		return c.JSON(fiber.Map{"path": name, "markdown": string(data), "preview": preview})
+	})
+
+	// The workbook of a directory holds one sheet per Markdown file directly
+	// inside it, matching what `casemd --input dir/*.md` would write.
+	group.Get("/workbook", func(c *fiber.Ctx) error {
+		if s.previewer == nil {
+			return fiber.NewError(fiber.StatusServiceUnavailable, "previewer is not configured")
+		}
+
+		dir := path.Clean("/" + c.Query("dir"))[1:]
+		if dir == "" {
+			dir = "."
+		}
+		files, err := s.workspace.files()
+		if err != nil {
+			return fiber.NewError(fiber.StatusInternalServerError, err.Error())
+		}
+
+		ctx, cancel := s.conversionContext(c)
+		defer cancel()
+
+		language := s.language(c)
+		var sources []app.Source
+		for _, file := range files {
+			if path.Dir(file.Path) != dir {
+				continue
+			}
+			data, err := s.workspace.read(file.Path)
+			if err != nil {
+				return err
+			}
+			sources = append(sources, app.Source{Name: file.Path, Reader: bytes.NewReader(data), Context: ctx, Language: language, Includes: s.workspace.includes(file.Path)})
+		}
+		if len(sources) == 0 {
+			return fiber.NewError(fiber.StatusNotFound, fmt.Sprintf("no markdown files in %s", dir))
+		}
+
+		sheets, err := s.previewer.Workbook(sources)
+		if err != nil {
+			return s.conversionError(err, fiber.StatusUnprocessableEntity, "preview workbook")
+		}
+		return c.JSON(fiber.Map{"dir": dir, "sheets": sheets})
+	})
+
+	s.registerExecutionRoutes(group)
+
+	group.Get("/events", func(c *fiber.Ctx) error {
+		return streamEvents(c, s.live, workspaceTopic, "change")
+	})
+}
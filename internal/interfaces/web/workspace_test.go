@@ -0,0 +1,236 @@
+package web_test
+
+import (
+	"bufio"
+	"encoding/json"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+
+	"github.com/9renpoto/casemd/internal/app"
+	"github.com/9renpoto/casemd/internal/interfaces/web"
+)
+
+func newTestWorkspace(t *testing.T, files map[string]string) (string, *web.Workspace) {
+	t.Helper()
+
+	dir := t.TempDir()
+	for name, content := range files {
+		full := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(full), 0o755); err != nil {
+			t.Fatalf("create %s: %v", filepath.Dir(full), err)
+		}
+		if err := os.WriteFile(full, []byte(content), 0o644); err != nil {
+			t.Fatalf("write %s: %v", full, err)
+		}
+	}
+
+	workspace, err := web.OpenWorkspace(dir)
+	if err != nil {
+		t.Fatalf("open workspace: %v", err)
+	}
+	t.Cleanup(func() { workspace.Close() })
+	return dir, workspace
+}
+
+func getJSON(t *testing.T, server *web.Server, path string, target any) int {
+	t.Helper()
+
+	resp, err := server.App().Test(httptest.NewRequest(fiber.MethodGet, path, nil), -1)
+	if err != nil {
+		t.Fatalf("GET %s: unexpected error: %v", path, err)
+	}
+	if resp.StatusCode == fiber.StatusOK && target != nil {
+		if err := json.NewDecoder(resp.Body).Decode(target); err != nil {
+			t.Fatalf("GET %s: decode body: %v", path, err)
+		}
+	}
+	return resp.StatusCode
+}
+
+func TestWorkspaceListsAndPreviewsFiles(t *testing.T) {
+	_, workspace := newTestWorkspace(t, map[string]string{
+		"login.md":           "## Login",
+		"billing/invoice.md": "## Invoice",
+		"billing/refund.md":  "## Refund",
+		"README.txt":         "not a checklist",
+		".drafts/hidden.md":  "## Hidden",
+	})
+	previewer := &stubPreviewer{
+		preview: app.Preview{CSV: "Major Item\nInvoice\n"},
+		sheets:  []app.SheetPreview{{Name: "invoice"}, {Name: "refund"}},
+	}
+	server := web.NewServer(&stubConverter{}, web.Options{Previewer: previewer, Workspace: workspace})
+	defer server.Shutdown()
+
+	var listing struct {
+		Files []struct {
+			Path string `json:"path"`
+		} `json:"files"`
+	}
+	if status := getJSON(t, server, "/api/workspace", &listing); status != fiber.StatusOK {
+		t.Fatalf("expected status 200, got %d", status)
+	}
+	var paths []string
+	for _, file := range listing.Files {
+		paths = append(paths, file.Path)
+	}
+	if !reflect.DeepEqual(paths, []string{"billing/invoice.md", "billing/refund.md", "login.md"}) {
+		t.Fatalf("unexpected files: %v", paths)
+	}
+
+	var file struct {
+		Path     string      `json:"path"`
+		Markdown string      `json:"markdown"`
+		Preview  app.Preview `json:"preview"`
+	}
+	if status := getJSON(t, server, "/api/workspace/file?path=billing/invoice.md", &file); status != fiber.StatusOK {
+		t.Fatalf("expected status 200, got %d", status)
+	}
+	if file.Markdown != "## Invoice" || file.Preview.CSV != previewer.preview.CSV {
+		t.Fatalf("unexpected file response: %+v", file)
+	}
+
+	var workbook struct {
+		Sheets []app.SheetPreview `json:"sheets"`
+	}
+	if status := getJSON(t, server, "/api/workspace/workbook?dir=billing", &workbook); status != fiber.StatusOK {
+		t.Fatalf("expected status 200, got %d", status)
+	}
+	if len(workbook.Sheets) != 2 || len(previewer.sources) != 2 || previewer.sources[1].Name != "billing/refund.md" {
+		t.Fatalf("unexpected workbook sources: %+v", previewer.sources)
+	}
+
+	// The editor starts with the first workspace file instead of notes.md.
+	resp, err := server.App().Test(httptest.NewRequest(fiber.MethodGet, "/", nil), -1)
+	if err != nil {
+		t.Fatalf("GET /: %v", err)
+	}
+	body, _ := io.ReadAll(resp.Body)
+	if !strings.Contains(string(body), "## Invoice") || !strings.Contains(string(body), `id="workspace"`) {
+		t.Fatalf("index does not render the workspace: %s", body)
+	}
+}
+
+func TestWorkspaceRejectsPathsOutsideTheDirectory(t *testing.T) {
+	dir, workspace := newTestWorkspace(t, map[string]string{"inside.md": "## Inside"})
+	if err := os.WriteFile(filepath.Join(filepath.Dir(dir), "outside.md"), []byte("secret"), 0o644); err != nil {
+		t.Fatalf("write outside file: %v", err)
+	}
+	server := web.NewServer(&stubConverter{}, web.Options{Previewer: &stubPreviewer{}, Workspace: workspace})
+	defer server.Shutdown()
+
+	for path, expected := range map[string]int{
+		"/api/workspace/file?path=../outside.md":   fiber.StatusBadRequest,
+		"/api/workspace/file?path=%2Fetc%2Fpasswd": fiber.StatusBadRequest,
+		"/api/workspace/file?path=inside.txt":      fiber.StatusBadRequest,
+		"/api/workspace/file?path=missing.md":      fiber.StatusNotFound,
+		"/api/workspace/workbook?dir=../":          fiber.StatusOK,
+		"/api/workspace/workbook?dir=missing-dir/": fiber.StatusNotFound,
+	} {
+		if status := getJSON(t, server, path, nil); status != expected {
+			t.Fatalf("GET %s: expected status %d, got %d", path, expected, status)
+		}
+	}
+}
+
+func TestWorkspaceResolvesIncludesInsideTheDirectory(t *testing.T) {
+	dir, workspace := newTestWorkspace(t, map[string]string{
+		"checks/login.md": "## Login",
+		"shared/steps.md": "## Shared",
+	})
+	if err := os.WriteFile(filepath.Join(filepath.Dir(dir), "outside.md"), []byte("secret"), 0o644); err != nil {
+		t.Fatalf("write outside file: %v", err)
+	}
+	previewer := &stubPreviewer{}
+	server := web.NewServer(&stubConverter{}, web.Options{Previewer: previewer, Workspace: workspace})
+	defer server.Shutdown()
+
+	if status := getJSON(t, server, "/api/workspace/file?path=checks/login.md", nil); status != fiber.StatusOK {
+		t.Fatalf("expected status 200, got %d", status)
+	}
+	includes := previewer.source.Includes
+	if includes.Name != "checks/login.md" || includes.Open == nil {
+		t.Fatalf("unexpected includes: %+v", includes)
+	}
+	file, err := includes.Open("shared/steps.md")
+	if err != nil {
+		t.Fatalf("open shared/steps.md: %v", err)
+	}
+	file.Close()
+	if _, err := includes.Open("../outside.md"); err == nil {
+		t.Fatal("expected includes outside the workspace to fail")
+	}
+}
+
+func TestWorkspaceRoutesRequireWorkspaceMode(t *testing.T) {
+	server := web.NewServer(&stubConverter{}, web.Options{Previewer: &stubPreviewer{}})
+
+	if status := getJSON(t, server, "/api/workspace", nil); status != fiber.StatusNotFound {
+		t.Fatalf("expected status 404, got %d", status)
+	}
+}
+
+func TestWorkspaceStreamsFileChanges(t *testing.T) {
+	dir, workspace := newTestWorkspace(t, map[string]string{"login.md": "## Login"})
+	server := web.NewServer(&stubConverter{}, web.Options{
+		Previewer:     &stubPreviewer{},
+		Workspace:     workspace,
+		WatchInterval: 10 * time.Millisecond,
+	})
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	go func() { _ = server.App().Listener(listener) }()
+	defer server.Shutdown()
+
+	client := &http.Client{Timeout: 5 * time.Second}
+	resp, err := client.Get("http://" + listener.Addr().String() + "/api/workspace/events")
+	if err != nil {
+		t.Fatalf("open event stream: %v", err)
+	}
+	defer resp.Body.Close()
+
+	stream := bufio.NewReader(resp.Body)
+	if line, err := stream.ReadString('\n'); err != nil || line != ": connected\n" {
+		t.Fatalf("unexpected first line %q (err %v)", line, err)
+	}
+
+	if err := os.WriteFile(filepath.Join(dir, "login.md"), []byte("## Login\n#### Password reset\n"), 0o644); err != nil {
+		t.Fatalf("update file: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "signup.md"), []byte("## Signup"), 0o644); err != nil {
+		t.Fatalf("add file: %v", err)
+	}
+
+	changed := map[string]bool{}
+	for !changed["login.md"] || !changed["signup.md"] {
+		line, err := stream.ReadString('\n')
+		if err != nil {
+			t.Fatalf("read event: %v", err)
+		}
+		if !strings.HasPrefix(line, "data: ") {
+			continue
+		}
+		var event struct {
+			Changed []string `json:"changed"`
+		}
+		if err := json.Unmarshal([]byte(strings.TrimPrefix(line, "data: ")), &event); err != nil {
+			t.Fatalf("decode event: %v", err)
+		}
+		for _, path := range event.Changed {
+			changed[path] = true
+		}
+	}
+}